@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"github.com/mainbong/storage_doctor/internal/agent"
+	"github.com/mainbong/storage_doctor/internal/history"
+	"github.com/mainbong/storage_doctor/internal/llm"
+	"github.com/mainbong/storage_doctor/internal/logger"
+)
+
+// budgetApprovalTool names the pseudo tool call used to ask about the budget in the TUI
+const budgetApprovalTool = "session_budget"
+
+// sessionBudget holds the current limits; each approval to go over adds another configured budget
+var sessionBudget history.Budget
+
+// budgetConfirm asks the user whether to continue past the session budget
+type budgetConfirm func(reason string) bool
+
+type budgetConfirmKey struct{}
+
+// withBudgetConfirm sets how the budget guard asks the user; without one it prompts on stdin
+func withBudgetConfirm(ctx context.Context, confirm budgetConfirm) context.Context {
+	return context.WithValue(ctx, budgetConfirmKey{}, confirm)
+}
+
+// configuredBudget returns the session budget from the config
+func configuredBudget() history.Budget {
+	return history.Budget{MaxTokens: cfg.SessionBudget.MaxTokens, MaxCostUSD: cfg.SessionBudget.MaxCostUSD}
+}
+
+// setupUsageTracking records every LLM call in the session and enforces the session budget
+func setupUsageTracking() {
+	chatManager.SetUsageObserver(func(model string, usage llm.Usage) {
+		historyMgr.RecordUsage(model, usage)
+	})
+	sessionBudget = configuredBudget()
+	agentInstance.SetBudgetGuard(checkSessionBudget)
+}
+
+// checkSessionBudget stops the agent before an LLM call that would exceed the
+// session budget unless the user agrees to continue
+func checkSessionBudget(ctx context.Context, nextInputTokens int) error {
+	if !sessionBudget.Enabled() {
+		return nil
+	}
+	reason := sessionBudget.Check(historyMgr.Usage(), llmProvider.GetModel(), nextInputTokens)
+	if reason == "" {
+		return nil
+	}
+	logger.Warn("세션 예산 초과 예상: %s", reason)
+
+	confirm, ok := ctx.Value(budgetConfirmKey{}).(budgetConfirm)
+	if !ok {
+		confirm = confirmBudgetStdin
+	}
+	if !confirm(reason) {
+		return agent.ErrBudgetExceeded
+	}
+	sessionBudget = sessionBudget.Extend(configuredBudget())
+	logger.Info("세션 예산 증액 승인: %+v", sessionBudget)
+	return nil
+}
+
+func confirmBudgetStdin(reason string) bool {
+	color.Yellow("\n[세션 예산] %s\n", reason)
+	fmt.Printf("계속 진행하시겠습니까? 승인하면 예산이 한 번 더 늘어납니다. [y/n]: ")
+	reader := bufio.NewReader(os.Stdin)
+	response, _ := reader.ReadString('\n')
+	response = strings.TrimSpace(strings.ToLower(response))
+	return response == "y" || response == "yes"
+}
+
+// usageStatus summarizes the session's token usage and cost for status lines
+func usageStatus() string {
+	usage := historyMgr.Usage()
+	if usage.Calls == 0 {
+		return ""
+	}
+	status := fmt.Sprintf("토큰 %d", usage.Total())
+	if len(usage.UnpricedModels) == 0 || usage.CostUSD > 0 {
+		status += fmt.Sprintf(" · $%.4f", usage.CostUSD)
+	}
+	if sessionBudget.MaxCostUSD > 0 {
+		status += fmt.Sprintf(" / $%.2f", sessionBudget.MaxCostUSD)
+	} else if sessionBudget.MaxTokens > 0 {
+		status += fmt.Sprintf(" / 토큰 %d", sessionBudget.MaxTokens)
+	}
+	return status
+}
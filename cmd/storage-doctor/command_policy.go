@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"github.com/mainbong/storage_doctor/internal/config"
+	"github.com/mainbong/storage_doctor/internal/logger"
+	"github.com/mainbong/storage_doctor/internal/shell"
+	"github.com/mainbong/storage_doctor/internal/shell/policy"
+)
+
+// loadCommandPolicy loads the user-editable command policy, falling back to built-in rules
+func loadCommandPolicy() *policy.Engine {
+	path := filepath.Join(config.GetConfigDir(), "command_policy.json")
+	engine, err := policy.NewEngine(path)
+	if err != nil {
+		logger.Warn("명령어 정책 로드 실패: %v (기본 정책 사용)", err)
+		engine, _ = policy.NewEngineWithRules(policy.Rules{})
+	}
+	return engine
+}
+
+// evaluateCommand classifies a command and decides how it must be approved
+func evaluateCommand(command string) (policy.Evaluation, policy.Decision) {
+	engine := shellExec.GetPolicy()
+	evaluation := engine.Evaluate(command)
+	autoApprove := cfg.AutoApproveCommands || shellExec.GetApprovalMode() != shell.ApprovalModeManual
+	return evaluation, engine.Decide(evaluation, autoApprove)
+}
+
+// promptCommandApproval asks for approval of a mutating or read-only command in REPL mode
+func promptCommandApproval(evaluation policy.Evaluation) error {
+	reader := bufio.NewReader(os.Stdin)
+	if evaluation.Class == policy.ClassReadOnly {
+		fmt.Printf("실행하시겠습니까? [y/n/a/s]: ")
+	} else {
+		fmt.Printf("실행하시겠습니까? [y/n]: ")
+	}
+	response, _ := reader.ReadString('\n')
+	response = strings.TrimSpace(strings.ToLower(response))
+
+	switch response {
+	case "y", "yes":
+		return nil
+	case "n", "no":
+		return fmt.Errorf("사용자가 명령어 실행을 취소했습니다")
+	case "a", "always":
+		if evaluation.Class != policy.ClassReadOnly {
+			return fmt.Errorf("잘못된 입력입니다")
+		}
+		cfg.AutoApproveCommands = true
+		if err := cfg.Save(); err != nil {
+			logger.Warn("설정 저장 실패: %v", err)
+		}
+		color.Green("이제부터 읽기 전용 명령어를 자동으로 승인합니다.\n")
+		return nil
+	case "s", "session":
+		if evaluation.Class != policy.ClassReadOnly {
+			return fmt.Errorf("잘못된 입력입니다")
+		}
+		shellExec.SetApprovalMode(shell.ApprovalModeSession)
+		color.Green("이 세션 동안 읽기 전용 명령어를 자동으로 승인합니다.\n")
+		return nil
+	default:
+		return fmt.Errorf("잘못된 입력입니다")
+	}
+}
+
+// promptCommandConfirmation requires the user to type a phrase before running a destructive command
+func promptCommandConfirmation(evaluation policy.Evaluation) error {
+	phrase := evaluation.ConfirmationText()
+	color.Red("위험 사유: %s\n", evaluation.Reason)
+	fmt.Printf("실행하려면 '%s'를 정확히 입력하세요: ", phrase)
+	reader := bufio.NewReader(os.Stdin)
+	response, _ := reader.ReadString('\n')
+	if strings.TrimSpace(response) != phrase {
+		return fmt.Errorf("확인 문구가 일치하지 않아 명령어 실행을 취소했습니다")
+	}
+	return nil
+}
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mainbong/storage_doctor/internal/agent"
+	"github.com/mainbong/storage_doctor/internal/history"
+	"github.com/mainbong/storage_doctor/internal/llm"
+	"github.com/mainbong/storage_doctor/internal/logger"
+	"github.com/mainbong/storage_doctor/internal/shell/policy"
+)
+
+// Tool policies for unattended runs
+const (
+	diagnosePolicyReadOnly = "read-only" // Read-only commands and tools only
+	diagnosePolicyMutating = "mutating"  // Also non-destructive commands and file edits
+)
+
+// Exit codes of the diagnose command
+const (
+	diagnoseExitOK       = 0
+	diagnoseExitWarning  = 1
+	diagnoseExitCritical = 2
+	diagnoseExitFailed   = 3
+)
+
+// diagnoseOutputLimit caps each tool output kept in the report
+const diagnoseOutputLimit = 2000
+
+var (
+	diagnosePrompt        string
+	diagnosePromptFile    string
+	diagnoseMaxIterations int
+	diagnosePolicy        string
+	diagnoseOutput        string
+
+	// exitCode is returned by main once the command has finished
+	exitCode int
+)
+
+const diagnoseInstructions = `
+
+[비대화형 진단 모드]
+사용자에게 질문하거나 승인을 받을 수 없습니다. 도구 정책: %s
+정책에서 거부된 도구는 다시 시도하지 말고, 확인하지 못한 항목은 결론에 명시하세요.
+진단을 마치면 결론을 정리하고 마지막 줄에 다음 중 하나를 정확히 출력하세요:
+STATUS: OK (문제 없음)
+STATUS: WARNING (주의가 필요한 문제 발견)
+STATUS: CRITICAL (즉시 조치가 필요한 문제 발견)`
+
+var diagnoseCmd = &cobra.Command{
+	Use:   "diagnose",
+	Short: "비대화형 진단 실행 (CI / cron 용)",
+	Long: `프롬프트 하나로 Agent를 무인 실행하고 진단 보고서를 표준 출력으로 내보냅니다.
+승인이 필요한 도구는 --policy가 허용하지 않으면 거부되며, 파괴적 명령어와
+사용자 질문은 항상 거부됩니다.
+
+종료 코드:
+  0  문제 없음 (STATUS: OK)
+  1  주의 필요 (STATUS: WARNING)
+  2  즉시 조치 필요 (STATUS: CRITICAL)
+  3  진단 실패 (오류, 최대 반복 횟수 도달, 상태 미보고)`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		exitCode = runDiagnose(context.Background())
+	},
+}
+
+func init() {
+	diagnoseCmd.Flags().StringVar(&diagnosePrompt, "prompt", "", "진단 요청 내용")
+	diagnoseCmd.Flags().StringVar(&diagnosePromptFile, "prompt-file", "", "진단 요청 내용을 읽을 파일 (- 는 표준 입력)")
+	diagnoseCmd.Flags().IntVar(&diagnoseMaxIterations, "max-iterations", 10, "최대 LLM 호출 횟수")
+	diagnoseCmd.Flags().StringVar(&diagnosePolicy, "policy", diagnosePolicyReadOnly, "도구 허용 정책 (read-only, mutating)")
+	diagnoseCmd.Flags().StringVar(&diagnoseOutput, "output", "markdown", "보고서 형식 (json, markdown)")
+	rootCmd.AddCommand(diagnoseCmd)
+}
+
+type diagnoseReport struct {
+	Prompt     string               `json:"prompt"`
+	Provider   string               `json:"provider"`
+	Policy     string               `json:"policy"`
+	StartedAt  time.Time            `json:"started_at"`
+	FinishedAt time.Time            `json:"finished_at"`
+	Status     string               `json:"status"`
+	ExitCode   int                  `json:"exit_code"`
+	Conclusion string               `json:"conclusion"`
+	ToolCalls  []diagnoseToolCall   `json:"tool_calls"`
+	Usage      history.UsageSummary `json:"usage"`
+	Error      string               `json:"error,omitempty"`
+}
+
+type diagnoseToolCall struct {
+	Name    string `json:"name"`
+	Target  string `json:"target,omitempty"`
+	Success bool   `json:"success"`
+	Refused bool   `json:"refused,omitempty"`
+	Output  string `json:"output,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+func runDiagnose(ctx context.Context) int {
+	if diagnoseOutput != "json" && diagnoseOutput != "markdown" {
+		fmt.Fprintf(os.Stderr, "지원하지 않는 보고서 형식입니다: %s (json, markdown)\n", diagnoseOutput)
+		return diagnoseExitFailed
+	}
+	if diagnosePolicy != diagnosePolicyReadOnly && diagnosePolicy != diagnosePolicyMutating {
+		fmt.Fprintf(os.Stderr, "지원하지 않는 정책입니다: %s (read-only, mutating)\n", diagnosePolicy)
+		return diagnoseExitFailed
+	}
+	prompt, err := loadDiagnosePrompt()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return diagnoseExitFailed
+	}
+
+	report := diagnose(ctx, prompt)
+
+	if diagnoseOutput == "json" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "보고서 생성 실패: %v\n", err)
+			return diagnoseExitFailed
+		}
+		fmt.Println(string(data))
+	} else {
+		fmt.Print(renderDiagnoseMarkdown(report))
+	}
+	return report.ExitCode
+}
+
+// diagnose runs the agent unattended on prompt and builds the report
+func diagnose(ctx context.Context, prompt string) *diagnoseReport {
+	report := &diagnoseReport{
+		Prompt:    prompt,
+		Provider:  cfg.LLMProvider,
+		Policy:    diagnosePolicy,
+		StartedAt: time.Now(),
+	}
+	logger.Info("비대화형 진단 시작: policy=%s", diagnosePolicy)
+
+	agentInstance.SetMaxIterations(diagnoseMaxIterations)
+	historyMgr.NewSession("diagnose " + report.StartedAt.Format("2006-01-02 15:04"))
+	historyMgr.SetProblem(prompt)
+
+	// Nobody can approve going over the budget in a non-interactive run
+	ctx = withBudgetConfirm(ctx, func(string) bool { return false })
+	err := agentInstance.StreamTask(ctx, prompt+fmt.Sprintf(diagnoseInstructions, diagnosePolicy),
+		func(chunk string) {}, diagnoseToolHandler(report, diagnosePolicy))
+
+	report.FinishedAt = time.Now()
+	report.Conclusion = lastAssistantText(chatManager.GetMessages())
+	report.Status, report.ExitCode = diagnoseStatus(report.Conclusion)
+	report.Usage = historyMgr.Usage()
+	switch {
+	case errors.Is(err, agent.ErrMaxIterations):
+		report.Error = fmt.Sprintf("%v (--max-iterations %d)", err, diagnoseMaxIterations)
+		report.Status, report.ExitCode = "incomplete", diagnoseExitFailed
+	case errors.Is(err, agent.ErrBudgetExceeded):
+		report.Error = err.Error()
+		report.Status, report.ExitCode = "incomplete", diagnoseExitFailed
+	case err != nil:
+		report.Error = err.Error()
+		report.Status, report.ExitCode = "error", diagnoseExitFailed
+	}
+	historyMgr.SetConclusion(report.Conclusion)
+	persistSession()
+	logger.Info("비대화형 진단 완료: status=%s", report.Status)
+	return report
+}
+
+func loadDiagnosePrompt() (string, error) {
+	prompt := diagnosePrompt
+	if diagnosePromptFile != "" {
+		var data []byte
+		var err error
+		if diagnosePromptFile == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(diagnosePromptFile)
+		}
+		if err != nil {
+			return "", fmt.Errorf("프롬프트 파일 읽기 실패: %w", err)
+		}
+		prompt = strings.TrimSpace(prompt + "\n\n" + string(data))
+	}
+	if strings.TrimSpace(prompt) == "" {
+		return "", fmt.Errorf("--prompt 또는 --prompt-file이 필요합니다")
+	}
+	return prompt, nil
+}
+
+// diagnoseToolHandler runs allowed tool calls without prompting and records every call in the report
+func diagnoseToolHandler(report *diagnoseReport, mode string) agent.ToolHandler {
+	var mu sync.Mutex
+	record := func(call diagnoseToolCall) {
+		mu.Lock()
+		defer mu.Unlock()
+		report.ToolCalls = append(report.ToolCalls, call)
+	}
+
+	return func(ctx context.Context, toolCall llm.ToolCall) (string, error) {
+		call := diagnoseToolCall{Name: toolCall.Name, Target: toolTarget(toolCall)}
+		if reason := diagnoseRefusal(toolCall, mode); reason != "" {
+			logger.Info("비대화형 진단: 도구 거부 %s - %s", toolCall.Name, reason)
+			call.Refused = true
+			call.Error = reason
+			record(call)
+			return "", fmt.Errorf("비대화형 진단 정책(%s)에 의해 거부됨: %s", mode, reason)
+		}
+
+		result, success, err := handleToolCall(ctx, toolCall, true, true)
+		call.Success = success && err == nil
+		call.Output = truncateOutput(result, 0, diagnoseOutputLimit)
+		if err != nil {
+			call.Error = err.Error()
+		}
+		record(call)
+		if !success {
+			return result, err
+		}
+		return result, nil
+	}
+}
+
+// diagnoseRefusal returns why a tool call may not run unattended, or "" if it may
+func diagnoseRefusal(toolCall llm.ToolCall, mode string) string {
+	switch toolCall.Name {
+	case "execute_command":
+		evaluation := shellExec.GetPolicy().Evaluate(commandFromTool(toolCall))
+		switch {
+		case evaluation.Denied:
+			return "정책에 의해 차단된 명령어: " + evaluation.Reason
+		case evaluation.Class == policy.ClassDestructive:
+			return "파괴적 명령어는 비대화형으로 실행할 수 없습니다: " + evaluation.Reason
+		case evaluation.Class == policy.ClassMutating && mode != diagnosePolicyMutating:
+			return "변경 명령어는 --policy mutating에서만 허용됩니다"
+		}
+		return ""
+	case "write_file", "edit_file", "set_config_value":
+		if mode != diagnosePolicyMutating {
+			return "파일 수정은 --policy mutating에서만 허용됩니다"
+		}
+		return ""
+	case "ask_user":
+		return "비대화형 실행 중에는 사용자에게 질문할 수 없습니다"
+	case "monitor_log":
+		// Watch events would be printed into the report
+		if action, _ := toolCall.Input["action"].(string); action == "watch" || action == "tail" {
+			return "비대화형 실행에서는 로그를 계속 감시할 수 없습니다. search, filter나 summarize로 현재 로그를 확인하세요"
+		}
+		if agent.IsReadOnlyToolCall(toolCall) {
+			return ""
+		}
+		return "비대화형 실행에서 사용할 수 없는 도구입니다"
+	default:
+		if agent.IsReadOnlyToolCall(toolCall) {
+			return ""
+		}
+		return "비대화형 실행에서 사용할 수 없는 도구입니다"
+	}
+}
+
+// toolTarget returns the main argument of a tool call for display
+func toolTarget(toolCall llm.ToolCall) string {
+	for _, key := range []string{"command", "path", "query", "url", "pod", "unit", "key"} {
+		if value, ok := toolCall.Input[key].(string); ok && value != "" {
+			if keyPath, ok := toolCall.Input["key_path"].(string); ok && key == "path" {
+				return value + " " + keyPath
+			}
+			return value
+		}
+	}
+	return ""
+}
+
+// diagnoseStatus reads the STATUS line the model was asked to end with
+func diagnoseStatus(conclusion string) (string, int) {
+	lines := strings.Split(conclusion, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.ToUpper(strings.Trim(strings.TrimSpace(lines[i]), "*`_ "))
+		if !strings.HasPrefix(line, "STATUS:") {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(line, "STATUS:"))
+		if len(fields) == 0 {
+			break
+		}
+		switch strings.Trim(fields[0], "*`_") {
+		case "OK":
+			return "ok", diagnoseExitOK
+		case "WARNING":
+			return "warning", diagnoseExitWarning
+		case "CRITICAL":
+			return "critical", diagnoseExitCritical
+		}
+		break
+	}
+	return "unknown", diagnoseExitFailed
+}
+
+func renderDiagnoseMarkdown(report *diagnoseReport) string {
+	var sb strings.Builder
+	sb.WriteString("# Storage Doctor 진단 보고서\n\n")
+	sb.WriteString(fmt.Sprintf("- 상태: **%s**\n", strings.ToUpper(report.Status)))
+	sb.WriteString(fmt.Sprintf("- 정책: %s\n", report.Policy))
+	sb.WriteString(fmt.Sprintf("- 시작: %s\n", report.StartedAt.Format(time.RFC3339)))
+	sb.WriteString(fmt.Sprintf("- 소요 시간: %s\n", report.FinishedAt.Sub(report.StartedAt).Round(time.Second)))
+	if report.Usage.Calls > 0 {
+		sb.WriteString(fmt.Sprintf("- 토큰: %d (LLM 호출 %d회), API 비용: %s\n",
+			report.Usage.Total(), report.Usage.Calls, report.Usage.CostText()))
+	}
+	if report.Error != "" {
+		sb.WriteString(fmt.Sprintf("- 오류: %s\n", report.Error))
+	}
+
+	sb.WriteString("\n## 요청\n\n")
+	sb.WriteString(report.Prompt + "\n")
+
+	sb.WriteString("\n## 결론\n\n")
+	if report.Conclusion == "" {
+		sb.WriteString("(결론 없음)\n")
+	} else {
+		sb.WriteString(report.Conclusion + "\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("\n## 도구 실행 (%d)\n", len(report.ToolCalls)))
+	for i, call := range report.ToolCalls {
+		state := "성공"
+		switch {
+		case call.Refused:
+			state = "거부됨"
+		case !call.Success:
+			state = "실패"
+		}
+		sb.WriteString(fmt.Sprintf("\n### %d. %s — %s\n\n", i+1, call.Name, state))
+		if call.Target != "" {
+			sb.WriteString(fmt.Sprintf("`%s`\n\n", call.Target))
+		}
+		if call.Error != "" {
+			sb.WriteString(fmt.Sprintf("사유: %s\n\n", call.Error))
+		}
+		if call.Output != "" {
+			sb.WriteString("```text\n" + strings.TrimRight(call.Output, "\n") + "\n```\n")
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mainbong/storage_doctor/internal/diff"
+	"github.com/mainbong/storage_doctor/internal/files"
+	"github.com/mainbong/storage_doctor/internal/llm"
+)
+
+// diffPreviewLines limits how much of a diff the approval views show
+const diffPreviewLines = 40
+
+// editRequestFromTool converts edit_file tool input into a files.EditRequest
+func editRequestFromTool(toolCall llm.ToolCall) (files.EditRequest, error) {
+	var req files.EditRequest
+	req.Patch, _ = toolCall.Input["patch"].(string)
+
+	if raw, ok := toolCall.Input["edits"]; ok && raw != nil {
+		items, ok := raw.([]interface{})
+		if !ok {
+			return req, fmt.Errorf("invalid edits parameter")
+		}
+		for i, item := range items {
+			fields, ok := item.(map[string]interface{})
+			if !ok {
+				return req, fmt.Errorf("invalid edit %d", i+1)
+			}
+			oldText, ok := fields["old_text"].(string)
+			if !ok {
+				return req, fmt.Errorf("edit %d: invalid old_text", i+1)
+			}
+			newText, _ := fields["new_text"].(string)
+			replaceAll, _ := fields["replace_all"].(bool)
+			req.Edits = append(req.Edits, files.Edit{OldText: oldText, NewText: newText, ReplaceAll: replaceAll})
+		}
+	}
+	return req, nil
+}
+
+// configDocument returns the YAML document index of a config tool call
+func configDocument(toolCall llm.ToolCall) int {
+	if document, ok := toolCall.Input["document"].(float64); ok {
+		return int(document)
+	}
+	return files.DocumentAuto
+}
+
+// configEditFromTool prepares the edit a set_config_value call would make
+func configEditFromTool(toolCall llm.ToolCall) (*files.PreparedEdit, error) {
+	path, _ := toolCall.Input["path"].(string)
+	keyPath, ok := toolCall.Input["key_path"].(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid key_path parameter")
+	}
+	value, ok := toolCall.Input["value"]
+	if !ok {
+		return nil, fmt.Errorf("missing value parameter")
+	}
+	return fileManager.SetConfigValue(path, keyPath, value, configDocument(toolCall))
+}
+
+// formatConfigValue renders a config value for the model
+func formatConfigValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
+
+// fileChangePreview returns the unified diff a write_file, edit_file or set_config_value call would produce
+func fileChangePreview(toolCall llm.ToolCall) (string, error) {
+	path, _ := toolCall.Input["path"].(string)
+
+	switch toolCall.Name {
+	case "write_file":
+		content, _ := toolCall.Input["content"].(string)
+		oldContent, _ := fileManager.ReadFile(path)
+		oldName := "a/" + strings.TrimPrefix(path, "/")
+		if !fileManager.FileExists(path) {
+			oldName = "/dev/null"
+		}
+		return diff.Unified(oldName, "b/"+strings.TrimPrefix(path, "/"), oldContent, content, 3), nil
+	case "edit_file":
+		req, err := editRequestFromTool(toolCall)
+		if err != nil {
+			return "", err
+		}
+		edit, err := fileManager.PrepareEdit(path, req)
+		if err != nil {
+			return "", err
+		}
+		return edit.Diff(), nil
+	case "set_config_value":
+		edit, err := configEditFromTool(toolCall)
+		if err != nil {
+			return "", err
+		}
+		return edit.Diff(), nil
+	default:
+		return "", nil
+	}
+}
+
+// truncateDiff keeps the first maxLines lines of a diff
+func truncateDiff(unified string, maxLines int) string {
+	lines := strings.Split(strings.TrimSuffix(unified, "\n"), "\n")
+	if len(lines) <= maxLines {
+		return unified
+	}
+	return strings.Join(lines[:maxLines], "\n") + fmt.Sprintf("\n... (%d줄 더 있음)\n", len(lines)-maxLines)
+}
+
+// printDiffPreview prints a colorized diff for REPL approval
+func printDiffPreview(unified string) {
+	if unified == "" {
+		fmt.Println("변경 사항 없음")
+		return
+	}
+	inserted, deleted := diff.CountChanges(unified)
+	fmt.Printf("변경: +%d -%d줄\n", inserted, deleted)
+	fmt.Print(diff.Colorize(truncateDiff(unified, diffPreviewLines*2)))
+}
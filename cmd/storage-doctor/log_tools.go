@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mainbong/storage_doctor/internal/logs"
+)
+
+// newLogMonitor creates a monitor for the log source a monitor_log call selects
+func newLogMonitor(input map[string]interface{}) (*logs.Monitor, error) {
+	if source, _ := input["source"].(string); source == "" || source == "file" {
+		path, _ := input["path"].(string)
+		if path == "" {
+			return nil, fmt.Errorf("invalid path parameter")
+		}
+		// Only file monitors can tail
+		return logs.NewMonitor(path)
+	}
+	source, err := newLogSource(input)
+	if err != nil {
+		return nil, err
+	}
+	return logs.NewSourceMonitor(source), nil
+}
+
+// newLogSource creates the log source selected by source, path, unit, pod and
+// the other source parameters of monitor_log and correlate_logs
+func newLogSource(input map[string]interface{}) (logs.Source, error) {
+	source, _ := input["source"].(string)
+	path, _ := input["path"].(string)
+	namespace, _ := input["namespace"].(string)
+	pod, _ := input["pod"].(string)
+	container, _ := input["container"].(string)
+
+	switch source {
+	case "", "file":
+		if path == "" {
+			return nil, fmt.Errorf("invalid path parameter")
+		}
+		return logs.NewFileSource(path), nil
+	case "journal":
+		var units []string
+		if unit, _ := input["unit"].(string); unit != "" {
+			for _, name := range strings.Split(unit, ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					units = append(units, name)
+				}
+			}
+		}
+		return logs.NewJournalSource(shellExec, units...), nil
+	case "cri":
+		if path != "" {
+			return logs.NewCRIFileSource(path), nil
+		}
+		if pod == "" {
+			return nil, fmt.Errorf("cri source needs path or pod")
+		}
+		return logs.NewPodSource(logs.DefaultPodLogDir, namespace, pod, container), nil
+	case "kubectl":
+		if pod == "" {
+			return nil, fmt.Errorf("kubectl source needs pod")
+		}
+		previous, _ := input["previous"].(bool)
+		return logs.NewKubectlSource(shellExec, namespace, pod, container, previous), nil
+	default:
+		return nil, fmt.Errorf("unknown log source: %s", source)
+	}
+}
+
+// logSearchOptions reads the optional bounds of a monitor_log search, filter or summarize
+func logSearchOptions(input map[string]interface{}) (logs.SearchOptions, error) {
+	var opts logs.SearchOptions
+	if value, ok := input["max_matches"].(float64); ok {
+		opts.MaxMatches = int(value)
+	}
+	if value, ok := input["context"].(float64); ok && value > 0 {
+		opts.Before = int(value)
+		opts.After = int(value)
+	}
+
+	now := time.Now()
+	var err error
+	if value, ok := input["since"].(string); ok {
+		if opts.Since, err = logs.ParseTimeBound(value, now); err != nil {
+			return opts, err
+		}
+	}
+	if value, ok := input["until"].(string); ok {
+		if opts.Until, err = logs.ParseTimeBound(value, now); err != nil {
+			return opts, err
+		}
+	}
+
+	// A time range usually reaches into rotated files
+	opts.IncludeRotated = !opts.Since.IsZero()
+	if value, ok := input["include_rotated"].(bool); ok {
+		opts.IncludeRotated = value
+	}
+	return opts, nil
+}
+
+// formatLogMatches formats a bounded search result, noting matches left out
+func formatLogMatches(title string, result *logs.SearchResult) string {
+	var b strings.Builder
+	if omitted := result.Omitted(); omitted > 0 {
+		fmt.Fprintf(&b, "%s (%d개 중 %d개 표시):\n", title, result.Total, result.Matches)
+		b.WriteString(strings.Join(result.Lines, "\n"))
+		fmt.Fprintf(&b, "\n... %d개 더 있음 (pattern, since/until로 범위를 좁히거나 max_matches를 늘리세요)", omitted)
+		return b.String()
+	}
+	fmt.Fprintf(&b, "%s (%d개):\n%s", title, result.Total, strings.Join(result.Lines, "\n"))
+	return b.String()
+}
+
+// defaultCorrelateWindow is how far back correlate_logs reads without since
+const defaultCorrelateWindow = "1h"
+
+// correlateLogs merges the sources of a correlate_logs call into one timeline
+func correlateLogs(ctx context.Context, input map[string]interface{}) (string, error) {
+	items, ok := input["sources"].([]interface{})
+	if !ok || len(items) == 0 {
+		return "", fmt.Errorf("invalid sources parameter")
+	}
+	components := make([]logs.Component, 0, len(items))
+	for i, item := range items {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("invalid source %d", i+1)
+		}
+		source, err := newLogSource(fields)
+		if err != nil {
+			return "", fmt.Errorf("source %d: %w", i+1, err)
+		}
+		component := logs.Component{Source: source}
+		if component.Name, _ = fields["name"].(string); component.Name == "" {
+			component.Name = source.Name()
+		}
+		if zone, _ := fields["timezone"].(string); zone != "" {
+			if component.Location, err = logs.ParseLocation(zone); err != nil {
+				return "", fmt.Errorf("source %d: %w", i+1, err)
+			}
+		}
+		components = append(components, component)
+	}
+
+	now := time.Now()
+	since, _ := input["since"].(string)
+	if since == "" {
+		since = defaultCorrelateWindow
+	}
+	var opts logs.CorrelateOptions
+	var err error
+	if opts.Since, err = logs.ParseTimeBound(since, now); err != nil {
+		return "", err
+	}
+	if value, ok := input["until"].(string); ok {
+		if opts.Until, err = logs.ParseTimeBound(value, now); err != nil {
+			return "", err
+		}
+	}
+	if key, _ := input["key"].(string); key != "" {
+		for _, value := range strings.Split(key, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				opts.Keys = append(opts.Keys, value)
+			}
+		}
+	}
+	if pattern, _ := input["pattern"].(string); pattern != "" {
+		if opts.Pattern, err = regexp.Compile(pattern); err != nil {
+			return "", fmt.Errorf("invalid pattern: %w", err)
+		}
+	}
+	if value, ok := input["max_lines"].(float64); ok {
+		opts.MaxEntries = int(value)
+	}
+	// The window usually reaches into rotated files
+	opts.IncludeRotated = true
+	if value, ok := input["include_rotated"].(bool); ok {
+		opts.IncludeRotated = value
+	}
+	display := time.Local
+	if zone, _ := input["timezone"].(string); zone != "" {
+		if display, err = logs.ParseLocation(zone); err != nil {
+			return "", err
+		}
+	}
+
+	timeline, err := logs.Correlate(ctx, components, opts)
+	if err != nil {
+		return "", err
+	}
+	return logs.FormatTimeline(timeline, opts, display), nil
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/mainbong/storage_doctor/internal/agent"
 	"github.com/mainbong/storage_doctor/internal/chat"
 	"github.com/mainbong/storage_doctor/internal/config"
+	"github.com/mainbong/storage_doctor/internal/diff"
 	"github.com/mainbong/storage_doctor/internal/files"
 	"github.com/mainbong/storage_doctor/internal/history"
 	"github.com/mainbong/storage_doctor/internal/llm"
@@ -29,15 +30,18 @@ import (
 	"github.com/mainbong/storage_doctor/internal/logs"
 	"github.com/mainbong/storage_doctor/internal/search"
 	"github.com/mainbong/storage_doctor/internal/shell"
+	"github.com/mainbong/storage_doctor/internal/shell/policy"
 	"github.com/mainbong/storage_doctor/internal/terminal"
 )
 
 var (
 	cfg           *config.Config
+	llmProvider   llm.Provider
 	chatManager   *chat.Manager
 	shellExec     *shell.Executor
 	fileManager   *files.Manager
 	searchMgr     *search.Manager
+	pageFetcher   *search.Fetcher
 	historyMgr    *history.Manager
 	agentInstance *agent.Agent
 	skillMgr      *agent.SkillManager
@@ -158,6 +162,9 @@ var sessionLoadCmd = &cobra.Command{
 			return
 		}
 		fmt.Printf("세션 '%s' 로드 완료\n", args[0])
+		if historyMgr.GetCurrentSession().Transcript != nil {
+			fmt.Printf("대화를 이어서 진행하려면: storage-doctor --resume %s\n", args[0])
+		}
 		actions := historyMgr.GetActions()
 		if len(actions) == 0 {
 			fmt.Println("복구된 작업 히스토리가 없습니다.")
@@ -186,7 +193,11 @@ var sessionListCmd = &cobra.Command{
 		}
 		fmt.Println("저장된 세션:")
 		for _, session := range sessions {
-			fmt.Printf("  - %s (ID: %s, 생성: %s)\n", session.Name, session.ID, session.CreatedAt.Format("2006-01-02 15:04:05"))
+			usage := ""
+			if session.Usage.Calls > 0 {
+				usage = fmt.Sprintf(", 토큰: %d, 비용: %s", session.Usage.Total(), session.Usage.CostText())
+			}
+			fmt.Printf("  - %s (ID: %s, 생성: %s%s)\n", session.Name, session.ID, session.CreatedAt.Format("2006-01-02 15:04:05"), usage)
 		}
 	},
 }
@@ -195,6 +206,10 @@ var sessionHistoryCmd = &cobra.Command{
 	Use:   "history",
 	Short: "현재 세션 작업 히스토리 조회",
 	Run: func(cmd *cobra.Command, args []string) {
+		// A fresh process starts with an empty session; show the latest saved one instead
+		if len(historyMgr.GetActions()) == 0 {
+			_ = historyMgr.LoadLatestSession()
+		}
 		actions := historyMgr.GetActions()
 		if len(actions) == 0 {
 			fmt.Println("현재 세션에 기록된 작업이 없습니다.")
@@ -204,9 +219,12 @@ var sessionHistoryCmd = &cobra.Command{
 		for i, action := range actions {
 			switch action.Type {
 			case history.ActionTypeCommand:
-				fmt.Printf("  %d. [%s] 명령어: %s\n", i+1, action.Timestamp.Format("2006-01-02 15:04:05"), action.Command)
+				fmt.Printf("  %d. [%s] 명령어: %s (ID: %s)\n", i+1, action.Timestamp.Format("2006-01-02 15:04:05"), action.Command, action.ID)
+				if action.InverseCommand != "" {
+					fmt.Printf("     되돌리기: %s\n", action.InverseCommand)
+				}
 			case history.ActionTypeFile:
-				fmt.Printf("  %d. [%s] 파일 수정: %s\n", i+1, action.Timestamp.Format("2006-01-02 15:04:05"), action.FilePath)
+				fmt.Printf("  %d. [%s] 파일 수정: %s (ID: %s)\n", i+1, action.Timestamp.Format("2006-01-02 15:04:05"), action.FilePath, action.ID)
 			default:
 				fmt.Printf("  %d. [%s] 알 수 없는 작업 타입: %s\n", i+1, action.Timestamp.Format("2006-01-02 15:04:05"), action.Type)
 			}
@@ -279,12 +297,60 @@ func ensureAPIKeys(cfg *config.Config) error {
 			}
 			fmt.Printf("✓ OpenAI API 키가 설정되었습니다. (저장 위치: %s)\n", config.GetConfigFile())
 		}
+	case "ollama", "openai_compatible":
+		// Local servers usually run without an API key; set one in the config if required
+	default:
+		return fmt.Errorf("알 수 없는 LLM 프로바이더: %s", cfg.LLMProvider)
+	}
+	return nil
+}
+
+// requireAPIKeys checks that the selected LLM provider has an API key without
+// asking for one, for runs that do not own the terminal
+func requireAPIKeys(cfg *config.Config) error {
+	switch cfg.LLMProvider {
+	case "anthropic":
+		if cfg.Anthropic.APIKey == "" {
+			return fmt.Errorf("Anthropic API 키가 설정되지 않았습니다. 환경변수 ANTHROPIC_API_KEY를 설정하세요")
+		}
+	case "openai":
+		if cfg.OpenAI.APIKey == "" {
+			return fmt.Errorf("OpenAI API 키가 설정되지 않았습니다. 환경변수 OPENAI_API_KEY를 설정하세요")
+		}
+	case "ollama", "openai_compatible":
 	default:
 		return fmt.Errorf("알 수 없는 LLM 프로바이더: %s", cfg.LLMProvider)
 	}
 	return nil
 }
 
+// newLLMProvider creates the provider selected by c with its fallback, text
+// tool support and retries
+func newLLMProvider(c *config.Config) (llm.Provider, error) {
+	primaryProvider, err := newPrimaryProvider(c)
+	if err != nil {
+		return nil, fmt.Errorf("LLM 프로바이더 초기화 실패: %w", err)
+	}
+	var fallbackProvider llm.Provider
+	if mode, _ := fixtureMode(); mode == "" {
+		// Fixtures cover a single provider, so failover is off while recording or replaying
+		fallbackProvider, err = llm.NewFallbackProvider(c)
+		if err != nil {
+			return nil, fmt.Errorf("대체 LLM 프로바이더 초기화 실패: %w", err)
+		}
+	}
+	if !llm.SupportsNativeTools(primaryProvider) {
+		logger.Info("네이티브 도구 호출 미지원 모델: 텍스트 도구 호출 형식 사용")
+	}
+	// Models without native tool calling use <function_call> blocks in text
+	primaryProvider = chat.WithTextTools(primaryProvider)
+	if fallbackProvider != nil {
+		fallbackProvider = chat.WithTextTools(fallbackProvider)
+		logger.Info("대체 LLM 프로바이더: %s", fallbackProvider.GetModel())
+	}
+	return llm.NewRetryProvider(primaryProvider, fallbackProvider, llm.RetryPolicyFromConfig(c)), nil
+}
+
 func main() {
 	var err error
 
@@ -292,7 +358,7 @@ func main() {
 	cfg, err = config.Load()
 	if err != nil {
 		fmt.Printf("설정 로드 실패: %v\n", err)
-		os.Exit(1)
+		os.Exit(startupFailureCode())
 	}
 
 	// Initialize logger
@@ -315,7 +381,7 @@ func main() {
 		cwd, err := os.Getwd()
 		if err != nil {
 			fmt.Printf("현재 디렉토리 확인 실패: %v\n", err)
-			os.Exit(1)
+			os.Exit(startupFailureCode())
 		}
 		logDir = cwd
 		fmt.Printf("[개발 모드] 로그 파일이 현재 디렉토리에 저장됩니다: %s\n", logDir)
@@ -323,7 +389,7 @@ func main() {
 
 	if err := logger.Init(logDir, logLevel); err != nil {
 		fmt.Printf("로거 초기화 실패: %v\n", err)
-		os.Exit(1)
+		os.Exit(startupFailureCode())
 	}
 	defer logger.Close()
 
@@ -337,28 +403,46 @@ func main() {
 	logger.Info("Storage Doctor 시작")
 	logger.Debug("설정 로드 완료: LLM Provider=%s", cfg.LLMProvider)
 
-	// Ensure API keys are set before initializing LLM provider
-	if err := ensureAPIKeys(cfg); err != nil {
-		logger.Error("API 키 설정 실패: %v", err)
-		fmt.Printf("API 키 설정 실패: %v\n", err)
-		os.Exit(1)
+	// Ensure API keys are set before initializing LLM provider; replays never reach the API
+	mode, _ := fixtureMode()
+	if mode != "replay" {
+		// diagnose may read its prompt from stdin and writes its report to stdout, so it never asks
+		if isDiagnoseCommand() {
+			if err := requireAPIKeys(cfg); err != nil {
+				logger.Error("API 키 설정 실패: %v", err)
+				fmt.Fprintf(os.Stderr, "API 키 설정 실패: %v\n", err)
+				os.Exit(diagnoseExitFailed)
+			}
+		} else if err := ensureAPIKeys(cfg); err != nil {
+			logger.Error("API 키 설정 실패: %v", err)
+			fmt.Printf("API 키 설정 실패: %v\n", err)
+			os.Exit(startupFailureCode())
+		}
+		logger.Info("API 키 확인 완료")
 	}
-	logger.Info("API 키 확인 완료")
 
 	// Initialize LLM provider
-	llmProvider, err := llm.NewProvider(cfg)
+	llmProvider, err = newLLMProvider(cfg)
 	if err != nil {
-		logger.Error("LLM 프로바이더 초기화 실패: %v", err)
-		fmt.Printf("LLM 프로바이더 초기화 실패: %v\n", err)
-		os.Exit(1)
+		logger.Error("%v", err)
+		fmt.Printf("%v\n", err)
+		os.Exit(startupFailureCode())
 	}
 	logger.Info("LLM 프로바이더 초기화 완료: %s", llmProvider.GetModel())
 
 	// Initialize managers
 	chatManager = chat.NewManager(llmProvider)
+	chatManager.SetContextWindow(cfg.ContextWindow)
 	logger.Debug("Chat Manager 초기화 완료")
 
-	shellExec = shell.NewExecutor("")
+	commandExecutor, err := newCommandExecutor()
+	if err != nil {
+		logger.Error("%v", err)
+		fmt.Printf("%v\n", err)
+		os.Exit(startupFailureCode())
+	}
+	shellExec = shell.NewExecutorWithCommandExecutor("", commandExecutor)
+	shellExec.SetPolicy(loadCommandPolicy())
 	logger.Debug("Shell Executor 초기화 완료")
 
 	fileManager = files.NewManager(cfg.BackupDir)
@@ -367,18 +451,20 @@ func main() {
 	searchMgr, err = search.NewManager(cfg)
 	if err != nil {
 		logger.Warn("검색 매니저 초기화 실패: %v (검색 기능 없이 계속)", err)
-		fmt.Printf("검색 매니저 초기화 실패: %v\n", err)
+		fmt.Fprintf(os.Stderr, "검색 매니저 초기화 실패: %v\n", err)
 		// Continue without search
 		searchMgr = nil
 	} else {
 		logger.Debug("Search Manager 초기화 완료: Provider=%s", cfg.Search.Provider)
 	}
 
+	pageFetcher = search.NewFetcher(filepath.Join(config.GetConfigDir(), "cache", "pages"))
+
 	historyMgr, err = history.NewManager(cfg.SessionDir)
 	if err != nil {
 		logger.Error("히스토리 매니저 초기화 실패: %v", err)
 		fmt.Printf("히스토리 매니저 초기화 실패: %v\n", err)
-		os.Exit(1)
+		os.Exit(startupFailureCode())
 	}
 	logger.Debug("History Manager 초기화 완료: SessionDir=%s", cfg.SessionDir)
 
@@ -388,25 +474,58 @@ func main() {
 	if err != nil {
 		logger.Error("스킬 매니저 초기화 실패: %v", err)
 		fmt.Printf("스킬 매니저 초기화 실패: %v\n", err)
-		os.Exit(1)
+		os.Exit(startupFailureCode())
 	}
 	logger.Info("스킬 매니저 초기화 완료: SkillsDir=%s", skillsDir)
 
 	// Initialize Agent
 	agentInstance = agent.NewAgent(llmProvider, chatManager, skillMgr)
+	setupUsageTracking()
 	logger.Info("Agent 초기화 완료")
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
-		os.Exit(1)
+		os.Exit(startupFailureCode())
+	}
+	if exitCode != 0 {
+		logger.Close()
+		os.Exit(exitCode)
+	}
+}
+
+// startupFailureCode is the exit code for failures before a command has run.
+// diagnose reports them as a failed diagnosis, not as warnings found.
+func startupFailureCode() int {
+	if isDiagnoseCommand() {
+		return diagnoseExitFailed
 	}
+	return 1
+}
+
+// isDiagnoseCommand reports whether the command line runs diagnose
+func isDiagnoseCommand() bool {
+	cmd, _, err := rootCmd.Find(os.Args[1:])
+	return err == nil && cmd == diagnoseCmd
 }
 
 func runREPL(cmd *cobra.Command, args []string) {
 	color.Cyan("=== Storage Doctor AI Assistant ===\n")
 	color.Yellow("스토리지 문제를 설명해주세요. 'exit' 또는 'quit'로 종료합니다.\n\n")
 
-	ctx := context.Background()
+	ctx := llm.WithRetryReporter(context.Background(), func(status llm.RetryStatus) {
+		if status.Failover {
+			color.Yellow("\n[LLM 요청 실패로 대체 모델 %s(으)로 전환합니다: %v]\n", status.Model, status.Err)
+			return
+		}
+		color.Yellow("\n[LLM 요청 실패 (%s), %s 후 재시도합니다 (%d/%d)]\n", retryCause(status.Err), status.Wait.Round(time.Second), status.Attempt, status.MaxAttempts)
+	})
+
+	if resumeSessionID != "" {
+		if err := resumeSession(resumeSessionID); err != nil {
+			color.Red("세션 재개 실패: %v\n", err)
+			return
+		}
+	}
 
 	if tuiEnabled {
 		if err := runTUI(); err != nil {
@@ -436,6 +555,10 @@ func runREPL(cmd *cobra.Command, args []string) {
 
 	reader := bufio.NewReader(os.Stdin)
 
+	if resumeSessionID != "" {
+		printResumedConversation()
+	}
+
 	for {
 		input, err := promptUserInput(reader)
 		if err != nil {
@@ -458,10 +581,24 @@ func runREPL(cmd *cobra.Command, args []string) {
 			break
 		}
 
+		if isSlashCommand(input) {
+			result := runSlashCommand(input)
+			if result.rollback {
+				runRollback(result.rollbackTarget, reader)
+			} else if result.output != "" {
+				fmt.Println(result.output)
+			}
+			continue
+		}
+
 		if handleOutputCommand(input) {
 			continue
 		}
 
+		if handleRollbackCommand(input, reader) {
+			continue
+		}
+
 		taskCtx, taskCancel := context.WithCancel(ctx)
 		cancelMu.Lock()
 		currentCancel = taskCancel
@@ -526,7 +663,7 @@ func printInputDivider() {
 }
 
 func printShortcutHint() {
-	color.New(color.FgHiBlack).Fprintln(os.Stdout, "? 단축키 안내 (추가 예정)")
+	color.New(color.FgHiBlack).Fprintln(os.Stdout, "/help 명령어 안내")
 }
 
 func printUserMessage(message string) {
@@ -578,8 +715,34 @@ func readMultilineInput(reader *bufio.Reader) (string, error) {
 	return strings.TrimRight(builder.String(), "\r\n"), nil
 }
 
+// replReasoning prints model reasoning dimmed, set apart from the answer that follows
+type replReasoning struct {
+	active bool
+}
+
+func (r *replReasoning) write(delta string) {
+	dim := color.New(color.FgHiBlack)
+	if !r.active {
+		r.active = true
+		dim.Print("[추론]\n")
+	}
+	dim.Print(delta)
+}
+
+func (r *replReasoning) end() {
+	if r.active {
+		r.active = false
+		fmt.Print("\n\n")
+	}
+}
+
 func processInput(ctx context.Context, userInput string, reader *bufio.Reader) error {
 	logger.Info("사용자 입력 수신: %s", userInput)
+	historyMgr.SetProblem(userInput)
+	// Events of injecting watches seen since the last request go along with this one
+	if note := takeWatchInjection(); note != "" {
+		userInput = note + "\n\n" + userInput
+	}
 
 	// Use Agent system for autonomous task execution
 	if tuiEnabled {
@@ -594,15 +757,19 @@ func processInput(ctx context.Context, userInput string, reader *bufio.Reader) e
 		renderer.SetLinePrefix(color.New(color.FgHiBlack).Sprint("| "))
 	}
 
+	reasoning := &replReasoning{}
+	ctx = llm.WithThinkingReporter(ctx, reasoning.write)
+
 	err := agentInstance.StreamTask(ctx, userInput, func(chunk string) {
+		reasoning.end()
 		if renderer != nil {
 			renderer.Write(chunk)
 			return
 		}
 		fmt.Print(chunk)
-	}, func(toolCall llm.ToolCall) (string, error) {
+	}, func(toolCtx context.Context, toolCall llm.ToolCall) (string, error) {
 		logger.Debug("도구 호출: %s", toolCall.Name)
-		result, err := executeToolCallForAgent(ctx, toolCall)
+		result, err := executeToolCallForAgent(toolCtx, toolCall)
 		if err != nil {
 			logger.Error("도구 실행 실패: %s - %v", toolCall.Name, err)
 		} else {
@@ -615,7 +782,13 @@ func processInput(ctx context.Context, userInput string, reader *bufio.Reader) e
 		renderer.Flush()
 	}
 
+	if errors.Is(err, agent.ErrMaxIterations) || errors.Is(err, agent.ErrBudgetExceeded) {
+		// The conversation is intact; the user can ask the agent to continue
+		color.Yellow("\n[%v]\n", err)
+		err = nil
+	}
 	if err != nil {
+		persistSession()
 		if errors.Is(err, context.Canceled) {
 			return context.Canceled
 		}
@@ -626,12 +799,16 @@ func processInput(ctx context.Context, userInput string, reader *bufio.Reader) e
 
 	// Check if we got any response
 	logger.Info("Agent 작업 완료")
+	recordConclusion()
 
 	if tuiEnabled {
 		printAssistantFooter()
 	} else {
 		fmt.Println()
 	}
+	if usage := usageStatus(); usage != "" {
+		color.New(color.FgHiBlack).Fprintf(os.Stdout, "[세션 사용량] %s\n", usage)
+	}
 
 	// Ask user if they want to continue
 	color.New(color.FgHiBlack).Fprintln(os.Stdout, "추가로 질문이 있으시면 입력해주세요. (엔터만 누르면 계속)")
@@ -710,8 +887,12 @@ func executeToolCall(ctx context.Context, toolCall llm.ToolCall) error {
 	result, success, err := handleToolCall(ctx, toolCall, false, false)
 
 	// Add tool result to chat context
-	toolResult := chat.FormatToolCall(toolCall.Name, result, success)
-	chatManager.AddMessage("user", toolResult)
+	chatManager.AddToolResults([]llm.ToolResult{{
+		ToolCallID: toolCall.ID,
+		Name:       toolCall.Name,
+		Content:    result,
+		IsError:    !success,
+	}})
 
 	color.Green("\n[도구 실행 완료]\n")
 	if !success {
@@ -744,32 +925,30 @@ func handleToolCall(ctx context.Context, toolCall llm.ToolCall, quiet bool, appr
 			color.Cyan("명령어: %s\n", command)
 		}
 
-		// Always ask for approval unless auto-approve is set
-		if !cfg.AutoApproveCommands && shellExec.GetApprovalMode() == shell.ApprovalModeManual {
+		evaluation, decision := evaluateCommand(command)
+		if !quiet {
+			color.Cyan("분류: %s\n", evaluation.Class.Label())
+		}
+
+		switch decision {
+		case policy.DecisionDeny:
+			return "", false, fmt.Errorf("정책에 의해 차단된 명령어입니다: %s", evaluation.Reason)
+		case policy.DecisionConfirm:
 			if !approved {
 				if quiet {
 					return "", false, fmt.Errorf("승인 필요")
 				}
-				// Request approval
-				reader := bufio.NewReader(os.Stdin)
-				fmt.Printf("실행하시겠습니까? [y/n/a/s]: ")
-				response, _ := reader.ReadString('\n')
-				response = strings.TrimSpace(strings.ToLower(response))
-
-				switch response {
-				case "y", "yes":
-					// Continue
-				case "n", "no":
-					return "", false, fmt.Errorf("사용자가 명령어 실행을 취소했습니다")
-				case "a", "always":
-					cfg.AutoApproveCommands = true
-					cfg.Save()
-					color.Green("이제부터 모든 명령어를 자동으로 승인합니다.\n")
-				case "s", "session":
-					shellExec.SetApprovalMode(shell.ApprovalModeSession)
-					color.Green("이 세션 동안 모든 명령어를 자동으로 승인합니다.\n")
-				default:
-					return "", false, fmt.Errorf("잘못된 입력입니다")
+				if err := promptCommandConfirmation(evaluation); err != nil {
+					return "", false, err
+				}
+			}
+		case policy.DecisionAsk:
+			if !approved {
+				if quiet {
+					return "", false, fmt.Errorf("승인 필요")
+				}
+				if err := promptCommandApproval(evaluation); err != nil {
+					return "", false, err
 				}
 			}
 		}
@@ -781,7 +960,8 @@ func handleToolCall(ctx context.Context, toolCall llm.ToolCall, quiet bool, appr
 		} else {
 			result = fmt.Sprintf("명령어 실행 성공\n출력:\n%s", output)
 			success = true
-			historyMgr.AddCommandAction(command, output)
+			inverseCommand, _ := toolCall.Input["inverse_command"].(string)
+			historyMgr.AddCommandActionWithInverse(command, output, strings.TrimSpace(inverseCommand))
 			if !quiet {
 				displayCommandOutput(output)
 			}
@@ -825,8 +1005,14 @@ func handleToolCall(ctx context.Context, toolCall llm.ToolCall, quiet bool, appr
 		}
 
 		// Read old content for backup
+		existed := fileManager.FileExists(path)
 		oldContent, _ := fileManager.ReadFile(path)
 
+		if !quiet {
+			preview, _ := fileChangePreview(toolCall)
+			printDiffPreview(preview)
+		}
+
 		// Ask for approval
 		if !approved {
 			if quiet {
@@ -842,14 +1028,150 @@ func handleToolCall(ctx context.Context, toolCall llm.ToolCall, quiet bool, appr
 			}
 		}
 
-		err := fileManager.WriteFile(path, content)
+		backupPath, err := fileManager.WriteFileWithBackup(path, content)
 		if err != nil {
 			result = fmt.Sprintf("파일 쓰기 실패: %v", err)
 			success = false
 		} else {
 			result = fmt.Sprintf("파일 수정 성공 (백업 생성됨)")
 			success = true
-			historyMgr.AddFileAction(path, oldContent, content)
+			historyMgr.AddFileActionWithBackup(path, oldContent, content, backupPath, !existed)
+			if err := historyMgr.SaveSession(""); err != nil {
+				logger.Warn("세션 자동 저장 실패: %v", err)
+			}
+		}
+
+	case "edit_file":
+		path, ok := toolCall.Input["path"].(string)
+		if !ok {
+			return "", false, fmt.Errorf("invalid path parameter")
+		}
+		description, _ := toolCall.Input["description"].(string)
+		req, err := editRequestFromTool(toolCall)
+		if err != nil {
+			return "", false, err
+		}
+
+		if !quiet {
+			color.Yellow("\n[파일 부분 수정 요청]\n")
+			if description != "" {
+				color.Cyan("목적: %s\n", description)
+			}
+			color.Cyan("파일: %s\n", path)
+		}
+
+		edit, err := fileManager.PrepareEdit(path, req)
+		if err != nil {
+			// Conflicts go back to the model so it can re-read the file and retry
+			return fmt.Sprintf("파일 수정 실패: %v", err), false, nil
+		}
+		unified := edit.Diff()
+		if unified == "" {
+			return "변경 사항 없음 (파일 내용이 이미 동일합니다)", true, nil
+		}
+		if !quiet {
+			printDiffPreview(unified)
+		}
+
+		if !approved {
+			if quiet {
+				return "", false, fmt.Errorf("승인 필요")
+			}
+			reader := bufio.NewReader(os.Stdin)
+			fmt.Printf("파일을 수정하시겠습니까? [y/n]: ")
+			response, _ := reader.ReadString('\n')
+			response = strings.TrimSpace(strings.ToLower(response))
+
+			if response != "y" && response != "yes" {
+				return "", false, fmt.Errorf("사용자가 파일 수정을 취소했습니다")
+			}
+		}
+
+		backupPath, err := fileManager.ApplyEdit(edit)
+		if err != nil {
+			result = fmt.Sprintf("파일 수정 실패: %v", err)
+			success = false
+		} else {
+			inserted, deleted := diff.CountChanges(unified)
+			result = fmt.Sprintf("파일 수정 성공 (+%d -%d줄, 백업 생성됨)", inserted, deleted)
+			success = true
+			historyMgr.AddFileActionWithBackup(path, edit.OldContent, edit.NewContent, backupPath, !edit.Existed)
+			if err := historyMgr.SaveSession(""); err != nil {
+				logger.Warn("세션 자동 저장 실패: %v", err)
+			}
+		}
+
+	case "get_config_value":
+		path, ok := toolCall.Input["path"].(string)
+		if !ok {
+			return "", false, fmt.Errorf("invalid path parameter")
+		}
+		keyPath, ok := toolCall.Input["key_path"].(string)
+		if !ok {
+			return "", false, fmt.Errorf("invalid key_path parameter")
+		}
+
+		value, err := fileManager.GetConfigValue(path, keyPath, configDocument(toolCall))
+		if err != nil {
+			result = fmt.Sprintf("설정 값 읽기 실패: %v", err)
+			success = false
+		} else {
+			result = fmt.Sprintf("%s:\n%s", keyPath, formatConfigValue(value))
+			success = true
+		}
+
+	case "set_config_value":
+		path, ok := toolCall.Input["path"].(string)
+		if !ok {
+			return "", false, fmt.Errorf("invalid path parameter")
+		}
+		keyPath, _ := toolCall.Input["key_path"].(string)
+		description, _ := toolCall.Input["description"].(string)
+
+		if !quiet {
+			color.Yellow("\n[설정 값 변경 요청]\n")
+			if description != "" {
+				color.Cyan("목적: %s\n", description)
+			}
+			color.Cyan("파일: %s\n", path)
+			color.Cyan("경로: %s\n", keyPath)
+		}
+
+		edit, err := configEditFromTool(toolCall)
+		if err != nil {
+			// Path and format errors go back to the model so it can correct the request
+			return fmt.Sprintf("설정 값 변경 실패: %v", err), false, nil
+		}
+		unified := edit.Diff()
+		if unified == "" {
+			return "변경 사항 없음 (이미 같은 값입니다)", true, nil
+		}
+		if !quiet {
+			printDiffPreview(unified)
+		}
+
+		if !approved {
+			if quiet {
+				return "", false, fmt.Errorf("승인 필요")
+			}
+			reader := bufio.NewReader(os.Stdin)
+			fmt.Printf("설정을 변경하시겠습니까? [y/n]: ")
+			response, _ := reader.ReadString('\n')
+			response = strings.TrimSpace(strings.ToLower(response))
+
+			if response != "y" && response != "yes" {
+				return "", false, fmt.Errorf("사용자가 설정 변경을 취소했습니다")
+			}
+		}
+
+		backupPath, err := fileManager.ApplyEdit(edit)
+		if err != nil {
+			result = fmt.Sprintf("설정 값 변경 실패: %v", err)
+			success = false
+		} else {
+			result = fmt.Sprintf("설정 값 변경 성공: %s (백업 생성됨)", keyPath)
+			success = true
+			historyMgr.AddFileActionWithBackup(path, edit.OldContent, edit.NewContent, backupPath, false)
 			if err := historyMgr.SaveSession(""); err != nil {
 				logger.Warn("세션 자동 저장 실패: %v", err)
 			}
@@ -875,29 +1197,74 @@ func handleToolCall(ctx context.Context, toolCall llm.ToolCall, quiet bool, appr
 		} else {
 			result = searchMgr.FormatResults(results)
 			success = true
+			sources := make([]history.Source, len(results))
+			for i, r := range results {
+				sources[i] = history.Source{Title: r.Title, URL: r.URL}
+			}
+			historyMgr.AddSearch(query, sources)
 		}
 
-	case "monitor_log":
-		path, ok := toolCall.Input["path"].(string)
+	case "fetch_url":
+		pageURL, ok := toolCall.Input["url"].(string)
 		if !ok {
-			return "", false, fmt.Errorf("invalid path parameter")
+			return "", false, fmt.Errorf("invalid url parameter")
+		}
+		maxTokens := 0
+		if value, ok := toolCall.Input["max_tokens"].(float64); ok {
+			maxTokens = int(value)
+		}
+
+		if pageFetcher == nil {
+			return "", false, fmt.Errorf("페이지 가져오기 기능이 사용 불가능합니다")
+		}
+
+		if !quiet {
+			color.Yellow("\n[페이지 읽는 중: %s]\n", pageURL)
+		}
+		page, err := pageFetcher.Fetch(ctx, pageURL)
+		if err != nil {
+			result = fmt.Sprintf("페이지 가져오기 실패: %v", err)
+			success = false
+		} else {
+			result = search.FormatPage(page, maxTokens)
+			success = true
+			historyMgr.AddSearch(pageURL, []history.Source{{Title: page.Title, URL: page.URL}})
 		}
+
+	case "monitor_log":
 		action, ok := toolCall.Input["action"].(string)
 		if !ok {
 			return "", false, fmt.Errorf("invalid action parameter")
 		}
 
-		monitor, err := logs.NewMonitor(path)
+		switch action {
+		case "list_watches":
+			return formatWatchList(), true, nil
+		case "cancel_watch":
+			id, _ := toolCall.Input["watch_id"].(string)
+			if id == "" {
+				return "", false, fmt.Errorf("invalid watch_id parameter")
+			}
+			result, err := cancelLogWatch(id)
+			if err != nil {
+				return "", false, err
+			}
+			return result, true, nil
+		}
+
+		monitor, err := newLogMonitor(toolCall.Input)
 		if err != nil {
 			return "", false, fmt.Errorf("로그 모니터 생성 실패: %w", err)
 		}
+		// Watches keep the monitor after the call returns; the TUI cannot
+		// give a blocking tail the terminal, so it watches in the background
+		if action == "watch" || (action == "tail" && quiet) {
+			return startLogWatch(monitor, toolCall.Input, action == "tail")
+		}
 		defer monitor.Close()
 
 		switch action {
 		case "tail":
-			if quiet {
-				return "", false, fmt.Errorf("TUI 모드에서는 로그 실시간 모니터링 출력을 지원하지 않습니다")
-			}
 			color.Yellow("\n[로그 실시간 모니터링 - Ctrl+C로 중지]\n")
 			ctx, cancel := context.WithCancel(ctx)
 			go func() {
@@ -917,38 +1284,66 @@ func handleToolCall(ctx context.Context, toolCall llm.ToolCall, quiet bool, appr
 			}
 		case "search":
 			pattern, _ := toolCall.Input["pattern"].(string)
-			matches, err := monitor.Search(pattern)
+			opts, err := logSearchOptions(toolCall.Input)
+			if err != nil {
+				return "", false, err
+			}
+			matches, err := monitor.SearchWithOptions(ctx, pattern, opts)
 			if err != nil {
 				result = fmt.Sprintf("검색 실패: %v", err)
 				success = false
 			} else {
-				result = fmt.Sprintf("검색 결과 (%d개):\n%s", len(matches), strings.Join(matches, "\n"))
+				result = formatLogMatches("검색 결과", matches)
 				success = true
 			}
 		case "filter":
 			pattern, _ := toolCall.Input["pattern"].(string)
-			matches, err := monitor.Filter(pattern)
+			opts, err := logSearchOptions(toolCall.Input)
+			if err != nil {
+				return "", false, err
+			}
+			matches, err := monitor.FilterWithOptions(ctx, pattern, opts)
 			if err != nil {
 				result = fmt.Sprintf("필터링 실패: %v", err)
 				success = false
 			} else {
-				result = fmt.Sprintf("필터링 결과 (%d개):\n%s", len(matches), strings.Join(matches, "\n"))
+				result = formatLogMatches("필터링 결과", matches)
 				success = true
 			}
 		case "summarize":
-			stats, err := monitor.Summarize()
+			opts, err := logSearchOptions(toolCall.Input)
+			if err != nil {
+				return "", false, err
+			}
+			top := 0
+			if value, ok := toolCall.Input["top"].(float64); ok {
+				top = int(value)
+			}
+			summary, err := monitor.SummarizePatterns(ctx, opts)
 			if err != nil {
 				result = fmt.Sprintf("요약 실패: %v", err)
 				success = false
 			} else {
-				result = fmt.Sprintf("로그 요약:\n총 라인: %v\n에러: %v\n경고: %v\n정보: %v",
-					stats["total_lines"], stats["error_count"], stats["warn_count"], stats["info_count"])
+				result = logs.FormatSummary(summary, top)
 				success = true
 			}
 		default:
 			return "", false, fmt.Errorf("unknown action: %s", action)
 		}
 
+	case "correlate_logs":
+		if !quiet {
+			color.Yellow("\n[로그 상관 분석 중]\n")
+		}
+		timeline, err := correlateLogs(ctx, toolCall.Input)
+		if err != nil {
+			result = fmt.Sprintf("로그 상관 분석 실패: %v", err)
+			success = false
+		} else {
+			result = timeline
+			success = true
+		}
+
 	case "ask_user":
 		question, ok := toolCall.Input["question"].(string)
 		if !ok {
@@ -965,8 +1360,6 @@ func handleToolCall(ctx context.Context, toolCall llm.ToolCall, quiet bool, appr
 		result = fmt.Sprintf("사용자 답변: %s", answer)
 		success = true
 
-		chatManager.AddMessage("user", fmt.Sprintf("질문: %s\n답변: %s", question, answer))
-
 	default:
 		return "", false, fmt.Errorf("unknown tool: %s", toolCall.Name)
 	}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStartupFailureCode(t *testing.T) {
+	args := os.Args
+	defer func() { os.Args = args }()
+
+	tests := []struct {
+		args     []string
+		expected int
+	}{
+		{[]string{"storage-doctor", "diagnose", "--prompt", "check"}, diagnoseExitFailed},
+		{[]string{"storage-doctor", "--dev", "diagnose"}, diagnoseExitFailed},
+		{[]string{"storage-doctor"}, 1},
+		{[]string{"storage-doctor", "session", "list"}, 1},
+	}
+	for _, tt := range tests {
+		os.Args = tt.args
+		if got := startupFailureCode(); got != tt.expected {
+			t.Errorf("startupFailureCode() for %v = %d, expected %d", tt.args, got, tt.expected)
+		}
+	}
+}
+
+func TestDiagnoseWithoutAPIKey(t *testing.T) {
+	if os.Getenv("STORAGE_DOCTOR_TEST_MAIN") == "1" {
+		os.Args = []string{"storage-doctor", "diagnose", "--prompt-file", "-"}
+		main()
+		return
+	}
+
+	// Run main in a child process with an empty home and the prompt piped in
+	home := t.TempDir()
+	cmd := exec.Command(os.Args[0], "-test.run=^TestDiagnoseWithoutAPIKey$")
+	for _, env := range os.Environ() {
+		switch strings.SplitN(env, "=", 2)[0] {
+		case "HOME", "ANTHROPIC_API_KEY", "OPENAI_API_KEY", recordDirEnv, replayDirEnv:
+			continue
+		}
+		cmd.Env = append(cmd.Env, env)
+	}
+	cmd.Env = append(cmd.Env, "HOME="+home, "STORAGE_DOCTOR_TEST_MAIN=1")
+	cmd.Stdin = strings.NewReader("check the pvc\n")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != diagnoseExitFailed {
+		t.Fatalf("Expected exit code %d, got %v (stderr %q)", diagnoseExitFailed, err, stderr.String())
+	}
+	if strings.Contains(stdout.String(), "API Key") {
+		t.Errorf("Expected no key prompt in the report, got %q", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "ANTHROPIC_API_KEY") {
+		t.Errorf("Expected the missing key on stderr, got %q", stderr.String())
+	}
+	data, err := os.ReadFile(filepath.Join(home, ".storage-doctor", "config.json"))
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	if strings.Contains(string(data), "check the pvc") {
+		t.Errorf("Expected the prompt not to be saved as the API key, got %s", data)
+	}
+}
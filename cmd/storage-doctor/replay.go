@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mainbong/storage_doctor/internal/config"
+	"github.com/mainbong/storage_doctor/internal/llm"
+	"github.com/mainbong/storage_doctor/internal/logger"
+	"github.com/mainbong/storage_doctor/internal/shell"
+)
+
+// Environment variables selecting a fixture directory. They are read before
+// the command line is parsed, since providers are created first.
+const (
+	recordDirEnv = "STORAGE_DOCTOR_RECORD" // Record LLM exchanges and commands into the directory
+	replayDirEnv = "STORAGE_DOCTOR_REPLAY" // Answer from the directory without network or commands
+)
+
+// Fixture files inside a record/replay directory
+const (
+	llmFixtureFile     = "llm.json"
+	commandFixtureFile = "commands.json"
+)
+
+// fixtureMode returns "record" or "replay" with the fixture directory, or "" for normal runs
+func fixtureMode() (string, string) {
+	if dir := strings.TrimSpace(os.Getenv(replayDirEnv)); dir != "" {
+		return "replay", dir
+	}
+	if dir := strings.TrimSpace(os.Getenv(recordDirEnv)); dir != "" {
+		return "record", dir
+	}
+	return "", ""
+}
+
+// newPrimaryProvider creates the LLM provider selected by c, recording or replaying it when requested
+func newPrimaryProvider(c *config.Config) (llm.Provider, error) {
+	mode, dir := fixtureMode()
+	path := filepath.Join(dir, llmFixtureFile)
+	switch mode {
+	case "record":
+		logger.Info("LLM 요청 기록: %s", path)
+		return llm.NewRecordingProvider(c, path)
+	case "replay":
+		logger.Info("LLM 응답 재생: %s", path)
+		return llm.NewReplayProvider(c, path)
+	default:
+		return llm.NewProvider(c)
+	}
+}
+
+// newCommandExecutor returns the executor running shell commands, recording or replaying them when requested
+func newCommandExecutor() (shell.CommandExecutor, error) {
+	mode, dir := fixtureMode()
+	path := filepath.Join(dir, commandFixtureFile)
+	switch mode {
+	case "record":
+		logger.Info("명령어 실행 기록: %s", path)
+		return shell.NewCommandRecorder(shell.NewOSCommandExecutor(), path), nil
+	case "replay":
+		logger.Info("명령어 결과 재생: %s", path)
+		replayer, err := shell.NewCommandReplayer(path)
+		if err != nil {
+			return nil, fmt.Errorf("명령어 재생 파일 로드 실패: %w", err)
+		}
+		return replayer, nil
+	default:
+		return shell.NewOSCommandExecutor(), nil
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mainbong/storage_doctor/internal/llm"
+	"github.com/mainbong/storage_doctor/internal/report"
+)
+
+var (
+	reportFormat string
+	reportOutput string
+)
+
+var sessionReportCmd = &cobra.Command{
+	Use:   "report [session-id]",
+	Short: "세션 진단 보고서 생성 (Markdown / HTML / JSON)",
+	Long: `세션의 문제 설명, 실행한 명령어와 출력, 파일 변경 diff, 참고한 웹 자료,
+Agent의 결론을 하나의 보고서로 만듭니다. 세션 ID를 생략하면 가장 최근 세션을 사용합니다.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		if len(args) > 0 {
+			err = historyMgr.LoadSession(args[0])
+		} else {
+			err = historyMgr.LoadLatestSession()
+		}
+		if err != nil {
+			fmt.Printf("세션 로드 실패: %v\n", err)
+			return
+		}
+
+		out, err := report.Render(report.FromSession(historyMgr.GetCurrentSession(), report.DefaultOptions), reportFormat)
+		if err != nil {
+			fmt.Printf("보고서 생성 실패: %v\n", err)
+			return
+		}
+		if reportOutput == "" {
+			fmt.Print(out)
+			return
+		}
+		if err := os.WriteFile(reportOutput, []byte(out), 0644); err != nil {
+			fmt.Printf("보고서 저장 실패: %v\n", err)
+			return
+		}
+		fmt.Printf("보고서 저장 완료: %s\n", reportOutput)
+	},
+}
+
+func init() {
+	sessionReportCmd.Flags().StringVar(&reportFormat, "format", "md", "보고서 형식 (md, html, json)")
+	sessionReportCmd.Flags().StringVarP(&reportOutput, "output", "o", "", "보고서를 저장할 파일 (기본: 표준 출력)")
+	sessionCmd.AddCommand(sessionReportCmd)
+}
+
+// recordConclusion stores the agent's final answer in the session for reports
+func recordConclusion() {
+	if conclusion := lastAssistantText(chatManager.GetMessages()); conclusion != "" {
+		historyMgr.SetConclusion(conclusion)
+	}
+	persistSession()
+}
+
+// lastAssistantText returns the most recent non-empty assistant text
+func lastAssistantText(messages []llm.Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "assistant" && strings.TrimSpace(messages[i].Content) != "" {
+			return strings.TrimSpace(messages[i].Content)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"github.com/mainbong/storage_doctor/internal/history"
+	"github.com/mainbong/storage_doctor/internal/llm"
+	"github.com/mainbong/storage_doctor/internal/logger"
+)
+
+var resumeSessionID string
+
+func init() {
+	rootCmd.Flags().StringVar(&resumeSessionID, "resume", "", "저장된 세션 ID의 대화를 이어서 진행")
+}
+
+// persistSession records the conversation in the current session and saves it
+func persistSession() {
+	if err := saveSession(""); err != nil {
+		logger.Warn("세션 자동 저장 실패: %v", err)
+	}
+}
+
+// saveSession saves the session with its transcript, renaming it when name is set
+func saveSession(name string) error {
+	historyMgr.SetTranscript(history.Transcript{
+		Provider:     cfg.LLMProvider,
+		Model:        llmProvider.GetModel(),
+		Messages:     chatManager.GetMessages(),
+		ActiveSkills: agentInstance.ActiveSkills(),
+		Usage:        chatManager.Usage(),
+	})
+	return historyMgr.SaveSession(name)
+}
+
+// resumeSession loads a saved session and restores its conversation, skills and token usage
+func resumeSession(sessionID string) error {
+	if err := historyMgr.LoadSession(sessionID); err != nil {
+		return err
+	}
+	session := historyMgr.GetCurrentSession()
+	transcript := session.Transcript
+	if transcript == nil {
+		return fmt.Errorf("세션 '%s'에 저장된 대화 기록이 없습니다", sessionID)
+	}
+
+	if transcript.Provider != cfg.LLMProvider || transcript.Model != llmProvider.GetModel() {
+		logger.Warn("세션 모델(%s/%s)과 현재 모델(%s/%s)이 다릅니다", transcript.Provider, transcript.Model, cfg.LLMProvider, llmProvider.GetModel())
+		color.Yellow("세션은 %s/%s로 진행되었으며 현재 %s/%s로 이어서 진행합니다.\n", transcript.Provider, transcript.Model, cfg.LLMProvider, llmProvider.GetModel())
+	}
+
+	chatManager.Restore(transcript.Messages, transcript.Usage)
+	if err := agentInstance.RestoreSkills(transcript.ActiveSkills); err != nil {
+		logger.Warn("스킬 복원 실패: %v", err)
+		color.Yellow("스킬 복원 실패: %v\n", err)
+	}
+	logger.Info("세션 재개: %s (메시지 %d개)", session.ID, len(transcript.Messages))
+	return nil
+}
+
+// resumedChatMessages converts the restored conversation into TUI messages
+func resumedChatMessages() []chatMessage {
+	session := historyMgr.GetCurrentSession()
+	messages := []chatMessage{{
+		role:    "system",
+		content: resumeBanner(session),
+	}}
+	for _, msg := range chatManager.GetMessages() {
+		content := strings.TrimSpace(msg.Content)
+		if content == "" {
+			continue
+		}
+		switch msg.Role {
+		case "user", "assistant":
+			messages = append(messages, chatMessage{role: msg.Role, content: content})
+		}
+	}
+	return messages
+}
+
+// printResumedConversation shows where a resumed REPL session left off
+func printResumedConversation() {
+	session := historyMgr.GetCurrentSession()
+	color.Cyan("%s\n", resumeBanner(session))
+	messages := chatManager.GetMessages()
+	if request := lastUserText(messages); request != "" {
+		color.New(color.FgHiBlack).Printf("마지막 요청: %s\n", request)
+	}
+	if answer := lastAssistantText(messages); answer != "" {
+		color.Cyan("\n[Agent]\n")
+		fmt.Println(answer)
+	}
+	fmt.Println()
+}
+
+func resumeBanner(session *history.Session) string {
+	usage := llm.Usage{}
+	count := 0
+	if session.Transcript != nil {
+		usage = session.Transcript.Usage
+		count = len(session.Transcript.Messages)
+	}
+	return fmt.Sprintf("세션 '%s' 재개 (ID: %s, 메시지 %d개, 작업 %d개, 토큰 입력 %d / 출력 %d)",
+		session.Name, session.ID, count, len(session.Actions), usage.InputTokens, usage.OutputTokens)
+}
+
+// lastUserText returns the most recent non-empty user text
+func lastUserText(messages []llm.Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" && strings.TrimSpace(messages[i].Content) != "" {
+			return strings.TrimSpace(messages[i].Content)
+		}
+	}
+	return ""
+}
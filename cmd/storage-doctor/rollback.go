@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/mainbong/storage_doctor/internal/diff"
+	"github.com/mainbong/storage_doctor/internal/history"
+	"github.com/mainbong/storage_doctor/internal/shell/policy"
+)
+
+var rollbackSessionID string
+
+var sessionRollbackCmd = &cobra.Command{
+	Use:   "rollback [n|action-id]",
+	Short: "최근 작업 롤백 (파일 복원 / 되돌리기 명령어 실행)",
+	Long: `최근 작업을 역순으로 되돌립니다.
+  인자 없음: 마지막 작업 1개
+  n: 마지막 n개 작업
+  action-id: 해당 작업과 그 이후의 모든 작업
+
+파일 작업은 변경 내용(diff)을 보여준 뒤 이전 내용으로 복원하고,
+명령어 작업은 실행 시 기록된 되돌리기 명령어가 있을 때만 실행합니다.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		if rollbackSessionID != "" {
+			err = historyMgr.LoadSession(rollbackSessionID)
+		} else {
+			err = historyMgr.LoadLatestSession()
+		}
+		if err != nil {
+			fmt.Printf("세션 로드 실패: %v\n", err)
+			return
+		}
+
+		target := ""
+		if len(args) > 0 {
+			target = args[0]
+		}
+		session := historyMgr.GetCurrentSession()
+		fmt.Printf("세션: %s (ID: %s)\n", session.Name, session.ID)
+		runRollback(target, bufio.NewReader(os.Stdin))
+	},
+}
+
+func init() {
+	sessionRollbackCmd.Flags().StringVar(&rollbackSessionID, "session", "", "롤백할 세션 ID (기본: 가장 최근 세션)")
+	sessionCmd.AddCommand(sessionRollbackCmd)
+}
+
+// handleRollbackCommand handles "rollback [n|action-id]" typed in the REPL
+func handleRollbackCommand(input string, reader *bufio.Reader) bool {
+	fields := strings.Fields(input)
+	if len(fields) == 0 || len(fields) > 2 || strings.ToLower(fields[0]) != "rollback" {
+		return false
+	}
+	target := ""
+	if len(fields) == 2 {
+		target = fields[1]
+	}
+	runRollback(target, reader)
+	return true
+}
+
+// runRollback undoes the selected actions, most recent first. Each step is
+// previewed and confirmed; declining a step stops the rollback so earlier
+// actions are never undone out of order.
+func runRollback(target string, reader *bufio.Reader) {
+	actions, err := historyMgr.SelectRollback(target)
+	if err != nil {
+		color.Red("롤백 대상 선택 실패: %v\n", err)
+		return
+	}
+
+	undone := 0
+	for i, action := range actions {
+		step := history.PlanRollback(action, fileManager.FindBackup, fileManager.ReadFile)
+		color.Yellow("\n[롤백 %d/%d] %s\n", i+1, len(actions), action.Timestamp.Format("2006-01-02 15:04:05"))
+
+		done, proceed := executeRollbackStep(step, reader)
+		if done {
+			if err := historyMgr.RemoveAction(action.ID); err != nil {
+				color.Red("히스토리 갱신 실패: %v\n", err)
+			}
+			undone++
+		}
+		if !proceed {
+			color.Yellow("롤백을 중단했습니다.\n")
+			break
+		}
+	}
+
+	if undone > 0 {
+		if err := historyMgr.SaveSession(""); err != nil {
+			color.Red("세션 저장 실패: %v\n", err)
+		}
+	}
+	color.Green("\n롤백 완료: %d/%d개 작업\n", undone, len(actions))
+}
+
+// executeRollbackStep previews and applies one rollback step.
+// It reports whether the action was undone and whether to continue with older actions.
+func executeRollbackStep(step history.RollbackStep, reader *bufio.Reader) (bool, bool) {
+	action := step.Action
+
+	switch step.Kind {
+	case history.RollbackRestoreFile, history.RollbackDeleteFile:
+		color.Cyan("파일: %s\n", action.FilePath)
+		current, _ := fileManager.ReadFile(action.FilePath)
+		exists := fileManager.FileExists(action.FilePath)
+		if exists && current != action.NewValue {
+			color.Red("주의: 작업 이후 파일이 다시 변경되었습니다. 이후 변경 사항도 함께 사라집니다.\n")
+		}
+
+		if step.Kind == history.RollbackDeleteFile {
+			if !exists {
+				fmt.Println("파일이 이미 존재하지 않습니다.")
+				return true, true
+			}
+			fmt.Print(diff.Colorize(diff.Unified(action.FilePath, "/dev/null", current, "", 3)))
+			if !confirmRollback("이 작업으로 생성된 파일을 삭제하시겠습니까?", reader) {
+				return false, false
+			}
+			if err := fileManager.RemoveFile(action.FilePath); err != nil {
+				color.Red("파일 삭제 실패: %v\n", err)
+				return false, false
+			}
+			color.Green("파일 삭제 완료\n")
+			return true, true
+		}
+
+		if step.Source != "old_value" {
+			fmt.Printf("복원 원본: %s\n", step.Source)
+		}
+		unified := diff.Unified(action.FilePath+" (현재)", action.FilePath+" (복원 후)", current, step.Content, 3)
+		if exists && unified == "" {
+			fmt.Println("현재 내용이 이미 이전 내용과 같습니다.")
+			return true, true
+		}
+		fmt.Print(diff.Colorize(unified))
+		if !confirmRollback("이전 내용으로 복원하시겠습니까?", reader) {
+			return false, false
+		}
+		if err := fileManager.WriteFile(action.FilePath, step.Content); err != nil {
+			color.Red("파일 복원 실패: %v\n", err)
+			return false, false
+		}
+		color.Green("파일 복원 완료 (현재 내용은 백업됨)\n")
+		return true, true
+
+	case history.RollbackInverseCommand:
+		color.Cyan("명령어: %s\n", action.Command)
+		color.Cyan("되돌리기 명령어: %s\n", step.Command)
+
+		evaluation, decision := evaluateCommand(step.Command)
+		fmt.Printf("분류: %s\n", evaluation.Class.Label())
+		switch decision {
+		case policy.DecisionDeny:
+			color.Red("정책에 의해 차단된 명령어입니다: %s\n", evaluation.Reason)
+			return false, false
+		case policy.DecisionConfirm:
+			if err := promptCommandConfirmation(evaluation); err != nil {
+				color.Red("%v\n", err)
+				return false, false
+			}
+		default:
+			if !confirmRollback("되돌리기 명령어를 실행하시겠습니까?", reader) {
+				return false, false
+			}
+		}
+
+		output, err := shellExec.ExecuteSilent(step.Command)
+		if output != "" {
+			displayCommandOutput(output)
+		}
+		if err != nil {
+			color.Red("되돌리기 명령어 실패: %v\n", err)
+			return false, false
+		}
+		color.Green("되돌리기 명령어 실행 완료\n")
+		return true, true
+
+	default:
+		color.Cyan("명령어: %s\n", action.Command)
+		color.Red("되돌리기 명령어가 기록되지 않아 자동으로 롤백할 수 없습니다. 필요하면 직접 되돌려주세요.\n")
+		// Skipped actions stay in history so they remain visible
+		return false, confirmRollback("이 작업을 건너뛰고 이전 작업 롤백을 계속하시겠습니까?", reader)
+	}
+}
+
+func confirmRollback(question string, reader *bufio.Reader) bool {
+	fmt.Printf("%s [y/n]: ", question)
+	response, _ := reader.ReadString('\n')
+	response = strings.TrimSpace(strings.ToLower(response))
+	return response == "y" || response == "yes"
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mainbong/storage_doctor/internal/agent"
+	"github.com/mainbong/storage_doctor/internal/chat"
+	"github.com/mainbong/storage_doctor/internal/config"
+	"github.com/mainbong/storage_doctor/internal/files"
+	"github.com/mainbong/storage_doctor/internal/history"
+	"github.com/mainbong/storage_doctor/internal/llm"
+	"github.com/mainbong/storage_doctor/internal/shell"
+	"github.com/mainbong/storage_doctor/internal/shell/policy"
+)
+
+// Scenarios live in testdata/scenarios/<name>/ with
+//
+//	scenario.json  prompt, provider and model of the run
+//	llm.json       LLM exchanges recorded with STORAGE_DOCTOR_RECORD
+//	commands.json  shell commands recorded with STORAGE_DOCTOR_RECORD
+//	expected.json  golden result; rewrite with go test -run TestScenarios -update
+var updateGolden = flag.Bool("update", false, "rewrite scenario expected.json files")
+
+type scenario struct {
+	Prompt        string `json:"prompt"`
+	Provider      string `json:"provider"`
+	Model         string `json:"model"`
+	Policy        string `json:"policy,omitempty"`
+	MaxIterations int    `json:"max_iterations,omitempty"`
+}
+
+type scenarioResult struct {
+	Status          string             `json:"status"`
+	ExitCode        int                `json:"exit_code"`
+	Error           string             `json:"error,omitempty"`
+	LLMCalls        int                `json:"llm_calls"`
+	ToolCalls       []scenarioToolCall `json:"tool_calls"`
+	HistoryCommands []string           `json:"history_commands"`
+	Conclusion      string             `json:"conclusion"`
+}
+
+type scenarioToolCall struct {
+	Name    string `json:"name"`
+	Target  string `json:"target,omitempty"`
+	Success bool   `json:"success"`
+	Refused bool   `json:"refused,omitempty"`
+}
+
+func TestScenarios(t *testing.T) {
+	dirs, err := filepath.Glob(filepath.Join("testdata", "scenarios", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dirs) == 0 {
+		t.Fatal("no scenarios found in testdata/scenarios")
+	}
+	for _, dir := range dirs {
+		dir := dir
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			runScenario(t, dir)
+		})
+	}
+}
+
+func runScenario(t *testing.T, dir string) {
+	sc := loadScenario(t, dir)
+	setupScenario(t, sc)
+
+	provider, err := llm.NewReplayProvider(cfg, filepath.Join(dir, llmFixtureFile))
+	if err != nil {
+		t.Fatalf("NewReplayProvider() failed: %v", err)
+	}
+	replayer, err := shell.NewCommandReplayer(filepath.Join(dir, commandFixtureFile))
+	if err != nil {
+		t.Fatalf("NewCommandReplayer() failed: %v", err)
+	}
+	initScenarioManagers(t, provider, replayer)
+
+	report := diagnose(context.Background(), sc.Prompt)
+
+	result := scenarioResult{
+		Status:     report.Status,
+		ExitCode:   report.ExitCode,
+		Error:      report.Error,
+		LLMCalls:   report.Usage.Calls,
+		Conclusion: report.Conclusion,
+	}
+	for _, call := range report.ToolCalls {
+		result.ToolCalls = append(result.ToolCalls, scenarioToolCall{
+			Name: call.Name, Target: call.Target, Success: call.Success, Refused: call.Refused,
+		})
+	}
+
+	// The session must have been saved with the commands that ran
+	saved, err := history.NewManager(cfg.SessionDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := saved.ListSessions()
+	if err != nil || len(sessions) != 1 {
+		t.Fatalf("Expected one saved session, got %d (%v)", len(sessions), err)
+	}
+	for _, action := range sessions[0].Actions {
+		if action.Type == history.ActionTypeCommand {
+			result.HistoryCommands = append(result.HistoryCommands, action.Command)
+		}
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.Bytes()
+	expectedPath := filepath.Join(dir, "expected.json")
+	if *updateGolden {
+		if err := os.WriteFile(expectedPath, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	expected, err := os.ReadFile(expectedPath)
+	if err != nil {
+		t.Fatalf("Failed to read expected.json (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(got, expected) {
+		t.Errorf("Scenario result differs from expected.json\n--- got ---\n%s\n--- expected ---\n%s", got, expected)
+	}
+}
+
+func loadScenario(t *testing.T, dir string) scenario {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, "scenario.json"))
+	if err != nil {
+		t.Fatalf("Failed to read scenario.json: %v", err)
+	}
+	var sc scenario
+	if err := json.Unmarshal(data, &sc); err != nil {
+		t.Fatalf("Failed to parse scenario.json: %v", err)
+	}
+	if sc.Policy == "" {
+		sc.Policy = diagnosePolicyReadOnly
+	}
+	if sc.MaxIterations == 0 {
+		sc.MaxIterations = 10
+	}
+	return sc
+}
+
+// setupScenario configures a run like main does, with every directory inside the test
+func setupScenario(t *testing.T, sc scenario) {
+	t.Helper()
+	cfg = &config.Config{
+		LLMProvider: sc.Provider,
+		SessionDir:  t.TempDir(),
+		BackupDir:   t.TempDir(),
+	}
+	switch sc.Provider {
+	case "anthropic":
+		cfg.Anthropic.Model = sc.Model
+	case "openai":
+		cfg.OpenAI.Model = sc.Model
+	case "ollama":
+		cfg.Ollama.Model = sc.Model
+	case "openai_compatible":
+		cfg.OpenAICompatible.Model = sc.Model
+	}
+	diagnosePolicy = sc.Policy
+	diagnoseMaxIterations = sc.MaxIterations
+}
+
+func initScenarioManagers(t *testing.T, provider llm.Provider, executor shell.CommandExecutor) {
+	t.Helper()
+	var err error
+	llmProvider = chat.WithTextTools(provider)
+	chatManager = chat.NewManager(llmProvider)
+
+	shellExec = shell.NewExecutorWithCommandExecutor("", executor)
+	engine, err := policy.NewEngineWithRules(policy.Rules{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shellExec.SetPolicy(engine)
+
+	fileManager = files.NewManager(cfg.BackupDir)
+	searchMgr = nil
+	historyMgr, err = history.NewManager(cfg.SessionDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	skillMgr, err = agent.NewSkillManager(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	agentInstance = agent.NewAgent(llmProvider, chatManager, skillMgr)
+	setupUsageTracking()
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search",
+	Short: "검색 관리",
+}
+
+var searchIndexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "로컬 문서 검색 색인 재구성 (search.local.paths)",
+	Long: `search.local.paths에 설정한 런북, 벤더 문서, 세션 보고서, man 페이지를 다시 색인합니다.
+색인은 검색할 때 변경된 파일이 있으면 자동으로 갱신되므로, 문서가 많을 때 미리 만들어 둘 때만 필요합니다.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if searchMgr == nil {
+			fmt.Println("검색 기능이 사용 불가능합니다. 'storage-doctor config set search.provider local'과 search.local.paths를 확인하세요.")
+			return
+		}
+		count, err := searchMgr.Reindex(context.Background())
+		if err != nil {
+			fmt.Printf("색인 실패: %v\n", err)
+			return
+		}
+		fmt.Printf("문서 %d개를 색인했습니다.\n", count)
+	},
+}
+
+func init() {
+	searchCmd.AddCommand(searchIndexCmd)
+	rootCmd.AddCommand(searchCmd)
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mainbong/storage_doctor/internal/config"
+	"github.com/mainbong/storage_doctor/internal/llm"
+	"github.com/mainbong/storage_doctor/internal/logger"
+)
+
+// slashCommand is a command typed as "/name args" in the REPL or TUI. Slash
+// commands are handled locally and never reach the model.
+type slashCommand struct {
+	name        string
+	args        string
+	description string
+	run         func(args []string) (slashResult, error)
+}
+
+// slashResult is what the REPL or TUI shows or does after a slash command
+type slashResult struct {
+	output string
+	// clear means the conversation was cleared and the screen should be too
+	clear bool
+	// rollback asks the caller to run the interactive rollback of rollbackTarget
+	rollback       bool
+	rollbackTarget string
+}
+
+var slashCommands []slashCommand
+
+func init() {
+	// Assigned in init because /help lists slashCommands itself
+	slashCommands = []slashCommand{
+		{"model", "[모델]", "현재 모델 확인 또는 같은 프로바이더의 다른 모델로 전환", slashModel},
+		{"provider", "[프로바이더] [모델]", "현재 프로바이더 확인 또는 전환 (anthropic, openai, ollama, openai_compatible)", slashProvider},
+		{"skills", "[이름]", "스킬 목록 확인 또는 스킬 활성화", slashSkills},
+		{"clear", "", "대화 기록 지우기 (작업 기록과 활성 스킬은 유지)", slashClear},
+		{"save", "[이름]", "현재 세션 저장", slashSave},
+		{"rollback", "[n|작업 ID]", "최근 작업 롤백", slashRollback},
+		{"cost", "", "세션의 토큰 사용량과 비용 확인", slashCost},
+		{"watches", "", "실행 중인 로그 감시 목록", slashWatches},
+		{"unwatch", "<감시 ID|all>", "로그 감시 중지", slashUnwatch},
+		{"help", "", "명령어 목록", slashHelp},
+	}
+}
+
+// isSlashCommand reports whether input should be handled by runSlashCommand:
+// its first word must name a slash command, so that a message starting with a
+// path such as /var/log/kubelet.log still goes to the model
+func isSlashCommand(input string) bool {
+	fields := strings.Fields(input)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return false
+	}
+	name := strings.ToLower(fields[0][1:])
+	for _, command := range slashCommands {
+		if command.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// runSlashCommand runs a slash command; errors are returned as text to show
+func runSlashCommand(input string) slashResult {
+	fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(input), "/"))
+	if len(fields) == 0 {
+		return slashResult{output: slashHelpText()}
+	}
+	name := strings.ToLower(fields[0])
+	for _, command := range slashCommands {
+		if command.name != name {
+			continue
+		}
+		logger.Info("슬래시 명령어: /%s", name)
+		result, err := command.run(fields[1:])
+		if err != nil {
+			return slashResult{output: fmt.Sprintf("/%s 실패: %v", name, err)}
+		}
+		return result
+	}
+	return slashResult{output: fmt.Sprintf("알 수 없는 명령어입니다: /%s ('/help'로 목록 확인)", name)}
+}
+
+func slashHelp(args []string) (slashResult, error) {
+	return slashResult{output: slashHelpText()}, nil
+}
+
+func slashHelpText() string {
+	var b strings.Builder
+	b.WriteString("사용 가능한 명령어:")
+	for _, command := range slashCommands {
+		usage := "/" + command.name
+		if command.args != "" {
+			usage += " " + command.args
+		}
+		b.WriteString(fmt.Sprintf("\n  %-28s %s", usage, command.description))
+	}
+	return b.String()
+}
+
+func slashModel(args []string) (slashResult, error) {
+	if len(args) == 0 {
+		return slashResult{output: currentModelText()}, nil
+	}
+	if len(args) > 1 {
+		return slashResult{}, fmt.Errorf("사용법: /model [모델]")
+	}
+	if err := switchModel(cfg.LLMProvider, args[0]); err != nil {
+		return slashResult{}, err
+	}
+	return slashResult{output: fmt.Sprintf("모델을 %s(으)로 전환했습니다. 대화 기록은 유지됩니다.", llmProvider.GetModel())}, nil
+}
+
+func slashProvider(args []string) (slashResult, error) {
+	if len(args) == 0 {
+		return slashResult{output: currentModelText()}, nil
+	}
+	if len(args) > 2 {
+		return slashResult{}, fmt.Errorf("사용법: /provider [프로바이더] [모델]")
+	}
+	model := ""
+	if len(args) == 2 {
+		model = args[1]
+	}
+	if err := switchModel(args[0], model); err != nil {
+		return slashResult{}, err
+	}
+	return slashResult{output: fmt.Sprintf("프로바이더를 %s(%s)(으)로 전환했습니다. 대화 기록은 유지됩니다.", cfg.LLMProvider, llmProvider.GetModel())}, nil
+}
+
+func currentModelText() string {
+	return fmt.Sprintf("현재 모델: %s / %s (컨텍스트 %d 토큰)", cfg.LLMProvider, llmProvider.GetModel(), llm.ContextWindow(llmProvider.GetModel()))
+}
+
+// switchModel replaces the session's LLM provider with provider/model while
+// keeping the conversation. An empty model keeps the provider's configured
+// model. The change lasts for this session only; the config file is not written.
+func switchModel(provider, model string) error {
+	if mode, _ := fixtureMode(); mode != "" {
+		return fmt.Errorf("기록 / 재생 중에는 모델을 바꿀 수 없습니다")
+	}
+	next := *cfg
+	if err := next.Set("llm_provider", provider); err != nil {
+		return err
+	}
+	if model != "" {
+		if err := next.Set(provider+".model", model); err != nil {
+			return err
+		}
+	}
+	if err := checkAPIKey(&next); err != nil {
+		return err
+	}
+
+	newProvider, err := newLLMProvider(&next)
+	if err != nil {
+		return err
+	}
+	*cfg = next
+	llmProvider = newProvider
+	agentInstance.SetProvider(newProvider)
+	chatManager.SetContextWindow(cfg.ContextWindow)
+	logger.Info("LLM 모델 전환: %s / %s", cfg.LLMProvider, newProvider.GetModel())
+	return nil
+}
+
+// checkAPIKey reports a missing API key without prompting, since the TUI owns the terminal
+func checkAPIKey(c *config.Config) error {
+	switch {
+	case c.LLMProvider == "anthropic" && c.Anthropic.APIKey == "":
+		return fmt.Errorf("Anthropic API 키가 없습니다. 'storage-doctor config set anthropic.api_key <키>'로 설정하세요")
+	case c.LLMProvider == "openai" && c.OpenAI.APIKey == "":
+		return fmt.Errorf("OpenAI API 키가 없습니다. 'storage-doctor config set openai.api_key <키>'로 설정하세요")
+	case c.LLMProvider == "openai_compatible" && c.OpenAICompatible.BaseURL == "":
+		return fmt.Errorf("openai_compatible.base_url이 설정되지 않았습니다")
+	}
+	return nil
+}
+
+func slashSkills(args []string) (slashResult, error) {
+	if len(args) > 0 {
+		if err := agentInstance.ActivateSkill(args[0]); err != nil {
+			return slashResult{}, err
+		}
+		return slashResult{output: fmt.Sprintf("스킬 '%s' 활성화 완료", args[0])}, nil
+	}
+
+	skills := skillMgr.GetSkills()
+	if len(skills) == 0 {
+		return slashResult{output: "사용 가능한 스킬이 없습니다."}, nil
+	}
+	active := make(map[string]bool)
+	for _, name := range agentInstance.ActiveSkills() {
+		active[name] = true
+	}
+	var b strings.Builder
+	b.WriteString("사용 가능한 스킬 (* 활성화됨):")
+	for _, skill := range skills {
+		mark := " "
+		if active[skill.Name] {
+			mark = "*"
+		}
+		b.WriteString(fmt.Sprintf("\n %s %s: %s", mark, skill.Name, skill.Description))
+	}
+	return slashResult{output: b.String()}, nil
+}
+
+func slashClear(args []string) (slashResult, error) {
+	chatManager.Clear()
+	return slashResult{output: "대화 기록을 지웠습니다. 작업 기록과 활성 스킬은 유지됩니다.", clear: true}, nil
+}
+
+func slashSave(args []string) (slashResult, error) {
+	name := strings.Join(args, " ")
+	if err := saveSession(name); err != nil {
+		return slashResult{}, err
+	}
+	session := historyMgr.GetCurrentSession()
+	return slashResult{output: fmt.Sprintf("세션 '%s' 저장 완료 (ID: %s)", session.Name, session.ID)}, nil
+}
+
+func slashRollback(args []string) (slashResult, error) {
+	if len(args) > 1 {
+		return slashResult{}, fmt.Errorf("사용법: /rollback [n|작업 ID]")
+	}
+	target := ""
+	if len(args) == 1 {
+		target = args[0]
+	}
+	return slashResult{rollback: true, rollbackTarget: target}, nil
+}
+
+func slashCost(args []string) (slashResult, error) {
+	usage := historyMgr.Usage()
+	if usage.Calls == 0 {
+		return slashResult{output: "아직 LLM 호출이 없습니다."}, nil
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("LLM 호출: %d회\n", usage.Calls))
+	b.WriteString(fmt.Sprintf("토큰: 입력 %d · 출력 %d", usage.InputTokens, usage.OutputTokens))
+	if usage.CacheCreationInputTokens > 0 || usage.CacheReadInputTokens > 0 {
+		b.WriteString(fmt.Sprintf(" · 캐시 쓰기 %d · 캐시 읽기 %d (적중률 %.0f%%)",
+			usage.CacheCreationInputTokens, usage.CacheReadInputTokens, usage.CacheHitRate()*100))
+	}
+	b.WriteString("\n비용: " + usage.CostText())
+	switch {
+	case sessionBudget.MaxCostUSD > 0:
+		b.WriteString(fmt.Sprintf("\n예산: $%.2f", sessionBudget.MaxCostUSD))
+	case sessionBudget.MaxTokens > 0:
+		b.WriteString(fmt.Sprintf("\n예산: 토큰 %d", sessionBudget.MaxTokens))
+	}
+	return slashResult{output: b.String()}, nil
+}
+
+func slashWatches(args []string) (slashResult, error) {
+	return slashResult{output: formatWatchList()}, nil
+}
+
+func slashUnwatch(args []string) (slashResult, error) {
+	if len(args) != 1 {
+		return slashResult{}, fmt.Errorf("사용법: /unwatch <감시 ID|all>")
+	}
+	output, err := cancelLogWatch(args[0])
+	if err != nil {
+		return slashResult{}, err
+	}
+	return slashResult{output: output}, nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mainbong/storage_doctor/internal/llm"
+	"github.com/mainbong/storage_doctor/internal/logs"
+	"github.com/mainbong/storage_doctor/internal/shell"
+)
+
+func TestSlashModel_KeepsConversation(t *testing.T) {
+	setupScenario(t, scenario{Provider: "anthropic", Model: "claude-sonnet-4-5"})
+	cfg.Anthropic.APIKey = "sk-ant-test"
+	initScenarioManagers(t, llm.NewMockProvider(), shell.NewMockCommandExecutor())
+	chatManager.AddMessage("user", "PVC가 Pending입니다")
+
+	result := runSlashCommand("/model claude-haiku-4-5")
+	if !strings.Contains(result.output, "claude-haiku-4-5") {
+		t.Fatalf("Expected the switch to be reported, got %q", result.output)
+	}
+	if llmProvider.GetModel() != "claude-haiku-4-5" || cfg.Anthropic.Model != "claude-haiku-4-5" {
+		t.Errorf("Expected claude-haiku-4-5, got provider %s / config %s", llmProvider.GetModel(), cfg.Anthropic.Model)
+	}
+	if messages := chatManager.GetMessages(); len(messages) != 1 {
+		t.Errorf("Expected the conversation to be kept, got %+v", messages)
+	}
+}
+
+func TestSlashProvider_MissingKeyKeepsProvider(t *testing.T) {
+	setupScenario(t, scenario{Provider: "anthropic", Model: "claude-sonnet-4-5"})
+	cfg.Anthropic.APIKey = "sk-ant-test"
+	initScenarioManagers(t, llm.NewMockProvider(), shell.NewMockCommandExecutor())
+
+	result := runSlashCommand("/provider openai gpt-4o")
+	if !strings.Contains(result.output, "API 키") {
+		t.Errorf("Expected a missing API key error, got %q", result.output)
+	}
+	if cfg.LLMProvider != "anthropic" || cfg.OpenAI.Model != "" {
+		t.Errorf("Expected the config to be unchanged, got %s / %s", cfg.LLMProvider, cfg.OpenAI.Model)
+	}
+}
+
+func TestSlashCommand_HelpAndUnknown(t *testing.T) {
+	for input, expected := range map[string]bool{
+		"  /help":       true,
+		"/Model gpt-4o": true,
+		"/unwatch w1":   true,
+		"show output":   false,
+		"/var/log/kubelet.log 에러 확인해줘": false,
+		"/model/x": false,
+		"/nope":    false,
+		"/":        false,
+	} {
+		if got := isSlashCommand(input); got != expected {
+			t.Errorf("isSlashCommand(%q) = %v, expected %v", input, got, expected)
+		}
+	}
+	help := runSlashCommand("/help").output
+	for _, command := range slashCommands {
+		if !strings.Contains(help, "/"+command.name) {
+			t.Errorf("Expected /%s in help, got %q", command.name, help)
+		}
+	}
+	if output := runSlashCommand("/nope").output; !strings.Contains(output, "알 수 없는 명령어") {
+		t.Errorf("Expected unknown command message, got %q", output)
+	}
+	if result := runSlashCommand("/rollback 2"); !result.rollback || result.rollbackTarget != "2" {
+		t.Errorf("Expected rollback of the last 2 actions, got %+v", result)
+	}
+}
+
+func TestSlashWatches_StartListCancel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kubelet.log")
+	if err := os.WriteFile(path, []byte("I0102 10:00:00.000000 1 x.go:1] started\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer watchRegistry.CancelAll()
+
+	result, ok, err := handleToolCall(context.Background(), llm.ToolCall{Name: "monitor_log", Input: map[string]interface{}{
+		"action": "watch", "path": path, "pattern": "attach.*failed", "inject": true,
+	}}, true, true)
+	if err != nil || !ok || !strings.Contains(result, "패턴 attach.*failed") {
+		t.Fatalf("Expected the watch to start, got %q, %v", result, err)
+	}
+	watches := watchRegistry.List()
+	if len(watches) != 1 || !watches[0].Inject {
+		t.Fatalf("Expected one injecting watch, got %+v", watches)
+	}
+	id := watches[0].ID
+
+	list := runSlashCommand("/watches").output
+	if !strings.Contains(list, id) || !strings.Contains(list, path) || !strings.Contains(list, "대화에 전달") {
+		t.Errorf("Expected the watch in the list, got %q", list)
+	}
+
+	onWatchEvent(logs.WatchEvent{WatchID: id, Source: path, Inject: true, At: time.Now(), Line: logs.Line{Text: "E0102 attach of pvc-1 failed"}})
+	note := takeWatchInjection()
+	if !strings.Contains(note, id+" ("+path+"): 새 이벤트 1건") || !strings.Contains(note, "attach of pvc-1 failed") {
+		t.Errorf("Unexpected injected note: %q", note)
+	}
+	if takeWatchInjection() != "" {
+		t.Error("Expected the pending events to be cleared")
+	}
+
+	if output := runSlashCommand("/unwatch " + id).output; !strings.Contains(output, "중지했습니다") {
+		t.Errorf("Expected the watch to be cancelled, got %q", output)
+	}
+	if output := runSlashCommand("/unwatch " + id).output; !strings.Contains(output, "/unwatch 실패") {
+		t.Errorf("Expected an error for a cancelled watch, got %q", output)
+	}
+	if list := runSlashCommand("/watches").output; !strings.Contains(list, "없습니다") {
+		t.Errorf("Expected no watches, got %q", list)
+	}
+}
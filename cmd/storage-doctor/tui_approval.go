@@ -6,26 +6,52 @@ import (
 
 	"github.com/mainbong/storage_doctor/internal/llm"
 	"github.com/mainbong/storage_doctor/internal/shell"
+	"github.com/mainbong/storage_doctor/internal/shell/policy"
 )
 
 func needsApproval(toolCall llm.ToolCall) bool {
 	switch toolCall.Name {
-	case "execute_command", "write_file":
+	case "execute_command":
+		_, decision := evaluateCommand(commandFromTool(toolCall))
+		return decision == policy.DecisionAsk || decision == policy.DecisionConfirm
+	case "write_file", "edit_file", "set_config_value":
 		return !cfg.AutoApproveCommands && shellExec.GetApprovalMode() == shell.ApprovalModeManual
 	default:
 		return false
 	}
 }
 
+// confirmationText returns the phrase to type for destructive commands, or "" if none is required
+func confirmationText(toolCall llm.ToolCall) string {
+	if toolCall.Name != "execute_command" {
+		return ""
+	}
+	evaluation, decision := evaluateCommand(commandFromTool(toolCall))
+	if decision != policy.DecisionConfirm {
+		return ""
+	}
+	return evaluation.ConfirmationText()
+}
+
 func approvalOptionsCount(toolCall llm.ToolCall) int {
-	if toolCall.Name == "execute_command" {
+	if toolCall.Name == "execute_command" && isReadOnlyCommand(toolCall) {
 		return 3
 	}
 	return 2
 }
 
+func isReadOnlyCommand(toolCall llm.ToolCall) bool {
+	evaluation, _ := evaluateCommand(commandFromTool(toolCall))
+	return evaluation.Class == policy.ClassReadOnly
+}
+
+func commandFromTool(toolCall llm.ToolCall) string {
+	command, _ := toolCall.Input["command"].(string)
+	return command
+}
+
 func isAutoApproved(auto map[string]bool, toolCall llm.ToolCall) bool {
-	if toolCall.Name != "execute_command" {
+	if toolCall.Name != "execute_command" || !isReadOnlyCommand(toolCall) {
 		return false
 	}
 	key := commandKeyFromTool(toolCall)
@@ -62,7 +88,7 @@ func commandKey(command string) string {
 	return strings.Join(parts, " ")
 }
 
-func renderApprovalPromptWithSelection(req *approvalRequest, width int, approveIdx int) string {
+func renderApprovalPromptWithSelection(req *approvalRequest, width int, approveIdx int, confirmInput string) string {
 	title, body := approvalContent(req.tool)
 	options := approvalOptionsCount(req.tool)
 	yesLabel := approvalOption.Render("[ 승인 ]")
@@ -84,6 +110,13 @@ func renderApprovalPromptWithSelection(req *approvalRequest, width int, approveI
 	if options == 3 {
 		hint = "y/n/a 또는 화살표 + Enter"
 	}
+	if req.preview != "" {
+		body += "\n" + req.preview
+	}
+	if req.confirmText != "" {
+		body += fmt.Sprintf("\n확인 입력: %s_", confirmInput)
+		hint = fmt.Sprintf("'%s' 입력 후 Enter로 승인 (Esc 취소)", req.confirmText)
+	}
 	content := approvalTitle.Render(title) + "\n" + body + "\n" + choices + "\n" + approvalHint.Render(hint)
 	if width <= 0 {
 		return approvalBox.Render(content)
@@ -96,13 +129,22 @@ func approvalContent(toolCall llm.ToolCall) (string, string) {
 	case "execute_command":
 		command, _ := toolCall.Input["command"].(string)
 		desc, _ := toolCall.Input["description"].(string)
-		key := commandKey(command)
-		body := fmt.Sprintf("명령어: %s\n자동 승인 범위: %s", command, key)
+		evaluation, _ := evaluateCommand(command)
+		body := fmt.Sprintf("명령어: %s\n분류: %s", command, evaluation.Class.Label())
+		if evaluation.Reason != "" {
+			body += fmt.Sprintf(" (%s)", evaluation.Reason)
+		}
+		if evaluation.Class == policy.ClassReadOnly {
+			body += fmt.Sprintf("\n자동 승인 범위: %s", commandKey(command))
+		}
 		if desc != "" {
 			body = fmt.Sprintf("목적: %s\n%s", desc, body)
 		}
+		if evaluation.Class == policy.ClassDestructive {
+			return "위험 명령어 실행 요청", body
+		}
 		return "명령어 실행 요청", body
-	case "write_file":
+	case "write_file", "edit_file":
 		path, _ := toolCall.Input["path"].(string)
 		desc, _ := toolCall.Input["description"].(string)
 		body := fmt.Sprintf("파일: %s", path)
@@ -110,7 +152,54 @@ func approvalContent(toolCall llm.ToolCall) (string, string) {
 			body = fmt.Sprintf("목적: %s\n%s", desc, body)
 		}
 		return "파일 수정 요청", body
+	case "set_config_value":
+		path, _ := toolCall.Input["path"].(string)
+		keyPath, _ := toolCall.Input["key_path"].(string)
+		desc, _ := toolCall.Input["description"].(string)
+		body := fmt.Sprintf("파일: %s\n경로: %s", path, keyPath)
+		if desc != "" {
+			body = fmt.Sprintf("목적: %s\n%s", desc, body)
+		}
+		return "설정 값 변경 요청", body
+	case budgetApprovalTool:
+		reason, _ := toolCall.Input["reason"].(string)
+		return "세션 예산 초과", reason + "\n승인하면 설정된 예산만큼 한도를 늘리고 계속 진행합니다."
 	default:
 		return "작업 실행 요청", toolCall.Name
 	}
 }
+
+// approvalPreview renders the diff shown in the approval box for file changes.
+// It is computed once when the request arrives rather than on every frame.
+func approvalPreview(toolCall llm.ToolCall) string {
+	switch toolCall.Name {
+	case "write_file", "edit_file", "set_config_value":
+	default:
+		return ""
+	}
+	unified, err := fileChangePreview(toolCall)
+	if err != nil {
+		return systemStyle.Render(fmt.Sprintf("적용 불가: %v", err))
+	}
+	if unified == "" {
+		return hintStyle.Render("변경 사항 없음")
+	}
+	return renderDiff(truncateDiff(unified, diffPreviewLines))
+}
+
+func renderDiff(unified string) string {
+	lines := strings.Split(strings.TrimSuffix(unified, "\n"), "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---"):
+			lines[i] = diffHeaderStyle.Render(line)
+		case strings.HasPrefix(line, "@@"):
+			lines[i] = diffHunkStyle.Render(line)
+		case strings.HasPrefix(line, "+"):
+			lines[i] = diffAddStyle.Render(line)
+		case strings.HasPrefix(line, "-"):
+			lines[i] = diffDelStyle.Render(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
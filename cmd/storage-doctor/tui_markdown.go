@@ -1,8 +1,11 @@
 package main
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
 
-func renderMessages(messages []chatMessage, width int) string {
+func renderMessages(messages []chatMessage, width int, showThinking bool) string {
 	if width <= 0 {
 		width = 80
 	}
@@ -28,6 +31,8 @@ func renderMessages(messages []chatMessage, width int) string {
 				return assistantPrefix.Render("│ ") + assistantStyle.Render(line)
 			})
 			b.WriteString("\n")
+		case "thinking":
+			renderThinking(&b, msg.content, contentWidth, showThinking)
 		case "tool":
 			b.WriteString(toolLabelStyle.Render("도구"))
 			b.WriteString("\n")
@@ -47,6 +52,31 @@ func renderMessages(messages []chatMessage, width int) string {
 	return strings.TrimRight(b.String(), "\n")
 }
 
+// renderThinking shows model reasoning dimmed. Collapsed, only its latest line is shown.
+func renderThinking(b *strings.Builder, content string, width int, expanded bool) {
+	lines := strings.Split(strings.TrimSpace(content), "\n")
+	if expanded {
+		b.WriteString(thinkingLabel.Render("추론") + " " + hintStyle.Render("(Ctrl+O 접기)"))
+		b.WriteString("\n")
+		renderWrappedLines(b, strings.Join(lines, "\n"), width, func(line string) string {
+			return thinkingPrefix.Render("┊ ") + thinkingStyle.Render(line)
+		})
+		b.WriteString("\n")
+		return
+	}
+	b.WriteString(thinkingLabel.Render("추론") + " " + hintStyle.Render(fmt.Sprintf("(%d줄, Ctrl+O 펼치기)", len(lines))))
+	b.WriteString("\n")
+	last := ""
+	for i := len(lines) - 1; i >= 0 && last == ""; i-- {
+		last = strings.TrimSpace(lines[i])
+	}
+	if wrapped := wrapText(last, width-1); len(wrapped) > 1 {
+		last = wrapped[0] + "…"
+	}
+	b.WriteString(thinkingPrefix.Render("┊ ") + thinkingStyle.Render(last))
+	b.WriteString("\n\n")
+}
+
 func renderWrappedLines(b *strings.Builder, content string, width int, style func(string) string) {
 	if content == "" {
 		b.WriteString(style(" "))
@@ -14,6 +14,7 @@ import (
 
 type streamEvent struct {
 	chunk    string
+	thinking string
 	done     bool
 	err      error
 	sys      *chatMessage
@@ -27,13 +28,16 @@ type chatMessage struct {
 }
 
 type approvalRequest struct {
-	tool     llm.ToolCall
-	response chan bool
+	tool        llm.ToolCall
+	response    chan bool
+	confirmText string // Phrase the user must type for destructive commands
+	preview     string // Rendered diff for file changes
 }
 
 type rateLimitStatus struct {
 	waiting bool
 	wait    time.Duration
+	reason  string // Set while waiting to retry a failed request
 }
 
 type tuiModel struct {
@@ -45,18 +49,28 @@ type tuiModel struct {
 	approval     *approvalRequest
 	approveIdx   int
 	approveMax   int
+	confirmInput string
 	autoApprove  map[string]bool
 	viewport     viewport.Model
 	followOutput bool
+	showThinking bool // Expand reasoning blocks instead of showing their latest line
 	spinner      spinner.Model
 	rateLimit    *rateLimitStatus
 	width        int
 	height       int
+	// injectQueued is set while a watchInjectMsg is on its way
+	injectQueued bool
 }
 
 func runTUI() error {
 	model := newTUIModel()
 	program := tea.NewProgram(model, tea.WithMouseCellMotion())
+	setWatchNotify(func() {
+		// Send blocks until the event loop takes the message, and watches
+		// must not wait on the UI
+		go program.Send(watchEventMsg{})
+	})
+	defer setWatchNotify(nil)
 	_, err := program.Run()
 	return err
 }
@@ -81,7 +95,7 @@ func newTUIModel() tuiModel {
 		Placeholder: placeholderStyle,
 	}
 
-	return tuiModel{
+	model := tuiModel{
 		input:        input,
 		streamIndex:  -1,
 		autoApprove:  make(map[string]bool),
@@ -89,6 +103,10 @@ func newTUIModel() tuiModel {
 		followOutput: true,
 		spinner:      newSpinner(),
 	}
+	if resumeSessionID != "" {
+		model.messages = resumedChatMessages()
+	}
+	return model
 }
 
 func (m tuiModel) Init() tea.Cmd {
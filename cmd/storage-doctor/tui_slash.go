@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func (m tuiModel) handleSlashCommand(value string) (tuiModel, tea.Cmd) {
+	m.input.SetValue("")
+	m.adjustInputHeight()
+
+	result := runSlashCommand(value)
+	if result.rollback {
+		// Rollback asks about every step, so it gets the terminal while the TUI is suspended
+		return m, tea.Exec(&rollbackExec{target: result.rollbackTarget}, func(err error) tea.Msg {
+			return rollbackDoneMsg{err: err}
+		})
+	}
+	if result.clear {
+		m.messages = nil
+		m.streamIndex = -1
+	} else {
+		m.messages = append(m.messages, chatMessage{role: "user", content: value})
+	}
+	if result.output != "" {
+		m.messages = append(m.messages, chatMessage{role: "system", content: result.output})
+	}
+	m.followOutput = true
+	m.refreshViewport()
+	return m, nil
+}
+
+// rollbackExec runs the REPL rollback flow on the terminal released by the TUI
+type rollbackExec struct {
+	target string
+	stdin  io.Reader
+}
+
+func (r *rollbackExec) Run() error {
+	stdin := r.stdin
+	if stdin == nil {
+		stdin = os.Stdin
+	}
+	reader := bufio.NewReader(stdin)
+	runRollback(r.target, reader)
+	fmt.Print("\nEnter를 누르면 돌아갑니다...")
+	_, err := reader.ReadString('\n')
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
+func (r *rollbackExec) SetStdin(stdin io.Reader) { r.stdin = stdin }
+func (r *rollbackExec) SetStdout(io.Writer)      {}
+func (r *rollbackExec) SetStderr(io.Writer)      {}
+
+// rollbackDoneMsg is sent when the TUI takes the terminal back after a rollback
+type rollbackDoneMsg struct {
+	err error
+}
+
+func (msg rollbackDoneMsg) text() string {
+	if msg.err != nil {
+		return fmt.Sprintf("롤백 중 오류: %v", msg.err)
+	}
+	return "롤백을 마쳤습니다."
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -12,23 +13,47 @@ import (
 
 func (m *tuiModel) startStream(input string) tea.Cmd {
 	m.streamCh = make(chan streamEvent, 32)
+	historyMgr.SetProblem(input)
 	go func() {
 		ctx := llm.WithRateLimitReporter(context.Background(), func(wait time.Duration, waiting bool) {
 			m.streamCh <- streamEvent{rate: &rateLimitStatus{waiting: waiting, wait: wait}}
 		})
+		ctx = llm.WithRetryReporter(ctx, func(status llm.RetryStatus) {
+			if status.Failover {
+				m.streamCh <- streamEvent{sys: &chatMessage{
+					role:    "system",
+					content: fmt.Sprintf("LLM 요청 실패로 대체 모델 %s(으)로 전환합니다: %v", status.Model, status.Err),
+				}}
+				return
+			}
+			m.streamCh <- streamEvent{rate: &rateLimitStatus{
+				waiting: true,
+				wait:    status.Wait,
+				reason:  fmt.Sprintf("재시도 대기 중 (%d/%d, %s)", status.Attempt, status.MaxAttempts, retryCause(status.Err)),
+			}}
+		})
+		ctx = llm.WithThinkingReporter(ctx, func(delta string) {
+			m.streamCh <- streamEvent{thinking: delta}
+		})
+		ctx = withBudgetConfirm(ctx, func(reason string) bool {
+			resp := make(chan bool, 1)
+			toolCall := llm.ToolCall{Name: budgetApprovalTool, Input: map[string]interface{}{"reason": reason}}
+			m.streamCh <- streamEvent{approval: &approvalRequest{tool: toolCall, response: resp}}
+			return <-resp
+		})
 		err := agentInstance.StreamTask(ctx, input, func(chunk string) {
 			m.streamCh <- streamEvent{chunk: chunk}
-		}, func(toolCall llm.ToolCall) (string, error) {
+		}, func(toolCtx context.Context, toolCall llm.ToolCall) (string, error) {
 			approved := true
 			if needsApproval(toolCall) {
 				resp := make(chan bool, 1)
-				m.streamCh <- streamEvent{approval: &approvalRequest{tool: toolCall, response: resp}}
+				m.streamCh <- streamEvent{approval: &approvalRequest{tool: toolCall, response: resp, confirmText: confirmationText(toolCall), preview: approvalPreview(toolCall)}}
 				approved = <-resp
 			}
 			if !approved {
 				return "", fmt.Errorf("사용자가 실행을 취소했습니다")
 			}
-			result, err := executeToolCallForAgentApproved(context.Background(), toolCall, approved)
+			result, err := executeToolCallForAgentApproved(toolCtx, toolCall, approved)
 			msg := &chatMessage{
 				role:    "tool",
 				content: formatToolDisplay(toolCall, result, err),
@@ -39,12 +64,26 @@ func (m *tuiModel) startStream(input string) tea.Cmd {
 			m.streamCh <- streamEvent{sys: msg}
 			return result, err
 		})
+		if err == nil {
+			recordConclusion()
+		} else {
+			persistSession()
+		}
 		m.streamCh <- streamEvent{done: true, err: err}
 		close(m.streamCh)
 	}()
 	return waitForStream(m.streamCh)
 }
 
+// retryCause summarizes why a request is being retried
+func retryCause(err error) string {
+	var apiErr *llm.APIError
+	if errors.As(err, &apiErr) {
+		return fmt.Sprintf("HTTP %d", apiErr.StatusCode)
+	}
+	return "연결 끊김"
+}
+
 func waitForStream(ch <-chan streamEvent) tea.Cmd {
 	return func() tea.Msg {
 		msg, ok := <-ch
@@ -12,12 +12,15 @@ var (
 	assistantLabel   = lipgloss.NewStyle().Foreground(lipgloss.Color("245")).Bold(true)
 	systemLabel      = lipgloss.NewStyle().Foreground(lipgloss.Color("203")).Bold(true)
 	toolLabelStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("81")).Bold(true)
+	thinkingLabel    = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Bold(true)
 	userBubble       = lipgloss.NewStyle().Background(lipgloss.Color("238")).Foreground(lipgloss.Color("255")).Padding(0, 1)
 	assistantStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("250"))
 	assistantPrefix  = lipgloss.NewStyle().Foreground(lipgloss.Color("239"))
 	systemStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
 	toolStyle        = lipgloss.NewStyle().Foreground(lipgloss.Color("81"))
 	toolPrefix       = lipgloss.NewStyle().Foreground(lipgloss.Color("81"))
+	thinkingStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Italic(true)
+	thinkingPrefix   = lipgloss.NewStyle().Foreground(lipgloss.Color("238"))
 	codeBlockStyle   = lipgloss.NewStyle().Background(lipgloss.Color("235")).Foreground(lipgloss.Color("252"))
 	codeFenceStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
 	inlineCodeStyle  = lipgloss.NewStyle().Background(lipgloss.Color("235")).Foreground(lipgloss.Color("252"))
@@ -33,4 +36,10 @@ var (
 	approvalOption   = lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
 	approvalActive   = lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true)
 	rateLimitStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	diffHeaderStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("252"))
+	diffHunkStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("81"))
+	diffAddStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("35"))
+	diffDelStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+	watchPaneStyle   = lipgloss.NewStyle().Border(lipgloss.NormalBorder(), false, false, false, true).BorderForeground(lipgloss.Color("238")).PaddingLeft(1)
+	watchTitleStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("176"))
 )
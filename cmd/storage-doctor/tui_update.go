@@ -41,16 +41,12 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if isExitCommand(value) {
 				return m, tea.Quit
 			}
+			if isSlashCommand(value) {
+				return m.handleSlashCommand(value)
+			}
 			m.messages = append(m.messages, chatMessage{role: "user", content: value})
-			m.messages = append(m.messages, chatMessage{role: "assistant", content: ""})
-			m.streamIndex = len(m.messages) - 1
-			m.streaming = true
 			m.input.SetValue("")
-			m.input.Blur()
-			m.adjustInputHeight()
-			m.followOutput = true
-			m.refreshViewport()
-			return m, m.startStream(value)
+			return m, m.beginTurn(value)
 		}
 
 		var cmd tea.Cmd
@@ -66,6 +62,14 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	case streamEvent:
 		return m.handleStreamEvent(msg)
+	case watchEventMsg:
+		return m.handleWatchEvent()
+	case watchInjectMsg:
+		return m.handleWatchInject()
+	case rollbackDoneMsg:
+		m.messages = append(m.messages, chatMessage{role: "system", content: msg.text()})
+		m.refreshViewport()
+		return m, nil
 	}
 
 	var cmd tea.Cmd
@@ -74,7 +78,22 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// beginTurn streams the agent's answer to input into a new assistant message
+func (m *tuiModel) beginTurn(input string) tea.Cmd {
+	m.messages = append(m.messages, chatMessage{role: "assistant", content: ""})
+	m.streamIndex = len(m.messages) - 1
+	m.streaming = true
+	m.input.Blur()
+	m.adjustInputHeight()
+	m.followOutput = true
+	m.refreshViewport()
+	return m.startStream(input)
+}
+
 func (m tuiModel) handleApprovalKey(msg tea.KeyMsg) (tuiModel, tea.Cmd) {
+	if m.approval.confirmText != "" {
+		return m.handleConfirmKey(msg)
+	}
 	switch msg.String() {
 	case "y":
 		m.approval.response <- true
@@ -157,6 +176,48 @@ func (m tuiModel) handleApprovalKey(msg tea.KeyMsg) (tuiModel, tea.Cmd) {
 	return m, nil
 }
 
+// handleConfirmKey handles typed confirmation for destructive commands
+func (m tuiModel) handleConfirmKey(msg tea.KeyMsg) (tuiModel, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.finishConfirm(false)
+	case tea.KeyEnter:
+		if m.approveIdx == 1 {
+			m.finishConfirm(false)
+		} else if m.confirmInput == m.approval.confirmText {
+			m.finishConfirm(true)
+		}
+	case tea.KeyBackspace:
+		if runes := []rune(m.confirmInput); len(runes) > 0 {
+			m.confirmInput = string(runes[:len(runes)-1])
+		}
+	case tea.KeyLeft, tea.KeyRight, tea.KeyTab, tea.KeyShiftTab:
+		m.approveIdx = (m.approveIdx + 1) % 2
+	case tea.KeySpace:
+		m.confirmInput += " "
+	case tea.KeyRunes:
+		m.confirmInput += string(msg.Runes)
+	}
+	return m, nil
+}
+
+func (m *tuiModel) finishConfirm(approved bool) {
+	m.approval.response <- approved
+	close(m.approval.response)
+	m.approval = nil
+	m.approveMax = 0
+	m.confirmInput = ""
+	if !approved {
+		m.messages = append(m.messages, chatMessage{
+			role:    "system",
+			content: "승인을 취소했습니다. 추가 요청을 입력해주세요.",
+		})
+	}
+	m.input.Focus()
+	m.adjustViewport()
+	m.refreshViewport()
+}
+
 func (m tuiModel) handleStreamEvent(msg streamEvent) (tuiModel, tea.Cmd) {
 	if msg.done {
 		m.streaming = false
@@ -172,7 +233,8 @@ func (m tuiModel) handleStreamEvent(msg streamEvent) (tuiModel, tea.Cmd) {
 			})
 			m.refreshViewport()
 		}
-		return m, nil
+		// Watch events that arrived during the turn are handed over next
+		return m, m.queueWatchInjection()
 	}
 	if msg.sys != nil {
 		m.messages = append(m.messages, *msg.sys)
@@ -187,17 +249,36 @@ func (m tuiModel) handleStreamEvent(msg streamEvent) (tuiModel, tea.Cmd) {
 			m.approval = msg.approval
 			m.approveIdx = 0
 			m.approveMax = approvalOptionsCount(msg.approval.tool)
+			m.confirmInput = ""
 			m.input.Blur()
 			m.adjustViewport()
 		}
 	}
 	if msg.rate != nil {
 		if msg.rate.waiting {
+			if msg.rate.reason == "" && m.rateLimit != nil {
+				// Keep showing the retry reason while its backoff is reported
+				msg.rate.reason = m.rateLimit.reason
+			}
 			m.rateLimit = msg.rate
 			return m, m.spinner.Tick
 		}
 		m.rateLimit = nil
 	}
+	if msg.thinking != "" {
+		if m.streamIndex < 0 || m.streamIndex >= len(m.messages) || m.messages[m.streamIndex].role != "thinking" {
+			if m.streamIndex >= 0 && m.streamIndex < len(m.messages) &&
+				m.messages[m.streamIndex].role == "assistant" && m.messages[m.streamIndex].content == "" {
+				// Reasoning comes before the answer; reuse the empty answer placeholder
+				m.messages[m.streamIndex].role = "thinking"
+			} else {
+				m.messages = append(m.messages, chatMessage{role: "thinking", content: ""})
+				m.streamIndex = len(m.messages) - 1
+			}
+		}
+		m.messages[m.streamIndex].content += msg.thinking
+		m.refreshViewport()
+	}
 	if msg.chunk != "" {
 		if m.streamIndex < 0 || m.streamIndex >= len(m.messages) || m.messages[m.streamIndex].role != "assistant" {
 			m.messages = append(m.messages, chatMessage{role: "assistant", content: ""})
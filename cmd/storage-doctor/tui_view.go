@@ -15,20 +15,34 @@ func (m tuiModel) View() string {
 	}
 
 	divider := strings.Repeat("-", m.width)
-	hintText := "? 단축키 안내 (추가 예정) | Enter 전송 | Shift+Enter 줄바꿈 | PgUp/PgDn 스크롤"
+	hintText := "/help 명령어 | Enter 전송 | Shift+Enter 줄바꿈 | PgUp/PgDn 스크롤"
+	if usage := usageStatus(); usage != "" {
+		hintText += " | " + usage
+	}
 	hint := lipgloss.PlaceHorizontal(m.width, lipgloss.Left, hintStyle.Render(hintText))
 	if m.rateLimit != nil && m.rateLimit.waiting {
 		waitSeconds := int(math.Ceil(m.rateLimit.wait.Seconds()))
-		status := fmt.Sprintf("%s rate limit 대기 중... (약 %ds)", m.spinner.View(), waitSeconds)
+		label := "rate limit 대기 중..."
+		if m.rateLimit.reason != "" {
+			label = m.rateLimit.reason
+		}
+		status := fmt.Sprintf("%s %s (약 %ds)", m.spinner.View(), label, waitSeconds)
 		hint = lipgloss.PlaceHorizontal(m.width, lipgloss.Left, rateLimitStyle.Render(status))
 	} else if m.streaming {
-		hint = lipgloss.PlaceHorizontal(m.width, lipgloss.Left, hintStyle.Render("응답 생성 중... (Ctrl+C 종료)"))
+		streamingText := "응답 생성 중... (Ctrl+C 종료)"
+		if usage := usageStatus(); usage != "" {
+			streamingText += " | " + usage
+		}
+		hint = lipgloss.PlaceHorizontal(m.width, lipgloss.Left, hintStyle.Render(streamingText))
 	}
 
 	content := m.viewport.View()
+	if paneWidth := m.watchPaneWidth(); paneWidth > 0 {
+		content = lipgloss.JoinHorizontal(lipgloss.Top, content, renderWatchPane(paneWidth, m.viewport.Height))
+	}
 	approval := ""
 	if m.approval != nil {
-		approval = renderApprovalPromptWithSelection(m.approval, m.width, m.approveIdx)
+		approval = renderApprovalPromptWithSelection(m.approval, m.width, m.approveIdx, m.confirmInput)
 	}
 
 	parts := []string{}
@@ -22,17 +22,17 @@ func (m *tuiModel) adjustViewport() {
 	if m.width <= 0 || m.height <= 0 {
 		return
 	}
-	m.viewport.Width = max(10, m.width)
+	m.viewport.Width = max(10, m.width-m.watchPaneWidth())
 	contentHeight := m.height - m.input.Height() - 3
 	if m.approval != nil {
-		approval := renderApprovalPromptWithSelection(m.approval, m.width, m.approveIdx)
+		approval := renderApprovalPromptWithSelection(m.approval, m.width, m.approveIdx, m.confirmInput)
 		contentHeight -= lineCount(approval)
 	}
 	m.viewport.Height = max(1, contentHeight)
 }
 
 func (m *tuiModel) refreshViewport() {
-	m.viewport.SetContent(renderMessages(m.messages, m.viewport.Width))
+	m.viewport.SetContent(renderMessages(m.messages, m.viewport.Width, m.showThinking))
 	if m.followOutput {
 		m.viewport.GotoBottom()
 	}
@@ -53,6 +53,10 @@ func (m *tuiModel) handleViewportKey(msg tea.KeyMsg) (bool, tea.Cmd) {
 		m.viewport.GotoBottom()
 		m.followOutput = true
 		return true, nil
+	case "ctrl+o":
+		m.showThinking = !m.showThinking
+		m.refreshViewport()
+		return true, nil
 	default:
 		return false, nil
 	}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// watchPaneMinWidth is the terminal width below which the watch pane is hidden
+const watchPaneMinWidth = 100
+
+// watchEventMsg is sent when a watch starts, stops or reports an event
+type watchEventMsg struct{}
+
+// watchInjectMsg is sent when pending watch events should go to the agent
+type watchInjectMsg struct{}
+
+func (m tuiModel) handleWatchEvent() (tuiModel, tea.Cmd) {
+	m.adjustViewport()
+	m.refreshViewport()
+	return m, m.queueWatchInjection()
+}
+
+// queueWatchInjection schedules a watchInjectMsg if events are waiting for the agent
+func (m *tuiModel) queueWatchInjection() tea.Cmd {
+	if m.injectQueued || !hasWatchInjection() {
+		return nil
+	}
+	m.injectQueued = true
+	return tea.Tick(watchInjectDelay, func(time.Time) tea.Msg {
+		return watchInjectMsg{}
+	})
+}
+
+// handleWatchInject starts an agent turn with the pending watch events. While
+// the agent is busy they wait for the end of the current turn.
+func (m tuiModel) handleWatchInject() (tuiModel, tea.Cmd) {
+	m.injectQueued = false
+	if m.streaming || m.approval != nil {
+		return m, nil
+	}
+	note := takeWatchInjection()
+	if note == "" {
+		return m, nil
+	}
+	m.messages = append(m.messages, chatMessage{role: "system", content: note})
+	return m, m.beginTurn(note)
+}
+
+// watchPaneWidth is the width of the watch pane, 0 when it is hidden
+func (m tuiModel) watchPaneWidth() int {
+	if m.width < watchPaneMinWidth {
+		return 0
+	}
+	if len(watchRegistry.List()) == 0 && len(recentWatchEvents(1)) == 0 {
+		return 0
+	}
+	return min(48, m.width/3)
+}
+
+// renderWatchPane lists the running watches above their latest events
+func renderWatchPane(width, height int) string {
+	inner := width - watchPaneStyle.GetHorizontalFrameSize()
+	watches := watchRegistry.List()
+
+	lines := []string{watchTitleStyle.Render(fmt.Sprintf("로그 감시 (%d)", len(watches)))}
+	for _, info := range watches {
+		lines = append(lines, hintStyle.Render(fmt.Sprintf("%s %s · %d건", info.ID, info.Source, info.Events)))
+	}
+	lines = append(lines, hintStyle.Render(strings.Repeat("-", max(1, inner))))
+
+	for _, event := range recentWatchEvents(height - len(lines)) {
+		prefix := fmt.Sprintf("%s %s ", event.At.Format("15:04:05"), event.WatchID)
+		if event.Err != nil {
+			lines = append(lines, systemStyle.Render(prefix+"중지: "+event.Err.Error()))
+			continue
+		}
+		text := event.Line.Text
+		switch event.Line.Level {
+		case "ERROR":
+			text = systemStyle.Render(text)
+		case "WARN":
+			text = rateLimitStyle.Render(text)
+		}
+		lines = append(lines, hintStyle.Render(prefix)+text)
+	}
+
+	content := lipgloss.NewStyle().Height(height).MaxHeight(height).MaxWidth(inner).Render(strings.Join(lines, "\n"))
+	return watchPaneStyle.Render(content)
+}
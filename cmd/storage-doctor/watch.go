@@ -0,0 +1,261 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/mainbong/storage_doctor/internal/logger"
+	"github.com/mainbong/storage_doctor/internal/logs"
+)
+
+// watchFeedSize bounds the recent watch events kept for the TUI pane
+const watchFeedSize = 200
+
+// watchInjectDelay gathers the events of injecting watches for a while before
+// they are handed to the agent, so a burst becomes one message
+const watchInjectDelay = 10 * time.Second
+
+// maxInjectedLines is how many lines of each watch an injected summary quotes
+const maxInjectedLines = 3
+
+// watchDigest collects the events of one watch waiting to be injected
+type watchDigest struct {
+	id     string
+	source string
+	count  int
+	first  time.Time
+	last   time.Time
+	lines  []string
+}
+
+var (
+	watchRegistry = logs.NewWatchRegistry(onWatchEvent)
+
+	watchMu      sync.Mutex
+	watchFeed    []logs.WatchEvent
+	watchPending []*watchDigest
+	// watchNotify tells the TUI that watches or their events changed; nil
+	// outside the TUI, where events are printed as they arrive
+	watchNotify func()
+)
+
+// onWatchEvent records an event for the pane and, for injecting watches, for
+// the next message to the agent. It runs on the watch goroutines.
+func onWatchEvent(event logs.WatchEvent) {
+	watchMu.Lock()
+	watchFeed = append(watchFeed, event)
+	if len(watchFeed) > watchFeedSize {
+		watchFeed = append([]logs.WatchEvent(nil), watchFeed[len(watchFeed)-watchFeedSize:]...)
+	}
+	if event.Inject {
+		addWatchDigest(event)
+	}
+	notify := watchNotify
+	watchMu.Unlock()
+
+	if event.Err != nil {
+		logger.Warn("로그 감시 %s 중지: %v", event.WatchID, event.Err)
+	}
+	if notify != nil {
+		notify()
+		return
+	}
+	if event.Err != nil {
+		color.Red("\n[감시 %s 중지] %v\n", event.WatchID, event.Err)
+		return
+	}
+	color.Magenta("\n[감시 %s] %s\n", event.WatchID, event.Line.Text)
+}
+
+// addWatchDigest adds an event to its watch's pending digest; watchMu must be held
+func addWatchDigest(event logs.WatchEvent) {
+	var digest *watchDigest
+	for _, pending := range watchPending {
+		if pending.id == event.WatchID {
+			digest = pending
+			break
+		}
+	}
+	if digest == nil {
+		digest = &watchDigest{id: event.WatchID, source: event.Source, first: event.At}
+		watchPending = append(watchPending, digest)
+	}
+
+	text := event.Line.Text
+	if event.Err != nil {
+		text = fmt.Sprintf("감시 중지: %v", event.Err)
+	} else {
+		digest.count++
+	}
+	digest.last = event.At
+	digest.lines = append(digest.lines, text)
+	if len(digest.lines) > maxInjectedLines {
+		digest.lines = digest.lines[len(digest.lines)-maxInjectedLines:]
+	}
+}
+
+// hasWatchInjection reports whether events are waiting to be injected
+func hasWatchInjection() bool {
+	watchMu.Lock()
+	defer watchMu.Unlock()
+	return len(watchPending) > 0
+}
+
+// takeWatchInjection returns the pending events as a message for the agent
+// and clears them; it returns "" when nothing is pending
+func takeWatchInjection() string {
+	watchMu.Lock()
+	pending := watchPending
+	watchPending = nil
+	watchMu.Unlock()
+	if len(pending) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("[백그라운드 로그 감시 알림]")
+	for _, digest := range pending {
+		fmt.Fprintf(&b, "\n%s (%s): 새 이벤트 %d건, %s ~ %s", digest.id, digest.source, digest.count,
+			digest.first.Format("15:04:05"), digest.last.Format("15:04:05"))
+		for _, line := range digest.lines {
+			b.WriteString("\n  " + cutLine(line, 500))
+		}
+	}
+	b.WriteString("\n위 이벤트가 진행 중인 진단이나 앞서 적용한 조치와 관련이 있는지 확인하고, 필요하면 원인을 조사하세요.")
+	return b.String()
+}
+
+// recentWatchEvents returns up to n of the latest watch events, oldest first
+func recentWatchEvents(n int) []logs.WatchEvent {
+	watchMu.Lock()
+	defer watchMu.Unlock()
+	if n <= 0 {
+		return nil
+	}
+	start := max(0, len(watchFeed)-n)
+	return append([]logs.WatchEvent(nil), watchFeed[start:]...)
+}
+
+// setWatchNotify sets the function telling the TUI about watch changes
+func setWatchNotify(notify func()) {
+	watchMu.Lock()
+	defer watchMu.Unlock()
+	watchNotify = notify
+}
+
+// notifyWatchChange asks the TUI to redraw the watch pane
+func notifyWatchChange() {
+	watchMu.Lock()
+	notify := watchNotify
+	watchMu.Unlock()
+	if notify != nil {
+		notify()
+	}
+}
+
+// watchTrigger reads the pattern and level of a watch from a monitor_log call
+func watchTrigger(input map[string]interface{}) (logs.Trigger, error) {
+	var trigger logs.Trigger
+	if pattern, _ := input["pattern"].(string); pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return trigger, fmt.Errorf("invalid pattern: %w", err)
+		}
+		trigger.Pattern = re
+	}
+	trigger.Level, _ = input["level"].(string)
+	return trigger, nil
+}
+
+// startLogWatch hands monitor to the watch registry. A tail in the TUI becomes
+// a watch without a trigger whose lines only show in the pane.
+func startLogWatch(monitor *logs.Monitor, input map[string]interface{}, tail bool) (string, bool, error) {
+	var trigger logs.Trigger
+	inject := false
+	if !tail {
+		var err error
+		if trigger, err = watchTrigger(input); err != nil {
+			monitor.Close()
+			return "", false, err
+		}
+		inject, _ = input["inject"].(bool)
+	}
+
+	id := watchRegistry.Start(monitor, trigger, inject)
+	logger.Info("로그 감시 시작: %s (%s, %s)", id, monitor.Name(), triggerText(trigger))
+	notifyWatchChange()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "로그 감시 %s 시작: %s (%s)", id, monitor.Name(), triggerText(trigger))
+	if tuiEnabled {
+		b.WriteString("\n새 이벤트는 감시 패널에 표시됩니다.")
+	}
+	if inject {
+		b.WriteString("\n이벤트가 발생하면 요약해서 대화에 전달합니다.")
+	}
+	fmt.Fprintf(&b, "\n중지하려면 monitor_log의 cancel_watch 액션에 watch_id '%s'를 지정하거나 /unwatch %s를 입력하세요.", id, id)
+	return b.String(), true, nil
+}
+
+// cancelLogWatch stops one watch, or every watch for "all"
+func cancelLogWatch(id string) (string, error) {
+	if id == "all" {
+		n := watchRegistry.CancelAll()
+		notifyWatchChange()
+		return fmt.Sprintf("로그 감시 %d개를 중지했습니다.", n), nil
+	}
+	if err := watchRegistry.Cancel(id); err != nil {
+		return "", err
+	}
+	logger.Info("로그 감시 중지: %s", id)
+	notifyWatchChange()
+	return fmt.Sprintf("로그 감시 %s를 중지했습니다.", id), nil
+}
+
+// formatWatchList describes the running watches
+func formatWatchList() string {
+	watches := watchRegistry.List()
+	if len(watches) == 0 {
+		return "실행 중인 로그 감시가 없습니다."
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "실행 중인 로그 감시 (%d개):", len(watches))
+	for _, info := range watches {
+		fmt.Fprintf(&b, "\n  %s  %s  %s, 이벤트 %d건", info.ID, info.Source, triggerText(info.Trigger), info.Events)
+		if !info.LastEvent.IsZero() {
+			fmt.Fprintf(&b, " (마지막 %s)", info.LastEvent.Format("15:04:05"))
+		}
+		if info.Inject {
+			b.WriteString(", 대화에 전달")
+		}
+	}
+	return b.String()
+}
+
+func triggerText(trigger logs.Trigger) string {
+	var parts []string
+	if trigger.Pattern != nil {
+		parts = append(parts, "패턴 "+trigger.Pattern.String())
+	}
+	if trigger.Level != "" {
+		parts = append(parts, "레벨 "+strings.ToUpper(trigger.Level))
+	}
+	if len(parts) == 0 {
+		return "모든 새 줄"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// cutLine shortens a log line to at most n runes
+func cutLine(line string, n int) string {
+	runes := []rune(line)
+	if len(runes) <= n {
+		return line
+	}
+	return string(runes[:n]) + "..."
+}
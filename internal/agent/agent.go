@@ -2,35 +2,87 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/mainbong/storage_doctor/internal/chat"
 	"github.com/mainbong/storage_doctor/internal/llm"
+	"github.com/mainbong/storage_doctor/internal/logger"
 )
 
 // Agent represents an autonomous agent that can use tools
 type Agent struct {
-	llmProvider   llm.Provider
-	chatManager   *chat.Manager
-	skillManager  *SkillManager
-	tools         []llm.Tool
-	maxIterations int
+	llmProvider      llm.Provider
+	chatManager      *chat.Manager
+	skillManager     *SkillManager
+	tools            []llm.Tool
+	maxIterations    int
+	maxParallelTools int
+	toolTimeout      time.Duration
+	isReadOnly       func(llm.ToolCall) bool
+	activeSkills     []string
+	budgetGuard      BudgetGuard
 }
 
+// ErrMaxIterations is returned by StreamTask when the model still wanted to
+// call tools after the iteration limit was reached.
+var ErrMaxIterations = errors.New("최대 반복 횟수에 도달하여 작업을 중단했습니다")
+
+// ErrBudgetExceeded is returned by a BudgetGuard when the session budget
+// would be exceeded and the user chose not to continue.
+var ErrBudgetExceeded = errors.New("세션 예산 한도에 도달하여 작업을 중단했습니다")
+
+// BudgetGuard is called before each LLM call with the estimated input tokens
+// of that call. A non-nil error stops the task before the call is made.
+type BudgetGuard func(ctx context.Context, nextInputTokens int) error
+
+// ToolHandler executes a single tool call. The context is cancelled when the
+// task is cancelled or the per-call timeout expires.
+type ToolHandler func(ctx context.Context, toolCall llm.ToolCall) (string, error)
+
 // NewAgent creates a new agent
 func NewAgent(llmProvider llm.Provider, chatManager *chat.Manager, skillManager *SkillManager) *Agent {
 	return &Agent{
-		llmProvider:   llmProvider,
-		chatManager:   chatManager,
-		skillManager:  skillManager,
-		tools:         llm.GetTools(),
-		maxIterations: 10, // Maximum tool calls per task
+		llmProvider:      llmProvider,
+		chatManager:      chatManager,
+		skillManager:     skillManager,
+		tools:            llm.GetTools(),
+		maxIterations:    10, // Maximum tool calls per task
+		maxParallelTools: 4,  // Concurrent read-only tool calls per iteration
+		isReadOnly:       IsReadOnlyToolCall,
+	}
+}
+
+// SetMaxIterations sets how many LLM round trips a task may take
+func (a *Agent) SetMaxIterations(n int) {
+	if n < 1 {
+		n = 1
+	}
+	a.maxIterations = n
+}
+
+// SetProvider switches the model used for the following LLM calls; the conversation is kept
+func (a *Agent) SetProvider(provider llm.Provider) {
+	a.llmProvider = provider
+	a.chatManager.SetProvider(provider)
+}
+
+// SetBudgetGuard sets the check run before each LLM call; nil disables it
+func (a *Agent) SetBudgetGuard(guard BudgetGuard) {
+	a.budgetGuard = guard
+}
+
+func (a *Agent) checkBudget(ctx context.Context, messages []llm.Message) error {
+	if a.budgetGuard == nil {
+		return nil
 	}
+	return a.budgetGuard(ctx, llm.EstimateTokens(messages))
 }
 
 // ExecuteTask executes a task autonomously using tools
-func (a *Agent) ExecuteTask(ctx context.Context, task string, onToolCall func(llm.ToolCall) (string, error)) (string, error) {
+func (a *Agent) ExecuteTask(ctx context.Context, task string, onToolCall ToolHandler) (string, error) {
 	// Build system prompt with skill metadata
 	systemPrompt := a.buildSystemPrompt()
 	a.chatManager.SetSystemPrompt(systemPrompt)
@@ -44,14 +96,25 @@ func (a *Agent) ExecuteTask(ctx context.Context, task string, onToolCall func(ll
 	for iterations < a.maxIterations {
 		iterations++
 
-		// Get current messages
+		// Keep the conversation within the context window, then get current messages
+		if err := a.chatManager.Compact(ctx); err != nil {
+			logger.Warn("컨텍스트 압축 실패: %v", err)
+		}
 		messages := a.chatManager.GetMessages()
 
 		// Stream response and collect tool calls
 		var responseText strings.Builder
 		var toolCalls []llm.ToolCall
 
-		err := a.llmProvider.StreamChatWithTools(ctx, messages, a.tools, func(chunk string) {
+		if err := a.checkBudget(ctx, messages); err != nil {
+			return "", err
+		}
+		callCtx, meter := chat.MeterUsage(ctx)
+		var thinking []llm.ThinkingBlock
+		callCtx = llm.WithThinkingCollector(callCtx, func(block llm.ThinkingBlock) {
+			thinking = append(thinking, block)
+		})
+		err := a.llmProvider.StreamChatWithTools(callCtx, messages, a.tools, func(chunk string) {
 			responseText.WriteString(chunk)
 		}, func(toolCall llm.ToolCall) {
 			toolCalls = append(toolCalls, toolCall)
@@ -62,6 +125,7 @@ func (a *Agent) ExecuteTask(ctx context.Context, task string, onToolCall func(ll
 		}
 
 		response := responseText.String()
+		a.chatManager.RecordCall(meter, messages, llm.Message{Role: "assistant", Content: response, ToolCalls: toolCalls})
 		finalResponse.WriteString(response)
 		finalResponse.WriteString("\n\n")
 
@@ -72,20 +136,12 @@ func (a *Agent) ExecuteTask(ctx context.Context, task string, onToolCall func(ll
 			break
 		}
 
-		// Execute tool calls
-		var toolResults []string
-		for _, toolCall := range toolCalls {
-			result, err := onToolCall(toolCall)
-			if err != nil {
-				toolResults = append(toolResults, chat.FormatToolCall(toolCall.Name, fmt.Sprintf("오류: %v", err), false))
-			} else {
-				toolResults = append(toolResults, chat.FormatToolCall(toolCall.Name, result, true))
-			}
-		}
+		// Record the assistant turn with its tool calls, then execute them
+		a.chatManager.AddAssistantToolCalls(response, toolCalls, thinking)
+		toolResults := a.executeToolCalls(ctx, toolCalls, onToolCall)
 
 		// Add tool results to conversation
-		toolResultsText := strings.Join(toolResults, "\n\n")
-		a.chatManager.AddMessage("user", toolResultsText)
+		a.chatManager.AddToolResults(toolResults)
 
 		// Check if task is complete (no more tool calls needed)
 		// This will be determined in the next iteration
@@ -138,32 +194,57 @@ func (a *Agent) buildSystemPrompt() string {
 		builder.WriteString(fmt.Sprintf("- %s: %s\n", tool.Name, tool.Description))
 	}
 
+	// Add activated skills so they survive every rebuild of the prompt
+	for _, name := range a.activeSkills {
+		if content, err := a.skillManager.ActivateSkill(name); err == nil {
+			builder.WriteString("\n")
+			builder.WriteString(content)
+		}
+	}
+
 	return builder.String()
 }
 
-// ActivateSkill activates a skill and adds it to the context
+// ActivateSkill activates a skill and adds it to the system prompt
 func (a *Agent) ActivateSkill(skillName string) error {
-	skillContent, err := a.skillManager.ActivateSkill(skillName)
-	if err != nil {
+	if _, err := a.skillManager.ActivateSkill(skillName); err != nil {
 		return fmt.Errorf("스킬 활성화 실패: %w", err)
 	}
 
-	// Add skill content to system prompt or as a message
-	currentPrompt := a.chatManager.GetMessages()
-	if len(currentPrompt) > 0 && currentPrompt[0].Role == "system" {
-		// Append skill to system prompt
-		newPrompt := currentPrompt[0].Content + "\n\n" + skillContent
-		a.chatManager.SetSystemPrompt(newPrompt)
-	} else {
-		// Add as system message
-		a.chatManager.AddMessage("system", skillContent)
+	for _, name := range a.activeSkills {
+		if name == skillName {
+			return nil
+		}
 	}
+	a.activeSkills = append(a.activeSkills, skillName)
+	a.chatManager.SetSystemPrompt(a.buildSystemPrompt())
+
+	return nil
+}
+
+// ActiveSkills returns the names of the activated skills in activation order
+func (a *Agent) ActiveSkills() []string {
+	return append([]string(nil), a.activeSkills...)
+}
 
+// RestoreSkills re-activates skills saved with a session. Skills that no
+// longer exist are skipped and reported in the returned error.
+func (a *Agent) RestoreSkills(names []string) error {
+	a.activeSkills = nil
+	var missing []string
+	for _, name := range names {
+		if err := a.ActivateSkill(name); err != nil {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("스킬을 찾을 수 없습니다: %s", strings.Join(missing, ", "))
+	}
 	return nil
 }
 
 // StreamTask executes a task with streaming response
-func (a *Agent) StreamTask(ctx context.Context, task string, onChunk func(string), onToolCall func(llm.ToolCall) (string, error)) error {
+func (a *Agent) StreamTask(ctx context.Context, task string, onChunk func(string), onToolCall ToolHandler) error {
 	// Build system prompt
 	systemPrompt := a.buildSystemPrompt()
 	a.chatManager.SetSystemPrompt(systemPrompt)
@@ -176,14 +257,25 @@ func (a *Agent) StreamTask(ctx context.Context, task string, onChunk func(string
 	for iterations < a.maxIterations {
 		iterations++
 
-		// Get current messages
+		// Keep the conversation within the context window, then get current messages
+		if err := a.chatManager.Compact(ctx); err != nil {
+			logger.Warn("컨텍스트 압축 실패: %v", err)
+		}
 		messages := a.chatManager.GetMessages()
 
 		// Stream response and collect tool calls
 		var responseText strings.Builder
 		var toolCalls []llm.ToolCall
 
-		err := a.llmProvider.StreamChatWithTools(ctx, messages, a.tools, func(chunk string) {
+		if err := a.checkBudget(ctx, messages); err != nil {
+			return err
+		}
+		callCtx, meter := chat.MeterUsage(ctx)
+		var thinking []llm.ThinkingBlock
+		callCtx = llm.WithThinkingCollector(callCtx, func(block llm.ThinkingBlock) {
+			thinking = append(thinking, block)
+		})
+		err := a.llmProvider.StreamChatWithTools(callCtx, messages, a.tools, func(chunk string) {
 			onChunk(chunk)
 			responseText.WriteString(chunk)
 		}, func(toolCall llm.ToolCall) {
@@ -195,6 +287,7 @@ func (a *Agent) StreamTask(ctx context.Context, task string, onChunk func(string
 		}
 
 		response := responseText.String()
+		a.chatManager.RecordCall(meter, messages, llm.Message{Role: "assistant", Content: response, ToolCalls: toolCalls})
 
 		// Check if we got any response at all
 		if response == "" && len(toolCalls) == 0 {
@@ -207,24 +300,16 @@ func (a *Agent) StreamTask(ctx context.Context, task string, onChunk func(string
 			return nil
 		}
 
-		// Execute tool calls
-		var toolResults []string
-		for _, toolCall := range toolCalls {
-			result, err := onToolCall(toolCall)
-			if err != nil {
-				toolResults = append(toolResults, chat.FormatToolCall(toolCall.Name, fmt.Sprintf("오류: %v", err), false))
-			} else {
-				toolResults = append(toolResults, chat.FormatToolCall(toolCall.Name, result, true))
-			}
-		}
+		// Record the assistant turn with its tool calls, then execute them
+		a.chatManager.AddAssistantToolCalls(response, toolCalls, thinking)
+		toolResults := a.executeToolCalls(ctx, toolCalls, onToolCall)
 
 		// Add tool results to conversation
-		toolResultsText := strings.Join(toolResults, "\n\n")
-		a.chatManager.AddMessage("user", toolResultsText)
+		a.chatManager.AddToolResults(toolResults)
 
 		// Continue to next iteration
 		onChunk("\n\n[도구 실행 완료, 다음 단계 진행 중...]\n\n")
 	}
 
-	return nil
+	return ErrMaxIterations
 }
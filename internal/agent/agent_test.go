@@ -48,7 +48,7 @@ func TestStreamTask(t *testing.T) {
 	var chunks []string
 	err := agentInstance.StreamTask(context.Background(), "test task", func(chunk string) {
 		chunks = append(chunks, chunk)
-	}, func(toolCall llm.ToolCall) (string, error) {
+	}, func(ctx context.Context, toolCall llm.ToolCall) (string, error) {
 		// Do nothing
 		return "", nil
 	})
@@ -71,7 +71,7 @@ func TestStreamTask_ProviderError(t *testing.T) {
 
 	agentInstance := NewAgent(mockProvider, mockChatManager, mockSkillManager)
 
-	err := agentInstance.StreamTask(context.Background(), "test task", func(chunk string) {}, func(toolCall llm.ToolCall) (string, error) {
+	err := agentInstance.StreamTask(context.Background(), "test task", func(chunk string) {}, func(ctx context.Context, toolCall llm.ToolCall) (string, error) {
 		return "", nil
 	})
 	if err == nil {
@@ -88,7 +88,7 @@ func TestStreamTask_EmptyResponse(t *testing.T) {
 
 	agentInstance := NewAgent(mockProvider, mockChatManager, mockSkillManager)
 
-	err := agentInstance.StreamTask(context.Background(), "test task", func(chunk string) {}, func(toolCall llm.ToolCall) (string, error) {
+	err := agentInstance.StreamTask(context.Background(), "test task", func(chunk string) {}, func(ctx context.Context, toolCall llm.ToolCall) (string, error) {
 		return "", nil
 	})
 	if err == nil {
@@ -102,7 +102,7 @@ func TestStreamTask_EmptyResponse(t *testing.T) {
 
 func TestStreamTask_WithToolCalls(t *testing.T) {
 	mockProvider := llm.NewMockProvider()
-	
+
 	// First call: return tool call, second call: return empty (task complete)
 	callCount := 0
 	mockProvider.SetOnStreamChat(func(ctx context.Context, messages []llm.Message, tools []llm.Tool, onChunk func(string), onToolCall func(llm.ToolCall)) error {
@@ -121,7 +121,7 @@ func TestStreamTask_WithToolCalls(t *testing.T) {
 		}
 		return nil
 	})
-	
+
 	mockChatManager := chat.NewManager(mockProvider)
 	mockFS := filesystem.NewMockFileSystem()
 	mockSkillManager, _ := NewSkillManagerWithFS("/test/skills", mockFS)
@@ -129,7 +129,7 @@ func TestStreamTask_WithToolCalls(t *testing.T) {
 	agentInstance := NewAgent(mockProvider, mockChatManager, mockSkillManager)
 
 	var toolCalls []llm.ToolCall
-	err := agentInstance.StreamTask(context.Background(), "test task", func(chunk string) {}, func(toolCall llm.ToolCall) (string, error) {
+	err := agentInstance.StreamTask(context.Background(), "test task", func(chunk string) {}, func(ctx context.Context, toolCall llm.ToolCall) (string, error) {
 		toolCalls = append(toolCalls, toolCall)
 		return "result", nil
 	})
@@ -146,3 +146,254 @@ func TestStreamTask_WithToolCalls(t *testing.T) {
 		t.Errorf("Expected tool call name 'execute_command', got '%s'", toolCalls[0].Name)
 	}
 }
+
+func TestStreamTask_ToolResultsMatchCallIDs(t *testing.T) {
+	mockProvider := llm.NewMockProvider()
+
+	callCount := 0
+	var lastMessages []llm.Message
+	mockProvider.SetOnStreamChat(func(ctx context.Context, messages []llm.Message, tools []llm.Tool, onChunk func(string), onToolCall func(llm.ToolCall)) error {
+		callCount++
+		lastMessages = messages
+		if callCount == 1 {
+			onToolCall(llm.ToolCall{ID: "call_1", Name: "read_file", Input: map[string]interface{}{"path": "/a"}})
+			onToolCall(llm.ToolCall{ID: "call_2", Name: "read_file", Input: map[string]interface{}{"path": "/b"}})
+		} else {
+			onChunk("done")
+		}
+		return nil
+	})
+
+	mockChatManager := chat.NewManager(mockProvider)
+	mockFS := filesystem.NewMockFileSystem()
+	mockSkillManager, _ := NewSkillManagerWithFS("/test/skills", mockFS)
+	agentInstance := NewAgent(mockProvider, mockChatManager, mockSkillManager)
+
+	err := agentInstance.StreamTask(context.Background(), "test task", func(chunk string) {}, func(ctx context.Context, toolCall llm.ToolCall) (string, error) {
+		if toolCall.ID == "call_2" {
+			return "", errors.New("not found")
+		}
+		return "content of " + toolCall.Input["path"].(string), nil
+	})
+	if err != nil {
+		t.Fatalf("StreamTask() failed: %v", err)
+	}
+
+	toolMsg := lastMessages[len(lastMessages)-1]
+	if toolMsg.Role != "tool" || len(toolMsg.ToolResults) != 2 {
+		t.Fatalf("Expected tool message with 2 results, got %+v", toolMsg)
+	}
+	if toolMsg.ToolResults[0].ToolCallID != "call_1" || toolMsg.ToolResults[0].Content != "content of /a" {
+		t.Errorf("Unexpected first result: %+v", toolMsg.ToolResults[0])
+	}
+	if toolMsg.ToolResults[1].ToolCallID != "call_2" || !toolMsg.ToolResults[1].IsError {
+		t.Errorf("Expected error result for 'call_2', got %+v", toolMsg.ToolResults[1])
+	}
+	assistantMsg := lastMessages[len(lastMessages)-2]
+	if len(assistantMsg.ToolCalls) != 2 {
+		t.Errorf("Expected assistant message with 2 tool calls, got %+v", assistantMsg)
+	}
+}
+
+func TestStreamTask_MaxIterations(t *testing.T) {
+	mockProvider := llm.NewMockProvider()
+	calls := 0
+	mockProvider.SetOnStreamChat(func(ctx context.Context, messages []llm.Message, tools []llm.Tool, onChunk func(string), onToolCall func(llm.ToolCall)) error {
+		calls++
+		onToolCall(llm.ToolCall{ID: "call", Name: "read_file", Input: map[string]interface{}{"path": "/a"}})
+		return nil
+	})
+
+	mockChatManager := chat.NewManager(mockProvider)
+	mockFS := filesystem.NewMockFileSystem()
+	mockSkillManager, _ := NewSkillManagerWithFS("/test/skills", mockFS)
+	agentInstance := NewAgent(mockProvider, mockChatManager, mockSkillManager)
+	agentInstance.SetMaxIterations(2)
+
+	err := agentInstance.StreamTask(context.Background(), "test task", func(chunk string) {}, func(ctx context.Context, toolCall llm.ToolCall) (string, error) {
+		return "content", nil
+	})
+	if !errors.Is(err, ErrMaxIterations) {
+		t.Errorf("Expected ErrMaxIterations, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 LLM calls, got %d", calls)
+	}
+}
+
+func TestActivateSkill_SurvivesNewTask(t *testing.T) {
+	mockProvider := llm.NewMockProvider()
+	mockProvider.SetStreamChunks([]string{"done"})
+	mockChatManager := chat.NewManager(mockProvider)
+	mockFS := filesystem.NewMockFileSystem()
+	mockSkillManager, _ := NewSkillManagerWithFS("/test/skills", mockFS)
+	agentInstance := NewAgent(mockProvider, mockChatManager, mockSkillManager)
+
+	if err := agentInstance.ActivateSkill("storage_diagnosis"); err != nil {
+		t.Fatalf("ActivateSkill() failed: %v", err)
+	}
+	if err := agentInstance.ActivateSkill("storage_diagnosis"); err != nil {
+		t.Fatalf("ActivateSkill() failed on second activation: %v", err)
+	}
+	skillContent, _ := mockSkillManager.ActivateSkill("storage_diagnosis")
+
+	err := agentInstance.StreamTask(context.Background(), "test task", func(chunk string) {}, func(ctx context.Context, toolCall llm.ToolCall) (string, error) {
+		return "", nil
+	})
+	if err != nil {
+		t.Fatalf("StreamTask() failed: %v", err)
+	}
+
+	systemPrompt := mockChatManager.GetMessages()[0].Content
+	if strings.Count(systemPrompt, skillContent) != 1 {
+		t.Errorf("Expected activated skill once in the system prompt after a new task")
+	}
+	if skills := agentInstance.ActiveSkills(); len(skills) != 1 || skills[0] != "storage_diagnosis" {
+		t.Errorf("Expected active skills [storage_diagnosis], got %v", skills)
+	}
+	if usage := mockChatManager.Usage(); usage.InputTokens == 0 || usage.OutputTokens == 0 {
+		t.Errorf("Expected LLM call to be counted, got %+v", usage)
+	}
+}
+
+func TestRestoreSkills_MissingSkill(t *testing.T) {
+	mockProvider := llm.NewMockProvider()
+	mockChatManager := chat.NewManager(mockProvider)
+	mockFS := filesystem.NewMockFileSystem()
+	mockSkillManager, _ := NewSkillManagerWithFS("/test/skills", mockFS)
+	agentInstance := NewAgent(mockProvider, mockChatManager, mockSkillManager)
+
+	err := agentInstance.RestoreSkills([]string{"storage_diagnosis", "removed_skill"})
+	if err == nil || !strings.Contains(err.Error(), "removed_skill") {
+		t.Errorf("Expected error naming the missing skill, got %v", err)
+	}
+	if skills := agentInstance.ActiveSkills(); len(skills) != 1 || skills[0] != "storage_diagnosis" {
+		t.Errorf("Expected remaining skill to be restored, got %v", skills)
+	}
+}
+
+func TestStreamTask_CompactsContext(t *testing.T) {
+	mockProvider := llm.NewMockProvider()
+	var sentTokens int
+	mockProvider.SetOnStreamChat(func(ctx context.Context, messages []llm.Message, tools []llm.Tool, onChunk func(string), onToolCall func(llm.ToolCall)) error {
+		sentTokens = llm.EstimateTokens(messages)
+		onChunk("done")
+		return nil
+	})
+	mockChatManager := chat.NewManager(mockProvider)
+	mockChatManager.SetContextWindow(20000)
+	mockFS := filesystem.NewMockFileSystem()
+	mockSkillManager, _ := NewSkillManagerWithFS("/test/skills", mockFS)
+	agentInstance := NewAgent(mockProvider, mockChatManager, mockSkillManager)
+
+	mockChatManager.AddAssistantToolCalls("", []llm.ToolCall{{ID: "call_1", Name: "execute_command"}}, nil)
+	mockChatManager.AddToolResults([]llm.ToolResult{{ToolCallID: "call_1", Name: "execute_command", Content: strings.Repeat("kubectl describe output line\n", 5000)}})
+
+	err := agentInstance.StreamTask(context.Background(), "continue", func(chunk string) {}, func(ctx context.Context, toolCall llm.ToolCall) (string, error) {
+		return "", nil
+	})
+	if err != nil {
+		t.Fatalf("StreamTask() failed: %v", err)
+	}
+	if sentTokens == 0 || sentTokens > 20000 {
+		t.Errorf("Expected compacted request within the context window, got %d tokens", sentTokens)
+	}
+}
+
+func TestStreamTask_BudgetGuard(t *testing.T) {
+	mockProvider := llm.NewMockProvider()
+	calls := 0
+	mockProvider.SetOnStreamChat(func(ctx context.Context, messages []llm.Message, tools []llm.Tool, onChunk func(string), onToolCall func(llm.ToolCall)) error {
+		calls++
+		onToolCall(llm.ToolCall{ID: "call", Name: "read_file", Input: map[string]interface{}{"path": "/a"}})
+		return nil
+	})
+
+	mockChatManager := chat.NewManager(mockProvider)
+	mockFS := filesystem.NewMockFileSystem()
+	mockSkillManager, _ := NewSkillManagerWithFS("/test/skills", mockFS)
+	agentInstance := NewAgent(mockProvider, mockChatManager, mockSkillManager)
+
+	checks := 0
+	agentInstance.SetBudgetGuard(func(ctx context.Context, nextInputTokens int) error {
+		checks++
+		if nextInputTokens <= 0 {
+			t.Errorf("Expected estimated input tokens, got %d", nextInputTokens)
+		}
+		if checks > 2 {
+			return ErrBudgetExceeded
+		}
+		return nil
+	})
+
+	err := agentInstance.StreamTask(context.Background(), "test task", func(chunk string) {}, func(ctx context.Context, toolCall llm.ToolCall) (string, error) {
+		return "content", nil
+	})
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Expected ErrBudgetExceeded, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 LLM calls before the budget stopped the task, got %d", calls)
+	}
+}
+
+func TestStreamTask_KeepsThinkingWithToolCalls(t *testing.T) {
+	mockProvider := llm.NewMockProvider()
+	mockProvider.SetToolCalls([]llm.ToolCall{{ID: "call_1", Name: "read_file", Input: map[string]interface{}{"path": "/a"}}})
+	mockProvider.SetThinking([]llm.ThinkingBlock{{Thinking: "read the file first", Signature: "sig"}})
+
+	mockChatManager := chat.NewManager(mockProvider)
+	mockFS := filesystem.NewMockFileSystem()
+	mockSkillManager, _ := NewSkillManagerWithFS("/test/skills", mockFS)
+	agentInstance := NewAgent(mockProvider, mockChatManager, mockSkillManager)
+	agentInstance.SetMaxIterations(1)
+
+	agentInstance.StreamTask(context.Background(), "test task", func(chunk string) {}, func(ctx context.Context, toolCall llm.ToolCall) (string, error) {
+		return "content", nil
+	})
+
+	for _, msg := range mockChatManager.GetMessages() {
+		if len(msg.ToolCalls) > 0 {
+			if len(msg.Thinking) != 1 || msg.Thinking[0].Signature != "sig" {
+				t.Errorf("Expected signed thinking block on the tool call turn, got %+v", msg.Thinking)
+			}
+			return
+		}
+	}
+	t.Error("Expected an assistant message with tool calls")
+}
+
+func TestSetProvider_SwitchesModelMidSession(t *testing.T) {
+	first := llm.NewMockProvider()
+	first.SetStreamChunks([]string{"PVC is Pending"})
+	chatManager := chat.NewManager(first)
+	skillManager, _ := NewSkillManagerWithFS("/test/skills", filesystem.NewMockFileSystem())
+	agentInstance := NewAgent(first, chatManager, skillManager)
+	noTools := func(ctx context.Context, toolCall llm.ToolCall) (string, error) { return "", nil }
+
+	if err := agentInstance.StreamTask(context.Background(), "check pvc", func(string) {}, noTools); err != nil {
+		t.Fatalf("StreamTask() failed: %v", err)
+	}
+
+	second := llm.NewMockProvider()
+	second.SetModel("gpt-4o")
+	var received []llm.Message
+	second.SetOnStreamChat(func(ctx context.Context, messages []llm.Message, tools []llm.Tool, onChunk func(string), onToolCall func(llm.ToolCall)) error {
+		received = messages
+		onChunk("StorageClass missing")
+		return nil
+	})
+	agentInstance.SetProvider(second)
+
+	if err := agentInstance.StreamTask(context.Background(), "why?", func(string) {}, noTools); err != nil {
+		t.Fatalf("StreamTask() failed: %v", err)
+	}
+	var contents []string
+	for _, msg := range received {
+		contents = append(contents, msg.Content)
+	}
+	joined := strings.Join(contents, "|")
+	if !strings.Contains(joined, "check pvc") || !strings.Contains(joined, "PVC is Pending") {
+		t.Errorf("Expected the switched provider to get the earlier conversation, got %v", contents)
+	}
+}
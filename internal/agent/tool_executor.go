@@ -0,0 +1,133 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mainbong/storage_doctor/internal/llm"
+)
+
+// IsReadOnlyToolCall reports whether a tool call only reads state and can
+// safely run concurrently with other read-only calls.
+func IsReadOnlyToolCall(toolCall llm.ToolCall) bool {
+	switch toolCall.Name {
+	case "read_file", "get_config_value", "search_web", "fetch_url", "correlate_logs":
+		return true
+	case "monitor_log":
+		// tail blocks until the user stops it and watch/cancel_watch start or
+		// stop background work, so only the reading actions qualify
+		switch action, _ := toolCall.Input["action"].(string); action {
+		case "search", "filter", "summarize", "list_watches":
+			return true
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+// SetMaxParallelTools sets how many read-only tool calls may run at once (1 disables parallelism)
+func (a *Agent) SetMaxParallelTools(n int) {
+	if n < 1 {
+		n = 1
+	}
+	a.maxParallelTools = n
+}
+
+// SetToolTimeout sets a per-call timeout for tool execution (0 disables the timeout)
+func (a *Agent) SetToolTimeout(timeout time.Duration) {
+	a.toolTimeout = timeout
+}
+
+// SetReadOnlyClassifier replaces the function used to decide which tool calls are read-only
+func (a *Agent) SetReadOnlyClassifier(classifier func(llm.ToolCall) bool) {
+	if classifier == nil {
+		classifier = IsReadOnlyToolCall
+	}
+	a.isReadOnly = classifier
+}
+
+// executeToolCalls runs the tool calls and returns results in the order they were requested.
+// Consecutive read-only calls are fanned out to a bounded worker pool; mutating calls run
+// one at a time so their side effects keep the order chosen by the model.
+func (a *Agent) executeToolCalls(ctx context.Context, toolCalls []llm.ToolCall, onToolCall ToolHandler) []llm.ToolResult {
+	results := make([]llm.ToolResult, len(toolCalls))
+
+	for i := 0; i < len(toolCalls); {
+		if !a.isReadOnly(toolCalls[i]) || a.maxParallelTools <= 1 {
+			results[i] = a.runToolCall(ctx, toolCalls[i], onToolCall)
+			i++
+			continue
+		}
+
+		// Collect the run of consecutive read-only calls
+		end := i
+		for end < len(toolCalls) && a.isReadOnly(toolCalls[end]) {
+			end++
+		}
+
+		sem := make(chan struct{}, a.maxParallelTools)
+		var wg sync.WaitGroup
+		for j := i; j < end; j++ {
+			wg.Add(1)
+			go func(idx int) {
+				defer wg.Done()
+				select {
+				case sem <- struct{}{}:
+					defer func() { <-sem }()
+				case <-ctx.Done():
+					results[idx] = newToolResult(toolCalls[idx], "", ctx.Err())
+					return
+				}
+				results[idx] = a.runToolCall(ctx, toolCalls[idx], onToolCall)
+			}(j)
+		}
+		wg.Wait()
+		i = end
+	}
+
+	return results
+}
+
+// runToolCall executes one tool call with its own cancellable context
+func (a *Agent) runToolCall(ctx context.Context, toolCall llm.ToolCall, onToolCall ToolHandler) llm.ToolResult {
+	if err := ctx.Err(); err != nil {
+		return newToolResult(toolCall, "", err)
+	}
+
+	var callCtx context.Context
+	var cancel context.CancelFunc
+	if a.toolTimeout > 0 {
+		callCtx, cancel = context.WithTimeout(ctx, a.toolTimeout)
+	} else {
+		callCtx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	result, err := onToolCall(callCtx, toolCall)
+	return newToolResult(toolCall, result, err)
+}
+
+// newToolResult builds the tool result sent back to the LLM for a tool call
+func newToolResult(toolCall llm.ToolCall, result string, err error) llm.ToolResult {
+	if err != nil {
+		content := fmt.Sprintf("오류: %v", err)
+		if strings.TrimSpace(result) != "" {
+			content += "\n" + result
+		}
+		return llm.ToolResult{
+			ToolCallID: toolCall.ID,
+			Name:       toolCall.Name,
+			Content:    content,
+			IsError:    true,
+		}
+	}
+	return llm.ToolResult{
+		ToolCallID: toolCall.ID,
+		Name:       toolCall.Name,
+		Content:    result,
+	}
+}
@@ -0,0 +1,182 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mainbong/storage_doctor/internal/chat"
+	"github.com/mainbong/storage_doctor/internal/filesystem"
+	"github.com/mainbong/storage_doctor/internal/llm"
+)
+
+func newTestAgent(t *testing.T) *Agent {
+	t.Helper()
+	mockProvider := llm.NewMockProvider()
+	mockSkillManager, _ := NewSkillManagerWithFS("/test/skills", filesystem.NewMockFileSystem())
+	return NewAgent(mockProvider, chat.NewManager(mockProvider), mockSkillManager)
+}
+
+func TestIsReadOnlyToolCall(t *testing.T) {
+	tests := []struct {
+		call     llm.ToolCall
+		expected bool
+	}{
+		{llm.ToolCall{Name: "read_file"}, true},
+		{llm.ToolCall{Name: "search_web"}, true},
+		{llm.ToolCall{Name: "fetch_url"}, true},
+		{llm.ToolCall{Name: "get_config_value"}, true},
+		{llm.ToolCall{Name: "set_config_value"}, false},
+		{llm.ToolCall{Name: "monitor_log", Input: map[string]interface{}{"action": "search"}}, true},
+		{llm.ToolCall{Name: "monitor_log", Input: map[string]interface{}{"action": "tail"}}, false},
+		{llm.ToolCall{Name: "monitor_log", Input: map[string]interface{}{"action": "list_watches"}}, true},
+		{llm.ToolCall{Name: "monitor_log", Input: map[string]interface{}{"action": "watch"}}, false},
+		{llm.ToolCall{Name: "monitor_log", Input: map[string]interface{}{"action": "cancel_watch"}}, false},
+		{llm.ToolCall{Name: "monitor_log", Input: map[string]interface{}{"action": "last"}}, false},
+		{llm.ToolCall{Name: "monitor_log"}, false},
+		{llm.ToolCall{Name: "correlate_logs"}, true},
+		{llm.ToolCall{Name: "execute_command"}, false},
+		{llm.ToolCall{Name: "write_file"}, false},
+		{llm.ToolCall{Name: "ask_user"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsReadOnlyToolCall(tt.call); got != tt.expected {
+			t.Errorf("IsReadOnlyToolCall(%s) = %v, expected %v", tt.call.Name, got, tt.expected)
+		}
+	}
+}
+
+func TestExecuteToolCalls_ParallelReadOnly(t *testing.T) {
+	agentInstance := newTestAgent(t)
+
+	calls := []llm.ToolCall{
+		{ID: "1", Name: "read_file"},
+		{ID: "2", Name: "read_file"},
+		{ID: "3", Name: "search_web"},
+	}
+
+	// Every call waits until all three are running, which only succeeds if they run concurrently
+	var started sync.WaitGroup
+	started.Add(len(calls))
+	results := agentInstance.executeToolCalls(context.Background(), calls, func(ctx context.Context, toolCall llm.ToolCall) (string, error) {
+		started.Done()
+		done := make(chan struct{})
+		go func() {
+			started.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+			return "result " + toolCall.ID, nil
+		case <-time.After(2 * time.Second):
+			return "", errors.New("calls did not run concurrently")
+		}
+	})
+
+	for i, result := range results {
+		if result.IsError {
+			t.Fatalf("unexpected error result: %s", result.Content)
+		}
+		if result.ToolCallID != calls[i].ID || result.Content != "result "+calls[i].ID {
+			t.Errorf("expected result for call %s at index %d, got %+v", calls[i].ID, i, result)
+		}
+	}
+}
+
+func TestExecuteToolCalls_MutatingRunsAlone(t *testing.T) {
+	agentInstance := newTestAgent(t)
+
+	calls := []llm.ToolCall{
+		{ID: "1", Name: "read_file"},
+		{ID: "2", Name: "write_file"},
+		{ID: "3", Name: "read_file"},
+	}
+
+	var running int32
+	var order []string
+	var mu sync.Mutex
+	results := agentInstance.executeToolCalls(context.Background(), calls, func(ctx context.Context, toolCall llm.ToolCall) (string, error) {
+		if atomic.AddInt32(&running, 1) > 1 && toolCall.Name == "write_file" {
+			t.Error("write_file ran concurrently with another call")
+		}
+		defer atomic.AddInt32(&running, -1)
+		mu.Lock()
+		order = append(order, toolCall.ID)
+		mu.Unlock()
+		return toolCall.ID, nil
+	})
+
+	if len(order) != 3 || order[1] != "2" {
+		t.Errorf("expected write_file to run second, got order %v", order)
+	}
+	for i, result := range results {
+		if result.Content != calls[i].ID {
+			t.Errorf("expected result %s at index %d, got %s", calls[i].ID, i, result.Content)
+		}
+	}
+}
+
+func TestExecuteToolCalls_MaxParallel(t *testing.T) {
+	agentInstance := newTestAgent(t)
+	agentInstance.SetMaxParallelTools(2)
+
+	calls := make([]llm.ToolCall, 6)
+	for i := range calls {
+		calls[i] = llm.ToolCall{ID: string(rune('a' + i)), Name: "read_file"}
+	}
+
+	var running, peak int32
+	agentInstance.executeToolCalls(context.Background(), calls, func(ctx context.Context, toolCall llm.ToolCall) (string, error) {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			old := atomic.LoadInt32(&peak)
+			if current <= old || atomic.CompareAndSwapInt32(&peak, old, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return "", nil
+	})
+
+	if peak > 2 {
+		t.Errorf("expected at most 2 concurrent calls, got %d", peak)
+	}
+}
+
+func TestExecuteToolCalls_Timeout(t *testing.T) {
+	agentInstance := newTestAgent(t)
+	agentInstance.SetToolTimeout(10 * time.Millisecond)
+
+	results := agentInstance.executeToolCalls(context.Background(), []llm.ToolCall{{ID: "1", Name: "read_file"}}, func(ctx context.Context, toolCall llm.ToolCall) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+
+	if !results[0].IsError {
+		t.Errorf("expected timeout error result, got %+v", results[0])
+	}
+}
+
+func TestExecuteToolCalls_Cancelled(t *testing.T) {
+	agentInstance := newTestAgent(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	called := false
+	results := agentInstance.executeToolCalls(ctx, []llm.ToolCall{{ID: "1", Name: "write_file"}}, func(ctx context.Context, toolCall llm.ToolCall) (string, error) {
+		called = true
+		return "", nil
+	})
+
+	if called {
+		t.Error("expected handler not to run after cancellation")
+	}
+	if !results[0].IsError || results[0].ToolCallID != "1" {
+		t.Errorf("expected cancelled error result for call 1, got %+v", results[0])
+	}
+}
@@ -0,0 +1,291 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mainbong/storage_doctor/internal/llm"
+)
+
+// Compaction keeps the conversation inside the model's context window.
+// Strategies run cheapest first and stop as soon as the estimate fits:
+//  1. truncate oversized tool outputs, oldest first
+//  2. reduce tool results outside the recent window to their key facts
+//  3. summarize old dialogue with the provider, pinning key facts verbatim
+
+const (
+	// reservedTokens leaves room for the response and the tool definitions
+	reservedTokens = 8192
+	// keepRecentMessages is how many trailing messages summarization leaves intact
+	keepRecentMessages = 5
+	// digestThreshold is the size above which an old tool result is reduced to its facts
+	digestThreshold = 200
+	maxPinnedFacts  = 40
+	maxFactLength   = 200
+	pinnedHeader    = "Pinned facts:"
+)
+
+var (
+	// errorLinePattern matches lines worth keeping verbatim when output is dropped
+	errorLinePattern = regexp.MustCompile(`(?i)\b(error|errors|fail|failed|failure|fatal|panic|denied|refused|timeout|timed out|no space|read-only|not found|unable|cannot|crashloopbackoff|imagepullbackoff|oomkilled|evicted|failedmount|failedattachvolume|i/o error|corrupt|corrupted)\b`)
+	// resourcePattern matches device paths and Kubernetes resource names
+	resourcePattern = regexp.MustCompile(`/dev/[\w./-]+|\bpvc-[0-9a-f-]{8,}\b|\b(?:pods?|pvc|pv|persistentvolumeclaims?|persistentvolumes?|storageclass(?:es)?|nodes?|deployments?|statefulsets?|daemonsets?|volumeattachments?)/[\w.-]+`)
+)
+
+// SetContextWindow overrides the context window, in tokens, used for compaction
+func (m *Manager) SetContextWindow(tokens int) {
+	if tokens > 0 {
+		m.contextWindow = tokens
+	}
+}
+
+// tokenBudget returns how many estimated tokens the messages may use
+func (m *Manager) tokenBudget() int {
+	available := m.contextWindow - reservedTokens
+	if available < m.contextWindow/2 {
+		available = m.contextWindow / 2
+	}
+	// Leave headroom for the inaccuracy of EstimateTokens
+	return available * 3 / 4
+}
+
+func (m *Manager) fitsBudget() bool {
+	return llm.EstimateTokens(m.messages) <= m.tokenBudget()
+}
+
+// Compact shrinks the conversation when its estimated size exceeds the token budget.
+// An error means summarization failed; the cheaper strategies have still been applied.
+func (m *Manager) Compact(ctx context.Context) error {
+	if m.fitsBudget() {
+		return nil
+	}
+	m.truncateToolResults()
+	if m.fitsBudget() {
+		return nil
+	}
+	m.digestOldToolResults()
+	if m.fitsBudget() {
+		return nil
+	}
+	return m.summarizeContext(ctx)
+}
+
+// truncateToolResults cuts tool outputs larger than a share of the budget, oldest first
+func (m *Manager) truncateToolResults() {
+	limit := m.tokenBudget() / 8
+	if limit < 1000 {
+		limit = 1000
+	}
+	for i := range m.messages {
+		if m.messages[i].Role != "tool" {
+			continue
+		}
+		m.rewriteToolResults(i, func(content string) string {
+			if llm.EstimateTokens([]llm.Message{{Content: content}}) <= limit {
+				return content
+			}
+			return truncateText(content, limit)
+		})
+		if m.fitsBudget() {
+			return
+		}
+	}
+}
+
+// digestOldToolResults replaces tool results outside the recent window with their key facts.
+// The tool calls and results stay paired so providers still accept the history.
+func (m *Manager) digestOldToolResults() {
+	recent := m.recentStart(0)
+	for i := 0; i < recent; i++ {
+		if m.messages[i].Role != "tool" {
+			continue
+		}
+		m.rewriteToolResults(i, func(content string) string {
+			if llm.EstimateTokens([]llm.Message{{Content: content}}) <= digestThreshold {
+				return content
+			}
+			return digestText(content)
+		})
+	}
+}
+
+// rewriteToolResults replaces the results of message i without touching slices shared with callers
+func (m *Manager) rewriteToolResults(i int, rewrite func(string) string) {
+	results := make([]llm.ToolResult, len(m.messages[i].ToolResults))
+	copy(results, m.messages[i].ToolResults)
+	for j := range results {
+		results[j].Content = rewrite(results[j].Content)
+	}
+	m.messages[i].ToolResults = results
+}
+
+// recentStart returns the index of the first message that summarization keeps.
+// Tool results are never separated from the assistant message that requested them.
+func (m *Manager) recentStart(start int) int {
+	split := len(m.messages) - keepRecentMessages
+	for split > start && m.messages[split].Role == "tool" {
+		split--
+	}
+	if split < start {
+		return start
+	}
+	return split
+}
+
+// truncateText keeps the head and tail of text within about maxTokens and
+// lists the key facts found in the omitted middle
+func truncateText(text string, maxTokens int) string {
+	if llm.EstimateTokens([]llm.Message{{Content: text}}) <= maxTokens {
+		return text
+	}
+	maxBytes := maxTokens * 3
+	lines := strings.Split(text, "\n")
+
+	headBytes, tailBytes := maxBytes/2, maxBytes/4
+	head, size := 0, 0
+	for head < len(lines) && size+len(lines[head])+1 <= headBytes {
+		size += len(lines[head]) + 1
+		head++
+	}
+	tail, size := 0, 0
+	for tail < len(lines)-head && size+len(lines[len(lines)-1-tail])+1 <= tailBytes {
+		size += len(lines[len(lines)-1-tail]) + 1
+		tail++
+	}
+	middle := lines[head : len(lines)-tail]
+	if len(middle) == 0 {
+		return text
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.Join(lines[:head], "\n"))
+	if head == 0 {
+		// A single huge line: keep its beginning
+		b.WriteString(cutBytes(middle[0], headBytes))
+	}
+	b.WriteString(fmt.Sprintf("\n... (%d줄 생략, 원래 약 %d 토큰) ...\n", len(middle), llm.EstimateTokens([]llm.Message{{Content: text}})))
+	facts := extractFacts(strings.Join(middle, "\n"), maxBytes/4/maxFactLength+1)
+	if len(facts) > 0 {
+		b.WriteString("생략된 부분의 주요 내용:\n")
+		for _, fact := range facts {
+			b.WriteString(fact + "\n")
+		}
+	}
+	b.WriteString(strings.Join(lines[len(lines)-tail:], "\n"))
+	return b.String()
+}
+
+// digestText reduces text to its key facts, or its first lines when none are found
+func digestText(text string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("[이전 도구 결과 압축: 원본 %d줄]\n", len(lines)))
+	facts := extractFacts(text, 10)
+	if len(facts) == 0 {
+		if len(lines) > 3 {
+			lines = lines[:3]
+		}
+		facts = lines
+	}
+	for _, fact := range facts {
+		b.WriteString(shorten(fact) + "\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// extractFacts returns up to limit error lines and resource names found in text
+func extractFacts(text string, limit int) []string {
+	var facts []string
+	seen := make(map[string]bool)
+	add := func(fact string) {
+		if fact != "" && !seen[fact] && len(facts) < limit {
+			seen[fact] = true
+			facts = append(facts, fact)
+		}
+	}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if errorLinePattern.MatchString(line) {
+			add(shorten(line))
+			continue
+		}
+		for _, match := range resourcePattern.FindAllString(line, -1) {
+			add(match)
+		}
+	}
+	return facts
+}
+
+// pinnedFacts collects the facts that must survive summarization of messages,
+// including those pinned by an earlier summary
+func pinnedFacts(messages []llm.Message) []string {
+	var facts []string
+	seen := make(map[string]bool)
+	add := func(candidates []string) {
+		for _, fact := range candidates {
+			if !seen[fact] {
+				seen[fact] = true
+				facts = append(facts, fact)
+			}
+		}
+	}
+	for _, msg := range messages {
+		if isSummary(msg) {
+			add(parsePinnedFacts(msg.Content))
+			continue
+		}
+		add(extractFacts(msg.Content, maxPinnedFacts))
+		for _, result := range msg.ToolResults {
+			add(extractFacts(result.Content, maxPinnedFacts))
+		}
+	}
+	// Keep the most recent facts
+	if len(facts) > maxPinnedFacts {
+		facts = facts[len(facts)-maxPinnedFacts:]
+	}
+	return facts
+}
+
+// parsePinnedFacts reads the facts listed after pinnedHeader in a summary
+func parsePinnedFacts(summary string) []string {
+	idx := strings.Index(summary, pinnedHeader)
+	if idx < 0 {
+		return nil
+	}
+	var facts []string
+	for _, line := range strings.Split(summary[idx+len(pinnedHeader):], "\n") {
+		if fact := strings.TrimPrefix(strings.TrimSpace(line), "- "); fact != "" {
+			facts = append(facts, fact)
+		}
+	}
+	return facts
+}
+
+// formatSummary renders a summary message with its pinned facts
+func formatSummary(summary string, facts []string) string {
+	if len(facts) == 0 {
+		return summaryPrefix + summary
+	}
+	return summaryPrefix + summary + "\n\n" + pinnedHeader + "\n- " + strings.Join(facts, "\n- ")
+}
+
+func shorten(line string) string {
+	if utf8.RuneCountInString(line) <= maxFactLength {
+		return line
+	}
+	return string([]rune(line)[:maxFactLength]) + "..."
+}
+
+// cutBytes cuts s to at most n bytes without splitting a UTF-8 sequence
+func cutBytes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,214 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mainbong/storage_doctor/internal/llm"
+)
+
+// describeOutput builds a large kubectl describe style output with one error buried in it
+func describeOutput(lines int) string {
+	var b strings.Builder
+	for i := 0; i < lines; i++ {
+		if i == lines/2 {
+			b.WriteString("  Warning  FailedMount  kubelet  MountVolume.SetUp failed for volume \"pvc-1a2b3c4d-0000\" : mount failed: exit status 32\n")
+			continue
+		}
+		b.WriteString(fmt.Sprintf("  Normal   Pulled  line %d of uneventful describe output padding padding\n", i))
+	}
+	return b.String()
+}
+
+func toolTurn(id, output string) []llm.Message {
+	return []llm.Message{
+		{Role: "assistant", ToolCalls: []llm.ToolCall{{ID: id, Name: "execute_command", Input: map[string]interface{}{"command": "kubectl describe pod db-0"}}}},
+		{Role: "tool", ToolResults: []llm.ToolResult{{ToolCallID: id, Name: "execute_command", Content: output}}},
+	}
+}
+
+func TestCompact_UnderBudget(t *testing.T) {
+	mockProvider := llm.NewMockProvider()
+	manager := NewManager(mockProvider)
+	manager.AddMessage("user", "check disks")
+
+	if err := manager.Compact(context.Background()); err != nil {
+		t.Fatalf("Compact() failed: %v", err)
+	}
+	if len(manager.GetMessages()) != 1 || manager.GetMessages()[0].Content != "check disks" {
+		t.Errorf("Expected messages to be unchanged, got %+v", manager.GetMessages())
+	}
+}
+
+func TestCompact_TruncatesLargeToolOutput(t *testing.T) {
+	mockProvider := llm.NewMockProvider()
+	mockProvider.SetChatError(errors.New("summarization must not be needed"))
+	manager := NewManager(mockProvider)
+	manager.SetContextWindow(20000)
+
+	manager.AddMessage("user", "pod db-0 is stuck")
+	for _, msg := range toolTurn("call_1", describeOutput(2000)) {
+		manager.messages = append(manager.messages, msg)
+	}
+
+	if err := manager.Compact(context.Background()); err != nil {
+		t.Fatalf("Compact() failed: %v", err)
+	}
+	if !manager.fitsBudget() {
+		t.Errorf("Expected messages to fit the budget, got %d tokens", llm.EstimateTokens(manager.GetMessages()))
+	}
+
+	messages := manager.GetMessages()
+	if len(messages) != 3 {
+		t.Fatalf("Expected tool turn to be kept, got %d messages", len(messages))
+	}
+	content := messages[2].ToolResults[0].Content
+	if !strings.Contains(content, "줄 생략") {
+		t.Errorf("Expected truncation marker, got %q", content[:100])
+	}
+	if !strings.Contains(content, "FailedMount") {
+		t.Error("Expected the error line from the omitted middle to be kept")
+	}
+	if !strings.HasPrefix(content, "  Normal   Pulled  line 0 ") {
+		t.Error("Expected the head of the output to be kept")
+	}
+}
+
+func TestCompact_DigestsOldToolResults(t *testing.T) {
+	mockProvider := llm.NewMockProvider()
+	mockProvider.SetChatError(errors.New("summarization must not be needed"))
+	manager := NewManager(mockProvider)
+	manager.SetContextWindow(20000)
+
+	manager.AddMessage("user", "pod db-0 is stuck")
+	// Each output is under the truncation limit, but together they exceed the budget
+	for i := 0; i < 12; i++ {
+		for _, msg := range toolTurn(fmt.Sprintf("call_%d", i), describeOutput(30)) {
+			manager.messages = append(manager.messages, msg)
+		}
+	}
+	manager.AddMessage("assistant", "checking events")
+
+	if err := manager.Compact(context.Background()); err != nil {
+		t.Fatalf("Compact() failed: %v", err)
+	}
+	if !manager.fitsBudget() {
+		t.Errorf("Expected messages to fit the budget, got %d tokens", llm.EstimateTokens(manager.GetMessages()))
+	}
+
+	messages := manager.GetMessages()
+	if len(messages) != 26 {
+		t.Fatalf("Expected dialogue to be kept without summarization, got %d messages", len(messages))
+	}
+	old := messages[2].ToolResults[0].Content
+	if !strings.HasPrefix(old, "[이전 도구 결과 압축") || !strings.Contains(old, "FailedMount") {
+		t.Errorf("Expected old tool result to be reduced to its facts, got %q", old)
+	}
+	recent := messages[24].ToolResults[0].Content
+	if strings.HasPrefix(recent, "[이전 도구 결과 압축") {
+		t.Error("Expected the most recent tool result to be left intact")
+	}
+}
+
+func TestCompact_SummarizesWithPinnedFacts(t *testing.T) {
+	mockProvider := llm.NewMockProvider()
+	mockProvider.SetChatResponse("DB 파드가 볼륨 마운트에 실패함")
+	manager := NewManager(mockProvider)
+	manager.SetContextWindow(20000)
+
+	manager.SetSystemPrompt("system prompt")
+	manager.AddMessage("user", "mount of /dev/sdb1 fails with: wrong fs type, bad superblock on /dev/sdb1")
+	long := strings.Repeat("대화 내용이 길어지는 중입니다. ", 300)
+	for i := 0; i < 12; i++ {
+		manager.AddMessage("assistant", long)
+		manager.AddMessage("user", "continue")
+	}
+
+	if err := manager.Compact(context.Background()); err != nil {
+		t.Fatalf("Compact() failed: %v", err)
+	}
+
+	messages := manager.GetMessages()
+	if messages[0].Content != "system prompt" {
+		t.Errorf("Expected system prompt to be kept, got %q", messages[0].Content)
+	}
+	if !isSummary(messages[1]) {
+		t.Fatalf("Expected summary after the system prompt, got %+v", messages[1])
+	}
+	facts := parsePinnedFacts(messages[1].Content)
+	if len(facts) == 0 || !strings.Contains(facts[0], "/dev/sdb1") {
+		t.Errorf("Expected the device error to be pinned, got %v", facts)
+	}
+	if len(messages) != 2+keepRecentMessages {
+		t.Errorf("Expected %d recent messages to be kept, got %d messages", keepRecentMessages, len(messages))
+	}
+
+	// Pinned facts survive a second summarization
+	for i := 0; i < 12; i++ {
+		manager.AddMessage("assistant", long)
+		manager.AddMessage("user", "continue")
+	}
+	if err := manager.Compact(context.Background()); err != nil {
+		t.Fatalf("second Compact() failed: %v", err)
+	}
+	facts = parsePinnedFacts(manager.GetMessages()[1].Content)
+	if len(facts) == 0 || !strings.Contains(facts[0], "/dev/sdb1") {
+		t.Errorf("Expected the device error to stay pinned, got %v", facts)
+	}
+}
+
+func TestCompact_SummaryErrorKeepsTruncation(t *testing.T) {
+	mockProvider := llm.NewMockProvider()
+	mockProvider.SetChatError(errors.New("provider down"))
+	manager := NewManager(mockProvider)
+	manager.SetContextWindow(20000)
+
+	long := strings.Repeat("긴 대화 ", 2000)
+	for i := 0; i < 10; i++ {
+		manager.AddMessage("user", long)
+	}
+
+	if err := manager.Compact(context.Background()); err == nil {
+		t.Error("Expected summarization error")
+	}
+	if len(manager.GetMessages()) != 10 {
+		t.Errorf("Expected messages to be kept when summarization fails, got %d", len(manager.GetMessages()))
+	}
+}
+
+func TestTruncateText_SingleHugeLine(t *testing.T) {
+	text := strings.Repeat("가", 10000) + "\nlast line"
+	result := truncateText(text, 1000)
+
+	if !strings.HasSuffix(result, "last line") {
+		t.Error("Expected the tail line to be kept")
+	}
+	if !strings.HasPrefix(result, "가가가") || !strings.Contains(result, "줄 생략") {
+		t.Errorf("Expected the beginning of the huge line and a marker, got %q", result[:60])
+	}
+	if len(result) > 3000+200 {
+		t.Errorf("Expected result to be cut to the budget, got %d bytes", len(result))
+	}
+}
+
+func TestExtractFacts(t *testing.T) {
+	text := `NAME   STATUS
+pvc/data-db-0   Pending
+Events:
+  Warning  ProvisioningFailed  storageclass "fast" not found
+  attached to /dev/nvme1n1 on node/worker-2`
+
+	facts := extractFacts(text, 10)
+	want := []string{"pvc/data-db-0", `Warning  ProvisioningFailed  storageclass "fast" not found`, "/dev/nvme1n1", "node/worker-2"}
+	if len(facts) != len(want) {
+		t.Fatalf("Expected facts %v, got %v", want, facts)
+	}
+	for i := range want {
+		if facts[i] != want[i] {
+			t.Errorf("Expected fact %q, got %q", want[i], facts[i])
+		}
+	}
+}
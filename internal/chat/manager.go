@@ -7,26 +7,37 @@ import (
 	"strings"
 
 	"github.com/mainbong/storage_doctor/internal/llm"
+	"github.com/mainbong/storage_doctor/internal/logger"
 )
 
 // Manager manages chat conversations and context
 type Manager struct {
-	provider           llm.Provider
-	messages           []llm.Message
-	maxMessages        int
-	summarizeThreshold int
+	provider      llm.Provider
+	messages      []llm.Message
+	contextWindow int // Tokens the model accepts; see Compact
+	usage         llm.Usage
+	onUsage       UsageObserver
 }
 
+// summaryPrefix marks the system message that replaces summarized history
+const summaryPrefix = "Previous conversation summary: "
+
 // NewManager creates a new chat manager
 func NewManager(provider llm.Provider) *Manager {
 	return &Manager{
-		provider:           provider,
-		messages:           make([]llm.Message, 0),
-		maxMessages:        50,
-		summarizeThreshold: 30,
+		provider:      provider,
+		messages:      make([]llm.Message, 0),
+		contextWindow: llm.ContextWindow(provider.GetModel()),
 	}
 }
 
+// SetProvider switches the provider used for the rest of the conversation.
+// The messages are kept and the context window follows the new model.
+func (m *Manager) SetProvider(provider llm.Provider) {
+	m.provider = provider
+	m.contextWindow = llm.ContextWindow(provider.GetModel())
+}
+
 // AddMessage adds a message to the conversation
 func (m *Manager) AddMessage(role, content string) {
 	m.messages = append(m.messages, llm.Message{
@@ -35,11 +46,36 @@ func (m *Manager) AddMessage(role, content string) {
 	})
 }
 
+// AddAssistantToolCalls adds an assistant message carrying the tool calls it
+// issued and the thinking blocks that preceded them
+func (m *Manager) AddAssistantToolCalls(content string, toolCalls []llm.ToolCall, thinking []llm.ThinkingBlock) {
+	m.messages = append(m.messages, llm.Message{
+		Role:      "assistant",
+		Content:   content,
+		ToolCalls: toolCalls,
+		Thinking:  thinking,
+	})
+}
+
+// AddToolResults adds the results of previously issued tool calls
+func (m *Manager) AddToolResults(results []llm.ToolResult) {
+	m.messages = append(m.messages, llm.Message{
+		Role:        "tool",
+		ToolResults: results,
+	})
+}
+
 // GetMessages returns all messages
 func (m *Manager) GetMessages() []llm.Message {
 	return m.messages
 }
 
+// Restore replaces the conversation with a saved transcript and its token usage
+func (m *Manager) Restore(messages []llm.Message, usage llm.Usage) {
+	m.messages = append(make([]llm.Message, 0, len(messages)), messages...)
+	m.usage = usage
+}
+
 // StreamChat streams a chat response
 func (m *Manager) StreamChat(ctx context.Context, userInput string, onChunk func(string)) error {
 	return m.StreamChatWithTools(ctx, userInput, nil, onChunk, nil)
@@ -50,19 +86,18 @@ func (m *Manager) StreamChatWithTools(ctx context.Context, userInput string, too
 	// Add user message
 	m.AddMessage("user", userInput)
 
-	// Check if we need to summarize
-	if len(m.messages) > m.summarizeThreshold {
-		if err := m.summarizeContext(ctx); err != nil {
-			// If summarization fails, continue with current messages
-			fmt.Printf("Warning: failed to summarize context: %v\n", err)
-		}
+	// Keep the conversation within the context window
+	if err := m.Compact(ctx); err != nil {
+		// If summarization fails, continue with the truncated messages
+		logger.Warn("failed to compact context: %v", err)
 	}
 
 	// Stream response with tools
 	var assistantResponse strings.Builder
 	var toolCalls []llm.ToolCall
 
-	err := m.provider.StreamChatWithTools(ctx, m.messages, tools, func(chunk string) {
+	callCtx, meter := MeterUsage(ctx)
+	err := m.provider.StreamChatWithTools(callCtx, m.messages, tools, func(chunk string) {
 		assistantResponse.WriteString(chunk)
 		onChunk(chunk)
 	}, func(toolCall llm.ToolCall) {
@@ -75,6 +110,7 @@ func (m *Manager) StreamChatWithTools(ctx context.Context, userInput string, too
 	if err != nil {
 		return fmt.Errorf("failed to stream chat: %w", err)
 	}
+	m.RecordCall(meter, m.messages, llm.Message{Role: "assistant", Content: assistantResponse.String(), ToolCalls: toolCalls})
 
 	// Add assistant response
 	responseText := assistantResponse.String()
@@ -107,21 +143,34 @@ func (m *Manager) Chat(ctx context.Context, userInput string) (string, error) {
 	return response.String(), err
 }
 
-// summarizeContext summarizes old messages to save tokens
+// summarizeContext summarizes old messages to save tokens. Error lines and
+// resource names from the summarized messages are pinned verbatim.
 func (m *Manager) summarizeContext(ctx context.Context) error {
-	if len(m.messages) < 10 {
-		return nil // Not enough messages to summarize
+	if len(m.messages) == 0 {
+		return nil
 	}
 
-	// Get old messages to summarize (keep last 5)
-	oldMessages := m.messages[:len(m.messages)-5]
-	recentMessages := m.messages[len(m.messages)-5:]
+	// Keep the system prompt out of the summary
+	start := 0
+	if m.messages[0].Role == "system" && !isSummary(m.messages[0]) {
+		start = 1
+	}
+
+	// Get old messages to summarize (keep the recent ones)
+	split := m.recentStart(start)
+	if split <= start {
+		return nil // Not enough messages to summarize
+	}
+	oldMessages := m.messages[start:split]
+	recentMessages := m.messages[split:]
 
 	// Create summary prompt
 	summaryPrompt := "다음 대화 내용을 간결하게 요약해주세요. 중요한 정보(에러 메시지, 명령어, 파일 경로 등)는 반드시 포함해주세요:\n\n"
+	var history strings.Builder
 	for _, msg := range oldMessages {
-		summaryPrompt += fmt.Sprintf("%s: %s\n", msg.Role, msg.Content)
+		history.WriteString(formatMessageForSummary(msg))
 	}
+	summaryPrompt += truncateText(history.String(), m.tokenBudget())
 
 	// Request summary
 	summaryMessages := []llm.Message{
@@ -129,30 +178,52 @@ func (m *Manager) summarizeContext(ctx context.Context) error {
 		{Role: "user", Content: summaryPrompt},
 	}
 
-	summary, err := m.provider.Chat(ctx, summaryMessages)
+	callCtx, meter := MeterUsage(ctx)
+	summary, err := m.provider.Chat(callCtx, summaryMessages)
 	if err != nil {
 		return fmt.Errorf("failed to generate summary: %w", err)
 	}
+	m.RecordCall(meter, summaryMessages, llm.Message{Role: "assistant", Content: summary})
 
 	// Replace old messages with summary
-	m.messages = append([]llm.Message{
-		{Role: "system", Content: "Previous conversation summary: " + summary},
-	}, recentMessages...)
+	compacted := append([]llm.Message{}, m.messages[:start]...)
+	compacted = append(compacted, llm.Message{Role: "system", Content: formatSummary(summary, pinnedFacts(oldMessages))})
+	m.messages = append(compacted, recentMessages...)
 
 	return nil
 }
 
+// formatMessageForSummary renders a message, including tool calls and results, as plain text
+func formatMessageForSummary(msg llm.Message) string {
+	var builder strings.Builder
+	if msg.Content != "" {
+		builder.WriteString(fmt.Sprintf("%s: %s\n", msg.Role, msg.Content))
+	}
+	for _, tc := range msg.ToolCalls {
+		builder.WriteString(fmt.Sprintf("%s: [도구 호출] %s %s\n", msg.Role, tc.Name, formatToolInput(tc.Input)))
+	}
+	for _, result := range msg.ToolResults {
+		builder.WriteString(fmt.Sprintf("%s: %s\n", msg.Role, FormatToolCall(result.Name, result.Content, !result.IsError)))
+	}
+	return builder.String()
+}
+
 // Clear clears all messages
 func (m *Manager) Clear() {
 	m.messages = make([]llm.Message, 0)
 }
 
-// SetSystemPrompt sets the system prompt
+// isSummary reports whether a message holds summarized conversation history
+func isSummary(msg llm.Message) bool {
+	return msg.Role == "system" && strings.HasPrefix(msg.Content, summaryPrefix)
+}
+
+// SetSystemPrompt sets the system prompt. Conversation summaries are kept.
 func (m *Manager) SetSystemPrompt(prompt string) {
 	// Remove existing system prompt if any
 	filtered := make([]llm.Message, 0, len(m.messages))
 	for _, msg := range m.messages {
-		if msg.Role != "system" {
+		if msg.Role != "system" || isSummary(msg) {
 			filtered = append(filtered, msg)
 		}
 	}
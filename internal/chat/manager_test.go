@@ -20,12 +20,8 @@ func TestNewManager(t *testing.T) {
 		t.Error("Expected provider to be set, but it's different")
 	}
 
-	if manager.maxMessages != 50 {
-		t.Errorf("Expected maxMessages 50, got %d", manager.maxMessages)
-	}
-
-	if manager.summarizeThreshold != 30 {
-		t.Errorf("Expected summarizeThreshold 30, got %d", manager.summarizeThreshold)
+	if manager.contextWindow != llm.ContextWindow(mockProvider.GetModel()) {
+		t.Errorf("Expected contextWindow %d, got %d", llm.ContextWindow(mockProvider.GetModel()), manager.contextWindow)
 	}
 }
 
@@ -205,3 +201,123 @@ func TestStreamChat_ProviderError(t *testing.T) {
 	}
 }
 
+
+func TestAddToolResults(t *testing.T) {
+	mockProvider := llm.NewMockProvider()
+	manager := NewManager(mockProvider)
+
+	manager.AddAssistantToolCalls("checking", []llm.ToolCall{{ID: "call_1", Name: "read_file"}}, nil)
+	manager.AddToolResults([]llm.ToolResult{{ToolCallID: "call_1", Name: "read_file", Content: "data"}})
+
+	messages := manager.GetMessages()
+	if len(messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(messages))
+	}
+	if len(messages[0].ToolCalls) != 1 || messages[0].ToolCalls[0].ID != "call_1" {
+		t.Errorf("Expected assistant tool call 'call_1', got %+v", messages[0].ToolCalls)
+	}
+	if messages[1].Role != "tool" || messages[1].ToolResults[0].ToolCallID != "call_1" {
+		t.Errorf("Expected tool result for 'call_1', got %+v", messages[1])
+	}
+}
+
+func TestSetSystemPrompt_KeepsSummary(t *testing.T) {
+	mockProvider := llm.NewMockProvider()
+	manager := NewManager(mockProvider)
+
+	manager.SetSystemPrompt("Old prompt")
+	manager.AddMessage("system", summaryPrefix+"disk /dev/sdb is full")
+	manager.AddMessage("user", "test")
+	manager.SetSystemPrompt("New prompt")
+
+	messages := manager.GetMessages()
+	if len(messages) != 3 {
+		t.Fatalf("Expected 3 messages, got %d", len(messages))
+	}
+	if messages[0].Content != "New prompt" {
+		t.Errorf("Expected new system prompt first, got '%s'", messages[0].Content)
+	}
+	if !isSummary(messages[1]) {
+		t.Errorf("Expected summary to be kept, got %+v", messages[1])
+	}
+}
+
+func TestSummarizeContext_KeepsSystemPrompt(t *testing.T) {
+	mockProvider := llm.NewMockProvider()
+	mockProvider.SetChatResponse("요약 내용")
+	manager := NewManager(mockProvider)
+
+	manager.SetSystemPrompt("system prompt")
+	for i := 0; i < 12; i++ {
+		manager.AddMessage("user", "question")
+	}
+
+	if err := manager.summarizeContext(context.Background()); err != nil {
+		t.Fatalf("summarizeContext() failed: %v", err)
+	}
+
+	messages := manager.GetMessages()
+	if len(messages) != 7 {
+		t.Fatalf("Expected system prompt, summary and 5 recent messages, got %d", len(messages))
+	}
+	if messages[0].Content != "system prompt" {
+		t.Errorf("Expected system prompt to be kept, got '%s'", messages[0].Content)
+	}
+	if messages[1].Content != summaryPrefix+"요약 내용" {
+		t.Errorf("Expected summary message, got '%s'", messages[1].Content)
+	}
+	if usage := manager.Usage(); usage.InputTokens == 0 || usage.OutputTokens == 0 {
+		t.Errorf("Expected summary call to be counted, got %+v", usage)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	mockProvider := llm.NewMockProvider()
+	manager := NewManager(mockProvider)
+	manager.AddMessage("user", "discarded")
+
+	saved := []llm.Message{
+		{Role: "system", Content: summaryPrefix + "earlier work"},
+		{Role: "user", Content: "check disks"},
+		{Role: "assistant", Content: "disk is full"},
+	}
+	manager.Restore(saved, llm.Usage{InputTokens: 100, OutputTokens: 20})
+	saved[1].Content = "modified"
+
+	messages := manager.GetMessages()
+	if len(messages) != 3 || messages[1].Content != "check disks" {
+		t.Fatalf("Expected restored copy of the transcript, got %+v", messages)
+	}
+
+	manager.RecordUsage("mock-model", llm.Usage{InputTokens: 10, OutputTokens: 5})
+	if usage := manager.Usage(); usage.InputTokens != 110 || usage.OutputTokens != 25 {
+		t.Errorf("Expected accumulated usage {110 25}, got %+v", usage)
+	}
+}
+
+func TestSetProvider_KeepsConversation(t *testing.T) {
+	first := llm.NewMockProvider()
+	manager := NewManager(first)
+	manager.AddMessage("user", "check disks")
+	manager.AddMessage("assistant", "disk is full")
+
+	second := llm.NewMockProvider()
+	second.SetModel("claude-sonnet-4-5")
+	var received []llm.Message
+	second.SetOnStreamChat(func(ctx context.Context, messages []llm.Message, tools []llm.Tool, onChunk func(string), onToolCall func(llm.ToolCall)) error {
+		received = messages
+		onChunk("ok")
+		return nil
+	})
+	manager.SetProvider(second)
+
+	if err := manager.StreamChat(context.Background(), "and now?", func(string) {}); err != nil {
+		t.Fatalf("StreamChat() failed: %v", err)
+	}
+	if len(received) != 3 || received[0].Content != "check disks" {
+		t.Errorf("Expected the new provider to get the earlier conversation, got %+v", received)
+	}
+	if manager.contextWindow != llm.ContextWindow("claude-sonnet-4-5") {
+		t.Errorf("Expected the context window of the new model, got %d", manager.contextWindow)
+	}
+}
@@ -22,7 +22,7 @@ func ParseResponse(response string) ([]ParsedAction, string) {
 
 	// Pattern 1: JSON function call blocks
 	// Format: <function_call>{"name": "execute_command", "input": {...}}</function_call>
-	jsonBlockPattern := regexp.MustCompile(`<function_call>(.*?)</function_call>`)
+	jsonBlockPattern := regexp.MustCompile(`(?s)<function_call>(.*?)</function_call>`)
 	matches := jsonBlockPattern.FindAllStringSubmatch(response, -1)
 
 	for _, match := range matches {
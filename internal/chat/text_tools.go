@@ -0,0 +1,202 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/mainbong/storage_doctor/internal/llm"
+)
+
+const (
+	functionCallOpen  = "<function_call>"
+	functionCallClose = "</function_call>"
+)
+
+// TextToolProvider adds tool calling to models without native support. Tools
+// are described in the system prompt, the model calls them with
+// <function_call> blocks parsed by ParseResponse, and earlier tool calls and
+// results are replayed to the model as plain text.
+type TextToolProvider struct {
+	llm.Provider
+
+	mu     sync.Mutex
+	nextID int
+}
+
+// WithTextTools wraps provider in a TextToolProvider when it lacks native tool calling
+func WithTextTools(provider llm.Provider) llm.Provider {
+	if llm.SupportsNativeTools(provider) {
+		return provider
+	}
+	return &TextToolProvider{Provider: provider}
+}
+
+// SupportsNativeTools reports true because the wrapper handles tools itself
+func (p *TextToolProvider) SupportsNativeTools() bool {
+	return true
+}
+
+// StreamChat streams a chat completion with tool history converted to text
+func (p *TextToolProvider) StreamChat(ctx context.Context, messages []llm.Message, onChunk func(string)) error {
+	return p.Provider.StreamChat(ctx, textToolMessages(messages, nil), onChunk)
+}
+
+// Chat sends a chat completion request with tool history converted to text
+func (p *TextToolProvider) Chat(ctx context.Context, messages []llm.Message) (string, error) {
+	var response strings.Builder
+	err := p.StreamChat(ctx, messages, func(chunk string) {
+		response.WriteString(chunk)
+	})
+	return response.String(), err
+}
+
+// StreamChatWithTools streams the response without its <function_call> blocks
+// and reports each block as a tool call once the response is complete
+func (p *TextToolProvider) StreamChatWithTools(ctx context.Context, messages []llm.Message, tools []llm.Tool, onChunk func(string), onToolCall func(llm.ToolCall)) error {
+	var response strings.Builder
+	filter := &functionCallFilter{emit: onChunk}
+	err := p.Provider.StreamChat(ctx, textToolMessages(messages, tools), func(chunk string) {
+		response.WriteString(chunk)
+		filter.write(chunk)
+	})
+	if err != nil {
+		return err
+	}
+	filter.flush()
+
+	if onToolCall == nil || len(tools) == 0 {
+		return nil
+	}
+	actions, _ := ParseResponse(response.String())
+	for _, action := range actions {
+		if action.Type != "function_call" || action.ToolName == "" {
+			continue
+		}
+		input := action.Parameters
+		if input == nil {
+			input = make(map[string]interface{})
+		}
+		onToolCall(llm.ToolCall{ID: p.newCallID(), Name: action.ToolName, Input: input})
+	}
+	return nil
+}
+
+func (p *TextToolProvider) newCallID() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.nextID++
+	return fmt.Sprintf("text_call_%d", p.nextID)
+}
+
+// textToolMessages rewrites tool calls and results as text and, when tools are
+// given, appends their descriptions to the system prompt
+func textToolMessages(messages []llm.Message, tools []llm.Tool) []llm.Message {
+	converted := make([]llm.Message, 0, len(messages)+1)
+	for _, msg := range messages {
+		switch {
+		case len(msg.ToolResults) > 0:
+			var results []string
+			for _, result := range msg.ToolResults {
+				results = append(results, FormatToolCall(result.Name, result.Content, !result.IsError))
+			}
+			converted = append(converted, llm.Message{Role: "user", Content: strings.Join(results, "\n")})
+		case len(msg.ToolCalls) > 0:
+			var content strings.Builder
+			content.WriteString(msg.Content)
+			for _, call := range msg.ToolCalls {
+				content.WriteString(fmt.Sprintf("\n%s{\"name\":%q,\"input\":%s}%s", functionCallOpen, call.Name, formatToolInput(call.Input), functionCallClose))
+			}
+			converted = append(converted, llm.Message{Role: "assistant", Content: strings.TrimSpace(content.String())})
+		default:
+			converted = append(converted, llm.Message{Role: msg.Role, Content: msg.Content})
+		}
+	}
+
+	if len(tools) == 0 {
+		return converted
+	}
+	instructions := textToolInstructions(tools)
+	if len(converted) > 0 && converted[0].Role == "system" {
+		converted[0].Content += "\n\n" + instructions
+		return converted
+	}
+	return append([]llm.Message{{Role: "system", Content: instructions}}, converted...)
+}
+
+// textToolInstructions tells the model how to call tools without native support
+func textToolInstructions(tools []llm.Tool) string {
+	var builder strings.Builder
+	builder.WriteString("도구 호출 방법:\n")
+	builder.WriteString("도구가 필요하면 응답에 아래 형식의 블록을 한 줄로 작성하세요. 여러 블록을 작성할 수 있습니다.\n")
+	builder.WriteString(functionCallOpen + `{"name": "도구 이름", "input": {"매개변수": "값"}}` + functionCallClose + "\n")
+	builder.WriteString("도구 결과는 다음 메시지에 <tool_result> 형식으로 전달됩니다. 결과를 받기 전에는 결과를 추측하지 마세요.\n\n")
+	builder.WriteString("도구 목록:\n")
+	for _, tool := range tools {
+		schema, err := json.Marshal(tool.InputSchema)
+		if err != nil {
+			schema = []byte("{}")
+		}
+		builder.WriteString(fmt.Sprintf("- %s: %s\n  입력 스키마: %s\n", tool.Name, tool.Description, schema))
+	}
+	return builder.String()
+}
+
+// functionCallFilter forwards streamed text while holding back <function_call> blocks
+type functionCallFilter struct {
+	emit    func(string)
+	pending string
+	inCall  bool
+}
+
+func (f *functionCallFilter) write(chunk string) {
+	f.pending += chunk
+	for {
+		if f.inCall {
+			end := strings.Index(f.pending, functionCallClose)
+			if end < 0 {
+				return
+			}
+			f.pending = f.pending[end+len(functionCallClose):]
+			f.inCall = false
+			continue
+		}
+		start := strings.Index(f.pending, functionCallOpen)
+		if start >= 0 {
+			f.send(f.pending[:start])
+			f.pending = f.pending[start+len(functionCallOpen):]
+			f.inCall = true
+			continue
+		}
+		// Hold back a trailing partial tag until the next chunk
+		keep := partialPrefix(f.pending, functionCallOpen)
+		f.send(f.pending[:len(f.pending)-keep])
+		f.pending = f.pending[len(f.pending)-keep:]
+		return
+	}
+}
+
+func (f *functionCallFilter) flush() {
+	if !f.inCall {
+		f.send(f.pending)
+	}
+	f.pending = ""
+}
+
+func (f *functionCallFilter) send(text string) {
+	if text != "" && f.emit != nil {
+		f.emit(text)
+	}
+}
+
+// partialPrefix returns the length of the longest suffix of s that is a prefix of tag
+func partialPrefix(s, tag string) int {
+	for n := len(tag) - 1; n > 0; n-- {
+		if strings.HasSuffix(s, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
@@ -0,0 +1,96 @@
+package chat
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mainbong/storage_doctor/internal/llm"
+)
+
+// textOnlyProvider is a model without native tool calling
+type textOnlyProvider struct {
+	*llm.MockProvider
+}
+
+func (p textOnlyProvider) SupportsNativeTools() bool { return false }
+
+func TestWithTextTools_NativeProviderUnchanged(t *testing.T) {
+	mockProvider := llm.NewMockProvider()
+	if WithTextTools(mockProvider) != llm.Provider(mockProvider) {
+		t.Error("Expected providers with native tools to be returned as is")
+	}
+}
+
+func TestTextToolProvider_ParsesFunctionCalls(t *testing.T) {
+	mockProvider := llm.NewMockProvider()
+	var sent []llm.Message
+	mockProvider.SetOnStreamChat(func(ctx context.Context, messages []llm.Message, tools []llm.Tool, onChunk func(string), onToolCall func(llm.ToolCall)) error {
+		sent = messages
+		if len(tools) != 0 {
+			t.Errorf("Expected no native tool definitions, got %d", len(tools))
+		}
+		for _, chunk := range []string{"디스크를 확인합니다.\n<func", "tion_call>{\"name\": \"execute_command\",\n \"input\": {\"command\": \"df -h\"}}</function", "_call>\n완료 후 알려드리겠습니다."} {
+			onChunk(chunk)
+		}
+		return nil
+	})
+	provider := WithTextTools(textOnlyProvider{mockProvider})
+
+	var streamed strings.Builder
+	var calls []llm.ToolCall
+	err := provider.StreamChatWithTools(context.Background(), []llm.Message{
+		{Role: "system", Content: "system prompt"},
+		{Role: "user", Content: "check disks"},
+	}, []llm.Tool{{Name: "execute_command", Description: "쉘 명령어 실행", InputSchema: map[string]interface{}{"type": "object"}}},
+		func(chunk string) { streamed.WriteString(chunk) },
+		func(call llm.ToolCall) { calls = append(calls, call) })
+	if err != nil {
+		t.Fatalf("StreamChatWithTools() failed: %v", err)
+	}
+
+	if streamed.String() != "디스크를 확인합니다.\n\n완료 후 알려드리겠습니다." {
+		t.Errorf("Expected function call block to be hidden, got %q", streamed.String())
+	}
+	if len(calls) != 1 || calls[0].Name != "execute_command" || calls[0].Input["command"] != "df -h" || calls[0].ID == "" {
+		t.Fatalf("Expected one execute_command call with an ID, got %+v", calls)
+	}
+	if len(sent) != 2 || !strings.Contains(sent[0].Content, "system prompt\n\n도구 호출 방법") || !strings.Contains(sent[0].Content, "- execute_command: 쉘 명령어 실행") {
+		t.Errorf("Expected tool instructions in the system prompt, got %+v", sent)
+	}
+}
+
+func TestTextToolMessages_ReplaysToolHistory(t *testing.T) {
+	messages := textToolMessages([]llm.Message{
+		{Role: "user", Content: "check disks"},
+		{Role: "assistant", Content: "checking", ToolCalls: []llm.ToolCall{{ID: "text_call_1", Name: "execute_command", Input: map[string]interface{}{"command": "df -h"}}}},
+		{Role: "tool", ToolResults: []llm.ToolResult{{ToolCallID: "text_call_1", Name: "execute_command", Content: "/dev/sda1 100%"}}},
+	}, []llm.Tool{{Name: "execute_command"}})
+
+	if len(messages) != 4 || messages[0].Role != "system" {
+		t.Fatalf("Expected a system message with tool instructions to be added, got %+v", messages)
+	}
+	if messages[2].Content != `checking`+"\n"+`<function_call>{"name":"execute_command","input":{"command":"df -h"}}</function_call>` {
+		t.Errorf("Expected tool call as text, got %q", messages[2].Content)
+	}
+	if messages[3].Role != "user" || !strings.Contains(messages[3].Content, `<tool_result name="execute_command" status="성공">/dev/sda1 100%</tool_result>`) {
+		t.Errorf("Expected tool result as a user message, got %+v", messages[3])
+	}
+	for _, msg := range messages {
+		if len(msg.ToolCalls) > 0 || len(msg.ToolResults) > 0 {
+			t.Errorf("Expected no structured tool data, got %+v", msg)
+		}
+	}
+}
+
+func TestFunctionCallFilter_UnclosedBlockHidden(t *testing.T) {
+	var out strings.Builder
+	filter := &functionCallFilter{emit: func(s string) { out.WriteString(s) }}
+	filter.write("answer <")
+	filter.write("b>bold</b> <function_call>{\"name\":")
+	filter.flush()
+
+	if out.String() != "answer <b>bold</b> " {
+		t.Errorf("Expected text before the unclosed block, got %q", out.String())
+	}
+}
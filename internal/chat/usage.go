@@ -0,0 +1,75 @@
+package chat
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mainbong/storage_doctor/internal/llm"
+)
+
+// UsageObserver is told about the usage of every provider call a Manager records
+type UsageObserver func(model string, usage llm.Usage)
+
+// SetUsageObserver registers a function called for every recorded provider call
+func (m *Manager) SetUsageObserver(observer UsageObserver) {
+	m.onUsage = observer
+}
+
+// RecordUsage adds the tokens of one provider call served by model to the conversation total
+func (m *Manager) RecordUsage(model string, usage llm.Usage) {
+	m.usage = m.usage.Add(usage)
+	if m.onUsage != nil {
+		m.onUsage(model, usage)
+	}
+}
+
+// Usage returns the tokens used by the conversation so far
+func (m *Manager) Usage() llm.Usage {
+	return m.usage
+}
+
+// EstimateUsage estimates the tokens of a provider call from its request and response
+func EstimateUsage(request []llm.Message, response llm.Message) llm.Usage {
+	return llm.Usage{
+		InputTokens:  llm.EstimateTokens(request),
+		OutputTokens: llm.EstimateTokens([]llm.Message{response}),
+	}
+}
+
+// UsageMeter collects the usage providers report during one call. A call that
+// was retried or failed over may report several times.
+type UsageMeter struct {
+	mu      sync.Mutex
+	reports []usageReport
+}
+
+type usageReport struct {
+	model string
+	usage llm.Usage
+}
+
+// MeterUsage returns a context whose provider calls report their usage to the returned meter
+func MeterUsage(ctx context.Context) (context.Context, *UsageMeter) {
+	meter := &UsageMeter{}
+	return llm.WithUsageReporter(ctx, func(model string, usage llm.Usage) {
+		meter.mu.Lock()
+		defer meter.mu.Unlock()
+		meter.reports = append(meter.reports, usageReport{model: model, usage: usage})
+	}), meter
+}
+
+// RecordCall records the usage collected by meter. When the provider reported
+// nothing, the usage is estimated from the request and response.
+func (m *Manager) RecordCall(meter *UsageMeter, request []llm.Message, response llm.Message) {
+	meter.mu.Lock()
+	reports := append([]usageReport(nil), meter.reports...)
+	meter.mu.Unlock()
+
+	if len(reports) == 0 {
+		m.RecordUsage(m.provider.GetModel(), EstimateUsage(request, response))
+		return
+	}
+	for _, report := range reports {
+		m.RecordUsage(report.model, report.usage)
+	}
+}
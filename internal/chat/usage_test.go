@@ -0,0 +1,53 @@
+package chat
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mainbong/storage_doctor/internal/llm"
+)
+
+func TestRecordCall_ReportedUsage(t *testing.T) {
+	mockProvider := llm.NewMockProvider()
+	mockProvider.SetStreamChunks([]string{"Hello"})
+	mockProvider.SetUsage(llm.Usage{InputTokens: 100, OutputTokens: 7, CacheReadInputTokens: 50})
+	manager := NewManager(mockProvider)
+
+	var observed []llm.Usage
+	manager.SetUsageObserver(func(model string, usage llm.Usage) {
+		if model != "mock-model" {
+			t.Errorf("Expected mock-model, got %s", model)
+		}
+		observed = append(observed, usage)
+	})
+
+	if err := manager.StreamChatWithTools(context.Background(), "hi", nil, func(string) {}, nil); err != nil {
+		t.Fatalf("StreamChatWithTools() failed: %v", err)
+	}
+
+	want := llm.Usage{InputTokens: 100, OutputTokens: 7, CacheReadInputTokens: 50}
+	if len(observed) != 1 || observed[0] != want {
+		t.Errorf("Expected observer to see %+v once, got %+v", want, observed)
+	}
+	if manager.Usage() != want {
+		t.Errorf("Expected conversation usage %+v, got %+v", want, manager.Usage())
+	}
+}
+
+func TestRecordCall_EstimatesWithoutReport(t *testing.T) {
+	mockProvider := llm.NewMockProvider()
+	manager := NewManager(mockProvider)
+
+	var observed []llm.Usage
+	manager.SetUsageObserver(func(model string, usage llm.Usage) {
+		observed = append(observed, usage)
+	})
+
+	_, meter := MeterUsage(context.Background())
+	request := []llm.Message{{Role: "user", Content: "check the disks please"}}
+	manager.RecordCall(meter, request, llm.Message{Role: "assistant", Content: "the disk is full"})
+
+	if len(observed) != 1 || observed[0].InputTokens == 0 || observed[0].OutputTokens == 0 {
+		t.Errorf("Expected one estimated usage, got %+v", observed)
+	}
+}
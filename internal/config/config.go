@@ -14,35 +14,94 @@ import (
 
 // Config holds the application configuration
 type Config struct {
-	LLMProvider string `json:"llm_provider"` // "anthropic" or "openai"
+	LLMProvider string `json:"llm_provider"` // "anthropic", "openai", "ollama" or "openai_compatible"
 	Anthropic   struct {
-		APIKey string `json:"api_key"`
-		Model  string `json:"model"` // e.g., "claude-3-5-sonnet-20241022"
+		APIKey  string `json:"api_key"`
+		Model   string `json:"model"`              // e.g., "claude-3-5-sonnet-20241022"
+		BaseURL string `json:"base_url,omitempty"` // e.g., an internal gateway; empty for the public API
 	} `json:"anthropic"`
 	OpenAI struct {
-		APIKey string `json:"api_key"`
-		Model  string `json:"model"` // e.g., "gpt-4-turbo-preview"
+		APIKey  string `json:"api_key"`
+		Model   string `json:"model"`              // e.g., "gpt-4-turbo-preview"
+		BaseURL string `json:"base_url,omitempty"` // e.g., an internal gateway; empty for the public API
 	} `json:"openai"`
+	// Ollama serves local models through its OpenAI-compatible API
+	Ollama struct {
+		BaseURL  string `json:"base_url"` // e.g., "http://localhost:11434/v1"
+		Model    string `json:"model"`    // e.g., "llama3.1"
+		ToolMode string `json:"tool_mode,omitempty"`
+	} `json:"ollama"`
+	// OpenAICompatible is any server speaking the OpenAI chat completions API (vLLM, llama.cpp server, LM Studio)
+	OpenAICompatible struct {
+		BaseURL  string `json:"base_url"` // e.g., "http://vllm.internal:8000/v1"
+		APIKey   string `json:"api_key,omitempty"`
+		Model    string `json:"model"`
+		ToolMode string `json:"tool_mode,omitempty"`
+	} `json:"openai_compatible"`
+	// Retry controls how transient LLM API failures (429, 529, 5xx, dropped connections) are retried
+	Retry struct {
+		MaxAttempts     int `json:"max_attempts"`      // Attempts per provider including the first; 1 disables retries
+		MaxDelaySeconds int `json:"max_delay_seconds"` // Upper bound of the exponential backoff
+	} `json:"retry"`
+	// Fallback is a secondary provider used when the primary keeps failing
+	Fallback struct {
+		Provider string `json:"provider,omitempty"` // Any llm_provider value; empty disables failover
+		Model    string `json:"model,omitempty"`    // Overrides that provider's model
+	} `json:"fallback"`
 	Search struct {
-		Provider string `json:"provider"` // "google", "bing", "duckduckgo", "serper"
-		Google   struct {
+		Provider string `json:"provider"` // "google", "bing", "brave", "duckduckgo", "serper", "local", "multi"
+		// Providers are the web providers "multi" queries, in priority order.
+		// Empty uses every provider with an API key plus duckduckgo.
+		Providers []string `json:"providers,omitempty"`
+		Google    struct {
 			APIKey string `json:"api_key"`
 			CX     string `json:"cx"` // Custom Search Engine ID
 		} `json:"google"`
 		Bing struct {
 			APIKey string `json:"api_key"`
 		} `json:"bing"`
+		Brave struct {
+			APIKey string `json:"api_key"`
+		} `json:"brave"`
 		Serper struct {
 			APIKey string `json:"api_key"`
 		} `json:"serper"`
+		// Local indexes runbooks, vendor docs, session reports and man pages on disk.
+		// With a web provider, local results are merged ahead of the web results.
+		Local struct {
+			Paths []string `json:"paths,omitempty"` // Files or directories to index
+		} `json:"local"`
 	} `json:"search"`
 	AutoApproveCommands bool   `json:"auto_approve_commands"` // Auto-approve all commands
 	SessionDir          string `json:"session_dir"`
 	BackupDir           string `json:"backup_dir"`
 	LogDir              string `json:"log_dir"`
 	LogLevel            string `json:"log_level"` // "debug", "info", "warn", "error"
+	// ContextWindow overrides the model's context window in tokens (0 = built-in table)
+	ContextWindow int `json:"context_window,omitempty"`
+	// SessionBudget makes the agent ask before a session exceeds these limits (0 = unlimited)
+	SessionBudget struct {
+		MaxTokens  int     `json:"max_tokens,omitempty"`
+		MaxCostUSD float64 `json:"max_cost_usd,omitempty"`
+	} `json:"session_budget"`
+	// Thinking lets models reason before answering, at the cost of extra output tokens
+	Thinking struct {
+		BudgetTokens    int    `json:"budget_tokens,omitempty"`    // Anthropic extended thinking budget (at least 1024); 0 disables
+		ReasoningEffort string `json:"reasoning_effort,omitempty"` // OpenAI-style reasoning models: "low", "medium" or "high"; empty uses the model default
+	} `json:"thinking"`
 }
 
+// MinThinkingBudget is the smallest extended thinking budget Anthropic accepts
+const MinThinkingBudget = 1024
+
+// Tool modes for local providers. Models without native tool calling use
+// ToolModeText, where tools are described in the prompt and called with
+// <function_call> blocks in the response.
+const (
+	ToolModeNative = "native"
+	ToolModeText   = "text"
+)
+
 var (
 	configDir  = filepath.Join(os.Getenv("HOME"), ".storage-doctor")
 	configFile = filepath.Join(configDir, "config.json")
@@ -67,6 +126,10 @@ func LoadWithFS(fs filesystem.FileSystem, dir, file string) (*Config, error) {
 	cfg.LLMProvider = "anthropic"
 	cfg.Anthropic.Model = "claude-haiku-4-5-20251001"
 	cfg.OpenAI.Model = "gpt-5"
+	cfg.Ollama.BaseURL = "http://localhost:11434/v1"
+	cfg.Ollama.Model = "llama3.1"
+	cfg.Retry.MaxAttempts = 4
+	cfg.Retry.MaxDelaySeconds = 30
 	cfg.Search.Provider = "duckduckgo"
 	cfg.AutoApproveCommands = false
 	cfg.SessionDir = filepath.Join(dir, "sessions")
@@ -220,7 +283,7 @@ func (c *Config) ensureDir(fs filesystem.FileSystem, file, key string, value *st
 func (c *Config) Set(key, value string) error {
 	switch strings.ToLower(strings.TrimSpace(key)) {
 	case "llm_provider":
-		if value != "anthropic" && value != "openai" {
+		if !isLLMProvider(value) {
 			return fmt.Errorf("invalid llm_provider: %s", value)
 		}
 		c.LLMProvider = value
@@ -228,23 +291,86 @@ func (c *Config) Set(key, value string) error {
 		c.Anthropic.APIKey = value
 	case "anthropic.model":
 		c.Anthropic.Model = value
+	case "anthropic.base_url":
+		c.Anthropic.BaseURL = value
 	case "openai.api_key":
 		c.OpenAI.APIKey = value
 	case "openai.model":
 		c.OpenAI.Model = value
+	case "openai.base_url":
+		c.OpenAI.BaseURL = value
+	case "ollama.base_url":
+		c.Ollama.BaseURL = value
+	case "ollama.model":
+		c.Ollama.Model = value
+	case "ollama.tool_mode":
+		if value != ToolModeNative && value != ToolModeText {
+			return fmt.Errorf("invalid ollama.tool_mode: %s", value)
+		}
+		c.Ollama.ToolMode = value
+	case "openai_compatible.base_url":
+		c.OpenAICompatible.BaseURL = value
+	case "openai_compatible.api_key":
+		c.OpenAICompatible.APIKey = value
+	case "openai_compatible.model":
+		c.OpenAICompatible.Model = value
+	case "openai_compatible.tool_mode":
+		if value != ToolModeNative && value != ToolModeText {
+			return fmt.Errorf("invalid openai_compatible.tool_mode: %s", value)
+		}
+		c.OpenAICompatible.ToolMode = value
+	case "retry.max_attempts":
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			return fmt.Errorf("invalid retry.max_attempts: %s", value)
+		}
+		c.Retry.MaxAttempts = parsed
+	case "retry.max_delay_seconds":
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			return fmt.Errorf("invalid retry.max_delay_seconds: %s", value)
+		}
+		c.Retry.MaxDelaySeconds = parsed
+	case "fallback.provider":
+		if value != "" && !isLLMProvider(value) {
+			return fmt.Errorf("invalid fallback.provider: %s", value)
+		}
+		c.Fallback.Provider = value
+	case "fallback.model":
+		c.Fallback.Model = value
 	case "search.provider":
-		if value != "google" && value != "bing" && value != "duckduckgo" && value != "serper" {
+		if !isWebSearchProvider(value) && value != "local" && value != "multi" {
 			return fmt.Errorf("invalid search.provider: %s", value)
 		}
 		c.Search.Provider = value
+	case "search.providers":
+		c.Search.Providers = nil
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+			if !isWebSearchProvider(name) {
+				return fmt.Errorf("invalid search.providers entry: %s", name)
+			}
+			c.Search.Providers = append(c.Search.Providers, name)
+		}
 	case "search.google.api_key":
 		c.Search.Google.APIKey = value
 	case "search.google.cx":
 		c.Search.Google.CX = value
 	case "search.bing.api_key":
 		c.Search.Bing.APIKey = value
+	case "search.brave.api_key":
+		c.Search.Brave.APIKey = value
 	case "search.serper.api_key":
 		c.Search.Serper.APIKey = value
+	case "search.local.paths":
+		c.Search.Local.Paths = nil
+		for _, path := range strings.Split(value, ",") {
+			if path = strings.TrimSpace(path); path != "" {
+				c.Search.Local.Paths = append(c.Search.Local.Paths, path)
+			}
+		}
 	case "auto_approve_commands":
 		parsed, err := strconv.ParseBool(value)
 		if err != nil {
@@ -264,9 +390,56 @@ func (c *Config) Set(key, value string) error {
 		default:
 			return fmt.Errorf("invalid log_level: %s", value)
 		}
+	case "context_window":
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			return fmt.Errorf("invalid context_window: %s", value)
+		}
+		c.ContextWindow = parsed
+	case "session_budget.max_tokens":
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			return fmt.Errorf("invalid session_budget.max_tokens: %s", value)
+		}
+		c.SessionBudget.MaxTokens = parsed
+	case "session_budget.max_cost_usd":
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 {
+			return fmt.Errorf("invalid session_budget.max_cost_usd: %s", value)
+		}
+		c.SessionBudget.MaxCostUSD = parsed
+	case "thinking.budget_tokens":
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 || (parsed > 0 && parsed < MinThinkingBudget) {
+			return fmt.Errorf("invalid thinking.budget_tokens: %s (0 or at least %d)", value, MinThinkingBudget)
+		}
+		c.Thinking.BudgetTokens = parsed
+	case "thinking.reasoning_effort":
+		switch value {
+		case "", "low", "medium", "high":
+			c.Thinking.ReasoningEffort = value
+		default:
+			return fmt.Errorf("invalid thinking.reasoning_effort: %s", value)
+		}
 	default:
 		return fmt.Errorf("unknown config key: %s", key)
 	}
 
 	return nil
 }
+
+func isLLMProvider(value string) bool {
+	switch value {
+	case "anthropic", "openai", "ollama", "openai_compatible":
+		return true
+	}
+	return false
+}
+
+func isWebSearchProvider(value string) bool {
+	switch value {
+	case "google", "bing", "brave", "duckduckgo", "serper":
+		return true
+	}
+	return false
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/mainbong/storage_doctor/internal/filesystem"
@@ -29,6 +30,12 @@ func TestLoad_DefaultConfig(t *testing.T) {
 	if cfg.OpenAI.Model != "gpt-5" {
 		t.Errorf("Expected OpenAI model 'gpt-5', got '%s'", cfg.OpenAI.Model)
 	}
+	if cfg.Ollama.BaseURL != "http://localhost:11434/v1" {
+		t.Errorf("Expected Ollama base URL 'http://localhost:11434/v1', got '%s'", cfg.Ollama.BaseURL)
+	}
+	if cfg.Retry.MaxAttempts != 4 || cfg.Retry.MaxDelaySeconds != 30 {
+		t.Errorf("Expected retry defaults 4 attempts / 30s, got %+v", cfg.Retry)
+	}
 	if cfg.Search.Provider != "duckduckgo" {
 		t.Errorf("Expected Search provider 'duckduckgo', got '%s'", cfg.Search.Provider)
 	}
@@ -83,23 +90,31 @@ func TestLoad_ExistingConfig(t *testing.T) {
 	existingConfig := &Config{
 		LLMProvider: "openai",
 		OpenAI: struct {
-			APIKey string `json:"api_key"`
-			Model  string `json:"model"`
+			APIKey  string `json:"api_key"`
+			Model   string `json:"model"`
+			BaseURL string `json:"base_url,omitempty"`
 		}{
 			Model: "gpt-4",
 		},
 		Search: struct {
-			Provider string `json:"provider"`
-			Google   struct {
+			Provider  string   `json:"provider"`
+			Providers []string `json:"providers,omitempty"`
+			Google    struct {
 				APIKey string `json:"api_key"`
 				CX     string `json:"cx"`
 			} `json:"google"`
 			Bing struct {
 				APIKey string `json:"api_key"`
 			} `json:"bing"`
+			Brave struct {
+				APIKey string `json:"api_key"`
+			} `json:"brave"`
 			Serper struct {
 				APIKey string `json:"api_key"`
 			} `json:"serper"`
+			Local struct {
+				Paths []string `json:"paths,omitempty"`
+			} `json:"local"`
 		}{
 			Provider: "google",
 		},
@@ -219,29 +234,38 @@ func TestSave(t *testing.T) {
 	cfg := &Config{
 		LLMProvider: "anthropic",
 		Anthropic: struct {
-			APIKey string `json:"api_key"`
-			Model  string `json:"model"`
+			APIKey  string `json:"api_key"`
+			Model   string `json:"model"`
+			BaseURL string `json:"base_url,omitempty"`
 		}{
 			Model: "claude-haiku-4-5-20251001",
 		},
 		OpenAI: struct {
-			APIKey string `json:"api_key"`
-			Model  string `json:"model"`
+			APIKey  string `json:"api_key"`
+			Model   string `json:"model"`
+			BaseURL string `json:"base_url,omitempty"`
 		}{
 			Model: "gpt-5",
 		},
 		Search: struct {
-			Provider string `json:"provider"`
-			Google   struct {
+			Provider  string   `json:"provider"`
+			Providers []string `json:"providers,omitempty"`
+			Google    struct {
 				APIKey string `json:"api_key"`
 				CX     string `json:"cx"`
 			} `json:"google"`
 			Bing struct {
 				APIKey string `json:"api_key"`
 			} `json:"bing"`
+			Brave struct {
+				APIKey string `json:"api_key"`
+			} `json:"brave"`
 			Serper struct {
 				APIKey string `json:"api_key"`
 			} `json:"serper"`
+			Local struct {
+				Paths []string `json:"paths,omitempty"`
+			} `json:"local"`
 		}{
 			Provider: "duckduckgo",
 		},
@@ -338,6 +362,92 @@ func TestSet_ConfigValues(t *testing.T) {
 	if cfg.Search.Provider != "duckduckgo" {
 		t.Errorf("Expected Search provider 'duckduckgo', got '%s'", cfg.Search.Provider)
 	}
+
+	if err := cfg.Set("search.provider", "multi"); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	if err := cfg.Set("search.providers", "brave, bing,duckduckgo"); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	if err := cfg.Set("search.brave.api_key", "brave-key"); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	if cfg.Search.Provider != "multi" || strings.Join(cfg.Search.Providers, ",") != "brave,bing,duckduckgo" || cfg.Search.Brave.APIKey != "brave-key" {
+		t.Errorf("Expected multi search settings, got %+v", cfg.Search)
+	}
+	if err := cfg.Set("search.providers", "brave,local"); err == nil {
+		t.Error("Expected error for a non-web provider in search.providers")
+	}
+
+	if err := cfg.Set("search.local.paths", "/srv/runbooks, /usr/share/man/man8,"); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	if len(cfg.Search.Local.Paths) != 2 || cfg.Search.Local.Paths[1] != "/usr/share/man/man8" {
+		t.Errorf("Expected two local search paths, got %v", cfg.Search.Local.Paths)
+	}
+
+	if err := cfg.Set("llm_provider", "openai_compatible"); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	if err := cfg.Set("openai_compatible.base_url", "http://vllm.internal:8000/v1"); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	if err := cfg.Set("openai_compatible.tool_mode", ToolModeText); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	if cfg.LLMProvider != "openai_compatible" || cfg.OpenAICompatible.BaseURL != "http://vllm.internal:8000/v1" || cfg.OpenAICompatible.ToolMode != ToolModeText {
+		t.Errorf("Expected openai_compatible settings, got %+v", cfg.OpenAICompatible)
+	}
+
+	if err := cfg.Set("anthropic.base_url", "https://llm-gateway.internal/anthropic"); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	if cfg.Anthropic.BaseURL != "https://llm-gateway.internal/anthropic" {
+		t.Errorf("Expected Anthropic base URL, got '%s'", cfg.Anthropic.BaseURL)
+	}
+
+	if err := cfg.Set("context_window", "65536"); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	if cfg.ContextWindow != 65536 {
+		t.Errorf("Expected ContextWindow 65536, got %d", cfg.ContextWindow)
+	}
+
+	if err := cfg.Set("retry.max_attempts", "6"); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	if err := cfg.Set("fallback.provider", "openai"); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	if err := cfg.Set("fallback.model", "gpt-4o"); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	if cfg.Retry.MaxAttempts != 6 || cfg.Fallback.Provider != "openai" || cfg.Fallback.Model != "gpt-4o" {
+		t.Errorf("Expected retry and fallback settings, got %+v %+v", cfg.Retry, cfg.Fallback)
+	}
+	if err := cfg.Set("fallback.provider", ""); err != nil {
+		t.Fatalf("Set() failed to disable fallback: %v", err)
+	}
+
+	if err := cfg.Set("session_budget.max_cost_usd", "2.5"); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	if err := cfg.Set("session_budget.max_tokens", "500000"); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	if cfg.SessionBudget.MaxCostUSD != 2.5 || cfg.SessionBudget.MaxTokens != 500000 {
+		t.Errorf("Expected session budget $2.5 / 500000 tokens, got %+v", cfg.SessionBudget)
+	}
+
+	if err := cfg.Set("thinking.budget_tokens", "8000"); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	if err := cfg.Set("thinking.reasoning_effort", "high"); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	if cfg.Thinking.BudgetTokens != 8000 || cfg.Thinking.ReasoningEffort != "high" {
+		t.Errorf("Expected thinking budget 8000 and effort high, got %+v", cfg.Thinking)
+	}
 }
 
 func TestSet_InvalidKey(t *testing.T) {
@@ -358,6 +468,27 @@ func TestSet_InvalidValue(t *testing.T) {
 	if err := cfg.Set("log_level", "verbose"); err == nil {
 		t.Error("Expected error for invalid log_level, got nil")
 	}
+	if err := cfg.Set("ollama.tool_mode", "auto"); err == nil {
+		t.Error("Expected error for invalid ollama.tool_mode, got nil")
+	}
+	if err := cfg.Set("context_window", "large"); err == nil {
+		t.Error("Expected error for invalid context_window, got nil")
+	}
+	if err := cfg.Set("retry.max_attempts", "0"); err == nil {
+		t.Error("Expected error for invalid retry.max_attempts, got nil")
+	}
+	if err := cfg.Set("fallback.provider", "gemini"); err == nil {
+		t.Error("Expected error for invalid fallback.provider, got nil")
+	}
+	if err := cfg.Set("session_budget.max_cost_usd", "-1"); err == nil {
+		t.Error("Expected error for negative session_budget.max_cost_usd, got nil")
+	}
+	if err := cfg.Set("thinking.budget_tokens", "500"); err == nil {
+		t.Error("Expected error for thinking.budget_tokens below the minimum, got nil")
+	}
+	if err := cfg.Set("thinking.reasoning_effort", "max"); err == nil {
+		t.Error("Expected error for invalid thinking.reasoning_effort, got nil")
+	}
 }
 
 func TestGetConfigDir(t *testing.T) {
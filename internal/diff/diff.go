@@ -0,0 +1,325 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// OpKind is the kind of a line operation in a diff
+type OpKind int
+
+const (
+	OpEqual OpKind = iota
+	OpDelete
+	OpInsert
+)
+
+// Op is a single line of a line-based diff
+type Op struct {
+	Kind OpKind
+	Line string
+}
+
+// maxEdits bounds the edit distance for which a minimal diff is computed; the
+// trace grows quadratically with it, so beyond this the changed region is
+// reported as a whole replacement.
+const maxEdits = 2000
+
+// Lines computes a line-based diff between old and new using the Myers algorithm
+func Lines(old, new string) []Op {
+	a := splitLines(old)
+	b := splitLines(new)
+
+	// Trim common prefix and suffix so the core algorithm only sees the changed region
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]Op, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		ops = append(ops, Op{Kind: OpEqual, Line: line})
+	}
+	ops = append(ops, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, Op{Kind: OpEqual, Line: line})
+	}
+	return ops
+}
+
+// HasChanges reports whether the diff contains any insertions or deletions
+func HasChanges(ops []Op) bool {
+	for _, op := range ops {
+		if op.Kind != OpEqual {
+			return true
+		}
+	}
+	return false
+}
+
+// Unified renders a unified diff between old and new with the given number of context lines.
+// It returns an empty string when the contents are identical.
+func Unified(oldName, newName, old, new string, context int) string {
+	ops := Lines(old, new)
+	if !HasChanges(ops) {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+	for _, h := range hunks(ops, context) {
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(h.oldStart, h.oldLines), hunkRange(h.newStart, h.newLines))
+		for _, op := range h.ops {
+			switch op.Kind {
+			case OpEqual:
+				sb.WriteString(" ")
+			case OpDelete:
+				sb.WriteString("-")
+			case OpInsert:
+				sb.WriteString("+")
+			}
+			sb.WriteString(op.Line)
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+// Colorize adds terminal colors to a unified diff
+func Colorize(unified string) string {
+	lines := strings.Split(strings.TrimSuffix(unified, "\n"), "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---"):
+			lines[i] = color.New(color.Bold).Sprint(line)
+		case strings.HasPrefix(line, "@@"):
+			lines[i] = color.CyanString(line)
+		case strings.HasPrefix(line, "+"):
+			lines[i] = color.GreenString(line)
+		case strings.HasPrefix(line, "-"):
+			lines[i] = color.RedString(line)
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// Stat returns the number of inserted and deleted lines
+func Stat(ops []Op) (inserted, deleted int) {
+	for _, op := range ops {
+		switch op.Kind {
+		case OpInsert:
+			inserted++
+		case OpDelete:
+			deleted++
+		}
+	}
+	return inserted, deleted
+}
+
+// CountChanges counts inserted and deleted lines in a unified diff
+func CountChanges(unified string) (inserted, deleted int) {
+	for _, line := range strings.Split(unified, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---"):
+		case strings.HasPrefix(line, "+"):
+			inserted++
+		case strings.HasPrefix(line, "-"):
+			deleted++
+		}
+	}
+	return inserted, deleted
+}
+
+type hunk struct {
+	oldStart, oldLines int
+	newStart, newLines int
+	ops                []Op
+}
+
+// hunks groups changes with surrounding context; nearby changes share a hunk
+func hunks(ops []Op, context int) []hunk {
+	if context < 0 {
+		context = 0
+	}
+
+	var result []hunk
+	oldLine, newLine := 1, 1
+	i := 0
+	for i < len(ops) {
+		if ops[i].Kind == OpEqual {
+			oldLine++
+			newLine++
+			i++
+			continue
+		}
+
+		// Start a hunk with up to context lines before the change
+		start := i
+		for start > 0 && i-start < context && ops[start-1].Kind == OpEqual {
+			start--
+		}
+		h := hunk{oldStart: oldLine - (i - start), newStart: newLine - (i - start)}
+
+		end := i
+		for end < len(ops) {
+			if ops[end].Kind != OpEqual {
+				end++
+				continue
+			}
+			// Count the run of equal lines; split the hunk if it is longer than 2*context
+			run := 0
+			for end+run < len(ops) && ops[end+run].Kind == OpEqual {
+				run++
+			}
+			if end+run == len(ops) || run > 2*context {
+				if run > context {
+					run = context
+				}
+				end += run
+				break
+			}
+			end += run
+		}
+
+		h.ops = ops[start:end]
+		for _, op := range h.ops {
+			if op.Kind != OpInsert {
+				h.oldLines++
+			}
+			if op.Kind != OpDelete {
+				h.newLines++
+			}
+		}
+		for _, op := range ops[i:end] {
+			if op.Kind != OpInsert {
+				oldLine++
+			}
+			if op.Kind != OpDelete {
+				newLine++
+			}
+		}
+		result = append(result, h)
+		i = end
+	}
+	return result
+}
+
+func hunkRange(start, lines int) string {
+	if lines == 0 {
+		// An empty range refers to the line before the change
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if lines == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, lines)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// myers computes the shortest edit script between a and b
+func myers(a, b []string) []Op {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return replaceAll(a, b)
+	}
+
+	max := n + m
+	if max > maxEdits {
+		max = maxEdits
+	}
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	var trace [][]int
+
+	for d := 0; d <= max; d++ {
+		// Keep only the diagonals reachable at this depth
+		snapshot := make([]int, 2*d+1)
+		copy(snapshot, v[offset-d:offset+d+1])
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b)
+			}
+		}
+	}
+	return replaceAll(a, b)
+}
+
+func backtrack(trace [][]int, a, b []string) []Op {
+	x, y := len(a), len(b)
+	var reversed []Op
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		// trace[d] holds diagonals -d..d before step d, indexed from 0
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || k != d && v[d+k-1] < v[d+k+1] {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := 0
+		if d > 0 {
+			prevX = v[d+prevK]
+		}
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reversed = append(reversed, Op{Kind: OpEqual, Line: a[x]})
+		}
+		if d > 0 {
+			if x == prevX {
+				y--
+				reversed = append(reversed, Op{Kind: OpInsert, Line: b[y]})
+			} else {
+				x--
+				reversed = append(reversed, Op{Kind: OpDelete, Line: a[x]})
+			}
+		}
+	}
+
+	ops := make([]Op, len(reversed))
+	for i, op := range reversed {
+		ops[len(reversed)-1-i] = op
+	}
+	return ops
+}
+
+func replaceAll(a, b []string) []Op {
+	ops := make([]Op, 0, len(a)+len(b))
+	for _, line := range a {
+		ops = append(ops, Op{Kind: OpDelete, Line: line})
+	}
+	for _, line := range b {
+		ops = append(ops, Op{Kind: OpInsert, Line: line})
+	}
+	return ops
+}
@@ -0,0 +1,125 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// apply rebuilds both sides of a diff to check that it is consistent
+func apply(ops []Op) (string, string) {
+	var old, new []string
+	for _, op := range ops {
+		if op.Kind != OpInsert {
+			old = append(old, op.Line)
+		}
+		if op.Kind != OpDelete {
+			new = append(new, op.Line)
+		}
+	}
+	return strings.Join(old, "\n"), strings.Join(new, "\n")
+}
+
+func TestLines_Reconstructs(t *testing.T) {
+	tests := []struct {
+		old, new string
+	}{
+		{"a\nb\nc", "a\nb\nc"},
+		{"a\nb\nc", "a\nx\nc"},
+		{"", "a\nb"},
+		{"a\nb", ""},
+		{"a\nb\nc\nd\ne", "b\nc\nx\ne\nf"},
+		{"x\ny\nz", "1\n2\n3"},
+	}
+
+	for _, tt := range tests {
+		ops := Lines(tt.old, tt.new)
+		old, new := apply(ops)
+		if old != tt.old || new != tt.new {
+			t.Errorf("Lines(%q, %q) reconstructs to (%q, %q)", tt.old, tt.new, old, new)
+		}
+	}
+}
+
+func TestLines_Minimal(t *testing.T) {
+	ops := Lines("a\nb\nc\nd", "a\nc\nd\ne")
+	inserted, deleted := Stat(ops)
+	if inserted != 1 || deleted != 1 {
+		t.Errorf("Expected 1 insertion and 1 deletion, got %d/%d", inserted, deleted)
+	}
+}
+
+func TestHasChanges(t *testing.T) {
+	if HasChanges(Lines("a\nb", "a\nb\n")) {
+		t.Error("Expected trailing newline difference to be ignored")
+	}
+	if !HasChanges(Lines("a", "b")) {
+		t.Error("Expected changes")
+	}
+}
+
+func TestUnified(t *testing.T) {
+	old := "line1\nline2\nline3\nline4\nline5\nline6\nline7\nline8\nline9\nline10\n"
+	new := "line1\nline2\nline3\nLINE4\nline5\nline6\nline7\nline8\nline9\nline10\nline11\n"
+
+	got := Unified("a/file", "b/file", old, new, 1)
+	expected := "--- a/file\n+++ b/file\n" +
+		"@@ -3,3 +3,3 @@\n line3\n-line4\n+LINE4\n line5\n" +
+		"@@ -10 +10,2 @@\n line10\n+line11\n"
+	if got != expected {
+		t.Errorf("Unexpected unified diff:\n%s\nwant:\n%s", got, expected)
+	}
+}
+
+func TestUnified_MergesNearbyChanges(t *testing.T) {
+	got := Unified("a", "b", "1\n2\n3\n4\n5", "1\nX\n3\nY\n5", 1)
+	if strings.Count(got, "@@ -") != 1 {
+		t.Errorf("Expected a single hunk, got:\n%s", got)
+	}
+	if !strings.Contains(got, "@@ -1,5 +1,5 @@") {
+		t.Errorf("Unexpected hunk header:\n%s", got)
+	}
+}
+
+func TestUnified_NewFile(t *testing.T) {
+	got := Unified("/dev/null", "b", "", "x\ny", 3)
+	if !strings.Contains(got, "@@ -0,0 +1,2 @@") {
+		t.Errorf("Unexpected hunk header for new file:\n%s", got)
+	}
+}
+
+func TestUnified_NoChanges(t *testing.T) {
+	if got := Unified("a", "b", "same", "same", 3); got != "" {
+		t.Errorf("Expected empty diff, got %q", got)
+	}
+}
+
+func TestColorize_KeepsText(t *testing.T) {
+	unified := Unified("a", "b", "x", "y", 3)
+	colored := Colorize(unified)
+	for _, line := range []string{"-x", "+y"} {
+		if !strings.Contains(colored, line) {
+			t.Errorf("Expected colorized diff to contain %q", line)
+		}
+	}
+}
+
+func TestCountChanges(t *testing.T) {
+	inserted, deleted := CountChanges(Unified("a", "b", "x\ny\nz", "x\nY\nz\nw", 3))
+	if inserted != 2 || deleted != 1 {
+		t.Errorf("Expected +2 -1, got +%d -%d", inserted, deleted)
+	}
+}
+
+func TestLines_LargeEditFallsBack(t *testing.T) {
+	var old, new []string
+	for i := 0; i < 3000; i++ {
+		old = append(old, fmt.Sprintf("old %d", i))
+		new = append(new, fmt.Sprintf("new %d", i))
+	}
+	ops := Lines(strings.Join(old, "\n"), strings.Join(new, "\n"))
+	gotOld, gotNew := apply(ops)
+	if gotOld != strings.Join(old, "\n") || gotNew != strings.Join(new, "\n") {
+		t.Error("Fallback diff does not reconstruct inputs")
+	}
+}
@@ -0,0 +1,220 @@
+package files
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// JSON values are edited in place on the original text so that key order,
+// formatting and untouched values are preserved exactly.
+
+type jsonMember struct {
+	key        string
+	keyStart   int
+	valueStart int
+	valueEnd   int
+}
+
+type jsonSpan struct {
+	start, end int
+}
+
+func getJSONValue(content string, path []PathSegment) (interface{}, error) {
+	var root interface{}
+	if err := json.Unmarshal([]byte(content), &root); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	return lookupValue(root, path)
+}
+
+func setJSONValue(content string, path []PathSegment, value interface{}) (string, error) {
+	if !json.Valid([]byte(content)) {
+		return "", fmt.Errorf("failed to parse JSON: invalid document")
+	}
+	unit := detectIndentUnit(content)
+
+	cur := skipJSONSpace(content, 0)
+	for i, seg := range path {
+		if seg.IsIndex {
+			if content[cur] != '[' {
+				return "", fmt.Errorf("%s is not a list", FormatConfigPath(path[:i]))
+			}
+			elements, _ := jsonElements(content, cur)
+			if seg.Index >= len(elements) {
+				return "", fmt.Errorf("index %d out of range at %s (length %d)", seg.Index, FormatConfigPath(path[:i]), len(elements))
+			}
+			cur = elements[seg.Index].start
+			continue
+		}
+
+		if content[cur] != '{' {
+			return "", fmt.Errorf("%s is not an object", FormatConfigPath(path[:i]))
+		}
+		members, closeIdx := jsonMembers(content, cur)
+		found := false
+		for _, member := range members {
+			if member.key == seg.Key {
+				cur = member.valueStart
+				found = true
+				break
+			}
+		}
+		if !found {
+			nested, err := nestValue(path[i+1:], value)
+			if err != nil {
+				return "", err
+			}
+			return insertJSONMember(content, cur, closeIdx, members, seg.Key, nested, unit)
+		}
+	}
+
+	end := jsonValueEnd(content, cur)
+	encoded, err := encodeJSON(value, lineIndent(content, cur), unit)
+	if err != nil {
+		return "", err
+	}
+	return content[:cur] + encoded + content[end:], nil
+}
+
+// insertJSONMember adds "key": value to the object starting at objStart
+func insertJSONMember(content string, objStart, closeIdx int, members []jsonMember, key string, value interface{}, unit string) (string, error) {
+	keyJSON, err := encodeJSON(key, "", unit)
+	if err != nil {
+		return "", err
+	}
+
+	if len(members) == 0 {
+		indent := lineIndent(content, objStart)
+		encoded, err := encodeJSON(value, indent+unit, unit)
+		if err != nil {
+			return "", err
+		}
+		object := "{\n" + indent + unit + keyJSON + ": " + encoded + "\n" + indent + "}"
+		return content[:objStart] + object + content[closeIdx+1:], nil
+	}
+
+	last := members[len(members)-1]
+	if !strings.Contains(content[objStart:last.keyStart], "\n") {
+		// Single-line object: keep it on one line
+		encoded, err := encodeJSON(value, "", "")
+		if err != nil {
+			return "", err
+		}
+		return content[:last.valueEnd] + ", " + keyJSON + ": " + encoded + content[last.valueEnd:], nil
+	}
+
+	indent := lineIndent(content, last.keyStart)
+	encoded, err := encodeJSON(value, indent, unit)
+	if err != nil {
+		return "", err
+	}
+	return content[:last.valueEnd] + ",\n" + indent + keyJSON + ": " + encoded + content[last.valueEnd:], nil
+}
+
+// encodeJSON encodes a value; lines after the first are prefixed with indent
+func encodeJSON(value interface{}, indent, unit string) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if unit != "" {
+		enc.SetIndent(indent, unit)
+	}
+	if err := enc.Encode(value); err != nil {
+		return "", fmt.Errorf("failed to encode JSON value: %w", err)
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// jsonMembers returns the members of the object starting at i and the index of its closing brace
+func jsonMembers(s string, i int) ([]jsonMember, int) {
+	var members []jsonMember
+	i = skipJSONSpace(s, i+1)
+	for i < len(s) && s[i] != '}' {
+		keyStart := i
+		keyEnd := jsonValueEnd(s, i)
+		var key string
+		_ = json.Unmarshal([]byte(s[keyStart:keyEnd]), &key)
+		i = skipJSONSpace(s, keyEnd)
+		i = skipJSONSpace(s, i+1) // ':'
+		valueEnd := jsonValueEnd(s, i)
+		members = append(members, jsonMember{key: key, keyStart: keyStart, valueStart: i, valueEnd: valueEnd})
+		i = skipJSONSpace(s, valueEnd)
+		if i < len(s) && s[i] == ',' {
+			i = skipJSONSpace(s, i+1)
+		}
+	}
+	return members, i
+}
+
+// jsonElements returns the spans of the array elements starting at i and the index of its closing bracket
+func jsonElements(s string, i int) ([]jsonSpan, int) {
+	var elements []jsonSpan
+	i = skipJSONSpace(s, i+1)
+	for i < len(s) && s[i] != ']' {
+		end := jsonValueEnd(s, i)
+		elements = append(elements, jsonSpan{start: i, end: end})
+		i = skipJSONSpace(s, end)
+		if i < len(s) && s[i] == ',' {
+			i = skipJSONSpace(s, i+1)
+		}
+	}
+	return elements, i
+}
+
+// jsonValueEnd returns the index just past the (valid) JSON value starting at i
+func jsonValueEnd(s string, i int) int {
+	switch s[i] {
+	case '"':
+		for j := i + 1; j < len(s); j++ {
+			switch s[j] {
+			case '\\':
+				j++
+			case '"':
+				return j + 1
+			}
+		}
+		return len(s)
+	case '{':
+		_, closeIdx := jsonMembers(s, i)
+		return closeIdx + 1
+	case '[':
+		_, closeIdx := jsonElements(s, i)
+		return closeIdx + 1
+	default:
+		j := i
+		for j < len(s) && !strings.ContainsRune(",}] \t\r\n", rune(s[j])) {
+			j++
+		}
+		return j
+	}
+}
+
+func skipJSONSpace(s string, i int) int {
+	for i < len(s) && strings.ContainsRune(" \t\r\n", rune(s[i])) {
+		i++
+	}
+	return i
+}
+
+// lineIndent returns the leading whitespace of the line containing offset
+func lineIndent(s string, offset int) string {
+	start := strings.LastIndex(s[:offset], "\n") + 1
+	end := start
+	for end < len(s) && (s[end] == ' ' || s[end] == '\t') {
+		end++
+	}
+	return s[start:end]
+}
+
+// detectIndentUnit guesses the indentation step used in a document
+func detectIndentUnit(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed != "" && len(trimmed) < len(line) {
+			return line[:len(line)-len(trimmed)]
+		}
+	}
+	return "  "
+}
@@ -0,0 +1,68 @@
+package files
+
+import (
+	"strings"
+	"testing"
+)
+
+const testDaemonJSON = `{
+    "data-root": "/var/lib/docker",
+    "storage-driver": "overlay2",
+    "log-opts": {"max-size": "10m"},
+    "dns": ["8.8.8.8"]
+}
+`
+
+func TestSetJSONValue_KeepsFormatting(t *testing.T) {
+	updated, err := setJSONValue(testDaemonJSON, mustPath(t, `["storage-driver"]`), "btrfs")
+	if err != nil {
+		t.Fatalf("setJSONValue() failed: %v", err)
+	}
+	if updated != strings.Replace(testDaemonJSON, `"overlay2"`, `"btrfs"`, 1) {
+		t.Errorf("Unexpected result:\n%s", updated)
+	}
+
+	updated, err = setJSONValue(testDaemonJSON, mustPath(t, "dns[0]"), "1.1.1.1")
+	if err != nil {
+		t.Fatalf("setJSONValue() failed: %v", err)
+	}
+	if !strings.Contains(updated, `"dns": ["1.1.1.1"]`) {
+		t.Errorf("Unexpected result:\n%s", updated)
+	}
+}
+
+func TestSetJSONValue_AddsKeys(t *testing.T) {
+	updated, err := setJSONValue(testDaemonJSON, mustPath(t, `["log-opts"]["max-file"]`), "3")
+	if err != nil {
+		t.Fatalf("setJSONValue() failed: %v", err)
+	}
+	if !strings.Contains(updated, `"log-opts": {"max-size": "10m", "max-file": "3"}`) {
+		t.Errorf("Unexpected result:\n%s", updated)
+	}
+
+	updated, err = setJSONValue(testDaemonJSON, mustPath(t, "features.buildkit"), true)
+	if err != nil {
+		t.Fatalf("setJSONValue() failed: %v", err)
+	}
+	expected := "    \"dns\": [\"8.8.8.8\"],\n    \"features\": {\n        \"buildkit\": true\n    }\n}\n"
+	if !strings.HasSuffix(updated, expected) {
+		t.Errorf("Unexpected result:\n%s", updated)
+	}
+
+	value, err := getJSONValue(updated, mustPath(t, "features.buildkit"))
+	if err != nil || value != true {
+		t.Errorf("getJSONValue() = %v, %v", value, err)
+	}
+}
+
+func TestSetJSONValue_Errors(t *testing.T) {
+	if _, err := setJSONValue(`{"a": 1`, mustPath(t, "a"), 2); err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+	if _, err := setJSONValue(testDaemonJSON, mustPath(t, "dns[3]"), "x"); err == nil {
+		t.Error("Expected error for out of range index")
+	}
+	if _, err := setJSONValue(testDaemonJSON, mustPath(t, "dns.primary"), "x"); err == nil {
+		t.Error("Expected error for key on a list")
+	}
+}
@@ -0,0 +1,498 @@
+package files
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// TOML values are edited in place: the document is scanned for table headers
+// and key/value expressions and only the bytes of the target value change, so
+// comments, ordering and the rest of the formatting stay untouched.
+
+type tomlTable struct {
+	path    []PathSegment
+	lastEnd int // End of the header or of the last expression line in the table
+	indent  string
+}
+
+type tomlEntry struct {
+	path       []PathSegment
+	table      *tomlTable
+	valueStart int
+	valueEnd   int
+	lineEnd    int
+	indent     string
+}
+
+type tomlDocument struct {
+	content string
+	root    *tomlTable
+	tables  []*tomlTable
+	entries []*tomlEntry
+}
+
+func getTOMLValue(content string, path []PathSegment) (interface{}, error) {
+	var root interface{}
+	if err := toml.Unmarshal([]byte(content), &root); err != nil {
+		return nil, fmt.Errorf("failed to parse TOML: %w", err)
+	}
+	return lookupValue(root, path)
+}
+
+func setTOMLValue(content string, path []PathSegment, value interface{}) (string, error) {
+	if err := toml.Unmarshal([]byte(content), new(interface{})); err != nil {
+		return "", fmt.Errorf("failed to parse TOML: %w", err)
+	}
+	doc, err := scanTOML(content)
+	if err != nil {
+		return "", err
+	}
+
+	updated, err := doc.set(path, value)
+	if err != nil {
+		return "", err
+	}
+
+	// Make sure the edit produced a valid document holding the new value
+	var result interface{}
+	if err := toml.Unmarshal([]byte(updated), &result); err != nil {
+		return "", fmt.Errorf("edit would produce invalid TOML: %w", err)
+	}
+	got, err := lookupValue(result, path)
+	if err != nil || !sameValue(got, value) {
+		return "", fmt.Errorf("could not safely set %s in this TOML layout", FormatConfigPath(path))
+	}
+	return updated, nil
+}
+
+func (d *tomlDocument) set(path []PathSegment, value interface{}) (string, error) {
+	for _, entry := range d.entries {
+		if pathEqual(entry.path, path) {
+			encoded, err := encodeTOMLValue(value, d.content[entry.valueStart:entry.valueEnd])
+			if err != nil {
+				return "", err
+			}
+			return d.content[:entry.valueStart] + encoded + d.content[entry.valueEnd:], nil
+		}
+		if pathHasPrefix(path, entry.path) {
+			return "", fmt.Errorf("%s is an inline value; set it as a whole", FormatConfigPath(entry.path))
+		}
+	}
+
+	parent := path[:len(path)-1]
+	last := path[len(path)-1]
+	if last.IsIndex {
+		return "", fmt.Errorf("index %d out of range at %s", last.Index, FormatConfigPath(parent))
+	}
+	encoded, err := encodeTOMLValue(value, "")
+	if err != nil {
+		return "", err
+	}
+
+	// 1. The parent table has a header: add the key at the end of it
+	for _, table := range append([]*tomlTable{d.root}, d.tables...) {
+		if pathEqual(table.path, parent) {
+			return d.insertLine(table.lastEnd, entryIndent(d, table), formatTOMLKey([]PathSegment{last})+" = "+encoded), nil
+		}
+	}
+
+	// 2. The parent table was defined with dotted keys: add a sibling dotted key
+	var sibling *tomlEntry
+	for _, entry := range d.entries {
+		if pathHasPrefix(entry.path, parent) && len(entry.path) > len(parent) {
+			sibling = entry
+		}
+	}
+	if sibling != nil {
+		relative := path[len(sibling.table.path):]
+		return d.insertLine(sibling.lineEnd, sibling.indent, formatTOMLKey(relative)+" = "+encoded), nil
+	}
+
+	// 3. Otherwise create the table at the end of the document
+	for _, seg := range parent {
+		if seg.IsIndex {
+			return "", fmt.Errorf("%s does not exist", FormatConfigPath(parent))
+		}
+	}
+	content := d.content
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return content + "\n[" + formatTOMLKey(parent) + "]\n" + formatTOMLKey([]PathSegment{last}) + " = " + encoded + "\n", nil
+}
+
+// insertLine inserts a line at offset (the end of a line or of a header)
+func (d *tomlDocument) insertLine(offset int, indent, line string) string {
+	content := d.content
+	prefix := content[:offset]
+	if prefix != "" && !strings.HasSuffix(prefix, "\n") {
+		prefix += "\n"
+	}
+	return prefix + indent + line + "\n" + content[offset:]
+}
+
+func entryIndent(d *tomlDocument, table *tomlTable) string {
+	indent := table.indent
+	for _, entry := range d.entries {
+		if entry.table == table {
+			indent = entry.indent
+		}
+	}
+	return indent
+}
+
+// scanTOML records the position of every table header and key/value expression
+func scanTOML(content string) (*tomlDocument, error) {
+	doc := &tomlDocument{content: content, root: &tomlTable{}}
+	current := doc.root
+	arrayCounts := make(map[string]int)
+
+	i := 0
+	for i < len(content) {
+		lineStart := i
+		for i < len(content) && (content[i] == ' ' || content[i] == '\t') {
+			i++
+		}
+		indent := content[lineStart:i]
+		if i >= len(content) {
+			break
+		}
+
+		switch content[i] {
+		case '\n', '\r', '#':
+			i = skipTOMLLine(content, i)
+			continue
+		case '[':
+			isArray := strings.HasPrefix(content[i:], "[[")
+			start := i + 1
+			if isArray {
+				start++
+			}
+			key, next, err := parseTOMLKey(content, start)
+			if err != nil {
+				return nil, err
+			}
+			path := resolveTOMLTablePath(key, arrayCounts)
+			if isArray {
+				name := FormatConfigPath(path)
+				path = append(path, PathSegment{Index: arrayCounts[name], IsIndex: true})
+				arrayCounts[name]++
+			}
+			i = skipTOMLLine(content, next)
+			current = &tomlTable{path: path, lastEnd: i, indent: indent}
+			doc.tables = append(doc.tables, current)
+		default:
+			key, next, err := parseTOMLKey(content, i)
+			if err != nil {
+				return nil, err
+			}
+			next = skipTOMLSpace(content, next)
+			if next >= len(content) || content[next] != '=' {
+				return nil, fmt.Errorf("invalid TOML near offset %d", i)
+			}
+			valueStart := skipTOMLSpace(content, next+1)
+			valueEnd, err := tomlValueEnd(content, valueStart)
+			if err != nil {
+				return nil, err
+			}
+			i = skipTOMLLine(content, valueEnd)
+			path := append(append([]PathSegment{}, current.path...), key...)
+			doc.entries = append(doc.entries, &tomlEntry{
+				path: path, table: current, valueStart: valueStart, valueEnd: valueEnd, lineEnd: i, indent: indent,
+			})
+			current.lastEnd = i
+		}
+	}
+	return doc, nil
+}
+
+// resolveTOMLTablePath adds the element index wherever a header continues an
+// array of tables, e.g. [servers.tls] after [[servers]] is servers[N].tls
+func resolveTOMLTablePath(key []PathSegment, arrayCounts map[string]int) []PathSegment {
+	var path []PathSegment
+	for i, seg := range key {
+		path = append(path, seg)
+		if i == len(key)-1 {
+			break
+		}
+		if count := arrayCounts[FormatConfigPath(path)]; count > 0 {
+			path = append(path, PathSegment{Index: count - 1, IsIndex: true})
+		}
+	}
+	return path
+}
+
+// parseTOMLKey parses a (dotted) key starting at i and returns the index after it
+func parseTOMLKey(s string, i int) ([]PathSegment, int, error) {
+	var key []PathSegment
+	for {
+		i = skipTOMLSpace(s, i)
+		if i >= len(s) {
+			return nil, i, fmt.Errorf("invalid TOML key at end of document")
+		}
+		switch s[i] {
+		case '"', '\'':
+			part, next, err := readQuotedKey(s, i)
+			if err != nil {
+				return nil, i, err
+			}
+			key = append(key, PathSegment{Key: part})
+			i = next
+		default:
+			start := i
+			for i < len(s) && isBareKeyChar(s[i]) {
+				i++
+			}
+			if start == i {
+				return nil, i, fmt.Errorf("invalid TOML key near offset %d", start)
+			}
+			key = append(key, PathSegment{Key: s[start:i]})
+		}
+		i = skipTOMLSpace(s, i)
+		if i < len(s) && s[i] == '.' {
+			i++
+			continue
+		}
+		return key, i, nil
+	}
+}
+
+// tomlValueEnd returns the index just past the value starting at i
+func tomlValueEnd(s string, i int) (int, error) {
+	if i >= len(s) {
+		return i, fmt.Errorf("missing TOML value at end of document")
+	}
+	switch {
+	case strings.HasPrefix(s[i:], `"""`) || strings.HasPrefix(s[i:], `'''`):
+		delim := s[i : i+3]
+		j := i + 3
+		for j < len(s) {
+			if s[j] == '\\' && delim == `"""` {
+				j += 2
+				continue
+			}
+			if strings.HasPrefix(s[j:], delim) {
+				j += 3
+				// Up to two extra quotes may belong to the content
+				for k := 0; k < 2 && j < len(s) && s[j] == delim[0]; k++ {
+					j++
+				}
+				return j, nil
+			}
+			j++
+		}
+		return len(s), fmt.Errorf("unterminated multi-line string")
+	case s[i] == '"' || s[i] == '\'':
+		for j := i + 1; j < len(s); j++ {
+			if s[j] == '\\' && s[i] == '"' {
+				j++
+				continue
+			}
+			if s[j] == s[i] {
+				return j + 1, nil
+			}
+		}
+		return len(s), fmt.Errorf("unterminated string")
+	case s[i] == '[' || s[i] == '{':
+		closing := byte(']')
+		if s[i] == '{' {
+			closing = '}'
+		}
+		j := i + 1
+		for {
+			for j < len(s) && strings.ContainsRune(" \t\r\n,", rune(s[j])) {
+				j++
+			}
+			if j >= len(s) {
+				return len(s), fmt.Errorf("unterminated array or inline table")
+			}
+			if s[j] == '#' {
+				j = skipTOMLComment(s, j)
+				continue
+			}
+			if s[j] == closing {
+				return j + 1, nil
+			}
+			if closing == '}' {
+				// Inline table member: key = value
+				_, next, err := parseTOMLKey(s, j)
+				if err != nil {
+					return 0, err
+				}
+				next = skipTOMLSpace(s, next)
+				if next >= len(s) || s[next] != '=' {
+					return 0, fmt.Errorf("invalid inline table near offset %d", j)
+				}
+				j = skipTOMLSpace(s, next+1)
+			}
+			end, err := tomlValueEnd(s, j)
+			if err != nil {
+				return 0, err
+			}
+			j = end
+		}
+	default:
+		j := i
+		for j < len(s) && !strings.ContainsRune(" \t\r\n#,]}", rune(s[j])) {
+			j++
+		}
+		// Local date-times may contain a space between date and time
+		if j+1 < len(s) && s[j] == ' ' && j-i == 10 && s[j+1] >= '0' && s[j+1] <= '9' {
+			j++
+			for j < len(s) && !strings.ContainsRune(" \t\r\n#,]}", rune(s[j])) {
+				j++
+			}
+		}
+		return j, nil
+	}
+}
+
+func skipTOMLSpace(s string, i int) int {
+	for i < len(s) && (s[i] == ' ' || s[i] == '\t') {
+		i++
+	}
+	return i
+}
+
+func skipTOMLComment(s string, i int) int {
+	for i < len(s) && s[i] != '\n' {
+		i++
+	}
+	return i
+}
+
+// skipTOMLLine moves past the rest of the line, including a trailing comment and the newline
+func skipTOMLLine(s string, i int) int {
+	for i < len(s) && s[i] != '\n' {
+		i++
+	}
+	if i < len(s) {
+		i++
+	}
+	return i
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+func formatTOMLKey(path []PathSegment) string {
+	parts := make([]string, len(path))
+	for i, seg := range path {
+		bare := seg.Key != ""
+		for j := 0; j < len(seg.Key); j++ {
+			if !isBareKeyChar(seg.Key[j]) {
+				bare = false
+				break
+			}
+		}
+		if bare {
+			parts[i] = seg.Key
+		} else {
+			parts[i] = quoteTOMLString(seg.Key)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// encodeTOMLValue encodes a value; old is the raw value being replaced, used to keep the string quote style
+func encodeTOMLValue(value interface{}, old string) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", fmt.Errorf("TOML has no null value")
+	case string:
+		if strings.HasPrefix(old, "'") && !strings.HasPrefix(old, "'''") && !strings.ContainsAny(v, "'\r\n") {
+			return "'" + v + "'", nil
+		}
+		return quoteTOMLString(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		switch {
+		case math.IsInf(v, 1):
+			return "inf", nil
+		case math.IsInf(v, -1):
+			return "-inf", nil
+		case math.IsNaN(v):
+			return "nan", nil
+		}
+		s := strconv.FormatFloat(v, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".eEn") {
+			s += ".0"
+		}
+		return s, nil
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			encoded, err := encodeTOMLValue(item, "")
+			if err != nil {
+				return "", err
+			}
+			items[i] = encoded
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		members := make([]string, len(keys))
+		for i, key := range keys {
+			encoded, err := encodeTOMLValue(v[key], "")
+			if err != nil {
+				return "", err
+			}
+			members[i] = formatTOMLKey([]PathSegment{{Key: key}}) + " = " + encoded
+		}
+		if len(members) == 0 {
+			return "{}", nil
+		}
+		return "{ " + strings.Join(members, ", ") + " }", nil
+	default:
+		return "", fmt.Errorf("unsupported TOML value type %T", value)
+	}
+}
+
+// quoteTOMLString returns a TOML basic string; JSON string escapes are valid TOML escapes
+func quoteTOMLString(s string) string {
+	encoded, _ := encodeJSON(s, "", "")
+	return encoded
+}
+
+func pathEqual(a, b []PathSegment) bool {
+	return len(a) == len(b) && pathHasPrefix(a, b)
+}
+
+func pathHasPrefix(path, prefix []PathSegment) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if path[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// sameValue compares decoded values regardless of the numeric types each parser uses
+func sameValue(a, b interface{}) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return reflect.DeepEqual(a, b)
+	}
+	return string(ja) == string(jb)
+}
@@ -0,0 +1,113 @@
+package files
+
+import (
+	"strings"
+	"testing"
+)
+
+const testContainerd = `version = 2
+
+# CRI plugin
+[plugins."io.containerd.grpc.v1.cri"]
+  sandbox_image = "registry.k8s.io/pause:3.9"
+
+  [plugins."io.containerd.grpc.v1.cri".containerd]
+    snapshotter = "overlayfs" # default
+    default_runtime_name = 'runc'
+
+[[proxy_plugins]]
+  name = "a"
+
+[[proxy_plugins]]
+  name = "b"
+  [proxy_plugins.exports]
+    root = "/var/lib/b"
+`
+
+func TestGetTOMLValue(t *testing.T) {
+	value, err := getTOMLValue(testContainerd, mustPath(t, `[plugins."io.containerd.grpc.v1.cri".containerd].snapshotter`))
+	if err != nil {
+		t.Fatalf("getTOMLValue() failed: %v", err)
+	}
+	if value != "overlayfs" {
+		t.Errorf("Expected overlayfs, got %v", value)
+	}
+}
+
+func TestSetTOMLValue_ReplacesInPlace(t *testing.T) {
+	updated, err := setTOMLValue(testContainerd, mustPath(t, `[plugins."io.containerd.grpc.v1.cri".containerd].snapshotter`), "zfs")
+	if err != nil {
+		t.Fatalf("setTOMLValue() failed: %v", err)
+	}
+	if updated != strings.Replace(testContainerd, `"overlayfs"`, `"zfs"`, 1) {
+		t.Errorf("Unexpected result:\n%s", updated)
+	}
+
+	// Literal strings keep their quote style
+	updated, err = setTOMLValue(testContainerd, mustPath(t, `plugins."io.containerd.grpc.v1.cri".containerd.default_runtime_name`), "kata")
+	if err != nil {
+		t.Fatalf("setTOMLValue() failed: %v", err)
+	}
+	if !strings.Contains(updated, "default_runtime_name = 'kata'") {
+		t.Errorf("Unexpected result:\n%s", updated)
+	}
+
+	updated, err = setTOMLValue(testContainerd, mustPath(t, "version"), int64(3))
+	if err != nil {
+		t.Fatalf("setTOMLValue() failed: %v", err)
+	}
+	if !strings.HasPrefix(updated, "version = 3\n") {
+		t.Errorf("Unexpected result:\n%s", updated)
+	}
+}
+
+func TestSetTOMLValue_ArrayTables(t *testing.T) {
+	updated, err := setTOMLValue(testContainerd, mustPath(t, "proxy_plugins[1].exports.root"), "/data/b")
+	if err != nil {
+		t.Fatalf("setTOMLValue() failed: %v", err)
+	}
+	if !strings.Contains(updated, `root = "/data/b"`) {
+		t.Errorf("Unexpected result:\n%s", updated)
+	}
+
+	updated, err = setTOMLValue(testContainerd, mustPath(t, "proxy_plugins[0].type"), "snapshot")
+	if err != nil {
+		t.Fatalf("setTOMLValue() failed: %v", err)
+	}
+	if !strings.Contains(updated, "  name = \"a\"\n  type = \"snapshot\"\n") {
+		t.Errorf("Unexpected result:\n%s", updated)
+	}
+}
+
+func TestSetTOMLValue_AddsKeys(t *testing.T) {
+	updated, err := setTOMLValue(testContainerd, mustPath(t, `[plugins."io.containerd.grpc.v1.cri"].enable_selinux`), true)
+	if err != nil {
+		t.Fatalf("setTOMLValue() failed: %v", err)
+	}
+	if !strings.Contains(updated, "  sandbox_image = \"registry.k8s.io/pause:3.9\"\n  enable_selinux = true\n") {
+		t.Errorf("Unexpected result:\n%s", updated)
+	}
+
+	updated, err = setTOMLValue(testContainerd, mustPath(t, `plugins."io.containerd.grpc.v1.cri".registry.config_path`), "/etc/containerd/certs.d")
+	if err != nil {
+		t.Fatalf("setTOMLValue() failed: %v", err)
+	}
+	if !strings.HasSuffix(updated, "\n[plugins.\"io.containerd.grpc.v1.cri\".registry]\nconfig_path = \"/etc/containerd/certs.d\"\n") {
+		t.Errorf("Unexpected result:\n%s", updated)
+	}
+	if !strings.Contains(updated, "# CRI plugin") || !strings.Contains(updated, "# default") {
+		t.Error("Comments were lost")
+	}
+}
+
+func TestSetTOMLValue_Errors(t *testing.T) {
+	if _, err := setTOMLValue(testContainerd, mustPath(t, "version"), nil); err == nil {
+		t.Error("Expected error for null value")
+	}
+	if _, err := setTOMLValue("a = {b = 1}\n", mustPath(t, "a.b"), int64(2)); err == nil {
+		t.Error("Expected error for key inside an inline table")
+	}
+	if _, err := setTOMLValue("a = [\n", mustPath(t, "a"), int64(2)); err == nil {
+		t.Error("Expected error for invalid TOML")
+	}
+}
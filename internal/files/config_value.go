@@ -0,0 +1,132 @@
+package files
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DocumentAuto selects the only document of a file; multi-document YAML
+// files require an explicit document index.
+const DocumentAuto = -1
+
+// GetConfigValue returns the value at a path expression in a YAML, JSON or TOML file
+func (m *Manager) GetConfigValue(path, expr string, document int) (interface{}, error) {
+	segments, err := ParseConfigPath(expr)
+	if err != nil {
+		return nil, err
+	}
+	content, err := m.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch m.GetFileType(path) {
+	case "yaml":
+		return getYAMLValue(content, segments, document)
+	case "json":
+		return getJSONValue(content, segments)
+	case "toml":
+		return getTOMLValue(content, segments)
+	default:
+		return nil, fmt.Errorf("unsupported config format: %s (yaml, json, toml only)", path)
+	}
+}
+
+// SetConfigValue prepares an edit that sets the value at a path expression.
+// Comments, key order and untouched formatting are preserved; the returned
+// edit can be previewed with Diff and written with ApplyEdit.
+func (m *Manager) SetConfigValue(path, expr string, value interface{}, document int) (*PreparedEdit, error) {
+	segments, err := ParseConfigPath(expr)
+	if err != nil {
+		return nil, err
+	}
+	content, err := m.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	value = normalizeNumber(value)
+	var updated string
+	switch m.GetFileType(path) {
+	case "yaml":
+		updated, err = setYAMLValue(content, segments, value, document)
+	case "json":
+		updated, err = setJSONValue(content, segments, value)
+	case "toml":
+		updated, err = setTOMLValue(content, segments, value)
+	default:
+		return nil, fmt.Errorf("unsupported config format: %s (yaml, json, toml only)", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &PreparedEdit{Path: path, OldContent: content, NewContent: updated, Existed: true}, nil
+}
+
+// lookupValue walks decoded maps and lists along a path
+func lookupValue(root interface{}, path []PathSegment) (interface{}, error) {
+	current := root
+	for i, seg := range path {
+		if seg.IsIndex {
+			list, ok := current.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s is not a list", FormatConfigPath(path[:i]))
+			}
+			if seg.Index >= len(list) {
+				return nil, fmt.Errorf("index %d out of range at %s (length %d)", seg.Index, FormatConfigPath(path[:i]), len(list))
+			}
+			current = list[seg.Index]
+			continue
+		}
+
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s is not a map", FormatConfigPath(path[:i]))
+		}
+		value, ok := object[seg.Key]
+		if !ok {
+			return nil, fmt.Errorf("key not found: %s", FormatConfigPath(path[:i+1]))
+		}
+		current = value
+	}
+	return current, nil
+}
+
+// nestValue wraps a value in maps for the missing keys of a path
+func nestValue(path []PathSegment, value interface{}) (interface{}, error) {
+	for i := len(path) - 1; i >= 0; i-- {
+		if path[i].IsIndex {
+			return nil, fmt.Errorf("cannot create list element %s; only missing map keys are created", path[i])
+		}
+		value = map[string]interface{}{path[i].Key: value}
+	}
+	return value, nil
+}
+
+// normalizeNumber turns integral float64 values from JSON tool input into int64
+func normalizeNumber(value interface{}) interface{} {
+	switch v := value.(type) {
+	case float64:
+		if v == float64(int64(v)) {
+			return int64(v)
+		}
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = normalizeNumber(item)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = normalizeNumber(item)
+		}
+		return out
+	}
+	return value
+}
+
+func hasLineBreak(s string) bool {
+	return strings.ContainsAny(s, "\r\n")
+}
@@ -0,0 +1,66 @@
+package files
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mainbong/storage_doctor/internal/filesystem"
+)
+
+func TestGetConfigValue(t *testing.T) {
+	mockFS := filesystem.NewMockFileSystem()
+	manager := NewManagerWithFS("/test/backups", mockFS)
+	mockFS.AddFile("/etc/app/config.yaml", []byte("spec:\n  replicas: 2\n"), 0644)
+
+	value, err := manager.GetConfigValue("/etc/app/config.yaml", "spec.replicas", DocumentAuto)
+	if err != nil {
+		t.Fatalf("GetConfigValue() failed: %v", err)
+	}
+	if value != 2 {
+		t.Errorf("Expected 2, got %v", value)
+	}
+
+	mockFS.AddFile("/etc/app/config.ini", []byte("a=1\n"), 0644)
+	if _, err := manager.GetConfigValue("/etc/app/config.ini", "a", DocumentAuto); err == nil {
+		t.Error("Expected error for unsupported format")
+	}
+}
+
+func TestSetConfigValue(t *testing.T) {
+	mockFS := filesystem.NewMockFileSystem()
+	manager := NewManagerWithFS("/test/backups", mockFS)
+	mockFS.AddDir("/etc/containerd", 0755)
+	mockFS.AddFile("/etc/containerd/config.toml", []byte("[metrics]\n  address = \"\" # off\n"), 0644)
+
+	// JSON tool input delivers numbers as float64
+	edit, err := manager.SetConfigValue("/etc/containerd/config.toml", "metrics.grpc_histogram_buckets", float64(10), DocumentAuto)
+	if err != nil {
+		t.Fatalf("SetConfigValue() failed: %v", err)
+	}
+	if edit.NewContent != "[metrics]\n  address = \"\" # off\n  grpc_histogram_buckets = 10\n" {
+		t.Errorf("Unexpected content:\n%s", edit.NewContent)
+	}
+	if !strings.Contains(edit.Diff(), "+  grpc_histogram_buckets = 10") {
+		t.Errorf("Unexpected diff:\n%s", edit.Diff())
+	}
+
+	if _, err := manager.ApplyEdit(edit); err != nil {
+		t.Fatalf("ApplyEdit() failed: %v", err)
+	}
+	content := mockFS.GetFile("/etc/containerd/config.toml")
+	if string(content) != edit.NewContent {
+		t.Errorf("File not written: %s", content)
+	}
+}
+
+func TestNormalizeNumber(t *testing.T) {
+	value := normalizeNumber(map[string]interface{}{"a": float64(3), "b": []interface{}{1.5, float64(2)}})
+	m := value.(map[string]interface{})
+	if m["a"] != int64(3) {
+		t.Errorf("Expected int64 3, got %T %v", m["a"], m["a"])
+	}
+	list := m["b"].([]interface{})
+	if list[0] != 1.5 || list[1] != int64(2) {
+		t.Errorf("Unexpected list: %v", list)
+	}
+}
@@ -0,0 +1,460 @@
+package files
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// YAML values are edited through yaml.Node trees, which keep comments and key
+// order. Replacing a single-line scalar is spliced directly into the original
+// text so that the rest of the file stays byte-for-byte identical; structural
+// changes re-encode only the edited document and splice it back between the
+// original separators.
+
+type yamlTarget struct {
+	node    *yaml.Node // Value node at the path, nil when a key is missing
+	parent  *yaml.Node // Collection holding node, or the mapping missing a key
+	missing int        // Index of the first missing path segment
+}
+
+func getYAMLValue(content string, path []PathSegment, document int) (interface{}, error) {
+	docs, err := decodeYAMLDocuments(content)
+	if err != nil {
+		return nil, err
+	}
+	doc, _, err := selectYAMLDocument(docs, document)
+	if err != nil {
+		return nil, err
+	}
+	target, err := walkYAML(doc, path)
+	if err != nil {
+		return nil, err
+	}
+	if target.node == nil {
+		return nil, fmt.Errorf("key not found: %s", FormatConfigPath(path[:target.missing+1]))
+	}
+
+	var value interface{}
+	if err := target.node.Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", FormatConfigPath(path), err)
+	}
+	return value, nil
+}
+
+func setYAMLValue(content string, path []PathSegment, value interface{}, document int) (string, error) {
+	docs, err := decodeYAMLDocuments(content)
+	if err != nil {
+		return "", err
+	}
+	doc, index, err := selectYAMLDocument(docs, document)
+	if err != nil {
+		return "", err
+	}
+	target, err := walkYAML(doc, path)
+	if err != nil {
+		return "", err
+	}
+
+	updated, spliced := "", false
+	if target.node != nil {
+		updated, spliced = spliceYAMLScalar(content, target.node, target.parent, value)
+	}
+	if !spliced {
+		if updated, err = setYAMLDocument(content, len(docs), index, path, value); err != nil {
+			return "", err
+		}
+	}
+
+	// Make sure the edit produced a valid document holding the new value
+	got, err := getYAMLValue(updated, path, index)
+	if err != nil || !sameValue(got, value) {
+		return "", fmt.Errorf("could not safely set %s in this YAML layout", FormatConfigPath(path))
+	}
+	return updated, nil
+}
+
+// setYAMLDocument re-encodes the document at index with the change applied.
+// The other documents keep their original text; when the separators cannot be
+// matched to the documents the whole file is re-encoded.
+func setYAMLDocument(content string, count, index int, path []PathSegment, value interface{}) (string, error) {
+	start, end := 0, len(content)
+	if s, e, ok := yamlDocumentSpan(content, count, index); ok {
+		start, end, index = s, e, 0
+	}
+	docs, err := decodeYAMLDocuments(content[start:end])
+	if err != nil {
+		return "", err
+	}
+	if index >= len(docs) {
+		return "", fmt.Errorf("document %d out of range (file has %d documents)", index, len(docs))
+	}
+	target, err := walkYAML(docs[index], path)
+	if err != nil {
+		return "", err
+	}
+	if err := setYAMLNode(target, path, value); err != nil {
+		return "", err
+	}
+	encoded, err := encodeYAMLDocuments(content[start:end], docs)
+	if err != nil {
+		return "", err
+	}
+	return content[:start] + encoded + content[end:], nil
+}
+
+// yamlDocumentSpan returns the text of the document at index in a file of count
+// documents, from the line after its "---" separator up to the next separator
+func yamlDocumentSpan(content string, count, index int) (int, int, bool) {
+	if count < 2 || index >= count {
+		return 0, 0, false
+	}
+	var starts, ends []int
+	offset := 0
+	for offset < len(content) {
+		lineEnd := len(content)
+		if i := strings.IndexByte(content[offset:], '\n'); i >= 0 {
+			lineEnd = offset + i + 1
+		}
+		line := strings.TrimRight(content[offset:lineEnd], "\r\n")
+		switch {
+		case strings.HasPrefix(line, "..."):
+			return 0, 0, false
+		case line == "---" || strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "---\t"):
+			// Content on the separator line would be left out of the document
+			if rest := strings.TrimSpace(line[3:]); rest != "" && !strings.HasPrefix(rest, "#") {
+				return 0, 0, false
+			}
+			if len(starts) == 0 {
+				// Text before the first separator is a document only if it holds one
+				docs, err := decodeYAMLDocuments(content[:offset])
+				if err != nil {
+					return 0, 0, false
+				}
+				if len(docs) > 0 {
+					starts = append(starts, 0)
+				}
+			}
+			if len(starts) > 0 {
+				ends = append(ends, offset)
+			}
+			starts = append(starts, lineEnd)
+		}
+		offset = lineEnd
+	}
+	ends = append(ends, len(content))
+	if len(starts) != count {
+		return 0, 0, false
+	}
+
+	start, end := starts[index], ends[index]
+	if docs, err := decodeYAMLDocuments(content[start:end]); err != nil || len(docs) != 1 {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// setYAMLNode applies the change to the node tree
+func setYAMLNode(target yamlTarget, path []PathSegment, value interface{}) error {
+	if target.node != nil {
+		replacement, err := yamlValueNode(value)
+		if err != nil {
+			return err
+		}
+		replacement.Anchor = target.node.Anchor
+		replacement.HeadComment = target.node.HeadComment
+		replacement.LineComment = target.node.LineComment
+		replacement.FootComment = target.node.FootComment
+		*target.node = *replacement
+		return nil
+	}
+
+	parent := target.parent
+	if parent.Kind == yaml.ScalarNode {
+		if parent.Tag != "!!null" {
+			return fmt.Errorf("%s is not a map", FormatConfigPath(path[:target.missing]))
+		}
+		// An empty value ("key:") becomes a map
+		parent.Kind, parent.Tag, parent.Value, parent.Style = yaml.MappingNode, "!!map", "", 0
+	}
+	nested, err := nestValue(path[target.missing+1:], value)
+	if err != nil {
+		return err
+	}
+	valueNode, err := yamlValueNode(nested)
+	if err != nil {
+		return err
+	}
+	keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: path[target.missing].Key}
+	parent.Content = append(parent.Content, keyNode, valueNode)
+	return nil
+}
+
+func yamlValueNode(value interface{}) (*yaml.Node, error) {
+	var node yaml.Node
+	if err := node.Encode(value); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML value: %w", err)
+	}
+	return &node, nil
+}
+
+func decodeYAMLDocuments(content string) ([]*yaml.Node, error) {
+	dec := yaml.NewDecoder(strings.NewReader(content))
+	var docs []*yaml.Node
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+		docs = append(docs, &doc)
+	}
+	return docs, nil
+}
+
+func encodeYAMLDocuments(original string, docs []*yaml.Node) (string, error) {
+	var buf bytes.Buffer
+	if strings.HasPrefix(original, "---") {
+		buf.WriteString("---\n")
+	}
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(detectYAMLIndent(original))
+	for _, doc := range docs {
+		if err := enc.Encode(doc); err != nil {
+			return "", fmt.Errorf("failed to encode YAML: %w", err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return "", fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// selectYAMLDocument picks the document to edit and returns its index
+func selectYAMLDocument(docs []*yaml.Node, document int) (*yaml.Node, int, error) {
+	if len(docs) == 0 {
+		return nil, 0, fmt.Errorf("empty YAML document")
+	}
+	if document == DocumentAuto {
+		if len(docs) == 1 {
+			return docs[0], 0, nil
+		}
+		return nil, 0, fmt.Errorf("file has %d YAML documents, specify one: %s", len(docs), describeYAMLDocuments(docs))
+	}
+	if document < 0 || document >= len(docs) {
+		return nil, 0, fmt.Errorf("document %d out of range (file has %d documents)", document, len(docs))
+	}
+	return docs[document], document, nil
+}
+
+// describeYAMLDocuments lists documents by index and Kubernetes kind/name when present
+func describeYAMLDocuments(docs []*yaml.Node) string {
+	parts := make([]string, len(docs))
+	for i, doc := range docs {
+		var meta struct {
+			Kind     string `yaml:"kind"`
+			Metadata struct {
+				Name string `yaml:"name"`
+			} `yaml:"metadata"`
+		}
+		parts[i] = fmt.Sprintf("%d", i)
+		if doc.Decode(&meta) == nil && meta.Kind != "" {
+			parts[i] += " (" + meta.Kind
+			if meta.Metadata.Name != "" {
+				parts[i] += "/" + meta.Metadata.Name
+			}
+			parts[i] += ")"
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func walkYAML(doc *yaml.Node, path []PathSegment) (yamlTarget, error) {
+	current := doc
+	if current.Kind == yaml.DocumentNode {
+		if len(current.Content) == 0 {
+			return yamlTarget{}, fmt.Errorf("empty YAML document")
+		}
+		current = current.Content[0]
+	}
+
+	var parent *yaml.Node
+	for i, seg := range path {
+		for current.Kind == yaml.AliasNode {
+			current = current.Alias
+		}
+		if seg.IsIndex {
+			if current.Kind != yaml.SequenceNode {
+				return yamlTarget{}, fmt.Errorf("%s is not a list", FormatConfigPath(path[:i]))
+			}
+			if seg.Index >= len(current.Content) {
+				return yamlTarget{}, fmt.Errorf("index %d out of range at %s (length %d)", seg.Index, FormatConfigPath(path[:i]), len(current.Content))
+			}
+			parent, current = current, current.Content[seg.Index]
+			continue
+		}
+
+		if current.Kind == yaml.ScalarNode && current.Tag == "!!null" {
+			return yamlTarget{parent: current, missing: i}, nil
+		}
+		if current.Kind != yaml.MappingNode {
+			return yamlTarget{}, fmt.Errorf("%s is not a map", FormatConfigPath(path[:i]))
+		}
+		found := false
+		for j := 0; j+1 < len(current.Content); j += 2 {
+			if current.Content[j].Value == seg.Key {
+				parent, current = current, current.Content[j+1]
+				found = true
+				break
+			}
+		}
+		if !found {
+			return yamlTarget{parent: current, missing: i}, nil
+		}
+	}
+	return yamlTarget{node: current, parent: parent, missing: len(path)}, nil
+}
+
+// spliceYAMLScalar replaces a single-line scalar in the original text
+func spliceYAMLScalar(content string, node, parent *yaml.Node, value interface{}) (string, bool) {
+	if node.Kind != yaml.ScalarNode || node.Anchor != "" ||
+		node.Style&(yaml.LiteralStyle|yaml.FoldedStyle|yaml.TaggedStyle) != 0 {
+		return "", false
+	}
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return "", false
+	}
+
+	start := yamlOffset(content, node.Line, node.Column)
+	if start < 0 {
+		return "", false
+	}
+	flow := parent != nil && parent.Style&yaml.FlowStyle != 0
+	end := yamlScalarEnd(content, start, node.Style, flow)
+	raw := content[start:end]
+	if hasLineBreak(raw) {
+		return "", false
+	}
+	// Only splice when the text found is exactly the parsed scalar
+	var parsed string
+	if node.Style == 0 {
+		parsed = raw
+	} else if err := yaml.Unmarshal([]byte(raw), &parsed); err != nil {
+		return "", false
+	}
+	if parsed != node.Value {
+		return "", false
+	}
+
+	encoded, ok := encodeYAMLScalar(value, node.Style, flow)
+	if !ok {
+		return "", false
+	}
+	return content[:start] + encoded + content[end:], true
+}
+
+func encodeYAMLScalar(value interface{}, style yaml.Style, flow bool) (string, bool) {
+	if s, ok := value.(string); ok {
+		switch {
+		case style&yaml.DoubleQuotedStyle != 0 || flow:
+			encoded, err := encodeJSON(s, "", "")
+			return encoded, err == nil
+		case style&yaml.SingleQuotedStyle != 0 && !hasLineBreak(s):
+			return "'" + strings.ReplaceAll(s, "'", "''") + "'", true
+		}
+	}
+	out, err := yaml.Marshal(value)
+	if err != nil {
+		return "", false
+	}
+	encoded := strings.TrimSuffix(string(out), "\n")
+	return encoded, !hasLineBreak(encoded)
+}
+
+// yamlOffset converts a 1-based line and (rune) column into a byte offset
+func yamlOffset(content string, line, column int) int {
+	offset := 0
+	for l := 1; l < line; l++ {
+		next := strings.IndexByte(content[offset:], '\n')
+		if next < 0 {
+			return -1
+		}
+		offset += next + 1
+	}
+	for c := 1; c < column; c++ {
+		if offset >= len(content) || content[offset] == '\n' {
+			return -1
+		}
+		_, size := utf8.DecodeRuneInString(content[offset:])
+		offset += size
+	}
+	return offset
+}
+
+// yamlScalarEnd returns the index just past the scalar starting at start
+func yamlScalarEnd(content string, start int, style yaml.Style, flow bool) int {
+	switch {
+	case style&yaml.DoubleQuotedStyle != 0:
+		for i := start + 1; i < len(content); i++ {
+			switch content[i] {
+			case '\\':
+				i++
+			case '"':
+				return i + 1
+			}
+		}
+		return len(content)
+	case style&yaml.SingleQuotedStyle != 0:
+		for i := start + 1; i < len(content); i++ {
+			if content[i] == '\'' {
+				if i+1 < len(content) && content[i+1] == '\'' {
+					i++
+					continue
+				}
+				return i + 1
+			}
+		}
+		return len(content)
+	default:
+		end := start
+		for end < len(content) && content[end] != '\n' {
+			c := content[end]
+			if c == '#' && end > start && (content[end-1] == ' ' || content[end-1] == '\t') {
+				break
+			}
+			if flow && (c == ',' || c == ']' || c == '}') {
+				break
+			}
+			end++
+		}
+		for end > start && strings.ContainsRune(" \t\r", rune(content[end-1])) {
+			end--
+		}
+		return end
+	}
+}
+
+// detectYAMLIndent guesses the indentation width of a document
+func detectYAMLIndent(content string) int {
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if width := len(line) - len(trimmed); width > 0 {
+			if width < 2 {
+				return 2
+			}
+			return width
+		}
+	}
+	return 2
+}
@@ -0,0 +1,160 @@
+package files
+
+import (
+	"strings"
+	"testing"
+)
+
+const testPVC = `# PVC for the database
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: data   # do not rename
+spec:
+  storageClassName: "standard"  # default class
+  accessModes: [ReadWriteOnce]
+  resources:
+    requests:
+      storage: 10Gi
+`
+
+func mustPath(t *testing.T, expr string) []PathSegment {
+	t.Helper()
+	path, err := ParseConfigPath(expr)
+	if err != nil {
+		t.Fatalf("ParseConfigPath(%q) failed: %v", expr, err)
+	}
+	return path
+}
+
+func TestGetYAMLValue(t *testing.T) {
+	value, err := getYAMLValue(testPVC, mustPath(t, "spec.resources.requests.storage"), DocumentAuto)
+	if err != nil {
+		t.Fatalf("getYAMLValue() failed: %v", err)
+	}
+	if value != "10Gi" {
+		t.Errorf("Expected 10Gi, got %v", value)
+	}
+
+	if _, err := getYAMLValue(testPVC, mustPath(t, "spec.volumeName"), DocumentAuto); err == nil {
+		t.Error("Expected error for missing key")
+	}
+}
+
+func TestSetYAMLValue_SplicesScalar(t *testing.T) {
+	updated, err := setYAMLValue(testPVC, mustPath(t, "spec.storageClassName"), "fast-ssd", DocumentAuto)
+	if err != nil {
+		t.Fatalf("setYAMLValue() failed: %v", err)
+	}
+	expected := strings.Replace(testPVC, `"standard"`, `"fast-ssd"`, 1)
+	if updated != expected {
+		t.Errorf("Unexpected result:\n%s", updated)
+	}
+
+	updated, err = setYAMLValue(testPVC, mustPath(t, "spec.accessModes[0]"), "ReadWriteMany", DocumentAuto)
+	if err != nil {
+		t.Fatalf("setYAMLValue() failed: %v", err)
+	}
+	if !strings.Contains(updated, `accessModes: ["ReadWriteMany"]`) || !strings.Contains(updated, "# do not rename") {
+		t.Errorf("Unexpected result:\n%s", updated)
+	}
+}
+
+func TestSetYAMLValue_AddsKeyKeepingComments(t *testing.T) {
+	updated, err := setYAMLValue(testPVC, mustPath(t, "spec.resources.limits.storage"), "20Gi", DocumentAuto)
+	if err != nil {
+		t.Fatalf("setYAMLValue() failed: %v", err)
+	}
+	for _, want := range []string{"# PVC for the database", "# do not rename", "# default class", "limits:\n      storage: 20Gi"} {
+		if !strings.Contains(updated, want) {
+			t.Errorf("Expected %q in result:\n%s", want, updated)
+		}
+	}
+	if strings.Index(updated, "apiVersion") > strings.Index(updated, "kind") {
+		t.Error("Key order changed")
+	}
+}
+
+func TestSetYAMLValue_MultiDocument(t *testing.T) {
+	content := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  mode: slow
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: 1
+`
+	path := mustPath(t, "spec.replicas")
+	_, err := setYAMLValue(content, path, 3, DocumentAuto)
+	if err == nil || !strings.Contains(err.Error(), "1 (Deployment/app)") {
+		t.Fatalf("Expected document selection error, got %v", err)
+	}
+
+	updated, err := setYAMLValue(content, path, 3, 1)
+	if err != nil {
+		t.Fatalf("setYAMLValue() failed: %v", err)
+	}
+	if updated != strings.Replace(content, "replicas: 1", "replicas: 3", 1) {
+		t.Errorf("Unexpected result:\n%s", updated)
+	}
+
+	updated, err = setYAMLValue(content, mustPath(t, "data.cache"), "on", 0)
+	if err != nil {
+		t.Fatalf("setYAMLValue() failed: %v", err)
+	}
+	if !strings.Contains(updated, "  mode: slow\n  cache: \"on\"\n---\n") || !strings.HasSuffix(updated, "spec:\n  replicas: 1\n") {
+		t.Errorf("Unexpected result:\n%s", updated)
+	}
+}
+
+func TestSetYAMLValue_MultiDocumentKeepsSiblings(t *testing.T) {
+	first := `# app config
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+spec:
+  mode: slow
+`
+	second := `# service
+apiVersion: v1
+kind: Service
+spec:
+  ports:
+  - port: 80
+`
+	content := first + "--- # next\n" + second + "---\n" + second
+	updated, err := setYAMLValue(content, mustPath(t, "spec.newkey.sub"), "x", 0)
+	if err != nil {
+		t.Fatalf("setYAMLValue() failed: %v", err)
+	}
+	if !strings.HasSuffix(updated, "  mode: slow\n  newkey:\n    sub: x\n--- # next\n"+second+"---\n"+second) {
+		t.Errorf("Expected the other documents unchanged:\n%s", updated)
+	}
+
+	updated, err = setYAMLValue(content, mustPath(t, "spec.type"), "NodePort", 1)
+	if err != nil {
+		t.Fatalf("setYAMLValue() failed: %v", err)
+	}
+	if !strings.HasPrefix(updated, first+"--- # next\n") || !strings.HasSuffix(updated, "type: NodePort\n---\n"+second) {
+		t.Errorf("Expected the other documents unchanged:\n%s", updated)
+	}
+}
+
+func TestSetYAMLValue_ReplacesWithList(t *testing.T) {
+	content := "args: --verbose  # flags\nname: x\n"
+	updated, err := setYAMLValue(content, mustPath(t, "args"), []interface{}{"--verbose", "--debug"}, DocumentAuto)
+	if err != nil {
+		t.Fatalf("setYAMLValue() failed: %v", err)
+	}
+	value, _ := getYAMLValue(updated, mustPath(t, "args[1]"), DocumentAuto)
+	if value != "--debug" || !strings.Contains(updated, "# flags") {
+		t.Errorf("Unexpected result:\n%s", updated)
+	}
+}
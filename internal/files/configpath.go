@@ -0,0 +1,178 @@
+package files
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PathSegment is one step of a config path: a map key or a list index
+type PathSegment struct {
+	Key     string
+	Index   int
+	IsIndex bool
+}
+
+func (s PathSegment) String() string {
+	if s.IsIndex {
+		return fmt.Sprintf("[%d]", s.Index)
+	}
+	return s.Key
+}
+
+// ParseConfigPath parses a path expression such as
+//
+//	spec.storageClassName
+//	controller.tolerations[0].key
+//	data["config.json"]
+//	[plugins."io.containerd.grpc.v1.cri"].snapshotter
+//
+// Keys are separated by dots and may be quoted to contain dots. Brackets hold
+// a list index, a quoted key, or a TOML-style dotted table name.
+func ParseConfigPath(expr string) ([]PathSegment, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, fmt.Errorf("empty path")
+	}
+
+	var segments []PathSegment
+	i := 0
+	expectKey := true
+	for i < len(expr) {
+		switch c := expr[i]; {
+		case c == '.':
+			if expectKey {
+				return nil, fmt.Errorf("invalid path %q: empty key at offset %d", expr, i)
+			}
+			expectKey = true
+			i++
+		case c == '[':
+			end, err := matchingBracket(expr, i)
+			if err != nil {
+				return nil, err
+			}
+			inner := strings.TrimSpace(expr[i+1 : end])
+			switch {
+			case inner == "":
+				return nil, fmt.Errorf("invalid path %q: empty brackets", expr)
+			case isInteger(inner):
+				index, _ := strconv.Atoi(inner)
+				segments = append(segments, PathSegment{Index: index, IsIndex: true})
+			default:
+				// A quoted key or a dotted table name: both parse as a path
+				nested, err := ParseConfigPath(inner)
+				if err != nil {
+					return nil, err
+				}
+				segments = append(segments, nested...)
+			}
+			expectKey = false
+			i = end + 1
+		case c == '"' || c == '\'':
+			if !expectKey {
+				return nil, fmt.Errorf("invalid path %q: missing '.' before offset %d", expr, i)
+			}
+			key, next, err := readQuotedKey(expr, i)
+			if err != nil {
+				return nil, err
+			}
+			segments = append(segments, PathSegment{Key: key})
+			expectKey = false
+			i = next
+		case c == ' ' || c == '\t':
+			i++
+		default:
+			if !expectKey {
+				return nil, fmt.Errorf("invalid path %q: missing '.' before offset %d", expr, i)
+			}
+			start := i
+			for i < len(expr) && !strings.ContainsRune(".[ \t\"'", rune(expr[i])) {
+				i++
+			}
+			segments = append(segments, PathSegment{Key: expr[start:i]})
+			expectKey = false
+		}
+	}
+
+	if expectKey {
+		return nil, fmt.Errorf("invalid path %q: trailing '.'", expr)
+	}
+	return segments, nil
+}
+
+// FormatConfigPath renders segments back into a path expression
+func FormatConfigPath(segments []PathSegment) string {
+	var sb strings.Builder
+	for i, seg := range segments {
+		switch {
+		case seg.IsIndex:
+			sb.WriteString(seg.String())
+		default:
+			if i > 0 {
+				sb.WriteString(".")
+			}
+			if seg.Key == "" || strings.ContainsAny(seg.Key, ".[]\"' \t") {
+				sb.WriteString(strconv.Quote(seg.Key))
+			} else {
+				sb.WriteString(seg.Key)
+			}
+		}
+	}
+	return sb.String()
+}
+
+func matchingBracket(expr string, start int) (int, error) {
+	depth := 0
+	for i := start; i < len(expr); i++ {
+		switch expr[i] {
+		case '"', '\'':
+			_, next, err := readQuotedKey(expr, i)
+			if err != nil {
+				return 0, err
+			}
+			i = next - 1
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("invalid path %q: unclosed '['", expr)
+}
+
+// readQuotedKey reads a "double" (with escapes) or 'single' quoted key starting at i
+func readQuotedKey(expr string, i int) (string, int, error) {
+	quote := expr[i]
+	for j := i + 1; j < len(expr); j++ {
+		switch {
+		case quote == '"' && expr[j] == '\\':
+			j++
+		case expr[j] == quote:
+			raw := expr[i : j+1]
+			if quote == '\'' {
+				return raw[1 : len(raw)-1], j + 1, nil
+			}
+			key, err := strconv.Unquote(raw)
+			if err != nil {
+				return "", 0, fmt.Errorf("invalid quoted key %s: %w", raw, err)
+			}
+			return key, j + 1, nil
+		}
+	}
+	return "", 0, fmt.Errorf("invalid path %q: unterminated quote", expr)
+}
+
+func isInteger(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,47 @@
+package files
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseConfigPath(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected []PathSegment
+	}{
+		{"spec.storageClassName", []PathSegment{{Key: "spec"}, {Key: "storageClassName"}}},
+		{"controller.tolerations[0].key", []PathSegment{{Key: "controller"}, {Key: "tolerations"}, {Index: 0, IsIndex: true}, {Key: "key"}}},
+		{`data["config.json"]`, []PathSegment{{Key: "data"}, {Key: "config.json"}}},
+		{`[plugins."io.containerd.grpc.v1.cri"].snapshotter`, []PathSegment{{Key: "plugins"}, {Key: "io.containerd.grpc.v1.cri"}, {Key: "snapshotter"}}},
+		{`plugins.'io.containerd.grpc.v1.cri'.containerd`, []PathSegment{{Key: "plugins"}, {Key: "io.containerd.grpc.v1.cri"}, {Key: "containerd"}}},
+		{"items[2][1]", []PathSegment{{Key: "items"}, {Index: 2, IsIndex: true}, {Index: 1, IsIndex: true}}},
+		{`"a b".c`, []PathSegment{{Key: "a b"}, {Key: "c"}}},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseConfigPath(tt.expr)
+		if err != nil {
+			t.Errorf("ParseConfigPath(%q) failed: %v", tt.expr, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("ParseConfigPath(%q) = %v, want %v", tt.expr, got, tt.expected)
+		}
+	}
+}
+
+func TestParseConfigPath_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "a..b", "a.", ".a", "a[0", `a["b]`, "a[]", `a"b"`} {
+		if _, err := ParseConfigPath(expr); err == nil {
+			t.Errorf("ParseConfigPath(%q) expected error", expr)
+		}
+	}
+}
+
+func TestFormatConfigPath(t *testing.T) {
+	segments, _ := ParseConfigPath(`[plugins."io.containerd.grpc.v1.cri"].list[1].name`)
+	if got := FormatConfigPath(segments); got != `plugins."io.containerd.grpc.v1.cri".list[1].name` {
+		t.Errorf("Unexpected formatted path: %s", got)
+	}
+}
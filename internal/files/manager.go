@@ -44,24 +44,85 @@ func (m *Manager) ReadFile(path string) (string, error) {
 
 // WriteFile writes content to a file
 func (m *Manager) WriteFile(path, content string) error {
+	_, err := m.WriteFileWithBackup(path, content)
+	return err
+}
+
+// WriteFileWithBackup writes content to a file and returns the path of the
+// backup taken of the previous contents ("" if the file did not exist or backups are disabled)
+func (m *Manager) WriteFileWithBackup(path, content string) (string, error) {
 	// Create backup before writing
-	if err := m.backupFile(path); err != nil {
-		return fmt.Errorf("failed to create backup: %w", err)
+	backupPath, err := m.backupFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to create backup: %w", err)
 	}
 
 	// Ensure directory exists
 	dir := filepath.Dir(path)
 	if err := m.fs.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
+		return "", fmt.Errorf("failed to create directory: %w", err)
 	}
 
 	if err := m.fs.WriteFile(path, []byte(content), 0644); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
+		return "", fmt.Errorf("failed to write file: %w", err)
 	}
 
+	return backupPath, nil
+}
+
+// RemoveFile removes a file after backing it up
+func (m *Manager) RemoveFile(path string) error {
+	if _, err := m.backupFile(path); err != nil {
+		return fmt.Errorf("failed to create backup: %w", err)
+	}
+	if err := m.fs.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove file: %w", err)
+	}
 	return nil
 }
 
+// FileExists reports whether a file exists
+func (m *Manager) FileExists(path string) bool {
+	_, err := m.fs.Stat(path)
+	return err == nil
+}
+
+// FindBackup returns the newest backup of path taken at or before the given time
+func (m *Manager) FindBackup(path string, before time.Time) (string, error) {
+	if m.backupDir == "" {
+		return "", fmt.Errorf("backup directory is not configured")
+	}
+
+	entries, err := m.fs.ReadDir(m.backupDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read backup directory: %w", err)
+	}
+
+	prefix := filepath.Base(path) + "."
+	suffix := "." + pathHash(path) + ".backup"
+	var best string
+	var bestTime time.Time
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), suffix)
+		taken, err := time.Parse(backupTimeFormat, stamp)
+		if err != nil || taken.After(before) {
+			continue
+		}
+		if best == "" || taken.After(bestTime) {
+			best, bestTime = name, taken
+		}
+	}
+
+	if best == "" {
+		return "", fmt.Errorf("no backup found for %s", path)
+	}
+	return filepath.Join(m.backupDir, best), nil
+}
+
 // ParseYAML parses a YAML file
 func (m *Manager) ParseYAML(path string) (interface{}, error) {
 	data, err := m.fs.ReadFile(path)
@@ -80,7 +141,7 @@ func (m *Manager) ParseYAML(path string) (interface{}, error) {
 // WriteYAML writes data as YAML to a file
 func (m *Manager) WriteYAML(path string, data interface{}) error {
 	// Create backup
-	if err := m.backupFile(path); err != nil {
+	if _, err := m.backupFile(path); err != nil {
 		return fmt.Errorf("failed to create backup: %w", err)
 	}
 
@@ -120,7 +181,7 @@ func (m *Manager) ParseJSON(path string) (interface{}, error) {
 // WriteJSON writes data as JSON to a file
 func (m *Manager) WriteJSON(path string, data interface{}) error {
 	// Create backup
-	if err := m.backupFile(path); err != nil {
+	if _, err := m.backupFile(path); err != nil {
 		return fmt.Errorf("failed to create backup: %w", err)
 	}
 
@@ -160,7 +221,7 @@ func (m *Manager) ParseTOML(path string) (interface{}, error) {
 // WriteTOML writes data as TOML to a file
 func (m *Manager) WriteTOML(path string, data interface{}) error {
 	// Create backup
-	if err := m.backupFile(path); err != nil {
+	if _, err := m.backupFile(path); err != nil {
 		return fmt.Errorf("failed to create backup: %w", err)
 	}
 
@@ -201,42 +262,49 @@ func (m *Manager) SearchInFile(path, pattern string) ([]string, error) {
 	return matches, nil
 }
 
-// backupFile creates a backup of a file
-func (m *Manager) backupFile(path string) error {
+// backupTimeFormat is the timestamp format embedded in backup file names
+const backupTimeFormat = "20060102-150405.000000000"
+
+// backupFile creates a backup of a file and returns the backup path
+func (m *Manager) backupFile(path string) (string, error) {
 	if m.backupDir == "" {
-		return nil // No backup directory specified
+		return "", nil // No backup directory specified
 	}
 
 	// Check if file exists
 	if _, err := m.fs.Stat(path); os.IsNotExist(err) {
-		return nil // File doesn't exist, no need to backup
+		return "", nil // File doesn't exist, no need to backup
 	}
 
 	// Create backup directory
 	if err := m.fs.MkdirAll(m.backupDir, 0755); err != nil {
-		return fmt.Errorf("failed to create backup directory: %w", err)
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
 	}
 
 	// Generate backup filename
 	baseName := filepath.Base(path)
-	hasher := fnv.New32a()
-	_, _ = hasher.Write([]byte(path))
-	pathHash := fmt.Sprintf("%08x", hasher.Sum32())
-	timestamp := time.Now().UTC().Format("20060102-150405.000000000")
-	backupPath := filepath.Join(m.backupDir, fmt.Sprintf("%s.%s.%s.backup", baseName, timestamp, pathHash))
+	timestamp := time.Now().UTC().Format(backupTimeFormat)
+	backupPath := filepath.Join(m.backupDir, fmt.Sprintf("%s.%s.%s.backup", baseName, timestamp, pathHash(path)))
 
 	// Read original file
 	data, err := m.fs.ReadFile(path)
 	if err != nil {
-		return fmt.Errorf("failed to read file for backup: %w", err)
+		return "", fmt.Errorf("failed to read file for backup: %w", err)
 	}
 
 	// Write backup
 	if err := m.fs.WriteFile(backupPath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write backup: %w", err)
+		return "", fmt.Errorf("failed to write backup: %w", err)
 	}
 
-	return nil
+	return backupPath, nil
+}
+
+// pathHash distinguishes backups of files that share a base name
+func pathHash(path string) string {
+	hasher := fnv.New32a()
+	_, _ = hasher.Write([]byte(path))
+	return fmt.Sprintf("%08x", hasher.Sum32())
 }
 
 // GetFileType determines the file type based on extension
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/mainbong/storage_doctor/internal/filesystem"
 )
@@ -356,3 +357,79 @@ func TestGetFileType(t *testing.T) {
 
 
 
+
+func TestWriteFileWithBackup_ReturnsBackupPath(t *testing.T) {
+	mockFS := filesystem.NewMockFileSystem()
+	manager := NewManagerWithFS("/test/backups", mockFS)
+
+	mockFS.AddFile("/test/file.txt", []byte("old content"), 0644)
+
+	backupPath, err := manager.WriteFileWithBackup("/test/file.txt", "new content")
+	if err != nil {
+		t.Fatalf("WriteFileWithBackup() failed: %v", err)
+	}
+	if backupPath == "" {
+		t.Fatal("Expected backup path for existing file")
+	}
+	if got := string(mockFS.GetFile(backupPath)); got != "old content" {
+		t.Errorf("Expected backup to contain old content, got '%s'", got)
+	}
+
+	backupPath, err = manager.WriteFileWithBackup("/test/new.txt", "content")
+	if err != nil {
+		t.Fatalf("WriteFileWithBackup() failed: %v", err)
+	}
+	if backupPath != "" {
+		t.Errorf("Expected no backup for new file, got '%s'", backupPath)
+	}
+}
+
+func TestRemoveFile(t *testing.T) {
+	mockFS := filesystem.NewMockFileSystem()
+	manager := NewManagerWithFS("/test/backups", mockFS)
+
+	mockFS.AddFile("/test/file.txt", []byte("content"), 0644)
+
+	if err := manager.RemoveFile("/test/file.txt"); err != nil {
+		t.Fatalf("RemoveFile() failed: %v", err)
+	}
+	if manager.FileExists("/test/file.txt") {
+		t.Error("Expected file to be removed")
+	}
+	if _, err := manager.FindBackup("/test/file.txt", time.Now()); err != nil {
+		t.Errorf("Expected backup of removed file: %v", err)
+	}
+}
+
+func TestFindBackup(t *testing.T) {
+	mockFS := filesystem.NewMockFileSystem()
+	manager := NewManagerWithFS("/test/backups", mockFS)
+
+	hash := pathHash("/etc/app/config.yaml")
+	older := filepath.Join("/test/backups", "config.yaml.20240101-100000.000000000."+hash+".backup")
+	newer := filepath.Join("/test/backups", "config.yaml.20240101-120000.000000000."+hash+".backup")
+	mockFS.AddFile(older, []byte("v1"), 0644)
+	mockFS.AddFile(newer, []byte("v2"), 0644)
+	// Same base name, different path
+	mockFS.AddFile(filepath.Join("/test/backups", "config.yaml.20240101-110000.000000000."+pathHash("/other/config.yaml")+".backup"), []byte("other"), 0644)
+
+	got, err := manager.FindBackup("/etc/app/config.yaml", time.Date(2024, 1, 1, 11, 30, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("FindBackup() failed: %v", err)
+	}
+	if got != older {
+		t.Errorf("Expected %s, got %s", older, got)
+	}
+
+	got, err = manager.FindBackup("/etc/app/config.yaml", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("FindBackup() failed: %v", err)
+	}
+	if got != newer {
+		t.Errorf("Expected %s, got %s", newer, got)
+	}
+
+	if _, err := manager.FindBackup("/etc/app/config.yaml", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Error("Expected error when no backup predates the given time")
+	}
+}
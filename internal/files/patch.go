@@ -0,0 +1,272 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mainbong/storage_doctor/internal/diff"
+)
+
+// Edit is a single search/replace edit
+type Edit struct {
+	OldText    string `json:"old_text"`
+	NewText    string `json:"new_text"`
+	ReplaceAll bool   `json:"replace_all,omitempty"`
+}
+
+// EditRequest describes a patch-based edit: search/replace edits or a unified diff
+type EditRequest struct {
+	Edits []Edit
+	Patch string
+}
+
+// PreparedEdit is an edit that has been applied in memory but not yet written
+type PreparedEdit struct {
+	Path       string
+	OldContent string
+	NewContent string
+	Existed    bool
+}
+
+// Diff returns a unified diff of the prepared change
+func (p *PreparedEdit) Diff() string {
+	return diff.Unified("a/"+strings.TrimPrefix(p.Path, "/"), "b/"+strings.TrimPrefix(p.Path, "/"), p.OldContent, p.NewContent, 3)
+}
+
+// ConflictError reports that an edit does not apply cleanly to the current file
+type ConflictError struct {
+	Path   string
+	Reason string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("edit conflict in %s: %s", e.Path, e.Reason)
+}
+
+// PrepareEdit reads a file and applies the edit in memory so it can be previewed
+func (m *Manager) PrepareEdit(path string, req EditRequest) (*PreparedEdit, error) {
+	if len(req.Edits) == 0 && strings.TrimSpace(req.Patch) == "" {
+		return nil, fmt.Errorf("either edits or patch is required")
+	}
+	if len(req.Edits) > 0 && strings.TrimSpace(req.Patch) != "" {
+		return nil, fmt.Errorf("edits and patch cannot be used together")
+	}
+
+	existed := m.FileExists(path)
+	oldContent := ""
+	if existed {
+		content, err := m.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		oldContent = content
+	}
+
+	var newContent string
+	var err error
+	if len(req.Edits) > 0 {
+		if !existed {
+			return nil, fmt.Errorf("file does not exist: %s", path)
+		}
+		newContent, err = ApplyEdits(oldContent, req.Edits)
+	} else {
+		newContent, err = ApplyUnifiedDiff(oldContent, req.Patch)
+	}
+	if err != nil {
+		var conflict *ConflictError
+		if errors.As(err, &conflict) {
+			conflict.Path = path
+		}
+		return nil, err
+	}
+
+	return &PreparedEdit{Path: path, OldContent: oldContent, NewContent: newContent, Existed: existed}, nil
+}
+
+// ApplyEdit writes a prepared edit. It fails with a ConflictError if the file
+// changed since the edit was prepared, and returns the backup path otherwise.
+func (m *Manager) ApplyEdit(edit *PreparedEdit) (string, error) {
+	current := ""
+	if m.FileExists(edit.Path) {
+		content, err := m.ReadFile(edit.Path)
+		if err != nil {
+			return "", err
+		}
+		current = content
+	} else if edit.Existed {
+		return "", &ConflictError{Path: edit.Path, Reason: "file was removed after the edit was prepared"}
+	}
+	if current != edit.OldContent {
+		return "", &ConflictError{Path: edit.Path, Reason: "file changed after the edit was prepared"}
+	}
+	return m.WriteFileWithBackup(edit.Path, edit.NewContent)
+}
+
+// ApplyEdits applies search/replace edits in order. Each OldText must match
+// exactly once unless ReplaceAll is set.
+func ApplyEdits(content string, edits []Edit) (string, error) {
+	for i, edit := range edits {
+		if edit.OldText == "" {
+			return "", fmt.Errorf("edit %d: old_text is empty", i+1)
+		}
+		count := strings.Count(content, edit.OldText)
+		switch {
+		case count == 0:
+			return "", &ConflictError{Reason: fmt.Sprintf("edit %d: old_text not found", i+1)}
+		case count > 1 && !edit.ReplaceAll:
+			return "", &ConflictError{Reason: fmt.Sprintf("edit %d: old_text matches %d locations; add more context or set replace_all", i+1, count)}
+		}
+		if edit.ReplaceAll {
+			content = strings.ReplaceAll(content, edit.OldText, edit.NewText)
+		} else {
+			content = strings.Replace(content, edit.OldText, edit.NewText, 1)
+		}
+	}
+	return content, nil
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+type patchHunk struct {
+	oldStart int
+	lines    []string // Lines with their ' ', '-' or '+' prefix
+}
+
+// maxHunkOffset is how far a hunk may have moved from its recorded position
+const maxHunkOffset = 1000
+
+// ApplyUnifiedDiff applies a unified diff to content. Hunks whose context has
+// moved are located by searching near the recorded line; hunks whose context
+// or removed lines do not match are reported as conflicts.
+func ApplyUnifiedDiff(content, patch string) (string, error) {
+	hunks, err := parseHunks(patch)
+	if err != nil {
+		return "", err
+	}
+
+	trailingNewline := content == "" || strings.HasSuffix(content, "\n")
+	var lines []string
+	if content != "" {
+		lines = strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	}
+
+	// Applied hunks shift later line numbers
+	shift := 0
+	minPos := 0
+	for i, h := range hunks {
+		var oldLines, newLines []string
+		for _, line := range h.lines {
+			switch line[0] {
+			case ' ':
+				oldLines = append(oldLines, line[1:])
+				newLines = append(newLines, line[1:])
+			case '-':
+				oldLines = append(oldLines, line[1:])
+			case '+':
+				newLines = append(newLines, line[1:])
+			}
+		}
+
+		expected := h.oldStart - 1 + shift
+		if len(oldLines) == 0 {
+			// Pure insertion: the header refers to the line after which to insert
+			expected = h.oldStart + shift
+		}
+		pos := findLines(lines, oldLines, expected, minPos)
+		if pos < 0 {
+			return "", &ConflictError{Reason: fmt.Sprintf("hunk %d (@@ -%d) does not match the current file", i+1, h.oldStart)}
+		}
+
+		updated := make([]string, 0, len(lines)-len(oldLines)+len(newLines))
+		updated = append(updated, lines[:pos]...)
+		updated = append(updated, newLines...)
+		updated = append(updated, lines[pos+len(oldLines):]...)
+		lines = updated
+
+		shift += len(newLines) - len(oldLines) + (pos - expected)
+		minPos = pos + len(newLines)
+	}
+
+	result := strings.Join(lines, "\n")
+	if trailingNewline && len(lines) > 0 {
+		result += "\n"
+	}
+	return result, nil
+}
+
+func parseHunks(patch string) ([]patchHunk, error) {
+	var hunks []patchHunk
+	var current *patchHunk
+
+	patch = strings.TrimRight(strings.ReplaceAll(patch, "\r\n", "\n"), "\n")
+	for _, line := range strings.Split(patch, "\n") {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			match := hunkHeader.FindStringSubmatch(line)
+			if match == nil {
+				return nil, fmt.Errorf("invalid hunk header: %s", line)
+			}
+			start, _ := strconv.Atoi(match[1])
+			hunks = append(hunks, patchHunk{oldStart: start})
+			current = &hunks[len(hunks)-1]
+		case current == nil:
+			// Headers such as "diff --git", "---" and "+++" before the first hunk
+			continue
+		case line == "":
+			// Some tools strip the space prefix from empty context lines
+			current.lines = append(current.lines, " ")
+		case line[0] == ' ' || line[0] == '-' || line[0] == '+':
+			current.lines = append(current.lines, line)
+		case strings.HasPrefix(line, `\`):
+			// "\ No newline at end of file"
+			continue
+		default:
+			return nil, fmt.Errorf("invalid patch line: %s", line)
+		}
+	}
+
+	if len(hunks) == 0 {
+		return nil, fmt.Errorf("patch contains no hunks")
+	}
+	return hunks, nil
+}
+
+// findLines returns the position of needle in lines closest to expected, not before minPos
+func findLines(lines, needle []string, expected, minPos int) int {
+	if len(needle) == 0 {
+		if expected < minPos {
+			expected = minPos
+		}
+		if expected > len(lines) {
+			return -1
+		}
+		return expected
+	}
+
+	for offset := 0; offset <= maxHunkOffset; offset++ {
+		for _, pos := range []int{expected - offset, expected + offset} {
+			if pos < minPos || pos+len(needle) > len(lines) {
+				continue
+			}
+			if linesEqual(lines[pos:pos+len(needle)], needle) {
+				return pos
+			}
+		}
+		if expected-offset < minPos && expected+offset+len(needle) > len(lines) {
+			break
+		}
+	}
+	return -1
+}
+
+func linesEqual(a, b []string) bool {
+	for i := range b {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
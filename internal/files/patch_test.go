@@ -0,0 +1,174 @@
+package files
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mainbong/storage_doctor/internal/diff"
+	"github.com/mainbong/storage_doctor/internal/filesystem"
+)
+
+func TestApplyEdits(t *testing.T) {
+	content := "storageClass: standard\nreplicas: 1\nstorageClass: standard\n"
+
+	got, err := ApplyEdits(content, []Edit{{OldText: "replicas: 1", NewText: "replicas: 3"}})
+	if err != nil {
+		t.Fatalf("ApplyEdits() failed: %v", err)
+	}
+	if got != "storageClass: standard\nreplicas: 3\nstorageClass: standard\n" {
+		t.Errorf("Unexpected result: %q", got)
+	}
+
+	got, err = ApplyEdits(content, []Edit{{OldText: "standard", NewText: "fast", ReplaceAll: true}})
+	if err != nil {
+		t.Fatalf("ApplyEdits() failed: %v", err)
+	}
+	if strings.Count(got, "fast") != 2 {
+		t.Errorf("Expected both occurrences replaced, got %q", got)
+	}
+}
+
+func TestApplyEdits_Conflicts(t *testing.T) {
+	content := "a: 1\na: 1\n"
+
+	tests := []Edit{
+		{OldText: "missing", NewText: "x"},
+		{OldText: "a: 1", NewText: "a: 2"},
+	}
+	for _, edit := range tests {
+		_, err := ApplyEdits(content, []Edit{edit})
+		var conflict *ConflictError
+		if !errors.As(err, &conflict) {
+			t.Errorf("ApplyEdits(%q) expected ConflictError, got %v", edit.OldText, err)
+		}
+	}
+
+	if _, err := ApplyEdits(content, []Edit{{OldText: "", NewText: "x"}}); err == nil {
+		t.Error("Expected error for empty old_text")
+	}
+}
+
+func TestApplyUnifiedDiff(t *testing.T) {
+	old := "line1\nline2\nline3\nline4\nline5\nline6\nline7\nline8\n"
+	new := "line1\nline2\nLINE3\nline4\nline5\nline6\nline7\nline8\nline9\n"
+
+	patch := diff.Unified("a/f", "b/f", old, new, 1)
+	got, err := ApplyUnifiedDiff(old, patch)
+	if err != nil {
+		t.Fatalf("ApplyUnifiedDiff() failed: %v\n%s", err, patch)
+	}
+	if got != new {
+		t.Errorf("Expected %q, got %q", new, got)
+	}
+}
+
+func TestApplyUnifiedDiff_Offset(t *testing.T) {
+	// The hunk header says line 2, but two lines were added at the top since
+	content := "new1\nnew2\na\nb\nc\n"
+	patch := "--- a/f\n+++ b/f\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n"
+
+	got, err := ApplyUnifiedDiff(content, patch)
+	if err != nil {
+		t.Fatalf("ApplyUnifiedDiff() failed: %v", err)
+	}
+	if got != "new1\nnew2\na\nB\nc\n" {
+		t.Errorf("Unexpected result: %q", got)
+	}
+}
+
+func TestApplyUnifiedDiff_NewFile(t *testing.T) {
+	got, err := ApplyUnifiedDiff("", "--- /dev/null\n+++ b/f\n@@ -0,0 +1,2 @@\n+x\n+y\n")
+	if err != nil {
+		t.Fatalf("ApplyUnifiedDiff() failed: %v", err)
+	}
+	if got != "x\ny\n" {
+		t.Errorf("Unexpected result: %q", got)
+	}
+}
+
+func TestApplyUnifiedDiff_Conflict(t *testing.T) {
+	_, err := ApplyUnifiedDiff("a\nb\nc\n", "@@ -1,2 +1,2 @@\n a\n-x\n+y\n")
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) {
+		t.Errorf("Expected ConflictError, got %v", err)
+	}
+
+	if _, err := ApplyUnifiedDiff("a\n", "not a patch"); err == nil {
+		t.Error("Expected error for patch without hunks")
+	}
+}
+
+func TestPrepareAndApplyEdit(t *testing.T) {
+	mockFS := filesystem.NewMockFileSystem()
+	manager := NewManagerWithFS("/test/backups", mockFS)
+	mockFS.AddFile("/test/values.yaml", []byte("storageClass: standard\nsize: 10Gi\n"), 0644)
+
+	edit, err := manager.PrepareEdit("/test/values.yaml", EditRequest{
+		Edits: []Edit{{OldText: "storageClass: standard", NewText: "storageClass: fast"}},
+	})
+	if err != nil {
+		t.Fatalf("PrepareEdit() failed: %v", err)
+	}
+	if !strings.Contains(edit.Diff(), "+storageClass: fast") {
+		t.Errorf("Expected diff to contain the change, got:\n%s", edit.Diff())
+	}
+	// Preparing does not touch the file
+	if string(mockFS.GetFile("/test/values.yaml")) != "storageClass: standard\nsize: 10Gi\n" {
+		t.Error("PrepareEdit() must not modify the file")
+	}
+
+	backupPath, err := manager.ApplyEdit(edit)
+	if err != nil {
+		t.Fatalf("ApplyEdit() failed: %v", err)
+	}
+	if backupPath == "" {
+		t.Error("Expected backup to be created")
+	}
+	if got := string(mockFS.GetFile("/test/values.yaml")); got != "storageClass: fast\nsize: 10Gi\n" {
+		t.Errorf("Unexpected file content: %q", got)
+	}
+}
+
+func TestApplyEdit_ConcurrentChange(t *testing.T) {
+	mockFS := filesystem.NewMockFileSystem()
+	manager := NewManagerWithFS("/test/backups", mockFS)
+	mockFS.AddFile("/test/values.yaml", []byte("a: 1\n"), 0644)
+
+	edit, err := manager.PrepareEdit("/test/values.yaml", EditRequest{Edits: []Edit{{OldText: "a: 1", NewText: "a: 2"}}})
+	if err != nil {
+		t.Fatalf("PrepareEdit() failed: %v", err)
+	}
+
+	mockFS.AddFile("/test/values.yaml", []byte("a: 1\nb: 2\n"), 0644)
+	_, err = manager.ApplyEdit(edit)
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("Expected ConflictError, got %v", err)
+	}
+	if got := string(mockFS.GetFile("/test/values.yaml")); got != "a: 1\nb: 2\n" {
+		t.Errorf("File must not be modified on conflict, got %q", got)
+	}
+}
+
+func TestPrepareEdit_InvalidRequest(t *testing.T) {
+	mockFS := filesystem.NewMockFileSystem()
+	manager := NewManagerWithFS("/test/backups", mockFS)
+	mockFS.AddFile("/test/f", []byte("x\n"), 0644)
+
+	if _, err := manager.PrepareEdit("/test/f", EditRequest{}); err == nil {
+		t.Error("Expected error for empty request")
+	}
+	if _, err := manager.PrepareEdit("/test/f", EditRequest{Edits: []Edit{{OldText: "x", NewText: "y"}}, Patch: "@@ -1 +1 @@\n-x\n+y\n"}); err == nil {
+		t.Error("Expected error when both edits and patch are given")
+	}
+	if _, err := manager.PrepareEdit("/test/missing", EditRequest{Edits: []Edit{{OldText: "x", NewText: "y"}}}); err == nil {
+		t.Error("Expected error for search/replace on missing file")
+	}
+
+	_, err := manager.PrepareEdit("/test/f", EditRequest{Edits: []Edit{{OldText: "nope", NewText: "y"}}})
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) || conflict.Path != "/test/f" {
+		t.Errorf("Expected ConflictError with path, got %v", err)
+	}
+}
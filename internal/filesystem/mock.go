@@ -216,6 +216,9 @@ func (m *MockFileSystem) Walk(root string, walkFn filepath.WalkFunc) error {
 		}
 
 		err = walkFn(path, info, nil)
+		if err == filepath.SkipDir && info.IsDir() {
+			return nil
+		}
 		if err != nil {
 			return err
 		}
@@ -4,9 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/mainbong/storage_doctor/internal/filesystem"
+	"github.com/mainbong/storage_doctor/internal/llm"
 )
 
 // ActionType represents the type of action
@@ -27,6 +29,35 @@ type Action struct {
 	OldValue  string     `json:"old_value,omitempty"` // For file actions (backup)
 	NewValue  string     `json:"new_value,omitempty"` // For file actions
 	Output    string     `json:"output,omitempty"`    // For command actions
+	// InverseCommand undoes a command action, when one was supplied at execution time
+	InverseCommand string `json:"inverse_command,omitempty"`
+	// BackupPath is the files.Manager backup taken before a file action
+	BackupPath string `json:"backup_path,omitempty"`
+	// Created marks file actions that created a file which did not exist before
+	Created bool `json:"created,omitempty"`
+}
+
+// Source is a web page returned by a search
+type Source struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// Search records a web search made during a session
+type Search struct {
+	Query     string    `json:"query"`
+	Timestamp time.Time `json:"timestamp"`
+	Sources   []Source  `json:"sources,omitempty"`
+}
+
+// Transcript is the conversation state needed to resume a session
+type Transcript struct {
+	Provider string `json:"provider,omitempty"`
+	Model    string `json:"model,omitempty"`
+	// Messages is the full message log, including the system prompt and context summaries
+	Messages     []llm.Message `json:"messages"`
+	ActiveSkills []string      `json:"active_skills,omitempty"`
+	Usage        llm.Usage     `json:"usage"`
 }
 
 // Session represents a session with its history
@@ -36,6 +67,15 @@ type Session struct {
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	Actions   []Action  `json:"actions"`
+	// Problem is the first request of the session
+	Problem string `json:"problem,omitempty"`
+	// Conclusion is the agent's most recent final answer
+	Conclusion string   `json:"conclusion,omitempty"`
+	Searches   []Search `json:"searches,omitempty"`
+	// Transcript is nil for sessions saved before transcripts were recorded
+	Transcript *Transcript `json:"transcript,omitempty"`
+	// Usage is the token usage and API cost of every LLM call in the session
+	Usage UsageSummary `json:"usage"`
 }
 
 // Manager manages action history and sessions
@@ -43,6 +83,8 @@ type Manager struct {
 	sessionDir     string
 	currentSession *Session
 	fs             filesystem.FileSystem
+	searchMu       sync.Mutex // Searches may be recorded by concurrent tool calls
+	usageMu        sync.Mutex // Usage is recorded by the agent while the TUI renders it
 }
 
 // NewManager creates a new history manager
@@ -71,12 +113,18 @@ func NewManagerWithFS(sessionDir string, fs filesystem.FileSystem) (*Manager, er
 
 // AddCommandAction adds a command action to history
 func (m *Manager) AddCommandAction(command, output string) {
+	m.AddCommandActionWithInverse(command, output, "")
+}
+
+// AddCommandActionWithInverse adds a command action along with the command that undoes it
+func (m *Manager) AddCommandActionWithInverse(command, output, inverseCommand string) {
 	action := Action{
-		ID:        generateID(),
-		Type:      ActionTypeCommand,
-		Timestamp: time.Now(),
-		Command:   command,
-		Output:    output,
+		ID:             generateID(),
+		Type:           ActionTypeCommand,
+		Timestamp:      time.Now(),
+		Command:        command,
+		Output:         output,
+		InverseCommand: inverseCommand,
 	}
 
 	m.currentSession.Actions = append(m.currentSession.Actions, action)
@@ -85,25 +133,69 @@ func (m *Manager) AddCommandAction(command, output string) {
 
 // AddFileAction adds a file action to history
 func (m *Manager) AddFileAction(filePath, oldValue, newValue string) {
+	m.AddFileActionWithBackup(filePath, oldValue, newValue, "", false)
+}
+
+// AddFileActionWithBackup adds a file action along with the backup taken before the change.
+// created is true when the file did not exist before the action.
+func (m *Manager) AddFileActionWithBackup(filePath, oldValue, newValue, backupPath string, created bool) {
 	action := Action{
-		ID:        generateID(),
-		Type:      ActionTypeFile,
-		Timestamp: time.Now(),
-		FilePath:  filePath,
-		OldValue:  oldValue,
-		NewValue:  newValue,
+		ID:         generateID(),
+		Type:       ActionTypeFile,
+		Timestamp:  time.Now(),
+		FilePath:   filePath,
+		OldValue:   oldValue,
+		NewValue:   newValue,
+		BackupPath: backupPath,
+		Created:    created,
 	}
 
 	m.currentSession.Actions = append(m.currentSession.Actions, action)
 	m.currentSession.UpdatedAt = time.Now()
 }
 
+// AddSearch records a web search and the sources it returned
+func (m *Manager) AddSearch(query string, sources []Source) {
+	m.searchMu.Lock()
+	defer m.searchMu.Unlock()
+
+	m.currentSession.Searches = append(m.currentSession.Searches, Search{
+		Query:     query,
+		Timestamp: time.Now(),
+		Sources:   sources,
+	})
+	m.currentSession.UpdatedAt = time.Now()
+}
+
+// SetProblem records the problem statement unless the session already has one
+func (m *Manager) SetProblem(problem string) {
+	if m.currentSession.Problem == "" {
+		m.currentSession.Problem = problem
+		m.currentSession.UpdatedAt = time.Now()
+	}
+}
+
+// SetConclusion records the agent's latest final answer
+func (m *Manager) SetConclusion(conclusion string) {
+	m.currentSession.Conclusion = conclusion
+	m.currentSession.UpdatedAt = time.Now()
+}
+
+// SetTranscript records the conversation so the session can be resumed
+func (m *Manager) SetTranscript(transcript Transcript) {
+	transcript.Messages = append([]llm.Message(nil), transcript.Messages...)
+	transcript.ActiveSkills = append([]string(nil), transcript.ActiveSkills...)
+	m.currentSession.Transcript = &transcript
+	m.currentSession.UpdatedAt = time.Now()
+}
+
 // GetActions returns all actions in current session
 func (m *Manager) GetActions() []Action {
 	return m.currentSession.Actions
 }
 
-// Rollback rolls back the last N actions
+// Rollback removes the last N actions from the session and returns them, most recent first.
+// It does not undo anything; see PlanRollback for restoring the actions' effects.
 func (m *Manager) Rollback(count int) ([]Action, error) {
 	if count <= 0 || count > len(m.currentSession.Actions) {
 		return nil, fmt.Errorf("invalid rollback count")
@@ -113,7 +205,7 @@ func (m *Manager) Rollback(count int) ([]Action, error) {
 	actionsToRollback := make([]Action, count)
 	copy(actionsToRollback, m.currentSession.Actions[len(m.currentSession.Actions)-count:])
 
-	// Reverse to get chronological order
+	// Reverse so the most recent action comes first
 	for i, j := 0, len(actionsToRollback)-1; i < j; i, j = i+1, j-1 {
 		actionsToRollback[i], actionsToRollback[j] = actionsToRollback[j], actionsToRollback[i]
 	}
@@ -192,6 +284,26 @@ func (m *Manager) ListSessions() ([]Session, error) {
 	return sessions, nil
 }
 
+// LoadLatestSession loads the most recently updated saved session
+func (m *Manager) LoadLatestSession() error {
+	sessions, err := m.ListSessions()
+	if err != nil {
+		return err
+	}
+	if len(sessions) == 0 {
+		return fmt.Errorf("no saved sessions")
+	}
+
+	latest := sessions[0]
+	for _, session := range sessions[1:] {
+		if session.UpdatedAt.After(latest.UpdatedAt) {
+			latest = session
+		}
+	}
+	m.currentSession = &latest
+	return nil
+}
+
 // GetCurrentSession returns the current session
 func (m *Manager) GetCurrentSession() *Session {
 	return m.currentSession
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/mainbong/storage_doctor/internal/filesystem"
+	"github.com/mainbong/storage_doctor/internal/llm"
 )
 
 func TestNewManager(t *testing.T) {
@@ -289,6 +290,37 @@ func TestListSessions(t *testing.T) {
 	}
 }
 
+func TestLoadLatestSession(t *testing.T) {
+	mockFS := filesystem.NewMockFileSystem()
+	manager, _ := NewManagerWithFS("/test/sessions", mockFS)
+
+	manager.AddCommandAction("cmd1", "output1")
+	manager.SaveSession("older")
+
+	manager.NewSession("newer")
+	manager.currentSession.UpdatedAt = time.Now().Add(time.Hour)
+	manager.AddFileAction("/test/file", "old", "new")
+	manager.currentSession.UpdatedAt = time.Now().Add(time.Hour)
+	manager.SaveSession("newer")
+	newerID := manager.currentSession.ID
+
+	manager.NewSession("unsaved")
+	if err := manager.LoadLatestSession(); err != nil {
+		t.Fatalf("LoadLatestSession() failed: %v", err)
+	}
+	if manager.currentSession.ID != newerID {
+		t.Errorf("Expected latest session %s, got %s", newerID, manager.currentSession.ID)
+	}
+}
+
+func TestLoadLatestSession_Empty(t *testing.T) {
+	manager, _ := NewManagerWithFS("/test/sessions", filesystem.NewMockFileSystem())
+
+	if err := manager.LoadLatestSession(); err == nil {
+		t.Error("Expected error when no sessions are saved")
+	}
+}
+
 func TestListSessions_Empty(t *testing.T) {
 	mockFS := filesystem.NewMockFileSystem()
 	manager, _ := NewManagerWithFS("/test/sessions", mockFS)
@@ -353,3 +385,99 @@ func TestSaveSession_WriteError(t *testing.T) {
 	}
 }
 
+
+func TestAddSearch(t *testing.T) {
+	mockFS := filesystem.NewMockFileSystem()
+	manager, _ := NewManagerWithFS("/test/sessions", mockFS)
+
+	manager.AddSearch("longhorn volume faulted", []Source{{Title: "Longhorn docs", URL: "https://longhorn.io/docs"}})
+
+	searches := manager.GetCurrentSession().Searches
+	if len(searches) != 1 {
+		t.Fatalf("Expected 1 search, got %d", len(searches))
+	}
+	if searches[0].Query != "longhorn volume faulted" || searches[0].Sources[0].URL != "https://longhorn.io/docs" {
+		t.Errorf("Unexpected search: %+v", searches[0])
+	}
+	if len(manager.GetActions()) != 0 {
+		t.Error("Searches must not be recorded as rollback actions")
+	}
+}
+
+func TestSetProblemAndConclusion(t *testing.T) {
+	mockFS := filesystem.NewMockFileSystem()
+	manager, _ := NewManagerWithFS("/test/sessions", mockFS)
+
+	manager.SetProblem("PVC가 Pending 상태입니다")
+	manager.SetProblem("추가 질문")
+	manager.SetConclusion("StorageClass가 없습니다")
+
+	if err := manager.SaveSession("report"); err != nil {
+		t.Fatalf("SaveSession() failed: %v", err)
+	}
+	id := manager.GetCurrentSession().ID
+	manager.NewSession("other")
+	if err := manager.LoadSession(id); err != nil {
+		t.Fatalf("LoadSession() failed: %v", err)
+	}
+
+	session := manager.GetCurrentSession()
+	if session.Problem != "PVC가 Pending 상태입니다" {
+		t.Errorf("Expected first problem to be kept, got %q", session.Problem)
+	}
+	if session.Conclusion != "StorageClass가 없습니다" {
+		t.Errorf("Unexpected conclusion %q", session.Conclusion)
+	}
+}
+
+func TestTranscript_SaveAndLoad(t *testing.T) {
+	mockFS := filesystem.NewMockFileSystem()
+	sessionDir := "/test/sessions"
+	manager, _ := NewManagerWithFS(sessionDir, mockFS)
+
+	messages := []llm.Message{
+		{Role: "system", Content: "Previous conversation summary: pvc pending"},
+		{Role: "user", Content: "check pvc"},
+		{Role: "assistant", Content: "checking", ToolCalls: []llm.ToolCall{
+			{ID: "call_1", Name: "execute_command", Input: map[string]interface{}{"command": "kubectl get pvc"}},
+		}},
+		{Role: "tool", ToolResults: []llm.ToolResult{{ToolCallID: "call_1", Name: "execute_command", Content: "Pending"}}},
+	}
+	manager.SetTranscript(Transcript{
+		Provider:     "anthropic",
+		Model:        "claude-3-5-sonnet-20241022",
+		Messages:     messages,
+		ActiveSkills: []string{"storage_diagnosis"},
+		Usage:        llm.Usage{InputTokens: 1200, OutputTokens: 300},
+	})
+	messages[1].Content = "modified"
+	sessionID := manager.currentSession.ID
+	if err := manager.SaveSession(""); err != nil {
+		t.Fatalf("SaveSession() failed: %v", err)
+	}
+
+	newManager, _ := NewManagerWithFS(sessionDir, mockFS)
+	if err := newManager.LoadSession(sessionID); err != nil {
+		t.Fatalf("LoadSession() failed: %v", err)
+	}
+
+	transcript := newManager.GetCurrentSession().Transcript
+	if transcript == nil {
+		t.Fatal("Expected transcript to be loaded")
+	}
+	if transcript.Provider != "anthropic" || transcript.Model != "claude-3-5-sonnet-20241022" {
+		t.Errorf("Expected provider and model to be kept, got %s/%s", transcript.Provider, transcript.Model)
+	}
+	if len(transcript.Messages) != 4 || transcript.Messages[1].Content != "check pvc" {
+		t.Fatalf("Expected 4 messages copied at record time, got %+v", transcript.Messages)
+	}
+	if transcript.Messages[2].ToolCalls[0].ID != "call_1" || transcript.Messages[3].ToolResults[0].Content != "Pending" {
+		t.Errorf("Expected tool calls and results to round-trip, got %+v", transcript.Messages[2:])
+	}
+	if len(transcript.ActiveSkills) != 1 || transcript.ActiveSkills[0] != "storage_diagnosis" {
+		t.Errorf("Expected active skills to be kept, got %v", transcript.ActiveSkills)
+	}
+	if transcript.Usage.InputTokens != 1200 || transcript.Usage.OutputTokens != 300 {
+		t.Errorf("Expected usage to be kept, got %+v", transcript.Usage)
+	}
+}
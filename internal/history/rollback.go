@@ -0,0 +1,123 @@
+package history
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// RollbackKind describes how an action is undone
+type RollbackKind string
+
+const (
+	RollbackRestoreFile    RollbackKind = "restore_file"    // Write the previous contents back
+	RollbackDeleteFile     RollbackKind = "delete_file"     // Remove a file the action created
+	RollbackInverseCommand RollbackKind = "inverse_command" // Run the recorded inverse command
+	RollbackUnsupported    RollbackKind = "unsupported"     // Nothing recorded to undo the action
+)
+
+// RollbackStep is the plan for undoing a single action
+type RollbackStep struct {
+	Action Action
+	Kind   RollbackKind
+	// Content is the file content to restore (RollbackRestoreFile)
+	Content string
+	// Source describes where Content came from ("old_value" or a backup path)
+	Source string
+	// Command is the inverse command to run (RollbackInverseCommand)
+	Command string
+}
+
+// BackupFinder returns the path of the newest backup of a file taken at or before a time
+type BackupFinder func(path string, before time.Time) (string, error)
+
+// BackupReader reads a backup file
+type BackupReader func(path string) (string, error)
+
+// SelectRollback returns the actions to undo, most recent first.
+// target is empty (last action), a count ("3"), or an action ID, in which case
+// that action and every action after it are selected.
+func (m *Manager) SelectRollback(target string) ([]Action, error) {
+	actions := m.currentSession.Actions
+	if len(actions) == 0 {
+		return nil, fmt.Errorf("no actions to roll back")
+	}
+
+	start := len(actions) - 1
+	if target != "" {
+		start = -1
+		for i, action := range actions {
+			if action.ID == target {
+				start = i
+				break
+			}
+		}
+		if start < 0 {
+			count, err := strconv.Atoi(target)
+			if err != nil {
+				return nil, fmt.Errorf("action not found: %s", target)
+			}
+			if count <= 0 || count > len(actions) {
+				return nil, fmt.Errorf("invalid rollback count: %d (1-%d)", count, len(actions))
+			}
+			start = len(actions) - count
+		}
+	}
+
+	selected := make([]Action, 0, len(actions)-start)
+	for i := len(actions) - 1; i >= start; i-- {
+		selected = append(selected, actions[i])
+	}
+	return selected, nil
+}
+
+// RemoveAction removes an action from the current session once it has been undone
+func (m *Manager) RemoveAction(id string) error {
+	for i, action := range m.currentSession.Actions {
+		if action.ID == id {
+			m.currentSession.Actions = append(m.currentSession.Actions[:i], m.currentSession.Actions[i+1:]...)
+			m.currentSession.UpdatedAt = time.Now()
+			return nil
+		}
+	}
+	return fmt.Errorf("action not found: %s", id)
+}
+
+// PlanRollback decides how to undo an action. File contents come from the
+// recorded OldValue; when that is empty the files.Manager backup on disk is
+// used instead, so sessions saved before OldValue was reliable can still be restored.
+func PlanRollback(action Action, findBackup BackupFinder, readBackup BackupReader) RollbackStep {
+	step := RollbackStep{Action: action, Kind: RollbackUnsupported}
+
+	switch action.Type {
+	case ActionTypeCommand:
+		if action.InverseCommand != "" {
+			step.Kind = RollbackInverseCommand
+			step.Command = action.InverseCommand
+		}
+	case ActionTypeFile:
+		if action.Created {
+			step.Kind = RollbackDeleteFile
+			return step
+		}
+		step.Kind = RollbackRestoreFile
+		step.Content = action.OldValue
+		step.Source = "old_value"
+		if action.OldValue != "" || readBackup == nil {
+			return step
+		}
+
+		backupPath := action.BackupPath
+		if backupPath == "" && findBackup != nil {
+			backupPath, _ = findBackup(action.FilePath, action.Timestamp)
+		}
+		if backupPath == "" {
+			return step
+		}
+		if content, err := readBackup(backupPath); err == nil {
+			step.Content = content
+			step.Source = backupPath
+		}
+	}
+	return step
+}
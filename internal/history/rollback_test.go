@@ -0,0 +1,147 @@
+package history
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mainbong/storage_doctor/internal/filesystem"
+)
+
+func newRollbackManager(t *testing.T) *Manager {
+	t.Helper()
+	manager, err := NewManagerWithFS("/test/sessions", filesystem.NewMockFileSystem())
+	if err != nil {
+		t.Fatalf("NewManagerWithFS() failed: %v", err)
+	}
+	manager.currentSession.Actions = []Action{
+		{ID: "a1", Type: ActionTypeCommand, Command: "cmd1"},
+		{ID: "a2", Type: ActionTypeFile, FilePath: "/etc/app.conf", OldValue: "old", NewValue: "new"},
+		{ID: "a3", Type: ActionTypeCommand, Command: "cmd3", InverseCommand: "undo3"},
+	}
+	return manager
+}
+
+func TestSelectRollback(t *testing.T) {
+	manager := newRollbackManager(t)
+
+	tests := []struct {
+		target   string
+		expected []string
+	}{
+		{"", []string{"a3"}},
+		{"2", []string{"a3", "a2"}},
+		{"3", []string{"a3", "a2", "a1"}},
+		{"a2", []string{"a3", "a2"}},
+		{"a1", []string{"a3", "a2", "a1"}},
+	}
+
+	for _, tt := range tests {
+		actions, err := manager.SelectRollback(tt.target)
+		if err != nil {
+			t.Errorf("SelectRollback(%q) failed: %v", tt.target, err)
+			continue
+		}
+		var ids []string
+		for _, action := range actions {
+			ids = append(ids, action.ID)
+		}
+		if fmt.Sprint(ids) != fmt.Sprint(tt.expected) {
+			t.Errorf("SelectRollback(%q) = %v, want %v", tt.target, ids, tt.expected)
+		}
+	}
+
+	// Selecting does not modify the session
+	if len(manager.GetActions()) != 3 {
+		t.Errorf("Expected 3 actions after selection, got %d", len(manager.GetActions()))
+	}
+}
+
+func TestSelectRollback_Invalid(t *testing.T) {
+	manager := newRollbackManager(t)
+
+	for _, target := range []string{"0", "4", "-1", "missing"} {
+		if _, err := manager.SelectRollback(target); err == nil {
+			t.Errorf("SelectRollback(%q) expected error", target)
+		}
+	}
+
+	manager.NewSession("empty")
+	if _, err := manager.SelectRollback(""); err == nil {
+		t.Error("Expected error for empty session")
+	}
+}
+
+func TestRemoveAction(t *testing.T) {
+	manager := newRollbackManager(t)
+
+	if err := manager.RemoveAction("a2"); err != nil {
+		t.Fatalf("RemoveAction() failed: %v", err)
+	}
+	actions := manager.GetActions()
+	if len(actions) != 2 || actions[0].ID != "a1" || actions[1].ID != "a3" {
+		t.Errorf("Unexpected actions after removal: %v", actions)
+	}
+	if err := manager.RemoveAction("a2"); err == nil {
+		t.Error("Expected error removing missing action")
+	}
+}
+
+func TestAddActionsWithRollbackInfo(t *testing.T) {
+	manager, _ := NewManagerWithFS("/test/sessions", filesystem.NewMockFileSystem())
+
+	manager.AddCommandActionWithInverse("systemctl stop foo", "", "systemctl start foo")
+	manager.AddFileActionWithBackup("/etc/new.conf", "", "content", "", true)
+
+	actions := manager.GetActions()
+	if actions[0].InverseCommand != "systemctl start foo" {
+		t.Errorf("Expected inverse command to be recorded, got '%s'", actions[0].InverseCommand)
+	}
+	if !actions[1].Created {
+		t.Error("Expected created flag to be recorded")
+	}
+}
+
+func TestPlanRollback_Command(t *testing.T) {
+	step := PlanRollback(Action{Type: ActionTypeCommand, Command: "x", InverseCommand: "undo"}, nil, nil)
+	if step.Kind != RollbackInverseCommand || step.Command != "undo" {
+		t.Errorf("Unexpected step: %+v", step)
+	}
+
+	step = PlanRollback(Action{Type: ActionTypeCommand, Command: "x"}, nil, nil)
+	if step.Kind != RollbackUnsupported {
+		t.Errorf("Expected unsupported without inverse command, got %s", step.Kind)
+	}
+}
+
+func TestPlanRollback_File(t *testing.T) {
+	readBackup := func(path string) (string, error) {
+		if path == "/backups/app.conf.backup" {
+			return "from backup", nil
+		}
+		return "", fmt.Errorf("not found")
+	}
+	findBackup := func(path string, before time.Time) (string, error) {
+		return "/backups/app.conf.backup", nil
+	}
+
+	step := PlanRollback(Action{Type: ActionTypeFile, FilePath: "/etc/app.conf", OldValue: "old"}, findBackup, readBackup)
+	if step.Kind != RollbackRestoreFile || step.Content != "old" || step.Source != "old_value" {
+		t.Errorf("Expected restore from OldValue, got %+v", step)
+	}
+
+	step = PlanRollback(Action{Type: ActionTypeFile, FilePath: "/etc/app.conf"}, findBackup, readBackup)
+	if step.Content != "from backup" || step.Source != "/backups/app.conf.backup" {
+		t.Errorf("Expected restore from backup, got %+v", step)
+	}
+
+	step = PlanRollback(Action{Type: ActionTypeFile, FilePath: "/etc/app.conf", BackupPath: "/missing"}, findBackup, readBackup)
+	if step.Kind != RollbackRestoreFile || step.Content != "" || step.Source != "old_value" {
+		t.Errorf("Expected fallback to empty OldValue when backup is unreadable, got %+v", step)
+	}
+
+	step = PlanRollback(Action{Type: ActionTypeFile, FilePath: "/etc/new.conf", Created: true}, findBackup, readBackup)
+	if step.Kind != RollbackDeleteFile {
+		t.Errorf("Expected delete for created file, got %s", step.Kind)
+	}
+}
@@ -0,0 +1,93 @@
+package history
+
+import (
+	"fmt"
+
+	"github.com/mainbong/storage_doctor/internal/llm"
+)
+
+// UsageSummary is the token usage and API cost of a session
+type UsageSummary struct {
+	llm.Usage
+	Calls   int     `json:"calls"`
+	CostUSD float64 `json:"cost_usd"`
+	// UnpricedModels lists models without a known price; their tokens are not in CostUSD
+	UnpricedModels []string `json:"unpriced_models,omitempty"`
+}
+
+// add records one provider call served by model
+func (s *UsageSummary) add(model string, usage llm.Usage) {
+	s.Usage = s.Usage.Add(usage)
+	s.Calls++
+	if price, ok := llm.PriceFor(model); ok {
+		s.CostUSD += price.Cost(usage)
+		return
+	}
+	for _, unpriced := range s.UnpricedModels {
+		if unpriced == model {
+			return
+		}
+	}
+	s.UnpricedModels = append(s.UnpricedModels, model)
+}
+
+// CostText formats the cost, noting models whose cost is not included
+func (s UsageSummary) CostText() string {
+	text := fmt.Sprintf("$%.4f", s.CostUSD)
+	if len(s.UnpricedModels) > 0 {
+		text += fmt.Sprintf(" (가격 미상 모델 제외: %v)", s.UnpricedModels)
+	}
+	return text
+}
+
+// RecordUsage adds the usage of one provider call served by model to the current session
+func (m *Manager) RecordUsage(model string, usage llm.Usage) {
+	m.usageMu.Lock()
+	defer m.usageMu.Unlock()
+	m.currentSession.Usage.add(model, usage)
+}
+
+// Usage returns the token usage and cost of the current session
+func (m *Manager) Usage() UsageSummary {
+	m.usageMu.Lock()
+	defer m.usageMu.Unlock()
+	summary := m.currentSession.Usage
+	summary.UnpricedModels = append([]string(nil), summary.UnpricedModels...)
+	return summary
+}
+
+// Budget limits the tokens and API cost of a session. Zero fields are unlimited.
+type Budget struct {
+	MaxTokens  int
+	MaxCostUSD float64
+}
+
+// Enabled reports whether any limit is set
+func (b Budget) Enabled() bool {
+	return b.MaxTokens > 0 || b.MaxCostUSD > 0
+}
+
+// Extend raises the limits by another budget of the same size
+func (b Budget) Extend(step Budget) Budget {
+	return Budget{MaxTokens: b.MaxTokens + step.MaxTokens, MaxCostUSD: b.MaxCostUSD + step.MaxCostUSD}
+}
+
+// Check returns why sending nextInputTokens more to model would exceed the
+// budget, or "" if it would not
+func (b Budget) Check(summary UsageSummary, model string, nextInputTokens int) string {
+	if b.MaxTokens > 0 && summary.Total()+nextInputTokens > b.MaxTokens {
+		return fmt.Sprintf("세션 토큰 사용량 %d + 다음 호출 약 %d 토큰이 예산 %d 토큰을 초과합니다",
+			summary.Total(), nextInputTokens, b.MaxTokens)
+	}
+	if b.MaxCostUSD > 0 {
+		next := 0.0
+		if price, ok := llm.PriceFor(model); ok {
+			next = price.Cost(llm.Usage{InputTokens: nextInputTokens})
+		}
+		if summary.CostUSD+next > b.MaxCostUSD {
+			return fmt.Sprintf("세션 비용 $%.4f + 다음 호출 약 $%.4f가 예산 $%.2f를 초과합니다",
+				summary.CostUSD, next, b.MaxCostUSD)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,64 @@
+package history
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/mainbong/storage_doctor/internal/filesystem"
+	"github.com/mainbong/storage_doctor/internal/llm"
+)
+
+func TestRecordUsage(t *testing.T) {
+	mockFS := filesystem.NewMockFileSystem()
+	manager, _ := NewManagerWithFS("/test/sessions", mockFS)
+
+	manager.RecordUsage("claude-sonnet-4-5", llm.Usage{InputTokens: 1000000, OutputTokens: 100000})
+	manager.RecordUsage("qwen2.5-7b", llm.Usage{InputTokens: 500, OutputTokens: 50})
+	manager.RecordUsage("qwen2.5-7b", llm.Usage{InputTokens: 500, OutputTokens: 50})
+
+	usage := manager.Usage()
+	if usage.Calls != 3 || usage.InputTokens != 1001000 || usage.OutputTokens != 100100 {
+		t.Errorf("Unexpected totals: %+v", usage)
+	}
+	if math.Abs(usage.CostUSD-4.5) > 1e-9 {
+		t.Errorf("Expected $4.5 for priced calls, got $%v", usage.CostUSD)
+	}
+	if len(usage.UnpricedModels) != 1 || usage.UnpricedModels[0] != "qwen2.5-7b" {
+		t.Errorf("Expected qwen2.5-7b as unpriced model, got %v", usage.UnpricedModels)
+	}
+	if !strings.Contains(usage.CostText(), "qwen2.5-7b") {
+		t.Errorf("Expected cost text to mention unpriced model, got %s", usage.CostText())
+	}
+
+	manager.NewSession("next")
+	if usage := manager.Usage(); usage.Calls != 0 {
+		t.Errorf("Expected new session to start without usage, got %+v", usage)
+	}
+}
+
+func TestBudget_Check(t *testing.T) {
+	summary := UsageSummary{Usage: llm.Usage{InputTokens: 9000, OutputTokens: 500}, Calls: 2, CostUSD: 0.9}
+
+	if reason := (Budget{}).Check(summary, "claude-sonnet-4-5", 100000); reason != "" {
+		t.Errorf("Expected no limit for empty budget, got %s", reason)
+	}
+	if reason := (Budget{MaxTokens: 10000}).Check(summary, "claude-sonnet-4-5", 400); reason != "" {
+		t.Errorf("Expected call within token budget, got %s", reason)
+	}
+	if reason := (Budget{MaxTokens: 10000}).Check(summary, "claude-sonnet-4-5", 600); reason == "" {
+		t.Error("Expected token budget to be exceeded")
+	}
+	// 50k input tokens at $3/MTok is $0.15
+	if reason := (Budget{MaxCostUSD: 1}).Check(summary, "claude-sonnet-4-5", 50000); reason == "" {
+		t.Error("Expected cost budget to be exceeded")
+	}
+	if reason := (Budget{MaxCostUSD: 1}).Check(summary, "qwen2.5-7b", 50000); reason != "" {
+		t.Errorf("Expected unpriced model to add no cost, got %s", reason)
+	}
+
+	extended := Budget{MaxTokens: 10000, MaxCostUSD: 1}.Extend(Budget{MaxTokens: 10000, MaxCostUSD: 1})
+	if extended.MaxTokens != 20000 || extended.MaxCostUSD != 2 {
+		t.Errorf("Expected doubled budget, got %+v", extended)
+	}
+}
@@ -1,6 +1,13 @@
 package httpclient
 
-import "net/http"
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
 
 // HTTPClient abstracts HTTP client operations for testability
 type HTTPClient interface {
@@ -19,6 +26,77 @@ func NewDefaultHTTPClient() *DefaultHTTPClient {
 	}
 }
 
+// ErrNonPublicAddress is returned when a public-only client is asked to
+// connect to a loopback, private, link-local or unspecified address
+var ErrNonPublicAddress = errors.New("refusing to connect to non-public address")
+
+// NewPublicHTTPClient creates a DefaultHTTPClient that only connects to public
+// addresses. Every connection, including those of redirects, is checked after
+// DNS resolution, so URLs from untrusted pages cannot reach the node itself,
+// its private network or a cloud metadata endpoint. Proxies are not used since
+// they would resolve the host instead.
+func NewPublicHTTPClient() *DefaultHTTPClient {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   publicAddressOnly,
+	}
+	return &DefaultHTTPClient{
+		client: &http.Client{
+			Transport: &http.Transport{
+				DialContext:           dialer.DialContext,
+				ForceAttemptHTTP2:     true,
+				MaxIdleConns:          10,
+				IdleConnTimeout:       90 * time.Second,
+				TLSHandshakeTimeout:   10 * time.Second,
+				ExpectContinueTimeout: time.Second,
+			},
+		},
+	}
+}
+
+// publicAddressOnly is a dialer Control rejecting connections to non-public IPs
+func publicAddressOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !IsPublicIP(ip) {
+		return fmt.Errorf("%w: %s", ErrNonPublicAddress, host)
+	}
+	return nil
+}
+
+// nonPublicNetworks are reserved ranges the net.IP predicates do not cover
+var nonPublicNetworks = []*net.IPNet{
+	mustParseCIDR("0.0.0.0/8"),
+	mustParseCIDR("100.64.0.0/10"),
+	mustParseCIDR("192.0.0.0/24"),
+	mustParseCIDR("198.18.0.0/15"),
+}
+
+// IsPublicIP reports whether ip is a globally routable unicast address
+func IsPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return network
+}
+
 func (c *DefaultHTTPClient) Do(req *http.Request) (*http.Response, error) {
 	return c.client.Do(req)
 }
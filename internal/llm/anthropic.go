@@ -16,11 +16,16 @@ import (
 	"github.com/mainbong/storage_doctor/internal/logger"
 )
 
+// anthropicBaseURL is the public Anthropic API endpoint
+const anthropicBaseURL = "https://api.anthropic.com"
+
 type AnthropicProvider struct {
-	apiKey  string
-	model   string
-	client  httpclient.HTTPClient
-	limiter *RateLimiter
+	apiKey         string
+	model          string
+	baseURL        string
+	thinkingBudget int // Extended thinking budget in tokens; 0 disables thinking
+	client         httpclient.HTTPClient
+	limiter        *RateLimiter
 }
 
 // NewAnthropicProvider creates a new Anthropic provider
@@ -31,41 +36,80 @@ func NewAnthropicProvider(cfg *config.Config) *AnthropicProvider {
 // NewAnthropicProviderWithClient creates a new Anthropic provider with a custom HTTPClient (for testing)
 func NewAnthropicProviderWithClient(cfg *config.Config, client httpclient.HTTPClient) *AnthropicProvider {
 	tokensPerMinute, requestsPerMinute := defaultRateLimits("anthropic")
+	baseURL := cfg.Anthropic.BaseURL
+	if baseURL == "" {
+		baseURL = anthropicBaseURL
+	}
 	return &AnthropicProvider{
-		apiKey:  cfg.Anthropic.APIKey,
-		model:   cfg.Anthropic.Model,
-		client:  client,
-		limiter: NewRateLimiter(time.Minute, tokensPerMinute, requestsPerMinute),
+		apiKey:         cfg.Anthropic.APIKey,
+		model:          cfg.Anthropic.Model,
+		baseURL:        strings.TrimRight(baseURL, "/"),
+		thinkingBudget: cfg.Thinking.BudgetTokens,
+		client:         client,
+		limiter:        NewRateLimiter(time.Minute, tokensPerMinute, requestsPerMinute),
 	}
 }
 
 type anthropicRequest struct {
-	Model     string             `json:"model"`
-	MaxTokens int                `json:"max_tokens"`
-	Messages  []anthropicMessage `json:"messages"`
-	Stream    bool               `json:"stream"`
-	System    string             `json:"system,omitempty"`
-	Tools     []anthropicTool    `json:"tools,omitempty"`
+	Model     string                  `json:"model"`
+	MaxTokens int                     `json:"max_tokens"`
+	Messages  []anthropicMessage      `json:"messages"`
+	Stream    bool                    `json:"stream"`
+	System    []anthropicContentBlock `json:"system,omitempty"`
+	Tools     []anthropicTool         `json:"tools,omitempty"`
+	Thinking  *anthropicThinking      `json:"thinking,omitempty"`
+}
+
+type anthropicThinking struct {
+	Type         string `json:"type"` // "enabled"
+	BudgetTokens int    `json:"budget_tokens"`
 }
 
 type anthropicMessage struct {
 	Role    string                  `json:"role"`
 	Content []anthropicContentBlock `json:"content"`
+	Usage   *anthropicUsage         `json:"usage,omitempty"` // Only in message_start events
+}
+
+type anthropicUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
 }
 
 type anthropicContentBlock struct {
-	Type      string                 `json:"type"` // "text" or "tool_use"
-	Text      string                 `json:"text,omitempty"`
-	ID        string                 `json:"id,omitempty"`          // Tool use ID (in content_block)
-	ToolUseID string                 `json:"tool_use_id,omitempty"` // Legacy field
-	Name      string                 `json:"name,omitempty"`
-	Input     map[string]interface{} `json:"input,omitempty"`
+	Type      string      `json:"type"` // "text", "tool_use" or "tool_result"
+	Text      string      `json:"text,omitempty"`
+	ID        string      `json:"id,omitempty"`          // Tool use ID (in content_block)
+	ToolUseID string      `json:"tool_use_id,omitempty"` // Tool result reference (or legacy tool use ID)
+	Name      string      `json:"name,omitempty"`
+	Input     interface{} `json:"input,omitempty"`     // Tool use input object
+	Content   string      `json:"content,omitempty"`   // Tool result content
+	IsError   bool        `json:"is_error,omitempty"`  // Tool result error flag
+	Thinking  string      `json:"thinking,omitempty"`  // Thinking block text
+	Signature string      `json:"signature,omitempty"` // Thinking block signature
+	Data      string      `json:"data,omitempty"`      // Redacted thinking block data
+
+	CacheControl *anthropicCacheControl `json:"cache_control,omitempty"`
 }
 
 type anthropicTool struct {
 	Name        string      `json:"name"`
 	Description string      `json:"description"`
 	InputSchema interface{} `json:"input_schema"`
+
+	CacheControl *anthropicCacheControl `json:"cache_control,omitempty"`
+}
+
+// anthropicCacheControl marks the end of a prompt prefix Anthropic should cache.
+// The cache covers everything before the mark in tools, system, messages order.
+type anthropicCacheControl struct {
+	Type string `json:"type"`
+}
+
+func ephemeralCache() *anthropicCacheControl {
+	return &anthropicCacheControl{Type: "ephemeral"}
 }
 
 type anthropicStreamResponse struct {
@@ -73,11 +117,20 @@ type anthropicStreamResponse struct {
 	Delta        *anthropicDelta        `json:"delta,omitempty"`
 	ContentBlock *anthropicContentBlock `json:"content_block,omitempty"`
 	Message      *anthropicMessage      `json:"message,omitempty"`
+	Error        *anthropicError        `json:"error,omitempty"`
+	Usage        *anthropicUsage        `json:"usage,omitempty"` // Cumulative counts in message_delta events
+}
+
+type anthropicError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
 }
 
 type anthropicDelta struct {
-	Type        string                 `json:"type"` // "text_delta" or "input_json_delta"
+	Type        string                 `json:"type"` // "text_delta", "input_json_delta", "thinking_delta" or "signature_delta"
 	Text        string                 `json:"text,omitempty"`
+	Thinking    string                 `json:"thinking,omitempty"`
+	Signature   string                 `json:"signature,omitempty"`
 	PartialJSON string                 `json:"partial_json,omitempty"` // For input_json_delta
 	ToolUseID   string                 `json:"tool_use_id,omitempty"`
 	Name        string                 `json:"name,omitempty"`
@@ -98,33 +151,7 @@ func (p *AnthropicProvider) StreamChatWithTools(ctx context.Context, messages []
 	}
 
 	// Convert messages to Anthropic format
-	anthropicMessages := make([]anthropicMessage, 0, len(messages))
-	var systemPrompt string
-
-	for _, msg := range messages {
-		if msg.Role == "system" {
-			systemPrompt = msg.Content
-			continue
-		}
-
-		// Skip empty messages
-		if strings.TrimSpace(msg.Content) == "" {
-			continue
-		}
-
-		// Regular text message - Anthropic requires content array with text blocks
-		content := []anthropicContentBlock{
-			{
-				Type: "text",
-				Text: msg.Content,
-			},
-		}
-
-		anthropicMessages = append(anthropicMessages, anthropicMessage{
-			Role:    msg.Role,
-			Content: content,
-		})
-	}
+	anthropicMessages, systemPrompt := buildAnthropicMessages(messages)
 
 	// Ensure at least one message exists
 	if len(anthropicMessages) == 0 {
@@ -140,9 +167,18 @@ func (p *AnthropicProvider) StreamChatWithTools(ctx context.Context, messages []
 		Messages:  anthropicMessages,
 		Stream:    true,
 	}
-	if systemPrompt != "" {
+	if len(systemPrompt) > 0 {
 		reqBody.System = systemPrompt
 	}
+	if p.thinkingBudget > 0 {
+		if thinkingAllowed(anthropicMessages) {
+			// max_tokens covers the thinking budget as well as the answer
+			reqBody.Thinking = &anthropicThinking{Type: "enabled", BudgetTokens: p.thinkingBudget}
+			reqBody.MaxTokens += p.thinkingBudget
+		} else {
+			logger.Debug("이전 도구 호출에 thinking 블록이 없어 이번 요청은 extended thinking 없이 보냅니다")
+		}
+	}
 
 	// Add tools if provided
 	if tools != nil && len(tools) > 0 {
@@ -154,6 +190,8 @@ func (p *AnthropicProvider) StreamChatWithTools(ctx context.Context, messages []
 				InputSchema: tool.InputSchema,
 			})
 		}
+		// The tool definitions never change within a session
+		anthropicTools[len(anthropicTools)-1].CacheControl = ephemeralCache()
 		reqBody.Tools = anthropicTools
 	}
 
@@ -162,7 +200,7 @@ func (p *AnthropicProvider) StreamChatWithTools(ctx context.Context, messages []
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.anthropic.com/v1/messages", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/v1/messages", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -175,17 +213,19 @@ func (p *AnthropicProvider) StreamChatWithTools(ctx context.Context, messages []
 	if err != nil {
 		errorMsg := fmt.Sprintf("HTTP 요청 실패: %v", err)
 		logger.Error("%s", errorMsg)
-		return fmt.Errorf("HTTP 요청 실패: %w", err)
+		return &ConnectionError{Op: "HTTP 요청", Err: err}
 	}
 	defer resp.Body.Close()
 	updateLimiterFromHeaders(p.limiter, resp.Header, "anthropic-ratelimit-limit-tokens", "anthropic-ratelimit-limit-requests")
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		errorMsg := fmt.Sprintf("anthropic API error: HTTP %d\n응답 본문: %s", resp.StatusCode, string(body))
+		apiErr := newAPIError("anthropic", resp)
 		// Log detailed error for debugging
-		logger.Error("%s", errorMsg)
-		return fmt.Errorf("anthropic API error: HTTP %d - %s", resp.StatusCode, string(body))
+		logger.Error("anthropic API error: HTTP %d\n응답 본문: %s", apiErr.StatusCode, apiErr.Body)
+		if apiErr.StatusCode == http.StatusTooManyRequests {
+			p.limiter.Pause(apiErr.RetryAfter)
+		}
+		return apiErr
 	}
 
 	logger.Debug("Anthropic API 응답 수신: HTTP %d", resp.StatusCode)
@@ -204,6 +244,11 @@ func (p *AnthropicProvider) StreamChatWithTools(ctx context.Context, messages []
 	var currentTool *toolBuffer
 	var receivedChunks int
 	var receivedToolCalls int
+	var completed bool
+	var usage Usage
+	defer func() { reportUsage(ctx, p.model, usage) }()
+	var thinking []ThinkingBlock
+	var currentThinking *ThinkingBlock
 
 	finalizeTool := func(reason string) {
 		if currentTool == nil {
@@ -252,6 +297,7 @@ loop:
 			data := strings.TrimPrefix(line, "data: ")
 			if data == "[DONE]" {
 				logger.Debug("스트림 완료: Chunks=%d, ToolCalls=%d", receivedChunks, receivedToolCalls)
+				completed = true
 				break
 			}
 
@@ -273,6 +319,23 @@ loop:
 			}
 
 			switch streamResp.Type {
+			case "message_start":
+				if streamResp.Message != nil && streamResp.Message.Usage != nil {
+					u := streamResp.Message.Usage
+					usage = Usage{
+						InputTokens:              u.InputTokens,
+						OutputTokens:             u.OutputTokens,
+						CacheCreationInputTokens: u.CacheCreationInputTokens,
+						CacheReadInputTokens:     u.CacheReadInputTokens,
+					}
+				}
+			case "message_delta":
+				if streamResp.Usage != nil {
+					usage.OutputTokens = streamResp.Usage.OutputTokens
+					if streamResp.Usage.InputTokens > 0 {
+						usage.InputTokens = streamResp.Usage.InputTokens
+					}
+				}
 			case "content_block_start":
 				if streamResp.ContentBlock != nil {
 					if streamResp.ContentBlock.Type == "tool_use" {
@@ -281,9 +344,11 @@ loop:
 						}
 						// ID can be in either "id" or "tool_use_id" field
 						currentTool = &toolBuffer{
-							id:    streamResp.ContentBlock.ID,
-							name:  streamResp.ContentBlock.Name,
-							input: streamResp.ContentBlock.Input,
+							id:   streamResp.ContentBlock.ID,
+							name: streamResp.ContentBlock.Name,
+						}
+						if input, ok := streamResp.ContentBlock.Input.(map[string]interface{}); ok {
+							currentTool.input = input
 						}
 						if currentTool.id == "" {
 							currentTool.id = streamResp.ContentBlock.ToolUseID
@@ -297,6 +362,10 @@ loop:
 						}
 					} else if streamResp.ContentBlock.Type == "text" {
 						logger.Debug("Text content block 시작")
+					} else if streamResp.ContentBlock.Type == "thinking" {
+						currentThinking = &ThinkingBlock{Signature: streamResp.ContentBlock.Signature}
+					} else if streamResp.ContentBlock.Type == "redacted_thinking" {
+						thinking = append(thinking, ThinkingBlock{Redacted: streamResp.ContentBlock.Data})
 					} else {
 						logger.Debug("content_block_start: Type=%s", streamResp.ContentBlock.Type)
 					}
@@ -308,6 +377,16 @@ loop:
 					if streamResp.Delta.Type == "text_delta" && streamResp.Delta.Text != "" {
 						receivedChunks++
 						onChunk(streamResp.Delta.Text)
+					} else if streamResp.Delta.Type == "thinking_delta" || streamResp.Delta.Type == "signature_delta" {
+						if currentThinking == nil {
+							logger.Warn("Thinking delta 수신했지만 thinking 블록이 시작되지 않음")
+							continue
+						}
+						currentThinking.Thinking += streamResp.Delta.Thinking
+						if streamResp.Delta.Signature != "" {
+							currentThinking.Signature = streamResp.Delta.Signature
+						}
+						reportThinking(ctx, streamResp.Delta.Thinking)
 					} else if streamResp.Delta.Type == "input_json_delta" {
 						// Anthropic sends tool input as incremental JSON strings in partial_json
 						if streamResp.Delta.PartialJSON != "" {
@@ -360,15 +439,20 @@ loop:
 						currentTool.id, currentTool.name, currentTool.inputJSON.Len())
 				}
 				finalizeTool("content_block_stop")
+				if currentThinking != nil {
+					thinking = append(thinking, *currentThinking)
+					currentThinking = nil
+				}
 			case "message_stop":
 				logger.Debug("메시지 스트림 종료: Chunks=%d, ToolCalls=%d", receivedChunks, receivedToolCalls)
 				finalizeTool("message_stop")
+				completed = true
 				break loop
 			case "error":
-				// Handle error events from stream
+				// Handle error events from stream, e.g. overloaded_error after the response started
 				errorData, _ := json.Marshal(streamResp)
 				logger.Error("스트림 에러 이벤트: %s", string(errorData))
-				return fmt.Errorf("스트림에서 에러 이벤트 수신")
+				return anthropicStreamError(streamResp.Error)
 			}
 		}
 	}
@@ -376,8 +460,13 @@ loop:
 	if err := scanner.Err(); err != nil && err != io.EOF {
 		errorMsg := fmt.Sprintf("스트림 읽기 실패: %v", err)
 		logger.Error("%s", errorMsg)
-		return fmt.Errorf("스트림 읽기 실패: %w", err)
+		return &ConnectionError{Op: "스트림 읽기", Err: err}
 	}
+	if !completed {
+		logger.Error("스트림 읽기 실패: message_stop 이전에 연결 종료 (Chunks=%d, ToolCalls=%d)", receivedChunks, receivedToolCalls)
+		return &ConnectionError{Op: "스트림 읽기", Err: errStreamTruncated}
+	}
+	collectThinking(ctx, thinking)
 
 	// If we received no chunks and no tool calls, that's suspicious
 	if receivedChunks == 0 && receivedToolCalls == 0 {
@@ -387,6 +476,143 @@ loop:
 	return nil
 }
 
+// anthropicStreamError converts an error event received mid-stream into an APIError
+// with the status code the same error would have had before the stream started
+func anthropicStreamError(streamErr *anthropicError) *APIError {
+	if streamErr == nil {
+		return &APIError{Provider: "anthropic", StatusCode: http.StatusInternalServerError, Body: "스트림에서 에러 이벤트 수신"}
+	}
+	status := http.StatusBadRequest
+	switch streamErr.Type {
+	case "overloaded_error":
+		status = 529
+	case "rate_limit_error":
+		status = http.StatusTooManyRequests
+	case "api_error":
+		status = http.StatusInternalServerError
+	case "timeout_error":
+		status = http.StatusGatewayTimeout
+	}
+	return &APIError{Provider: "anthropic", StatusCode: status, Body: streamErr.Type + ": " + streamErr.Message}
+}
+
+// buildAnthropicMessages converts messages to the Anthropic format.
+// Tool calls become tool_use blocks on the assistant turn, preceded by the
+// turn's thinking blocks, and tool results become tool_result blocks on the
+// following user turn, keyed by ToolCall.ID. System messages become system
+// blocks, and cache breakpoints are placed after the main system prompt and
+// after the last message so the next agent iteration reads the whole
+// conversation so far from the cache.
+func buildAnthropicMessages(messages []Message) ([]anthropicMessage, []anthropicContentBlock) {
+	anthropicMessages := make([]anthropicMessage, 0, len(messages))
+	var systemPrompt []anthropicContentBlock
+
+	for _, msg := range messages {
+		if msg.Role == "system" {
+			// Summaries follow the main prompt as separate blocks so compaction
+			// does not invalidate the cached main prompt
+			if strings.TrimSpace(msg.Content) == "" {
+				continue
+			}
+			block := anthropicContentBlock{Type: "text", Text: msg.Content}
+			if len(systemPrompt) == 0 {
+				block.CacheControl = ephemeralCache()
+			}
+			systemPrompt = append(systemPrompt, block)
+			continue
+		}
+
+		role := msg.Role
+		var content []anthropicContentBlock
+
+		switch {
+		case len(msg.ToolResults) > 0:
+			// Anthropic carries tool results inside a user turn
+			role = "user"
+			for _, result := range msg.ToolResults {
+				content = append(content, anthropicContentBlock{
+					Type:      "tool_result",
+					ToolUseID: result.ToolCallID,
+					Content:   result.Content,
+					IsError:   result.IsError,
+				})
+			}
+		case len(msg.ToolCalls) > 0:
+			for _, block := range msg.Thinking {
+				if block.Redacted != "" {
+					content = append(content, anthropicContentBlock{Type: "redacted_thinking", Data: block.Redacted})
+					continue
+				}
+				content = append(content, anthropicContentBlock{Type: "thinking", Thinking: block.Thinking, Signature: block.Signature})
+			}
+			if strings.TrimSpace(msg.Content) != "" {
+				content = append(content, anthropicContentBlock{Type: "text", Text: msg.Content})
+			}
+			for _, call := range msg.ToolCalls {
+				input := call.Input
+				if input == nil {
+					input = make(map[string]interface{})
+				}
+				content = append(content, anthropicContentBlock{
+					Type:  "tool_use",
+					ID:    call.ID,
+					Name:  call.Name,
+					Input: input,
+				})
+			}
+		default:
+			// Skip empty messages
+			if strings.TrimSpace(msg.Content) == "" {
+				continue
+			}
+			// Regular text message - Anthropic requires content array with text blocks
+			content = []anthropicContentBlock{{Type: "text", Text: msg.Content}}
+		}
+
+		// Consecutive turns with the same role are merged into one message
+		if n := len(anthropicMessages); n > 0 && anthropicMessages[n-1].Role == role {
+			anthropicMessages[n-1].Content = append(anthropicMessages[n-1].Content, content...)
+			continue
+		}
+
+		anthropicMessages = append(anthropicMessages, anthropicMessage{
+			Role:    role,
+			Content: content,
+		})
+	}
+
+	if n := len(anthropicMessages); n > 0 {
+		content := anthropicMessages[n-1].Content
+		content[len(content)-1].CacheControl = ephemeralCache()
+	}
+
+	return anthropicMessages, systemPrompt
+}
+
+// thinkingAllowed reports whether extended thinking can be enabled for a
+// request. With thinking on, an assistant turn that issued tool calls must
+// start with its thinking blocks; turns produced without thinking (by a
+// fallback model, or before thinking was enabled) cannot be continued with it.
+func thinkingAllowed(messages []anthropicMessage) bool {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != "assistant" {
+			continue
+		}
+		content := messages[i].Content
+		hasToolUse := false
+		for _, block := range content {
+			if block.Type == "tool_use" {
+				hasToolUse = true
+			}
+		}
+		if !hasToolUse {
+			return true
+		}
+		return content[0].Type == "thinking" || content[0].Type == "redacted_thinking"
+	}
+	return true
+}
+
 func (p *AnthropicProvider) Chat(ctx context.Context, messages []Message) (string, error) {
 	var fullResponse strings.Builder
 
@@ -2,6 +2,8 @@ package llm
 
 import (
 	"context"
+	"encoding/json"
+	"io"
 	"strings"
 	"testing"
 
@@ -13,8 +15,9 @@ func TestAnthropicProvider_StreamChat(t *testing.T) {
 	mockClient := httpclient.NewMockHTTPClient()
 	cfg := &config.Config{
 		Anthropic: struct {
-			APIKey string `json:"api_key"`
-			Model  string `json:"model"`
+			APIKey  string `json:"api_key"`
+			Model   string `json:"model"`
+			BaseURL string `json:"base_url,omitempty"`
 		}{
 			APIKey: "test-key",
 			Model:  "claude-haiku-4-5-20251001",
@@ -55,8 +58,9 @@ data: {"type":"message_stop"}
 func TestAnthropicProvider_StreamChat_MissingAPIKey(t *testing.T) {
 	cfg := &config.Config{
 		Anthropic: struct {
-			APIKey string `json:"api_key"`
-			Model  string `json:"model"`
+			APIKey  string `json:"api_key"`
+			Model   string `json:"model"`
+			BaseURL string `json:"base_url,omitempty"`
 		}{
 			APIKey: "",
 			Model:  "claude-haiku-4-5-20251001",
@@ -77,8 +81,9 @@ func TestAnthropicProvider_StreamChat_MissingAPIKey(t *testing.T) {
 func TestAnthropicProvider_GetModel(t *testing.T) {
 	cfg := &config.Config{
 		Anthropic: struct {
-			APIKey string `json:"api_key"`
-			Model  string `json:"model"`
+			APIKey  string `json:"api_key"`
+			Model   string `json:"model"`
+			BaseURL string `json:"base_url,omitempty"`
 		}{
 			APIKey: "test-key",
 			Model:  "claude-haiku-4-5-20251001",
@@ -96,8 +101,9 @@ func TestAnthropicProvider_Chat(t *testing.T) {
 	mockClient := httpclient.NewMockHTTPClient()
 	cfg := &config.Config{
 		Anthropic: struct {
-			APIKey string `json:"api_key"`
-			Model  string `json:"model"`
+			APIKey  string `json:"api_key"`
+			Model   string `json:"model"`
+			BaseURL string `json:"base_url,omitempty"`
 		}{
 			APIKey: "test-key",
 			Model:  "claude-haiku-4-5-20251001",
@@ -135,8 +141,9 @@ func TestAnthropicProvider_StreamChatWithTools_ToolCall(t *testing.T) {
 	mockClient := httpclient.NewMockHTTPClient()
 	cfg := &config.Config{
 		Anthropic: struct {
-			APIKey string `json:"api_key"`
-			Model  string `json:"model"`
+			APIKey  string `json:"api_key"`
+			Model   string `json:"model"`
+			BaseURL string `json:"base_url,omitempty"`
 		}{
 			APIKey: "test-key",
 			Model:  "claude-haiku-4-5-20251001",
@@ -187,3 +194,257 @@ data: {"type":"message_stop"}
 		t.Errorf("Expected description 'list', got '%v'", toolCalls[0].Input["description"])
 	}
 }
+
+func TestBuildAnthropicMessages_ToolRoundTrip(t *testing.T) {
+	messages := []Message{
+		{Role: "system", Content: "system prompt"},
+		{Role: "user", Content: "check disks"},
+		{Role: "assistant", Content: "checking", ToolCalls: []ToolCall{
+			{ID: "toolu_1", Name: "execute_command", Input: map[string]interface{}{"command": "df -h"}},
+			{ID: "toolu_2", Name: "read_file", Input: map[string]interface{}{"path": "/etc/fstab"}},
+		}},
+		{Role: "tool", ToolResults: []ToolResult{
+			{ToolCallID: "toolu_1", Name: "execute_command", Content: "ok"},
+			{ToolCallID: "toolu_2", Name: "read_file", Content: "denied", IsError: true},
+		}},
+	}
+
+	result, system := buildAnthropicMessages(messages)
+
+	if len(system) != 1 || system[0].Text != "system prompt" {
+		t.Errorf("Expected system prompt, got %+v", system)
+	}
+	if len(result) != 3 {
+		t.Fatalf("Expected 3 messages, got %d", len(result))
+	}
+
+	assistant := result[1]
+	if assistant.Role != "assistant" || len(assistant.Content) != 3 {
+		t.Fatalf("Expected assistant message with text and 2 tool_use blocks, got %+v", assistant)
+	}
+	if assistant.Content[1].Type != "tool_use" || assistant.Content[1].ID != "toolu_1" {
+		t.Errorf("Expected tool_use block with ID 'toolu_1', got %+v", assistant.Content[1])
+	}
+
+	toolTurn := result[2]
+	if toolTurn.Role != "user" || len(toolTurn.Content) != 2 {
+		t.Fatalf("Expected user message with 2 tool_result blocks, got %+v", toolTurn)
+	}
+	if toolTurn.Content[0].Type != "tool_result" || toolTurn.Content[0].ToolUseID != "toolu_1" {
+		t.Errorf("Expected tool_result for 'toolu_1', got %+v", toolTurn.Content[0])
+	}
+	if !toolTurn.Content[1].IsError || toolTurn.Content[1].ToolUseID != "toolu_2" {
+		t.Errorf("Expected error tool_result for 'toolu_2', got %+v", toolTurn.Content[1])
+	}
+}
+
+func TestBuildAnthropicMessages_JoinsSystemMessages(t *testing.T) {
+	result, system := buildAnthropicMessages([]Message{
+		{Role: "system", Content: "system prompt"},
+		{Role: "system", Content: "Previous conversation summary: disk full"},
+		{Role: "user", Content: "continue"},
+	})
+
+	if len(system) != 2 || system[0].Text != "system prompt" || system[1].Text != "Previous conversation summary: disk full" {
+		t.Errorf("Expected both system messages as system blocks, got %+v", system)
+	}
+	if system[0].CacheControl == nil || system[1].CacheControl != nil {
+		t.Errorf("Expected only the main prompt to be cached, got %+v", system)
+	}
+	if len(result) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(result))
+	}
+}
+
+func TestBuildAnthropicMessages_EmptyToolInput(t *testing.T) {
+	result, _ := buildAnthropicMessages([]Message{
+		{Role: "user", Content: "hi"},
+		{Role: "assistant", ToolCalls: []ToolCall{{ID: "toolu_1", Name: "ask_user"}}},
+	})
+
+	data, err := json.Marshal(result[1])
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if !strings.Contains(string(data), `"input":{}`) {
+		t.Errorf("Expected empty input object to be serialized, got %s", string(data))
+	}
+}
+
+func TestAnthropicProvider_StreamChat_ReportsUsage(t *testing.T) {
+	mockClient := httpclient.NewMockHTTPClient()
+	cfg := &config.Config{}
+	cfg.Anthropic.APIKey = "test-key"
+	cfg.Anthropic.Model = "claude-sonnet-4-5"
+
+	streamData := `data: {"type":"message_start","message":{"id":"msg_123","usage":{"input_tokens":120,"cache_creation_input_tokens":30,"cache_read_input_tokens":400,"output_tokens":1}}}
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}
+data: {"type":"content_block_stop","index":0}
+data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":42}}
+data: {"type":"message_stop"}
+`
+	mockClient.SetResponse("https://api.anthropic.com/v1/messages", 200, streamData, map[string]string{
+		"Content-Type": "text/event-stream",
+	})
+	provider := NewAnthropicProviderWithClient(cfg, mockClient)
+
+	var model string
+	var usage Usage
+	ctx := WithUsageReporter(context.Background(), func(m string, u Usage) {
+		model, usage = m, u
+	})
+	if err := provider.StreamChat(ctx, []Message{{Role: "user", Content: "Hello"}}, func(string) {}); err != nil {
+		t.Fatalf("StreamChat() failed: %v", err)
+	}
+
+	want := Usage{InputTokens: 120, OutputTokens: 42, CacheCreationInputTokens: 30, CacheReadInputTokens: 400}
+	if model != "claude-sonnet-4-5" || usage != want {
+		t.Errorf("Expected %s %+v, got %s %+v", "claude-sonnet-4-5", want, model, usage)
+	}
+}
+
+func TestAnthropicProvider_CacheBreakpoints(t *testing.T) {
+	mockClient := httpclient.NewMockHTTPClient()
+	cfg := &config.Config{}
+	cfg.Anthropic.APIKey = "test-key"
+	cfg.Anthropic.Model = "claude-sonnet-4-5"
+	mockClient.SetResponse("https://api.anthropic.com/v1/messages", 200, "data: {\"type\":\"message_stop\"}\n", map[string]string{
+		"Content-Type": "text/event-stream",
+	})
+	provider := NewAnthropicProviderWithClient(cfg, mockClient)
+
+	err := provider.StreamChatWithTools(context.Background(), []Message{
+		{Role: "system", Content: "system prompt"},
+		{Role: "user", Content: "check disks"},
+		{Role: "assistant", Content: "checking", ToolCalls: []ToolCall{{ID: "toolu_1", Name: "read_file"}}},
+		{Role: "tool", ToolResults: []ToolResult{{ToolCallID: "toolu_1", Name: "read_file", Content: "ok"}}},
+	}, []Tool{
+		{Name: "read_file", Description: "read", InputSchema: map[string]interface{}{}},
+		{Name: "write_file", Description: "write", InputSchema: map[string]interface{}{}},
+	}, func(string) {}, nil)
+	if err != nil {
+		t.Fatalf("StreamChatWithTools() failed: %v", err)
+	}
+
+	body, _ := mockClient.GetRequests()[0].GetBody()
+	data, _ := io.ReadAll(body)
+	var sent anthropicRequest
+	if err := json.Unmarshal(data, &sent); err != nil {
+		t.Fatalf("Failed to decode request: %v", err)
+	}
+
+	if sent.Tools[0].CacheControl != nil || sent.Tools[1].CacheControl == nil || sent.Tools[1].CacheControl.Type != "ephemeral" {
+		t.Errorf("Expected a cache breakpoint on the last tool only, got %+v", sent.Tools)
+	}
+	if len(sent.System) != 1 || sent.System[0].CacheControl == nil {
+		t.Errorf("Expected a cached system block, got %+v", sent.System)
+	}
+	last := sent.Messages[len(sent.Messages)-1]
+	if last.Content[len(last.Content)-1].CacheControl == nil {
+		t.Errorf("Expected a cache breakpoint after the last message, got %+v", last)
+	}
+	if sent.Messages[0].Content[0].CacheControl != nil {
+		t.Errorf("Expected no cache breakpoint on earlier messages, got %+v", sent.Messages[0])
+	}
+}
+
+func TestAnthropicProvider_Thinking(t *testing.T) {
+	mockClient := httpclient.NewMockHTTPClient()
+	cfg := &config.Config{}
+	cfg.Anthropic.APIKey = "test-key"
+	cfg.Anthropic.Model = "claude-sonnet-4-5"
+	cfg.Thinking.BudgetTokens = 2048
+
+	streamData := `data: {"type":"message_start","message":{"id":"msg_123"}}
+data: {"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":"","signature":""}}
+data: {"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"Check the CSI "}}
+data: {"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"node plugin logs."}}
+data: {"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"sig-abc"}}
+data: {"type":"content_block_stop","index":0}
+data: {"type":"content_block_start","index":1,"content_block":{"type":"redacted_thinking","data":"encrypted"}}
+data: {"type":"content_block_stop","index":1}
+data: {"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"toolu_1","name":"execute_command","input":{}}}
+data: {"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"{\"command\":\"kubectl logs\"}"}}
+data: {"type":"content_block_stop","index":2}
+data: {"type":"message_stop"}
+`
+	mockClient.SetResponse("https://api.anthropic.com/v1/messages", 200, streamData, map[string]string{
+		"Content-Type": "text/event-stream",
+	})
+	provider := NewAnthropicProviderWithClient(cfg, mockClient)
+
+	var reasoning strings.Builder
+	var blocks []ThinkingBlock
+	ctx := WithThinkingReporter(context.Background(), func(delta string) {
+		reasoning.WriteString(delta)
+	})
+	ctx = WithThinkingCollector(ctx, func(block ThinkingBlock) {
+		blocks = append(blocks, block)
+	})
+	var toolCalls []ToolCall
+	err := provider.StreamChatWithTools(ctx, []Message{{Role: "user", Content: "why does attach fail?"}},
+		[]Tool{{Name: "execute_command", Description: "run", InputSchema: map[string]interface{}{}}},
+		func(string) {}, func(call ToolCall) { toolCalls = append(toolCalls, call) })
+	if err != nil {
+		t.Fatalf("StreamChatWithTools() failed: %v", err)
+	}
+
+	if reasoning.String() != "Check the CSI node plugin logs." {
+		t.Errorf("Expected streamed reasoning, got '%s'", reasoning.String())
+	}
+	want := []ThinkingBlock{{Thinking: "Check the CSI node plugin logs.", Signature: "sig-abc"}, {Redacted: "encrypted"}}
+	if len(blocks) != 2 || blocks[0] != want[0] || blocks[1] != want[1] {
+		t.Errorf("Expected %+v, got %+v", want, blocks)
+	}
+	if len(toolCalls) != 1 {
+		t.Fatalf("Expected 1 tool call, got %d", len(toolCalls))
+	}
+
+	body, _ := mockClient.GetRequests()[0].GetBody()
+	data, _ := io.ReadAll(body)
+	var sent anthropicRequest
+	if err := json.Unmarshal(data, &sent); err != nil {
+		t.Fatalf("Failed to decode request: %v", err)
+	}
+	if sent.Thinking == nil || sent.Thinking.Type != "enabled" || sent.Thinking.BudgetTokens != 2048 {
+		t.Errorf("Expected thinking with budget 2048, got %+v", sent.Thinking)
+	}
+	if sent.MaxTokens <= 2048 {
+		t.Errorf("Expected max_tokens above the thinking budget, got %d", sent.MaxTokens)
+	}
+}
+
+func TestBuildAnthropicMessages_PreservesThinking(t *testing.T) {
+	result, _ := buildAnthropicMessages([]Message{
+		{Role: "user", Content: "why does attach fail?"},
+		{Role: "assistant", Content: "checking", Thinking: []ThinkingBlock{
+			{Thinking: "look at logs", Signature: "sig-abc"},
+			{Redacted: "encrypted"},
+		}, ToolCalls: []ToolCall{{ID: "toolu_1", Name: "execute_command"}}},
+		{Role: "tool", ToolResults: []ToolResult{{ToolCallID: "toolu_1", Name: "execute_command", Content: "ok"}}},
+	})
+
+	content := result[1].Content
+	if len(content) != 4 {
+		t.Fatalf("Expected thinking, redacted thinking, text and tool_use blocks, got %+v", content)
+	}
+	if content[0].Type != "thinking" || content[0].Thinking != "look at logs" || content[0].Signature != "sig-abc" {
+		t.Errorf("Expected signed thinking block first, got %+v", content[0])
+	}
+	if content[1].Type != "redacted_thinking" || content[1].Data != "encrypted" {
+		t.Errorf("Expected redacted thinking block, got %+v", content[1])
+	}
+	if !thinkingAllowed(result) {
+		t.Error("Expected thinking to be allowed after a turn with thinking blocks")
+	}
+
+	withoutThinking, _ := buildAnthropicMessages([]Message{
+		{Role: "user", Content: "why does attach fail?"},
+		{Role: "assistant", ToolCalls: []ToolCall{{ID: "toolu_1", Name: "execute_command"}}},
+		{Role: "tool", ToolResults: []ToolResult{{ToolCallID: "toolu_1", Name: "execute_command", Content: "ok"}}},
+	})
+	if thinkingAllowed(withoutThinking) {
+		t.Error("Expected thinking to be disabled after a tool call without thinking blocks")
+	}
+}
@@ -0,0 +1,38 @@
+package llm
+
+import "strings"
+
+// DefaultContextWindow is used for models missing from the table below
+const DefaultContextWindow = 32768
+
+// contextWindows maps model name prefixes to context window sizes in tokens.
+// More specific prefixes must come first.
+var contextWindows = []struct {
+	prefix string
+	tokens int
+}{
+	{"claude-", 200000},
+	{"gpt-5", 400000},
+	{"gpt-4.1", 1047576},
+	{"gpt-4o", 128000},
+	{"gpt-4-turbo", 128000},
+	{"gpt-4-1106", 128000},
+	{"gpt-4-0125", 128000},
+	{"gpt-4-32k", 32768},
+	{"gpt-4", 8192},
+	{"gpt-3.5-turbo", 16385},
+	{"o1", 200000},
+	{"o3", 200000},
+	{"o4", 200000},
+}
+
+// ContextWindow returns the context window of a model in tokens
+func ContextWindow(model string) int {
+	model = strings.ToLower(strings.TrimSpace(model))
+	for _, entry := range contextWindows {
+		if strings.HasPrefix(model, entry.prefix) {
+			return entry.tokens
+		}
+	}
+	return DefaultContextWindow
+}
@@ -0,0 +1,24 @@
+package llm
+
+import "testing"
+
+func TestContextWindow(t *testing.T) {
+	tests := []struct {
+		model string
+		want  int
+	}{
+		{"claude-haiku-4-5-20251001", 200000},
+		{"gpt-5", 400000},
+		{"gpt-4-turbo-preview", 128000},
+		{"gpt-4-32k-0613", 32768},
+		{"gpt-4", 8192},
+		{"GPT-4o-mini", 128000},
+		{"llama3:8b", DefaultContextWindow},
+		{"", DefaultContextWindow},
+	}
+	for _, tt := range tests {
+		if got := ContextWindow(tt.model); got != tt.want {
+			t.Errorf("ContextWindow(%q) = %d, want %d", tt.model, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// APIError is a non-200 response from an LLM API
+type APIError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+	// RetryAfter is the wait requested by the retry-after headers, 0 if none
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s API error: HTTP %d - %s", e.Provider, e.StatusCode, e.Body)
+}
+
+// Temporary reports whether the request may succeed when sent again:
+// timeouts, conflicts, rate limits, overload (529) and server errors
+func (e *APIError) Temporary() bool {
+	switch e.StatusCode {
+	case http.StatusRequestTimeout, http.StatusConflict, http.StatusTooManyRequests:
+		return true
+	}
+	return e.StatusCode >= 500
+}
+
+// ConnectionError is a transport failure while sending a request or reading its stream
+type ConnectionError struct {
+	Op  string // The failed step, e.g. "HTTP 요청" or "스트림 읽기"
+	Err error
+}
+
+func (e *ConnectionError) Error() string {
+	return fmt.Sprintf("%s 실패: %v", e.Op, e.Err)
+}
+
+func (e *ConnectionError) Unwrap() error {
+	return e.Err
+}
+
+// errStreamTruncated marks a stream that ended without its completion event
+var errStreamTruncated = fmt.Errorf("스트림이 완료 이벤트 없이 종료되었습니다: %w", io.ErrUnexpectedEOF)
+
+// IsRetryable reports whether err is a transient provider failure worth retrying
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+	var connErr *ConnectionError
+	return errors.As(err, &connErr)
+}
+
+// newAPIError reads a failed response into an APIError
+func newAPIError(provider string, resp *http.Response) *APIError {
+	body, _ := io.ReadAll(resp.Body)
+	return &APIError{
+		Provider:   provider,
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+		RetryAfter: parseRetryAfter(resp.Header, time.Now()),
+	}
+}
+
+// parseRetryAfter reads retry-after-ms (OpenAI) or retry-after in seconds or as an HTTP date
+func parseRetryAfter(headers http.Header, now time.Time) time.Duration {
+	if value := strings.TrimSpace(headers.Get("retry-after-ms")); value != "" {
+		if ms, err := strconv.ParseFloat(value, 64); err == nil && ms > 0 {
+			return time.Duration(ms * float64(time.Millisecond))
+		}
+	}
+	value := strings.TrimSpace(headers.Get("retry-after"))
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
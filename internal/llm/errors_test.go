@@ -0,0 +1,133 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mainbong/storage_doctor/internal/config"
+	"github.com/mainbong/storage_doctor/internal/httpclient"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    time.Duration
+	}{
+		{"none", nil, 0},
+		{"seconds", map[string]string{"retry-after": "7"}, 7 * time.Second},
+		{"milliseconds first", map[string]string{"retry-after-ms": "1500", "retry-after": "2"}, 1500 * time.Millisecond},
+		{"http date", map[string]string{"retry-after": now.Add(30 * time.Second).Format(http.TimeFormat)}, 30 * time.Second},
+		{"invalid", map[string]string{"retry-after": "soon"}, 0},
+	}
+	for _, tt := range tests {
+		headers := http.Header{}
+		for k, v := range tt.headers {
+			headers.Set(k, v)
+		}
+		if got := parseRetryAfter(headers, now); got != tt.want {
+			t.Errorf("%s: parseRetryAfter() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&APIError{Provider: "anthropic", StatusCode: 529}, true},
+		{&APIError{Provider: "anthropic", StatusCode: 429}, true},
+		{&APIError{Provider: "openai", StatusCode: 503}, true},
+		{&APIError{Provider: "openai", StatusCode: 400}, false},
+		{&APIError{Provider: "openai", StatusCode: 401}, false},
+		{fmt.Errorf("wrapped: %w", &ConnectionError{Op: "HTTP 요청", Err: errors.New("connection reset")}), true},
+		{&ConnectionError{Op: "HTTP 요청", Err: context.Canceled}, false},
+		{errors.New("anthropic API key not set"), false},
+	}
+	for _, tt := range tests {
+		if got := IsRetryable(tt.err); got != tt.want {
+			t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func anthropicTestConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.Anthropic.APIKey = "test-key"
+	cfg.Anthropic.Model = "claude-haiku-4-5-20251001"
+	return cfg
+}
+
+func TestAnthropicProvider_OverloadedReturnsAPIError(t *testing.T) {
+	mockClient := httpclient.NewMockHTTPClient()
+	mockClient.SetResponse("https://api.anthropic.com/v1/messages", 529, `{"type":"error","error":{"type":"overloaded_error"}}`, map[string]string{
+		"retry-after": "3",
+	})
+	provider := NewAnthropicProviderWithClient(anthropicTestConfig(), mockClient)
+
+	err := provider.StreamChat(context.Background(), []Message{{Role: "user", Content: "hi"}}, func(string) {})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected APIError, got %v", err)
+	}
+	if apiErr.StatusCode != 529 || apiErr.RetryAfter != 3*time.Second {
+		t.Errorf("Expected 529 with 3s retry-after, got %d / %v", apiErr.StatusCode, apiErr.RetryAfter)
+	}
+	if !IsRetryable(err) {
+		t.Error("Expected overloaded error to be retryable")
+	}
+}
+
+func TestAnthropicProvider_TruncatedStream(t *testing.T) {
+	mockClient := httpclient.NewMockHTTPClient()
+	mockClient.SetResponse("https://api.anthropic.com/v1/messages", 200, `data: {"type":"message_start","message":{"id":"msg_123"}}
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hel"}}
+`, nil)
+	provider := NewAnthropicProviderWithClient(anthropicTestConfig(), mockClient)
+
+	err := provider.StreamChat(context.Background(), []Message{{Role: "user", Content: "hi"}}, func(string) {})
+	var connErr *ConnectionError
+	if !errors.As(err, &connErr) {
+		t.Fatalf("Expected ConnectionError for a stream without message_stop, got %v", err)
+	}
+}
+
+func TestAnthropicProvider_StreamErrorEvent(t *testing.T) {
+	mockClient := httpclient.NewMockHTTPClient()
+	mockClient.SetResponse("https://api.anthropic.com/v1/messages", 200, `data: {"type":"message_start","message":{"id":"msg_123"}}
+data: {"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}
+`, nil)
+	provider := NewAnthropicProviderWithClient(anthropicTestConfig(), mockClient)
+
+	err := provider.StreamChat(context.Background(), []Message{{Role: "user", Content: "hi"}}, func(string) {})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 529 {
+		t.Fatalf("Expected overloaded APIError, got %v", err)
+	}
+}
+
+func TestOpenAIProvider_RateLimitedPausesLimiter(t *testing.T) {
+	mockClient := httpclient.NewMockHTTPClient()
+	mockClient.SetResponse("https://api.openai.com/v1/chat/completions", 429, `{"error":{"message":"Rate limit reached"}}`, map[string]string{
+		"retry-after-ms": "250",
+	})
+	cfg := &config.Config{}
+	cfg.OpenAI.APIKey = "test-key"
+	cfg.OpenAI.Model = "gpt-4o"
+	provider := NewOpenAIProviderWithClient(cfg, mockClient)
+
+	err := provider.StreamChat(context.Background(), []Message{{Role: "user", Content: "hi"}}, func(string) {})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 429 {
+		t.Fatalf("Expected 429 APIError, got %v", err)
+	}
+	if wait := provider.limiter.reserve(0); wait <= 0 || wait > 250*time.Millisecond {
+		t.Errorf("Expected limiter paused for up to 250ms, got %v", wait)
+	}
+}
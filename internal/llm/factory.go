@@ -4,17 +4,48 @@ import (
 	"fmt"
 
 	"github.com/mainbong/storage_doctor/internal/config"
+	"github.com/mainbong/storage_doctor/internal/httpclient"
 )
 
 // NewProvider creates a new LLM provider based on configuration
 func NewProvider(cfg *config.Config) (Provider, error) {
+	return NewProviderWithClient(cfg, httpclient.NewDefaultHTTPClient())
+}
+
+// NewProviderWithClient creates the configured LLM provider with a custom HTTPClient
+func NewProviderWithClient(cfg *config.Config, client httpclient.HTTPClient) (Provider, error) {
 	switch cfg.LLMProvider {
 	case "anthropic":
-		return NewAnthropicProvider(cfg), nil
+		return NewAnthropicProviderWithClient(cfg, client), nil
 	case "openai":
-		return NewOpenAIProvider(cfg), nil
+		return NewOpenAIProviderWithClient(cfg, client), nil
+	case "ollama":
+		return NewOllamaProviderWithClient(cfg, client), nil
+	case "openai_compatible":
+		return NewOpenAICompatibleProviderWithClient(cfg, client), nil
 	default:
 		return nil, fmt.Errorf("unknown LLM provider: %s", cfg.LLMProvider)
 	}
 }
 
+// NewFallbackProvider creates the provider configured under fallback, or nil when failover is disabled
+func NewFallbackProvider(cfg *config.Config) (Provider, error) {
+	if cfg.Fallback.Provider == "" {
+		return nil, nil
+	}
+	fallbackCfg := *cfg
+	fallbackCfg.LLMProvider = cfg.Fallback.Provider
+	if model := cfg.Fallback.Model; model != "" {
+		switch cfg.Fallback.Provider {
+		case "anthropic":
+			fallbackCfg.Anthropic.Model = model
+		case "openai":
+			fallbackCfg.OpenAI.Model = model
+		case "ollama":
+			fallbackCfg.Ollama.Model = model
+		case "openai_compatible":
+			fallbackCfg.OpenAICompatible.Model = model
+		}
+	}
+	return NewProvider(&fallbackCfg)
+}
@@ -12,6 +12,8 @@ type MockProvider struct {
 	toolCalls       []ToolCall
 	chatError       error
 	streamError     error
+	usage           Usage
+	thinking        []ThinkingBlock
 	onStreamChat    func(ctx context.Context, messages []Message, tools []Tool, onChunk func(string), onToolCall func(ToolCall)) error
 	onStreamChatWithTools func(ctx context.Context, messages []Message, tools []Tool, onChunk func(string), onToolCall func(ToolCall)) error
 }
@@ -25,6 +27,11 @@ func NewMockProvider() *MockProvider {
 	}
 }
 
+// SetModel sets the model name returned by GetModel()
+func (m *MockProvider) SetModel(model string) {
+	m.model = model
+}
+
 // SetChatResponse sets the response for Chat() calls
 func (m *MockProvider) SetChatResponse(response string) {
 	m.chatResponse = response
@@ -50,6 +57,16 @@ func (m *MockProvider) SetStreamError(err error) {
 	m.streamError = err
 }
 
+// SetUsage sets the usage reported for each StreamChatWithTools() call
+func (m *MockProvider) SetUsage(usage Usage) {
+	m.usage = usage
+}
+
+// SetThinking sets the thinking blocks collected for each StreamChatWithTools() call
+func (m *MockProvider) SetThinking(blocks []ThinkingBlock) {
+	m.thinking = blocks
+}
+
 // SetOnStreamChat sets a custom handler for StreamChat
 func (m *MockProvider) SetOnStreamChat(handler func(ctx context.Context, messages []Message, tools []Tool, onChunk func(string), onToolCall func(ToolCall)) error) {
 	m.onStreamChatWithTools = handler
@@ -82,6 +99,8 @@ func (m *MockProvider) StreamChatWithTools(ctx context.Context, messages []Messa
 		}
 	}
 
+	collectThinking(ctx, m.thinking)
+	reportUsage(ctx, m.model, m.usage)
 	return nil
 }
 
@@ -4,10 +4,12 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
@@ -15,13 +17,26 @@ import (
 	"github.com/mainbong/storage_doctor/internal/httpclient"
 )
 
+// openaiBaseURL is the public OpenAI API endpoint
+const openaiBaseURL = "https://api.openai.com/v1"
+
+// OpenAIProvider talks to the OpenAI chat completions API or a server compatible with it
 type OpenAIProvider struct {
-	apiKey  string
-	model   string
-	client  httpclient.HTTPClient
-	limiter *RateLimiter
+	name        string // Provider name used in errors and rate limits
+	apiKey      string
+	model       string
+	baseURL     string
+	keyRequired bool
+	nativeTools bool
+	// reasoningEffort is sent to reasoning models; empty leaves the model default
+	reasoningEffort string
+	client          httpclient.HTTPClient
+	limiter         *RateLimiter
 }
 
+// reasoningMaxCompletionTokens bounds reasoning plus answer tokens when a reasoning effort is set
+const reasoningMaxCompletionTokens = 16384
+
 // NewOpenAIProvider creates a new OpenAI provider
 func NewOpenAIProvider(cfg *config.Config) *OpenAIProvider {
 	return NewOpenAIProviderWithClient(cfg, httpclient.NewDefaultHTTPClient())
@@ -29,26 +44,58 @@ func NewOpenAIProvider(cfg *config.Config) *OpenAIProvider {
 
 // NewOpenAIProviderWithClient creates a new OpenAI provider with a custom HTTPClient (for testing)
 func NewOpenAIProviderWithClient(cfg *config.Config, client httpclient.HTTPClient) *OpenAIProvider {
-	tokensPerMinute, requestsPerMinute := defaultRateLimits("openai")
+	baseURL := cfg.OpenAI.BaseURL
+	if baseURL == "" {
+		baseURL = openaiBaseURL
+	}
+	provider := newOpenAIProvider("openai", baseURL, cfg.OpenAI.APIKey, cfg.OpenAI.Model, true, client)
+	provider.keyRequired = true
+	provider.reasoningEffort = cfg.Thinking.ReasoningEffort
+	return provider
+}
+
+func newOpenAIProvider(name, baseURL, apiKey, model string, nativeTools bool, client httpclient.HTTPClient) *OpenAIProvider {
+	tokensPerMinute, requestsPerMinute := defaultRateLimits(name)
 	return &OpenAIProvider{
-		apiKey:  cfg.OpenAI.APIKey,
-		model:   cfg.OpenAI.Model,
-		client:  client,
-		limiter: NewRateLimiter(time.Minute, tokensPerMinute, requestsPerMinute),
+		name:        name,
+		apiKey:      apiKey,
+		model:       model,
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		nativeTools: nativeTools,
+		client:      client,
+		limiter:     NewRateLimiter(time.Minute, tokensPerMinute, requestsPerMinute),
 	}
 }
 
+// SupportsNativeTools reports whether tool definitions are sent to the model
+func (p *OpenAIProvider) SupportsNativeTools() bool {
+	return p.nativeTools
+}
+
 type openaiRequest struct {
 	Model     string          `json:"model"`
 	Messages  []openaiMessage `json:"messages"`
 	Stream    bool            `json:"stream"`
 	MaxTokens int             `json:"max_tokens,omitempty"`
-	Tools     []openaiTool    `json:"tools,omitempty"`
+	// Reasoning models count reasoning tokens against max_completion_tokens and reject max_tokens
+	MaxCompletionTokens int          `json:"max_completion_tokens,omitempty"`
+	ReasoningEffort     string       `json:"reasoning_effort,omitempty"`
+	Tools               []openaiTool `json:"tools,omitempty"`
+	// StreamOptions asks for a final chunk carrying the token usage
+	StreamOptions *openaiStreamOptions `json:"stream_options,omitempty"`
+	// PromptCacheKey routes requests sharing a prefix to the same prompt cache
+	PromptCacheKey string `json:"prompt_cache_key,omitempty"`
+}
+
+type openaiStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
 }
 
 type openaiMessage struct {
-	Role    string      `json:"role"`
-	Content interface{} `json:"content"` // string or []openaiContentBlock
+	Role       string           `json:"role"`
+	Content    interface{}      `json:"content"` // string or []openaiContentBlock
+	ToolCalls  []openaiToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
 }
 
 type openaiContentBlock struct {
@@ -81,15 +128,38 @@ type openaiToolFunction struct {
 
 type openaiStreamResponse struct {
 	Choices []openaiChoice `json:"choices"`
+	Usage   *openaiUsage   `json:"usage,omitempty"`
+}
+
+type openaiUsage struct {
+	PromptTokens        int `json:"prompt_tokens"`
+	CompletionTokens    int `json:"completion_tokens"`
+	PromptTokensDetails *struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"prompt_tokens_details,omitempty"`
+}
+
+// toUsage converts OpenAI counts, where cached tokens are part of the prompt tokens
+func (u *openaiUsage) toUsage() Usage {
+	usage := Usage{InputTokens: u.PromptTokens, OutputTokens: u.CompletionTokens}
+	if u.PromptTokensDetails != nil {
+		usage.CacheReadInputTokens = u.PromptTokensDetails.CachedTokens
+		usage.InputTokens -= usage.CacheReadInputTokens
+	}
+	return usage
 }
 
 type openaiChoice struct {
-	Delta openaiDelta `json:"delta"`
+	Delta        openaiDelta `json:"delta"`
+	FinishReason string      `json:"finish_reason,omitempty"`
 }
 
 type openaiDelta struct {
 	Content   string                `json:"content"`
 	ToolCalls []openaiToolCallDelta `json:"tool_calls,omitempty"`
+	// Reasoning text streamed by servers that expose it (vLLM, Ollama, DeepSeek)
+	ReasoningContent string `json:"reasoning_content,omitempty"`
+	Reasoning        string `json:"reasoning,omitempty"`
 }
 
 type openaiToolCallDelta struct {
@@ -109,40 +179,34 @@ func (p *OpenAIProvider) StreamChat(ctx context.Context, messages []Message, onC
 }
 
 func (p *OpenAIProvider) StreamChatWithTools(ctx context.Context, messages []Message, tools []Tool, onChunk func(string), onToolCall func(ToolCall)) error {
-	if p.apiKey == "" {
-		return fmt.Errorf("openai API key not set")
+	if p.keyRequired && p.apiKey == "" {
+		return fmt.Errorf("%s API key not set", p.name)
+	}
+	if p.baseURL == "" {
+		return fmt.Errorf("%s base_url not set", p.name)
 	}
 	if err := p.limiter.Wait(ctx, EstimateTokens(messages)); err != nil {
 		return fmt.Errorf("rate limit 대기 실패: %w", err)
 	}
 
 	// Convert messages to OpenAI format
-	openaiMessages := make([]openaiMessage, 0, len(messages))
-	for _, msg := range messages {
-		// Check if message contains tool results
-		if strings.Contains(msg.Content, "<tool_result") {
-			// For tool results, keep as text
-			openaiMessages = append(openaiMessages, openaiMessage{
-				Role:    msg.Role,
-				Content: msg.Content,
-			})
-		} else {
-			openaiMessages = append(openaiMessages, openaiMessage{
-				Role:    msg.Role,
-				Content: msg.Content,
-			})
-		}
-	}
+	openaiMessages := buildOpenAIMessages(messages)
 
 	reqBody := openaiRequest{
-		Model:     p.model,
-		Messages:  openaiMessages,
-		Stream:    true,
-		MaxTokens: 4096,
+		Model:         p.model,
+		Messages:      openaiMessages,
+		Stream:        true,
+		MaxTokens:     4096,
+		StreamOptions: &openaiStreamOptions{IncludeUsage: true},
+	}
+	if p.reasoningEffort != "" {
+		reqBody.MaxTokens = 0
+		reqBody.MaxCompletionTokens = reasoningMaxCompletionTokens
+		reqBody.ReasoningEffort = p.reasoningEffort
 	}
 
-	// Add tools if provided
-	if tools != nil && len(tools) > 0 {
+	// Add tools if provided and the model can use them
+	if p.nativeTools && len(tools) > 0 {
 		openaiTools := make([]openaiTool, 0, len(tools))
 		for _, tool := range tools {
 			openaiTools = append(openaiTools, openaiTool{
@@ -156,36 +220,49 @@ func (p *OpenAIProvider) StreamChatWithTools(ctx context.Context, messages []Mes
 		}
 		reqBody.Tools = openaiTools
 	}
+	// OpenAI caches long prompt prefixes automatically; compatible servers may
+	// reject fields they do not know
+	if p.name == "openai" {
+		reqBody.PromptCacheKey = promptCacheKey(messages, reqBody.Tools)
+	}
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/chat/completions", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
 
 	resp, err := p.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
+		return &ConnectionError{Op: "HTTP 요청", Err: err}
 	}
 	defer resp.Body.Close()
 	updateLimiterFromHeaders(p.limiter, resp.Header, "x-ratelimit-limit-tokens", "x-ratelimit-limit-requests")
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("openai API error: %d - %s", resp.StatusCode, string(body))
+		apiErr := newAPIError(p.name, resp)
+		if apiErr.StatusCode == http.StatusTooManyRequests {
+			p.limiter.Pause(apiErr.RetryAfter)
+		}
+		return apiErr
 	}
 
 	// Parse SSE stream
 	scanner := bufio.NewScanner(resp.Body)
 	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
 	var currentToolCalls map[int]*ToolCall = make(map[int]*ToolCall)
+	var completed bool
+	var usage Usage
+	defer func() { reportUsage(ctx, p.model, usage) }()
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -197,6 +274,7 @@ func (p *OpenAIProvider) StreamChatWithTools(ctx context.Context, messages []Mes
 		if strings.HasPrefix(line, "data: ") {
 			data := strings.TrimPrefix(line, "data: ")
 			if data == "[DONE]" {
+				completed = true
 				break
 			}
 
@@ -204,9 +282,17 @@ func (p *OpenAIProvider) StreamChatWithTools(ctx context.Context, messages []Mes
 			if err := json.Unmarshal([]byte(data), &streamResp); err != nil {
 				continue
 			}
+			if streamResp.Usage != nil {
+				usage = streamResp.Usage.toUsage()
+			}
 
 			if len(streamResp.Choices) > 0 {
 				delta := streamResp.Choices[0].Delta
+				if streamResp.Choices[0].FinishReason != "" {
+					completed = true
+				}
+
+				reportThinking(ctx, delta.ReasoningContent+delta.Reasoning)
 
 				// Handle text content
 				if delta.Content != "" {
@@ -255,20 +341,94 @@ func (p *OpenAIProvider) StreamChatWithTools(ctx context.Context, messages []Mes
 		}
 	}
 
-	// Process any remaining tool calls
+	// A broken stream must not emit half-received tool calls
+	if err := scanner.Err(); err != nil {
+		return &ConnectionError{Op: "스트림 읽기", Err: err}
+	}
+	if !completed {
+		return &ConnectionError{Op: "스트림 읽기", Err: errStreamTruncated}
+	}
+
+	// Process any remaining tool calls in the order the model issued them
 	if onToolCall != nil {
-		for _, toolCall := range currentToolCalls {
+		indexes := make([]int, 0, len(currentToolCalls))
+		for idx := range currentToolCalls {
+			indexes = append(indexes, idx)
+		}
+		sort.Ints(indexes)
+		for _, idx := range indexes {
+			toolCall := currentToolCalls[idx]
 			if toolCall.ID != "" && toolCall.Name != "" {
 				onToolCall(*toolCall)
 			}
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("failed to read stream: %w", err)
+	return nil
+}
+
+// buildOpenAIMessages converts messages to the OpenAI format.
+// Tool calls are sent as assistant tool_calls and each tool result becomes a
+// separate "tool" role message referencing the originating call ID.
+// promptCacheKey identifies the static prefix of a request: the main system
+// prompt and the tool definitions
+func promptCacheKey(messages []Message, tools []openaiTool) string {
+	hash := sha256.New()
+	if len(messages) > 0 && messages[0].Role == "system" {
+		hash.Write([]byte(messages[0].Content))
+	}
+	for _, tool := range tools {
+		hash.Write([]byte{0})
+		hash.Write([]byte(tool.Function.Name))
 	}
+	return "storage-doctor-" + hex.EncodeToString(hash.Sum(nil))[:16]
+}
 
-	return nil
+func buildOpenAIMessages(messages []Message) []openaiMessage {
+	openaiMessages := make([]openaiMessage, 0, len(messages))
+	for _, msg := range messages {
+		switch {
+		case len(msg.ToolResults) > 0:
+			for _, result := range msg.ToolResults {
+				openaiMessages = append(openaiMessages, openaiMessage{
+					Role:       "tool",
+					Content:    result.Content,
+					ToolCallID: result.ToolCallID,
+				})
+			}
+		case len(msg.ToolCalls) > 0:
+			toolCalls := make([]openaiToolCall, 0, len(msg.ToolCalls))
+			for _, call := range msg.ToolCalls {
+				args, err := json.Marshal(call.Input)
+				if err != nil || call.Input == nil {
+					args = []byte("{}")
+				}
+				toolCalls = append(toolCalls, openaiToolCall{
+					ID:   call.ID,
+					Type: "function",
+					Function: openaiFunction{
+						Name:      call.Name,
+						Arguments: string(args),
+					},
+				})
+			}
+			var content interface{}
+			if msg.Content != "" {
+				content = msg.Content
+			}
+			openaiMessages = append(openaiMessages, openaiMessage{
+				Role:      "assistant",
+				Content:   content,
+				ToolCalls: toolCalls,
+			})
+		default:
+			openaiMessages = append(openaiMessages, openaiMessage{
+				Role:    msg.Role,
+				Content: msg.Content,
+			})
+		}
+	}
+	return openaiMessages
 }
 
 func (p *OpenAIProvider) Chat(ctx context.Context, messages []Message) (string, error) {
@@ -0,0 +1,39 @@
+package llm
+
+import (
+	"github.com/mainbong/storage_doctor/internal/config"
+	"github.com/mainbong/storage_doctor/internal/httpclient"
+)
+
+// ollamaBaseURL is Ollama's OpenAI-compatible endpoint on the local machine
+const ollamaBaseURL = "http://localhost:11434/v1"
+
+// NewOllamaProvider creates a provider for a local Ollama server
+func NewOllamaProvider(cfg *config.Config) *OpenAIProvider {
+	return NewOllamaProviderWithClient(cfg, httpclient.NewDefaultHTTPClient())
+}
+
+// NewOllamaProviderWithClient creates an Ollama provider with a custom HTTPClient (for testing)
+func NewOllamaProviderWithClient(cfg *config.Config, client httpclient.HTTPClient) *OpenAIProvider {
+	baseURL := cfg.Ollama.BaseURL
+	if baseURL == "" {
+		baseURL = ollamaBaseURL
+	}
+	provider := newOpenAIProvider("ollama", baseURL, "", cfg.Ollama.Model, cfg.Ollama.ToolMode != config.ToolModeText, client)
+	provider.reasoningEffort = cfg.Thinking.ReasoningEffort
+	return provider
+}
+
+// NewOpenAICompatibleProvider creates a provider for a server implementing the
+// OpenAI chat completions API, such as vLLM, llama.cpp server or LM Studio
+func NewOpenAICompatibleProvider(cfg *config.Config) *OpenAIProvider {
+	return NewOpenAICompatibleProviderWithClient(cfg, httpclient.NewDefaultHTTPClient())
+}
+
+// NewOpenAICompatibleProviderWithClient creates an OpenAI-compatible provider with a custom HTTPClient (for testing)
+func NewOpenAICompatibleProviderWithClient(cfg *config.Config, client httpclient.HTTPClient) *OpenAIProvider {
+	c := cfg.OpenAICompatible
+	provider := newOpenAIProvider("openai_compatible", c.BaseURL, c.APIKey, c.Model, c.ToolMode != config.ToolModeText, client)
+	provider.reasoningEffort = cfg.Thinking.ReasoningEffort
+	return provider
+}
@@ -0,0 +1,112 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/mainbong/storage_doctor/internal/config"
+	"github.com/mainbong/storage_doctor/internal/httpclient"
+)
+
+const helloStream = `data: {"choices":[{"delta":{"content":"Hello"}}]}
+data: [DONE]
+`
+
+func TestOllamaProvider_DefaultBaseURL(t *testing.T) {
+	mockClient := httpclient.NewMockHTTPClient()
+	mockClient.SetResponse("http://localhost:11434/v1/chat/completions", 200, helloStream, nil)
+	cfg := &config.Config{}
+	cfg.Ollama.Model = "llama3.1"
+
+	provider := NewOllamaProviderWithClient(cfg, mockClient)
+	response, err := provider.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}})
+	if err != nil {
+		t.Fatalf("Chat() failed without an API key: %v", err)
+	}
+	if response != "Hello" {
+		t.Errorf("Expected 'Hello', got '%s'", response)
+	}
+	if auth := mockClient.GetRequests()[0].Header.Get("Authorization"); auth != "" {
+		t.Errorf("Expected no Authorization header, got '%s'", auth)
+	}
+	if !provider.SupportsNativeTools() {
+		t.Error("Expected native tools by default")
+	}
+}
+
+func TestOpenAICompatibleProvider_BaseURLAndTextTools(t *testing.T) {
+	mockClient := httpclient.NewMockHTTPClient()
+	mockClient.SetResponse("http://vllm.internal:8000/v1/chat/completions", 200, helloStream, nil)
+	cfg := &config.Config{}
+	cfg.OpenAICompatible.BaseURL = "http://vllm.internal:8000/v1/"
+	cfg.OpenAICompatible.APIKey = "local-key"
+	cfg.OpenAICompatible.Model = "qwen2.5-7b"
+	cfg.OpenAICompatible.ToolMode = config.ToolModeText
+
+	provider := NewOpenAICompatibleProviderWithClient(cfg, mockClient)
+	if provider.SupportsNativeTools() {
+		t.Error("Expected text tool mode to disable native tools")
+	}
+	err := provider.StreamChatWithTools(context.Background(), []Message{{Role: "user", Content: "hi"}},
+		[]Tool{{Name: "read_file", Description: "read", InputSchema: map[string]interface{}{"type": "object"}}},
+		func(string) {}, func(ToolCall) {})
+	if err != nil {
+		t.Fatalf("StreamChatWithTools() failed: %v", err)
+	}
+
+	req := mockClient.GetRequests()[0]
+	if auth := req.Header.Get("Authorization"); auth != "Bearer local-key" {
+		t.Errorf("Expected bearer token, got '%s'", auth)
+	}
+	body, _ := req.GetBody()
+	data, _ := io.ReadAll(body)
+	var sent openaiRequest
+	if err := json.Unmarshal(data, &sent); err != nil {
+		t.Fatalf("Failed to decode request: %v", err)
+	}
+	if sent.Model != "qwen2.5-7b" || len(sent.Tools) != 0 {
+		t.Errorf("Expected model without tool definitions, got model '%s' and %d tools", sent.Model, len(sent.Tools))
+	}
+	if sent.PromptCacheKey != "" {
+		t.Errorf("Expected no prompt_cache_key for compatible servers, got '%s'", sent.PromptCacheKey)
+	}
+}
+
+func TestOpenAIProvider_CustomBaseURL(t *testing.T) {
+	mockClient := httpclient.NewMockHTTPClient()
+	mockClient.SetResponse("https://llm-gateway.internal/openai/v1/chat/completions", 200, helloStream, nil)
+	cfg := &config.Config{}
+	cfg.OpenAI.APIKey = "test-key"
+	cfg.OpenAI.BaseURL = "https://llm-gateway.internal/openai/v1"
+
+	provider := NewOpenAIProviderWithClient(cfg, mockClient)
+	if _, err := provider.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}); err != nil {
+		t.Fatalf("Chat() failed: %v", err)
+	}
+}
+
+func TestOpenAIProvider_RequiresAPIKey(t *testing.T) {
+	provider := NewOpenAIProviderWithClient(&config.Config{}, httpclient.NewMockHTTPClient())
+	_, err := provider.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}})
+	if err == nil || !strings.Contains(err.Error(), "API key not set") {
+		t.Errorf("Expected missing API key error, got %v", err)
+	}
+}
+
+func TestNewProvider_OpenAICompatibleRequiresBaseURL(t *testing.T) {
+	cfg := &config.Config{LLMProvider: "openai_compatible"}
+	provider, err := NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("NewProvider() failed: %v", err)
+	}
+	if !SupportsNativeTools(provider) {
+		t.Error("Expected native tools by default")
+	}
+	_, err = provider.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}})
+	if err == nil || !strings.Contains(err.Error(), "base_url not set") {
+		t.Errorf("Expected missing base_url error, got %v", err)
+	}
+}
@@ -2,6 +2,8 @@ package llm
 
 import (
 	"context"
+	"encoding/json"
+	"io"
 	"strings"
 	"testing"
 
@@ -13,8 +15,9 @@ func TestOpenAIProvider_StreamChat(t *testing.T) {
 	mockClient := httpclient.NewMockHTTPClient()
 	cfg := &config.Config{
 		OpenAI: struct {
-			APIKey string `json:"api_key"`
-			Model  string `json:"model"`
+			APIKey  string `json:"api_key"`
+			Model   string `json:"model"`
+			BaseURL string `json:"base_url,omitempty"`
 		}{
 			APIKey: "test-key",
 			Model:  "gpt-5",
@@ -52,8 +55,9 @@ data: [DONE]
 func TestOpenAIProvider_StreamChat_MissingAPIKey(t *testing.T) {
 	cfg := &config.Config{
 		OpenAI: struct {
-			APIKey string `json:"api_key"`
-			Model  string `json:"model"`
+			APIKey  string `json:"api_key"`
+			Model   string `json:"model"`
+			BaseURL string `json:"base_url,omitempty"`
 		}{
 			APIKey: "",
 			Model:  "gpt-5",
@@ -74,8 +78,9 @@ func TestOpenAIProvider_StreamChat_MissingAPIKey(t *testing.T) {
 func TestOpenAIProvider_GetModel(t *testing.T) {
 	cfg := &config.Config{
 		OpenAI: struct {
-			APIKey string `json:"api_key"`
-			Model  string `json:"model"`
+			APIKey  string `json:"api_key"`
+			Model   string `json:"model"`
+			BaseURL string `json:"base_url,omitempty"`
 		}{
 			APIKey: "test-key",
 			Model:  "gpt-5",
@@ -93,8 +98,9 @@ func TestOpenAIProvider_Chat(t *testing.T) {
 	mockClient := httpclient.NewMockHTTPClient()
 	cfg := &config.Config{
 		OpenAI: struct {
-			APIKey string `json:"api_key"`
-			Model  string `json:"model"`
+			APIKey  string `json:"api_key"`
+			Model   string `json:"model"`
+			BaseURL string `json:"base_url,omitempty"`
 		}{
 			APIKey: "test-key",
 			Model:  "gpt-5",
@@ -130,8 +136,9 @@ func TestOpenAIProvider_StreamChatWithTools_ToolCall(t *testing.T) {
 	mockClient := httpclient.NewMockHTTPClient()
 	cfg := &config.Config{
 		OpenAI: struct {
-			APIKey string `json:"api_key"`
-			Model  string `json:"model"`
+			APIKey  string `json:"api_key"`
+			Model   string `json:"model"`
+			BaseURL string `json:"base_url,omitempty"`
 		}{
 			APIKey: "test-key",
 			Model:  "gpt-5",
@@ -179,3 +186,139 @@ data: [DONE]
 		t.Errorf("Expected description 'list', got '%v'", toolCalls[0].Input["description"])
 	}
 }
+
+func TestBuildOpenAIMessages_ToolRoundTrip(t *testing.T) {
+	messages := []Message{
+		{Role: "user", Content: "check disks"},
+		{Role: "assistant", ToolCalls: []ToolCall{
+			{ID: "call_1", Name: "execute_command", Input: map[string]interface{}{"command": "df -h"}},
+			{ID: "call_2", Name: "read_file", Input: map[string]interface{}{"path": "/etc/fstab"}},
+		}},
+		{Role: "tool", ToolResults: []ToolResult{
+			{ToolCallID: "call_1", Name: "execute_command", Content: "ok"},
+			{ToolCallID: "call_2", Name: "read_file", Content: "content"},
+		}},
+	}
+
+	result := buildOpenAIMessages(messages)
+
+	if len(result) != 4 {
+		t.Fatalf("Expected 4 messages, got %d", len(result))
+	}
+	assistant := result[1]
+	if assistant.Content != nil {
+		t.Errorf("Expected nil content for tool-only assistant message, got %v", assistant.Content)
+	}
+	if len(assistant.ToolCalls) != 2 || assistant.ToolCalls[0].ID != "call_1" {
+		t.Fatalf("Expected 2 tool calls starting with 'call_1', got %+v", assistant.ToolCalls)
+	}
+	if assistant.ToolCalls[0].Function.Arguments != `{"command":"df -h"}` {
+		t.Errorf("Unexpected arguments: %s", assistant.ToolCalls[0].Function.Arguments)
+	}
+	for i, id := range []string{"call_1", "call_2"} {
+		msg := result[2+i]
+		if msg.Role != "tool" || msg.ToolCallID != id {
+			t.Errorf("Expected tool message for '%s', got %+v", id, msg)
+		}
+	}
+}
+
+func TestOpenAIProvider_StreamChat_ReportsUsage(t *testing.T) {
+	mockClient := httpclient.NewMockHTTPClient()
+	cfg := &config.Config{}
+	cfg.OpenAI.APIKey = "test-key"
+	cfg.OpenAI.Model = "gpt-5"
+
+	streamData := `data: {"choices":[{"delta":{"content":"Hello"}}]}
+data: {"choices":[{"delta":{},"finish_reason":"stop"}]}
+data: {"choices":[],"usage":{"prompt_tokens":500,"completion_tokens":20,"prompt_tokens_details":{"cached_tokens":300}}}
+data: [DONE]
+`
+	mockClient.SetResponse("https://api.openai.com/v1/chat/completions", 200, streamData, map[string]string{
+		"Content-Type": "text/event-stream",
+	})
+	provider := NewOpenAIProviderWithClient(cfg, mockClient)
+
+	var usage Usage
+	ctx := WithUsageReporter(context.Background(), func(m string, u Usage) {
+		usage = u
+	})
+	if err := provider.StreamChat(ctx, []Message{{Role: "user", Content: "Hello"}}, func(string) {}); err != nil {
+		t.Fatalf("StreamChat() failed: %v", err)
+	}
+
+	want := Usage{InputTokens: 200, OutputTokens: 20, CacheReadInputTokens: 300}
+	if usage != want {
+		t.Errorf("Expected %+v, got %+v", want, usage)
+	}
+
+	body, _ := mockClient.GetRequests()[0].GetBody()
+	data, _ := io.ReadAll(body)
+	var sent openaiRequest
+	if err := json.Unmarshal(data, &sent); err != nil {
+		t.Fatalf("Failed to decode request: %v", err)
+	}
+	if sent.StreamOptions == nil || !sent.StreamOptions.IncludeUsage {
+		t.Errorf("Expected stream_options.include_usage, got %+v", sent.StreamOptions)
+	}
+	if sent.PromptCacheKey == "" {
+		t.Error("Expected prompt_cache_key for OpenAI")
+	}
+}
+
+func TestPromptCacheKey(t *testing.T) {
+	tools := []openaiTool{{Type: "function", Function: openaiToolFunction{Name: "read_file"}}}
+	base := promptCacheKey([]Message{{Role: "system", Content: "prompt"}, {Role: "user", Content: "a"}}, tools)
+
+	if key := promptCacheKey([]Message{{Role: "system", Content: "prompt"}, {Role: "user", Content: "b"}}, tools); key != base {
+		t.Errorf("Expected the key to ignore the conversation, got %s and %s", base, key)
+	}
+	if key := promptCacheKey([]Message{{Role: "system", Content: "other prompt"}}, tools); key == base {
+		t.Error("Expected a different key for a different system prompt")
+	}
+	if key := promptCacheKey([]Message{{Role: "system", Content: "prompt"}}, nil); key == base {
+		t.Error("Expected a different key for different tools")
+	}
+}
+
+func TestOpenAIProvider_ReasoningEffort(t *testing.T) {
+	mockClient := httpclient.NewMockHTTPClient()
+	cfg := &config.Config{}
+	cfg.OpenAI.APIKey = "test-key"
+	cfg.OpenAI.Model = "o4-mini"
+	cfg.Thinking.ReasoningEffort = "high"
+
+	streamData := `data: {"choices":[{"delta":{"reasoning_content":"Compare mount "}}]}
+data: {"choices":[{"delta":{"reasoning_content":"options."}}]}
+data: {"choices":[{"delta":{"content":"Done"},"finish_reason":"stop"}]}
+data: [DONE]
+`
+	mockClient.SetResponse("https://api.openai.com/v1/chat/completions", 200, streamData, map[string]string{
+		"Content-Type": "text/event-stream",
+	})
+	provider := NewOpenAIProviderWithClient(cfg, mockClient)
+
+	var reasoning strings.Builder
+	ctx := WithThinkingReporter(context.Background(), func(delta string) {
+		reasoning.WriteString(delta)
+	})
+	var answer strings.Builder
+	if err := provider.StreamChat(ctx, []Message{{Role: "user", Content: "Hello"}}, func(chunk string) {
+		answer.WriteString(chunk)
+	}); err != nil {
+		t.Fatalf("StreamChat() failed: %v", err)
+	}
+	if reasoning.String() != "Compare mount options." || answer.String() != "Done" {
+		t.Errorf("Expected reasoning and answer to be separated, got '%s' / '%s'", reasoning.String(), answer.String())
+	}
+
+	body, _ := mockClient.GetRequests()[0].GetBody()
+	data, _ := io.ReadAll(body)
+	var sent openaiRequest
+	if err := json.Unmarshal(data, &sent); err != nil {
+		t.Fatalf("Failed to decode request: %v", err)
+	}
+	if sent.ReasoningEffort != "high" || sent.MaxTokens != 0 || sent.MaxCompletionTokens == 0 {
+		t.Errorf("Expected reasoning_effort with max_completion_tokens, got %+v", sent)
+	}
+}
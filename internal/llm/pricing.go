@@ -0,0 +1,62 @@
+package llm
+
+import "strings"
+
+// Price is the list price of a model in USD per million tokens
+type Price struct {
+	Input      float64
+	Output     float64
+	CacheWrite float64
+	CacheRead  float64
+}
+
+// prices maps model name prefixes to list prices. More specific prefixes must
+// come first. Models missing here (local models, private deployments) have no
+// known price and are reported by token count only.
+var prices = []struct {
+	prefix string
+	price  Price
+}{
+	{"claude-opus-4-5", Price{Input: 5, Output: 25, CacheWrite: 6.25, CacheRead: 0.5}},
+	{"claude-opus-4", Price{Input: 15, Output: 75, CacheWrite: 18.75, CacheRead: 1.5}},
+	{"claude-sonnet-4", Price{Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.3}},
+	{"claude-haiku-4", Price{Input: 1, Output: 5, CacheWrite: 1.25, CacheRead: 0.1}},
+	{"claude-3-7-sonnet", Price{Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.3}},
+	{"claude-3-5-sonnet", Price{Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.3}},
+	{"claude-3-5-haiku", Price{Input: 0.8, Output: 4, CacheWrite: 1, CacheRead: 0.08}},
+	{"claude-3-opus", Price{Input: 15, Output: 75, CacheWrite: 18.75, CacheRead: 1.5}},
+	{"claude-3-haiku", Price{Input: 0.25, Output: 1.25, CacheWrite: 0.3, CacheRead: 0.03}},
+	{"gpt-5-nano", Price{Input: 0.05, Output: 0.4, CacheRead: 0.005}},
+	{"gpt-5-mini", Price{Input: 0.25, Output: 2, CacheRead: 0.025}},
+	{"gpt-5", Price{Input: 1.25, Output: 10, CacheRead: 0.125}},
+	{"gpt-4.1-nano", Price{Input: 0.1, Output: 0.4, CacheRead: 0.025}},
+	{"gpt-4.1-mini", Price{Input: 0.4, Output: 1.6, CacheRead: 0.1}},
+	{"gpt-4.1", Price{Input: 2, Output: 8, CacheRead: 0.5}},
+	{"gpt-4o-mini", Price{Input: 0.15, Output: 0.6, CacheRead: 0.075}},
+	{"gpt-4o", Price{Input: 2.5, Output: 10, CacheRead: 1.25}},
+	{"gpt-4-turbo", Price{Input: 10, Output: 30}},
+	{"gpt-4", Price{Input: 30, Output: 60}},
+	{"gpt-3.5-turbo", Price{Input: 0.5, Output: 1.5}},
+	{"o4-mini", Price{Input: 1.1, Output: 4.4, CacheRead: 0.275}},
+	{"o3", Price{Input: 2, Output: 8, CacheRead: 0.5}},
+	{"o1", Price{Input: 15, Output: 60, CacheRead: 7.5}},
+}
+
+// PriceFor returns the list price of a model, or false when it is unknown
+func PriceFor(model string) (Price, bool) {
+	model = strings.ToLower(strings.TrimSpace(model))
+	for _, entry := range prices {
+		if strings.HasPrefix(model, entry.prefix) {
+			return entry.price, true
+		}
+	}
+	return Price{}, false
+}
+
+// Cost returns the cost of usage in USD
+func (p Price) Cost(usage Usage) float64 {
+	return (float64(usage.InputTokens)*p.Input +
+		float64(usage.OutputTokens)*p.Output +
+		float64(usage.CacheCreationInputTokens)*p.CacheWrite +
+		float64(usage.CacheReadInputTokens)*p.CacheRead) / 1e6
+}
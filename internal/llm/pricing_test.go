@@ -0,0 +1,42 @@
+package llm
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPriceFor(t *testing.T) {
+	tests := []struct {
+		model string
+		input float64
+		found bool
+	}{
+		{"claude-opus-4-5-20251101", 5, true},
+		{"claude-opus-4-1-20250805", 15, true},
+		{"claude-sonnet-4-5", 3, true},
+		{"gpt-4o-mini", 0.15, true},
+		{"gpt-4o-2024-08-06", 2.5, true},
+		{"GPT-5-mini", 0.25, true},
+		{"qwen2.5-7b", 0, false},
+	}
+	for _, tt := range tests {
+		price, ok := PriceFor(tt.model)
+		if ok != tt.found || price.Input != tt.input {
+			t.Errorf("PriceFor(%q) = %+v, %v; want input %v, %v", tt.model, price, ok, tt.input, tt.found)
+		}
+	}
+}
+
+func TestPrice_Cost(t *testing.T) {
+	price := Price{Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.3}
+	cost := price.Cost(Usage{
+		InputTokens:              1000000,
+		OutputTokens:             100000,
+		CacheCreationInputTokens: 200000,
+		CacheReadInputTokens:     1000000,
+	})
+	// 3 + 1.5 + 0.75 + 0.3
+	if math.Abs(cost-5.55) > 1e-9 {
+		t.Errorf("Expected $5.55, got $%v", cost)
+	}
+}
@@ -6,8 +6,48 @@ import (
 
 // Message represents a chat message
 type Message struct {
-	Role    string `json:"role"` // "user", "assistant", "system"
+	Role    string `json:"role"` // "user", "assistant", "system", "tool"
 	Content string `json:"content"`
+
+	// ToolCalls holds the tool calls issued by an assistant message
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	// ToolResults holds the results sent back for earlier tool calls (role "tool")
+	ToolResults []ToolResult `json:"tool_results,omitempty"`
+	// Thinking holds the reasoning blocks that preceded an assistant message's tool calls
+	Thinking []ThinkingBlock `json:"thinking,omitempty"`
+}
+
+// Usage counts the tokens sent to and received from a provider.
+// InputTokens excludes prompt tokens written to or read from the provider's prompt cache.
+type Usage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`
+}
+
+// Add returns the sum of two usages
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		InputTokens:              u.InputTokens + other.InputTokens,
+		OutputTokens:             u.OutputTokens + other.OutputTokens,
+		CacheCreationInputTokens: u.CacheCreationInputTokens + other.CacheCreationInputTokens,
+		CacheReadInputTokens:     u.CacheReadInputTokens + other.CacheReadInputTokens,
+	}
+}
+
+// Total returns all input, cache and output tokens
+func (u Usage) Total() int {
+	return u.InputTokens + u.OutputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+}
+
+// CacheHitRate returns the share of prompt tokens read from the prompt cache
+func (u Usage) CacheHitRate() float64 {
+	prompt := u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+	if prompt == 0 {
+		return 0
+	}
+	return float64(u.CacheReadInputTokens) / float64(prompt)
 }
 
 // Provider is the interface for LLM providers
@@ -24,3 +64,18 @@ type Provider interface {
 	// GetModel returns the model name being used
 	GetModel() string
 }
+
+// ToolCapability is implemented by providers that know whether their model
+// supports native tool calling
+type ToolCapability interface {
+	SupportsNativeTools() bool
+}
+
+// SupportsNativeTools reports whether provider accepts tool definitions natively.
+// Providers that do not implement ToolCapability are assumed to.
+func SupportsNativeTools(provider Provider) bool {
+	if capability, ok := provider.(ToolCapability); ok {
+		return capability.SupportsNativeTools()
+	}
+	return true
+}
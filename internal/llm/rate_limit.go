@@ -18,6 +18,7 @@ type RateLimiter struct {
 	windowStart  time.Time
 	usedTokens   int
 	usedRequests int
+	pausedUntil  time.Time
 }
 
 type RateLimitReporter func(wait time.Duration, waiting bool)
@@ -79,6 +80,9 @@ func (l *RateLimiter) reserve(tokens int) time.Duration {
 	defer l.mu.Unlock()
 
 	now := time.Now()
+	if now.Before(l.pausedUntil) {
+		return l.pausedUntil.Sub(now)
+	}
 	if l.windowStart.IsZero() || now.Sub(l.windowStart) >= l.window {
 		l.windowStart = now
 		l.usedTokens = 0
@@ -114,6 +118,18 @@ func (l *RateLimiter) UpdateLimits(tokensPerWindow, requestsPerWindow int) {
 	}
 }
 
+// Pause holds back requests for d, e.g. when the API answered 429 with retry-after
+func (l *RateLimiter) Pause(d time.Duration) {
+	if l == nil || d <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
 func updateLimiterFromHeaders(l *RateLimiter, headers http.Header, tokensKey, requestsKey string) {
 	if l == nil {
 		return
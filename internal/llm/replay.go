@@ -0,0 +1,317 @@
+package llm
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/mainbong/storage_doctor/internal/config"
+	"github.com/mainbong/storage_doctor/internal/filesystem"
+	"github.com/mainbong/storage_doctor/internal/httpclient"
+	"github.com/mainbong/storage_doctor/internal/logger"
+)
+
+// replayAPIKey stands in for the API key when replaying, since no request leaves the machine
+const replayAPIKey = "replay"
+
+// Fixture is a recorded sequence of LLM API exchanges
+type Fixture struct {
+	Exchanges []Exchange `json:"exchanges"`
+}
+
+// Exchange is one recorded HTTP request to a provider and its raw (SSE) response
+type Exchange struct {
+	RequestHash string `json:"request_hash"`
+	// Messages is the normalized conversation the hash was computed from, kept for review
+	Messages   json.RawMessage   `json:"messages,omitempty"`
+	StatusCode int               `json:"status_code"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Body       string            `json:"body"`
+}
+
+// ReplayProvider wraps a real provider whose HTTP traffic is recorded to, or
+// replayed from, a fixture file
+type ReplayProvider struct {
+	Provider
+	client *fixtureClient
+}
+
+// NewRecordingProvider creates the configured provider and records every exchange to path
+func NewRecordingProvider(cfg *config.Config, path string) (*ReplayProvider, error) {
+	return NewRecordingProviderWithClient(cfg, path, httpclient.NewDefaultHTTPClient(), filesystem.NewOSFileSystem())
+}
+
+// NewRecordingProviderWithClient creates a recording provider with a custom HTTPClient and FileSystem (for testing)
+func NewRecordingProviderWithClient(cfg *config.Config, path string, client httpclient.HTTPClient, fs filesystem.FileSystem) (*ReplayProvider, error) {
+	recorder := &fixtureClient{fs: fs, path: path, next: client}
+	provider, err := NewProviderWithClient(cfg, recorder)
+	if err != nil {
+		return nil, err
+	}
+	return &ReplayProvider{Provider: provider, client: recorder}, nil
+}
+
+// NewReplayProvider creates the configured provider answering from the fixture at path
+func NewReplayProvider(cfg *config.Config, path string) (*ReplayProvider, error) {
+	return NewReplayProviderWithFS(cfg, path, filesystem.NewOSFileSystem())
+}
+
+// NewReplayProviderWithFS creates a replay provider with a custom FileSystem (for testing)
+func NewReplayProviderWithFS(cfg *config.Config, path string, fs filesystem.FileSystem) (*ReplayProvider, error) {
+	data, err := fs.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read replay fixture: %w", err)
+	}
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("failed to parse replay fixture %s: %w", path, err)
+	}
+
+	replayCfg := *cfg
+	if replayCfg.Anthropic.APIKey == "" {
+		replayCfg.Anthropic.APIKey = replayAPIKey
+	}
+	if replayCfg.OpenAI.APIKey == "" {
+		replayCfg.OpenAI.APIKey = replayAPIKey
+	}
+	if replayCfg.OpenAICompatible.BaseURL == "" {
+		replayCfg.OpenAICompatible.BaseURL = "http://replay.invalid/v1"
+	}
+
+	replayer := &fixtureClient{fs: fs, path: path, fixture: fixture, replay: true, served: make(map[string]int)}
+	provider, err := NewProviderWithClient(&replayCfg, replayer)
+	if err != nil {
+		return nil, err
+	}
+	return &ReplayProvider{Provider: provider, client: replayer}, nil
+}
+
+// SupportsNativeTools reports whether the wrapped provider sends tool definitions
+func (p *ReplayProvider) SupportsNativeTools() bool {
+	return SupportsNativeTools(p.Provider)
+}
+
+// Fixture returns the exchanges recorded or loaded so far
+func (p *ReplayProvider) Fixture() Fixture {
+	p.client.mu.Lock()
+	defer p.client.mu.Unlock()
+	return Fixture{Exchanges: append([]Exchange(nil), p.client.fixture.Exchanges...)}
+}
+
+// fixtureClient records responses of the next client, or serves recorded ones
+type fixtureClient struct {
+	mu      sync.Mutex
+	fs      filesystem.FileSystem
+	path    string
+	next    httpclient.HTTPClient
+	replay  bool
+	fixture Fixture
+	served  map[string]int // Exchanges already replayed per request hash
+}
+
+func (c *fixtureClient) Do(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	hash, messages := RequestHash(body)
+
+	if c.replay {
+		return c.serve(req, hash)
+	}
+
+	resp, err := c.next.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	exchange := Exchange{
+		RequestHash: hash,
+		Messages:    messages,
+		StatusCode:  resp.StatusCode,
+		Headers:     recordedHeaders(resp.Header),
+	}
+	resp.Body = &recordingBody{ReadCloser: resp.Body, done: func(data []byte) error {
+		exchange.Body = string(data)
+		return c.save(exchange)
+	}}
+	return resp, nil
+}
+
+func (c *fixtureClient) serve(req *http.Request, hash string) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var matches []Exchange
+	for _, exchange := range c.fixture.Exchanges {
+		if exchange.RequestHash == hash {
+			matches = append(matches, exchange)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no recorded exchange in %s for request %s; re-record the fixture", c.path, hash)
+	}
+	// Identical requests are answered in recorded order, e.g. a 429 and then its retry
+	index := c.served[hash]
+	if index >= len(matches) {
+		index = len(matches) - 1
+	}
+	c.served[hash]++
+	exchange := matches[index]
+
+	header := make(http.Header)
+	for name, value := range exchange.Headers {
+		header.Set(name, value)
+	}
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", exchange.StatusCode, http.StatusText(exchange.StatusCode)),
+		StatusCode: exchange.StatusCode,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(exchange.Body)),
+		Request:    req,
+	}, nil
+}
+
+// save appends an exchange and rewrites the fixture so an interrupted session keeps what it recorded
+func (c *fixtureClient) save(exchange Exchange) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.fixture.Exchanges = append(c.fixture.Exchanges, exchange)
+	data, err := marshalFixture(c.fixture)
+	if err != nil {
+		return fmt.Errorf("failed to marshal replay fixture: %w", err)
+	}
+	if err := c.fs.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("failed to create fixture directory: %w", err)
+	}
+	if err := c.fs.WriteFile(c.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write replay fixture: %w", err)
+	}
+	return nil
+}
+
+// recordingBody keeps everything read from a response body and hands it over on Close
+type recordingBody struct {
+	io.ReadCloser
+	buf    bytes.Buffer
+	done   func(data []byte) error
+	closed bool
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	// Keep the part the provider did not read, e.g. after an early return
+	if _, err := io.Copy(&b.buf, b.ReadCloser); err != nil {
+		b.ReadCloser.Close()
+		return err
+	}
+	if err := b.ReadCloser.Close(); err != nil {
+		return err
+	}
+	if err := b.done(b.buf.Bytes()); err != nil {
+		logger.Error("%v", err)
+		return err
+	}
+	return nil
+}
+
+// recordedHeaders keeps the response headers providers act on and drops
+// identifying ones such as organization or request IDs
+func recordedHeaders(header http.Header) map[string]string {
+	recorded := make(map[string]string)
+	for name := range header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || lower == "retry-after" || strings.Contains(lower, "ratelimit-limit") {
+			recorded[name] = header.Get(name)
+		}
+	}
+	if len(recorded) == 0 {
+		return nil
+	}
+	return recorded
+}
+
+// RequestHash identifies a provider request by its conversation. System
+// prompts, tool definitions, cache markers and sampling parameters are left
+// out so fixtures survive prompt and tool changes that do not alter the dialogue.
+func RequestHash(body []byte) (string, json.RawMessage) {
+	var request struct {
+		Messages []map[string]interface{} `json:"messages"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		sum := sha256.Sum256(body)
+		return hex.EncodeToString(sum[:8]), nil
+	}
+
+	messages := make([]interface{}, 0, len(request.Messages))
+	for _, message := range request.Messages {
+		if message["role"] == "system" {
+			continue
+		}
+		messages = append(messages, stripCacheControl(message))
+	}
+	// encoding/json sorts map keys, so equal conversations marshal identically
+	normalized, err := json.Marshal(messages)
+	if err != nil {
+		sum := sha256.Sum256(body)
+		return hex.EncodeToString(sum[:8]), nil
+	}
+	sum := sha256.Sum256(normalized)
+	return hex.EncodeToString(sum[:8]), normalized
+}
+
+func stripCacheControl(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		stripped := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if key == "cache_control" {
+				continue
+			}
+			stripped[key] = stripCacheControl(item)
+		}
+		return stripped
+	case []interface{}:
+		stripped := make([]interface{}, len(v))
+		for i, item := range v {
+			stripped[i] = stripCacheControl(item)
+		}
+		return stripped
+	default:
+		return value
+	}
+}
+
+// marshalFixture indents a fixture and keeps shell characters such as > and & readable
+func marshalFixture(fixture interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(fixture); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,79 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mainbong/storage_doctor/internal/config"
+	"github.com/mainbong/storage_doctor/internal/filesystem"
+	"github.com/mainbong/storage_doctor/internal/httpclient"
+)
+
+func TestReplayProvider_RecordAndReplay(t *testing.T) {
+	fs := filesystem.NewMockFileSystem()
+	mockClient := httpclient.NewMockHTTPClient()
+	mockClient.SetResponse("https://api.openai.com/v1/chat/completions", 200, helloStream,
+		map[string]string{"Content-Type": "text/event-stream", "Openai-Organization": "org-secret"})
+
+	cfg := &config.Config{LLMProvider: "openai"}
+	cfg.OpenAI.APIKey = "sk-test"
+	cfg.OpenAI.Model = "gpt-4o"
+
+	recorder, err := NewRecordingProviderWithClient(cfg, "/fixtures/llm.json", mockClient, fs)
+	if err != nil {
+		t.Fatalf("NewRecordingProviderWithClient() failed: %v", err)
+	}
+	messages := []Message{{Role: "system", Content: "prompt v1"}, {Role: "user", Content: "hi"}}
+	if _, err := recorder.Chat(context.Background(), messages); err != nil {
+		t.Fatalf("Chat() failed: %v", err)
+	}
+	fixture := recorder.Fixture()
+	if len(fixture.Exchanges) != 1 {
+		t.Fatalf("Expected 1 recorded exchange, got %d", len(fixture.Exchanges))
+	}
+	if fixture.Exchanges[0].Body != helloStream {
+		t.Errorf("Expected the raw stream to be recorded, got %q", fixture.Exchanges[0].Body)
+	}
+	if _, ok := fixture.Exchanges[0].Headers["Openai-Organization"]; ok {
+		t.Error("Expected identifying headers to be dropped")
+	}
+	if data := fs.GetFile("/fixtures/llm.json"); data == nil || strings.Contains(string(data), "sk-test") {
+		t.Errorf("Expected a fixture without the API key, got %s", string(data))
+	}
+
+	// Replay needs no API key and ignores system prompt changes
+	replayCfg := &config.Config{LLMProvider: "openai"}
+	replayCfg.OpenAI.Model = "gpt-4o"
+	replayer, err := NewReplayProviderWithFS(replayCfg, "/fixtures/llm.json", fs)
+	if err != nil {
+		t.Fatalf("NewReplayProviderWithFS() failed: %v", err)
+	}
+	response, err := replayer.Chat(context.Background(), []Message{{Role: "system", Content: "prompt v2"}, {Role: "user", Content: "hi"}})
+	if err != nil {
+		t.Fatalf("Chat() failed on replay: %v", err)
+	}
+	if response != "Hello" {
+		t.Errorf("Expected 'Hello', got '%s'", response)
+	}
+	if !replayer.SupportsNativeTools() {
+		t.Error("Expected the wrapped provider's tool support")
+	}
+
+	_, err = replayer.Chat(context.Background(), []Message{{Role: "user", Content: "something else"}})
+	if err == nil || !strings.Contains(err.Error(), "no recorded exchange") {
+		t.Errorf("Expected a missing exchange error, got %v", err)
+	}
+}
+
+func TestRequestHash_IgnoresSystemAndCacheControl(t *testing.T) {
+	a, _ := RequestHash([]byte(`{"model":"a","system":[{"type":"text","text":"v1"}],"messages":[{"role":"user","content":[{"type":"text","text":"hi","cache_control":{"type":"ephemeral"}}]}]}`))
+	b, _ := RequestHash([]byte(`{"model":"b","system":[{"type":"text","text":"v2"}],"messages":[{"role":"user","content":[{"type":"text","text":"hi"}]}]}`))
+	if a != b {
+		t.Errorf("Expected equal hashes, got %s and %s", a, b)
+	}
+	c, _ := RequestHash([]byte(`{"messages":[{"role":"user","content":[{"type":"text","text":"hello"}]}]}`))
+	if a == c {
+		t.Error("Expected different conversations to hash differently")
+	}
+}
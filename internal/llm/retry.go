@@ -0,0 +1,279 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mainbong/storage_doctor/internal/config"
+	"github.com/mainbong/storage_doctor/internal/logger"
+)
+
+const (
+	// maxRetryAfter is the longest retry-after honored; longer waits fail over instead
+	maxRetryAfter = 2 * time.Minute
+	// failoverCooldown is how long the fallback is tried first after the primary gave up
+	failoverCooldown = 5 * time.Minute
+	// restartNotice separates a broken partial response from its regenerated replacement
+	restartNotice = "[연결이 끊겨 응답을 처음부터 다시 생성합니다]"
+)
+
+// RetryPolicy controls how RetryProvider retries transient failures
+type RetryPolicy struct {
+	MaxAttempts int // Attempts per provider, including the first
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// DefaultRetryPolicy returns the policy used when the config sets none
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 4, BaseDelay: time.Second, MaxDelay: 30 * time.Second}
+}
+
+// RetryPolicyFromConfig builds a RetryPolicy from the retry settings
+func RetryPolicyFromConfig(cfg *config.Config) RetryPolicy {
+	policy := DefaultRetryPolicy()
+	if cfg.Retry.MaxAttempts > 0 {
+		policy.MaxAttempts = cfg.Retry.MaxAttempts
+	}
+	if cfg.Retry.MaxDelaySeconds > 0 {
+		policy.MaxDelay = time.Duration(cfg.Retry.MaxDelaySeconds) * time.Second
+	}
+	return policy
+}
+
+// backoff returns the wait before the retry following attempt, honoring retry-after.
+// It reports false when the API asked for a longer wait than maxRetryAfter.
+func (p RetryPolicy) backoff(attempt int, err error) (time.Duration, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		return apiErr.RetryAfter, apiErr.RetryAfter <= maxRetryAfter
+	}
+	delay := p.MaxDelay
+	if attempt < 30 {
+		if exp := p.BaseDelay << uint(attempt-1); exp > 0 && exp < delay {
+			delay = exp
+		}
+	}
+	// Equal jitter: keep half the delay and spread the rest so clients don't retry in lockstep
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1)), true
+}
+
+// RetryStatus describes a retry or failover about to happen
+type RetryStatus struct {
+	Model       string // Model of the provider about to be called
+	Attempt     int    // Attempt about to start on that provider
+	MaxAttempts int
+	Wait        time.Duration
+	Err         error // The failure that caused the retry
+	Failover    bool  // Switching to another provider
+}
+
+// RetryReporter is notified before each retry; the wait itself goes to the RateLimitReporter
+type RetryReporter func(status RetryStatus)
+
+type retryReporterKey struct{}
+
+// WithRetryReporter attaches a RetryReporter to ctx
+func WithRetryReporter(ctx context.Context, reporter RetryReporter) context.Context {
+	if reporter == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, retryReporterKey{}, reporter)
+}
+
+func reportRetry(ctx context.Context, status RetryStatus) {
+	if reporter, ok := ctx.Value(retryReporterKey{}).(RetryReporter); ok && reporter != nil {
+		reporter(status)
+	}
+}
+
+// RetryProvider retries transient failures of a provider with jittered
+// exponential backoff and fails over to a fallback provider when the primary
+// keeps failing. A request is retried only while no tool call has been
+// emitted; text already streamed to the caller is not repeated.
+type RetryProvider struct {
+	primary  Provider
+	fallback Provider
+	policy   RetryPolicy
+
+	mu               sync.Mutex
+	primaryDownUntil time.Time
+}
+
+// NewRetryProvider wraps primary; fallback may be nil
+func NewRetryProvider(primary, fallback Provider, policy RetryPolicy) *RetryProvider {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	return &RetryProvider{primary: primary, fallback: fallback, policy: policy}
+}
+
+// SupportsNativeTools reports whether every wrapped provider accepts tool definitions
+func (p *RetryProvider) SupportsNativeTools() bool {
+	return SupportsNativeTools(p.primary) && (p.fallback == nil || SupportsNativeTools(p.fallback))
+}
+
+func (p *RetryProvider) StreamChat(ctx context.Context, messages []Message, onChunk func(string)) error {
+	return p.StreamChatWithTools(ctx, messages, nil, onChunk, nil)
+}
+
+// StreamChatWithTools streams from the primary provider, retrying and failing over on transient errors
+func (p *RetryProvider) StreamChatWithTools(ctx context.Context, messages []Message, tools []Tool, onChunk func(string), onToolCall func(ToolCall)) error {
+	stream := &resumableStream{onChunk: onChunk, onToolCall: onToolCall}
+	providers := p.candidates()
+
+	var err error
+	for i, provider := range providers {
+		if i > 0 {
+			logger.Warn("LLM 프로바이더 전환: %s → %s (%v)", providers[i-1].GetModel(), provider.GetModel(), err)
+			reportRetry(ctx, RetryStatus{Model: provider.GetModel(), Attempt: 1, MaxAttempts: p.policy.MaxAttempts, Err: err, Failover: true})
+		}
+		err = p.retry(ctx, provider, messages, tools, stream)
+		if err == nil {
+			if i > 0 && providers[0] == p.primary {
+				p.markPrimaryDown()
+			}
+			return nil
+		}
+		if !IsRetryable(err) || stream.toolCalls > 0 {
+			return err
+		}
+	}
+	return err
+}
+
+// retry calls provider until it succeeds, fails permanently or runs out of attempts
+func (p *RetryProvider) retry(ctx context.Context, provider Provider, messages []Message, tools []Tool, stream *resumableStream) error {
+	for attempt := 1; ; attempt++ {
+		stream.restart()
+		var onToolCall func(ToolCall)
+		if stream.onToolCall != nil {
+			onToolCall = stream.toolCall
+		}
+		err := provider.StreamChatWithTools(ctx, messages, tools, stream.chunk, onToolCall)
+		if err == nil || !IsRetryable(err) || stream.toolCalls > 0 || attempt >= p.policy.MaxAttempts {
+			return err
+		}
+		wait, ok := p.policy.backoff(attempt, err)
+		if !ok {
+			logger.Warn("retry-after %s이(가) 너무 길어 재시도하지 않습니다: %v", wait, err)
+			return err
+		}
+		logger.Warn("LLM 요청 실패, %s 후 재시도 (%d/%d): %v", wait.Round(time.Millisecond), attempt+1, p.policy.MaxAttempts, err)
+		reportRetry(ctx, RetryStatus{Model: provider.GetModel(), Attempt: attempt + 1, MaxAttempts: p.policy.MaxAttempts, Wait: wait, Err: err})
+		if waitErr := waitRetry(ctx, wait); waitErr != nil {
+			return waitErr
+		}
+	}
+}
+
+// waitRetry sleeps for wait, showing it through the RateLimitReporter
+func waitRetry(ctx context.Context, wait time.Duration) error {
+	reportRateLimit(ctx, wait, true)
+	defer reportRateLimit(ctx, 0, false)
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// candidates returns the providers to try in order
+func (p *RetryProvider) candidates() []Provider {
+	if p.fallback == nil {
+		return []Provider{p.primary}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if time.Now().Before(p.primaryDownUntil) {
+		return []Provider{p.fallback, p.primary}
+	}
+	return []Provider{p.primary, p.fallback}
+}
+
+func (p *RetryProvider) markPrimaryDown() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.primaryDownUntil = time.Now().Add(failoverCooldown)
+	logger.Warn("기본 LLM 프로바이더(%s)를 %s 동안 건너뜁니다", p.primary.GetModel(), failoverCooldown)
+}
+
+// Chat sends a chat completion request and returns the full response
+func (p *RetryProvider) Chat(ctx context.Context, messages []Message) (string, error) {
+	var response strings.Builder
+	err := p.StreamChat(ctx, messages, func(chunk string) {
+		response.WriteString(chunk)
+	})
+	if err != nil {
+		return "", err
+	}
+	return response.String(), nil
+}
+
+// GetModel returns the primary provider's model
+func (p *RetryProvider) GetModel() string {
+	return p.primary.GetModel()
+}
+
+// resumableStream forwards chunks and tool calls across attempts. After a
+// retry it holds back the text the caller has already seen and resumes where
+// the broken attempt stopped; if the new response differs, it is shown in full
+// after restartNotice.
+type resumableStream struct {
+	onChunk    func(string)
+	onToolCall func(ToolCall)
+
+	shown     strings.Builder // Text already passed to onChunk
+	current   strings.Builder // Text of the current attempt while replaying
+	replaying bool
+	toolCalls int
+}
+
+func (s *resumableStream) restart() {
+	s.current.Reset()
+	s.replaying = s.shown.Len() > 0
+}
+
+func (s *resumableStream) chunk(text string) {
+	if !s.replaying {
+		s.emit(text)
+		return
+	}
+	s.current.WriteString(text)
+	shown, current := s.shown.String(), s.current.String()
+	switch {
+	case len(current) <= len(shown) && strings.HasPrefix(shown, current):
+		// Still repeating text the caller has already seen
+	case strings.HasPrefix(current, shown):
+		s.replaying = false
+		s.emit(current[len(shown):])
+	default:
+		s.replaying = false
+		s.emit("\n\n" + restartNotice + "\n\n" + current)
+	}
+}
+
+func (s *resumableStream) emit(text string) {
+	if text == "" {
+		return
+	}
+	s.shown.WriteString(text)
+	if s.onChunk != nil {
+		s.onChunk(text)
+	}
+}
+
+func (s *resumableStream) toolCall(call ToolCall) {
+	s.toolCalls++
+	if s.onToolCall != nil {
+		s.onToolCall(call)
+	}
+}
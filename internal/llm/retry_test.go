@@ -0,0 +1,204 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func fastRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+}
+
+func TestRetryProvider_RetriesTransientErrors(t *testing.T) {
+	primary := NewMockProvider()
+	calls := 0
+	primary.SetOnStreamChat(func(ctx context.Context, messages []Message, tools []Tool, onChunk func(string), onToolCall func(ToolCall)) error {
+		calls++
+		if calls < 3 {
+			return &APIError{Provider: "anthropic", StatusCode: 529, Body: "overloaded"}
+		}
+		onChunk("ok")
+		return nil
+	})
+
+	var waits []bool
+	var retries []RetryStatus
+	ctx := WithRateLimitReporter(context.Background(), func(wait time.Duration, waiting bool) {
+		waits = append(waits, waiting)
+	})
+	ctx = WithRetryReporter(ctx, func(status RetryStatus) {
+		retries = append(retries, status)
+	})
+
+	provider := NewRetryProvider(primary, nil, fastRetryPolicy())
+	response := ""
+	if err := provider.StreamChat(ctx, []Message{{Role: "user", Content: "hi"}}, func(chunk string) { response += chunk }); err != nil {
+		t.Fatalf("StreamChat() failed: %v", err)
+	}
+	if calls != 3 || response != "ok" {
+		t.Errorf("Expected 3 calls and response 'ok', got %d calls and %q", calls, response)
+	}
+	if len(retries) != 2 || retries[1].Attempt != 3 || retries[1].MaxAttempts != 3 {
+		t.Errorf("Expected retries reported for attempts 2 and 3, got %+v", retries)
+	}
+	if len(waits) != 4 || !waits[0] || waits[1] {
+		t.Errorf("Expected backoff waits reported through the rate limit reporter, got %v", waits)
+	}
+}
+
+func TestRetryProvider_DoesNotRetryPermanentErrors(t *testing.T) {
+	primary := NewMockProvider()
+	calls := 0
+	primary.SetOnStreamChat(func(ctx context.Context, messages []Message, tools []Tool, onChunk func(string), onToolCall func(ToolCall)) error {
+		calls++
+		return &APIError{Provider: "openai", StatusCode: 400, Body: "bad request"}
+	})
+
+	provider := NewRetryProvider(primary, nil, fastRetryPolicy())
+	if err := provider.StreamChat(context.Background(), nil, func(string) {}); err == nil {
+		t.Fatal("Expected error")
+	}
+	if calls != 1 {
+		t.Errorf("Expected a single call, got %d", calls)
+	}
+}
+
+func TestRetryProvider_StopsAfterToolCall(t *testing.T) {
+	primary := NewMockProvider()
+	calls := 0
+	primary.SetOnStreamChat(func(ctx context.Context, messages []Message, tools []Tool, onChunk func(string), onToolCall func(ToolCall)) error {
+		calls++
+		onToolCall(ToolCall{ID: "call_1", Name: "execute_shell_command"})
+		return &ConnectionError{Op: "스트림 읽기", Err: errStreamTruncated}
+	})
+
+	provider := NewRetryProvider(primary, nil, fastRetryPolicy())
+	var toolCalls []ToolCall
+	err := provider.StreamChatWithTools(context.Background(), nil, []Tool{{Name: "execute_shell_command"}}, func(string) {}, func(call ToolCall) {
+		toolCalls = append(toolCalls, call)
+	})
+	if err == nil {
+		t.Fatal("Expected the stream error once a tool call was emitted")
+	}
+	if calls != 1 || len(toolCalls) != 1 {
+		t.Errorf("Expected no retry after a tool call, got %d calls and %d tool calls", calls, len(toolCalls))
+	}
+}
+
+func TestRetryProvider_ResumesPartialText(t *testing.T) {
+	primary := NewMockProvider()
+	calls := 0
+	primary.SetOnStreamChat(func(ctx context.Context, messages []Message, tools []Tool, onChunk func(string), onToolCall func(ToolCall)) error {
+		calls++
+		if calls == 1 {
+			onChunk("디스크 ")
+			onChunk("사용량이")
+			return &ConnectionError{Op: "스트림 읽기", Err: errStreamTruncated}
+		}
+		onChunk("디스크 사용")
+		onChunk("량이 90%입니다")
+		return nil
+	})
+
+	provider := NewRetryProvider(primary, nil, fastRetryPolicy())
+	var response strings.Builder
+	if err := provider.StreamChat(context.Background(), nil, func(chunk string) { response.WriteString(chunk) }); err != nil {
+		t.Fatalf("StreamChat() failed: %v", err)
+	}
+	if response.String() != "디스크 사용량이 90%입니다" {
+		t.Errorf("Expected the retry to continue the partial text, got %q", response.String())
+	}
+}
+
+func TestRetryProvider_DivergedRetryShowsNotice(t *testing.T) {
+	primary := NewMockProvider()
+	calls := 0
+	primary.SetOnStreamChat(func(ctx context.Context, messages []Message, tools []Tool, onChunk func(string), onToolCall func(ToolCall)) error {
+		calls++
+		if calls == 1 {
+			onChunk("첫 번째 답변")
+			return &ConnectionError{Op: "스트림 읽기", Err: errStreamTruncated}
+		}
+		onChunk("다른 답변")
+		return nil
+	})
+
+	provider := NewRetryProvider(primary, nil, fastRetryPolicy())
+	var response strings.Builder
+	if err := provider.StreamChat(context.Background(), nil, func(chunk string) { response.WriteString(chunk) }); err != nil {
+		t.Fatalf("StreamChat() failed: %v", err)
+	}
+	if !strings.Contains(response.String(), restartNotice) || !strings.HasSuffix(response.String(), "다른 답변") {
+		t.Errorf("Expected the restart notice followed by the new answer, got %q", response.String())
+	}
+}
+
+func TestRetryProvider_FailsOver(t *testing.T) {
+	primary := NewMockProvider()
+	primaryCalls := 0
+	primary.SetOnStreamChat(func(ctx context.Context, messages []Message, tools []Tool, onChunk func(string), onToolCall func(ToolCall)) error {
+		primaryCalls++
+		return &APIError{Provider: "anthropic", StatusCode: 503, Body: "unavailable"}
+	})
+	fallback := NewMockProvider()
+	fallback.SetStreamChunks([]string{"fallback answer"})
+
+	var failovers int
+	ctx := WithRetryReporter(context.Background(), func(status RetryStatus) {
+		if status.Failover {
+			failovers++
+		}
+	})
+
+	provider := NewRetryProvider(primary, fallback, fastRetryPolicy())
+	response, err := provider.Chat(ctx, []Message{{Role: "user", Content: "hi"}})
+	if err != nil {
+		t.Fatalf("Chat() failed: %v", err)
+	}
+	if response != "fallback answer" || primaryCalls != 3 || failovers != 1 {
+		t.Errorf("Expected failover after 3 attempts, got %q, %d primary calls, %d failovers", response, primaryCalls, failovers)
+	}
+
+	// The primary is skipped while it cools down
+	if _, err := provider.Chat(ctx, []Message{{Role: "user", Content: "again"}}); err != nil {
+		t.Fatalf("Chat() failed: %v", err)
+	}
+	if primaryCalls != 3 {
+		t.Errorf("Expected the fallback to be used first during cooldown, got %d primary calls", primaryCalls)
+	}
+}
+
+func TestRetryProvider_ContextCanceledDuringBackoff(t *testing.T) {
+	primary := NewMockProvider()
+	primary.SetOnStreamChat(func(ctx context.Context, messages []Message, tools []Tool, onChunk func(string), onToolCall func(ToolCall)) error {
+		return &APIError{Provider: "anthropic", StatusCode: 429, RetryAfter: time.Minute}
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = WithRetryReporter(ctx, func(RetryStatus) { cancel() })
+
+	provider := NewRetryProvider(primary, nil, fastRetryPolicy())
+	err := provider.StreamChat(ctx, nil, func(string) {})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: time.Second, MaxDelay: 4 * time.Second}
+	for attempt, max := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		wait, ok := policy.backoff(attempt+1, errors.New("boom"))
+		if !ok || wait < max/2 || wait > max {
+			t.Errorf("attempt %d: backoff %v outside [%v, %v]", attempt+1, wait, max/2, max)
+		}
+	}
+
+	if wait, ok := policy.backoff(1, &APIError{StatusCode: 429, RetryAfter: 10 * time.Second}); !ok || wait != 10*time.Second {
+		t.Errorf("Expected retry-after to be honored, got %v (%v)", wait, ok)
+	}
+	if _, ok := policy.backoff(1, &APIError{StatusCode: 429, RetryAfter: time.Hour}); ok {
+		t.Error("Expected a retry-after beyond the limit to stop retrying")
+	}
+}
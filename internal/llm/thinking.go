@@ -0,0 +1,57 @@
+package llm
+
+import "context"
+
+// ThinkingBlock is a reasoning block produced by a model with extended
+// thinking. Anthropic requires the blocks of an assistant turn that issued
+// tool calls to be sent back unchanged, signature included, with the results.
+type ThinkingBlock struct {
+	Thinking  string `json:"thinking,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	// Redacted holds the encrypted data of a redacted_thinking block
+	Redacted string `json:"redacted,omitempty"`
+}
+
+// ThinkingReporter receives reasoning text as it streams
+type ThinkingReporter func(delta string)
+
+// ThinkingCollector receives the thinking blocks of a response that completed
+type ThinkingCollector func(block ThinkingBlock)
+
+type thinkingReporterKey struct{}
+type thinkingCollectorKey struct{}
+
+// WithThinkingReporter attaches a ThinkingReporter to ctx
+func WithThinkingReporter(ctx context.Context, reporter ThinkingReporter) context.Context {
+	if reporter == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, thinkingReporterKey{}, reporter)
+}
+
+// WithThinkingCollector attaches a ThinkingCollector to ctx
+func WithThinkingCollector(ctx context.Context, collector ThinkingCollector) context.Context {
+	if collector == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, thinkingCollectorKey{}, collector)
+}
+
+func reportThinking(ctx context.Context, delta string) {
+	if delta == "" {
+		return
+	}
+	if reporter, ok := ctx.Value(thinkingReporterKey{}).(ThinkingReporter); ok && reporter != nil {
+		reporter(delta)
+	}
+}
+
+func collectThinking(ctx context.Context, blocks []ThinkingBlock) {
+	collector, ok := ctx.Value(thinkingCollectorKey{}).(ThinkingCollector)
+	if !ok || collector == nil {
+		return
+	}
+	for _, block := range blocks {
+		collector(block)
+	}
+}
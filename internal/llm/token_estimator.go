@@ -1,6 +1,7 @@
 package llm
 
 import (
+	"encoding/json"
 	"math"
 	"unicode/utf8"
 )
@@ -9,6 +10,15 @@ func EstimateTokens(messages []Message) int {
 	total := 0
 	for _, msg := range messages {
 		total += estimateTextTokens(msg.Content) + 4
+		for _, call := range msg.ToolCalls {
+			total += estimateTextTokens(call.Name) + 4
+			if input, err := json.Marshal(call.Input); err == nil {
+				total += estimateTextTokens(string(input))
+			}
+		}
+		for _, result := range msg.ToolResults {
+			total += estimateTextTokens(result.Content) + 4
+		}
 	}
 	if total < 1 {
 		return 1
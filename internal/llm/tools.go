@@ -14,6 +14,14 @@ type ToolCall struct {
 	Input map[string]interface{} `json:"input"`
 }
 
+// ToolResult represents the outcome of a tool call, matched to it by ToolCallID
+type ToolResult struct {
+	ToolCallID string `json:"tool_call_id"`
+	Name       string `json:"name"`
+	Content    string `json:"content"`
+	IsError    bool   `json:"is_error,omitempty"`
+}
+
 // GetTools returns the available tools for the LLM
 func GetTools() []Tool {
 	return []Tool{
@@ -31,6 +39,10 @@ func GetTools() []Tool {
 						"type":        "string",
 						"description": "명령어 실행 목적 설명",
 					},
+					"inverse_command": map[string]interface{}{
+						"type":        "string",
+						"description": "이 명령어의 효과를 되돌리는 명령어 (상태를 변경하는 명령어이고 되돌릴 수 있는 경우에만, 예: 'systemctl stop x'에 대해 'systemctl start x')",
+					},
 				},
 				"required": []string{"command", "description"},
 			},
@@ -71,6 +83,101 @@ func GetTools() []Tool {
 				"required": []string{"path", "content", "description"},
 			},
 		},
+		{
+			Name:        "edit_file",
+			Description: "파일의 일부만 수정합니다. 큰 파일을 수정할 때 write_file 대신 사용하세요. edits(검색/치환 목록) 또는 patch(unified diff) 중 하나를 지정합니다. 변경 전에 자동으로 백업이 생성됩니다.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "수정할 파일 경로",
+					},
+					"edits": map[string]interface{}{
+						"type":        "array",
+						"description": "순서대로 적용할 검색/치환 목록. old_text는 파일에서 정확히 한 번만 일치해야 합니다 (replace_all 사용 시 제외).",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"old_text": map[string]interface{}{
+									"type":        "string",
+									"description": "바꿀 기존 텍스트 (들여쓰기 포함, 고유하게 식별될 만큼의 주변 줄 포함)",
+								},
+								"new_text": map[string]interface{}{
+									"type":        "string",
+									"description": "새 텍스트",
+								},
+								"replace_all": map[string]interface{}{
+									"type":        "boolean",
+									"description": "모든 일치 항목을 치환할지 여부",
+								},
+							},
+							"required": []string{"old_text", "new_text"},
+						},
+					},
+					"patch": map[string]interface{}{
+						"type":        "string",
+						"description": "적용할 unified diff (@@ -l,s +l,s @@ 헝크 포함)",
+					},
+					"description": map[string]interface{}{
+						"type":        "string",
+						"description": "파일 수정 목적 설명",
+					},
+				},
+				"required": []string{"path", "description"},
+			},
+		},
+		{
+			Name:        "get_config_value",
+			Description: "YAML, JSON, TOML 설정 파일에서 경로로 지정한 값을 읽습니다. 큰 설정 파일 전체를 읽는 대신 사용하세요.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "설정 파일 경로",
+					},
+					"key_path": map[string]interface{}{
+						"type":        "string",
+						"description": `값의 경로 (예: spec.storageClassName, containers[0].image, [plugins."io.containerd.grpc.v1.cri"].snapshotter)`,
+					},
+					"document": map[string]interface{}{
+						"type":        "integer",
+						"description": "여러 문서(---)가 있는 YAML 파일에서 대상 문서 번호 (0부터 시작)",
+					},
+				},
+				"required": []string{"path", "key_path"},
+			},
+		},
+		{
+			Name:        "set_config_value",
+			Description: "YAML, JSON, TOML 설정 파일에서 경로로 지정한 값 하나를 변경하거나 추가합니다. 주석, 키 순서, 나머지 서식이 유지됩니다. 변경 전에 자동으로 백업이 생성됩니다.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "설정 파일 경로",
+					},
+					"key_path": map[string]interface{}{
+						"type":        "string",
+						"description": `값의 경로 (예: spec.storageClassName, containers[0].image, [plugins."io.containerd.grpc.v1.cri"].snapshotter). 없는 키는 생성됩니다.`,
+					},
+					"value": map[string]interface{}{
+						"description": "설정할 값 (문자열, 숫자, 불리언, 목록, 객체)",
+					},
+					"document": map[string]interface{}{
+						"type":        "integer",
+						"description": "여러 문서(---)가 있는 YAML 파일에서 대상 문서 번호 (0부터 시작)",
+					},
+					"description": map[string]interface{}{
+						"type":        "string",
+						"description": "설정 변경 목적 설명",
+					},
+				},
+				"required": []string{"path", "key_path", "value", "description"},
+			},
+		},
 		{
 			Name:        "search_web",
 			Description: "웹에서 정보를 검색합니다. 스토리지 문제 해결을 위한 유사 사례나 해결 방안을 찾을 때 사용합니다.",
@@ -85,26 +192,187 @@ func GetTools() []Tool {
 				"required": []string{"query"},
 			},
 		},
+		{
+			Name:        "fetch_url",
+			Description: "웹 페이지를 가져와 본문 텍스트를 읽습니다. search_web 결과의 GitHub 이슈, 벤더 KB 문서 등 전체 내용을 확인할 때 사용합니다. GitHub 이슈/PR은 댓글까지 포함됩니다.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"url": map[string]interface{}{
+						"type":        "string",
+						"description": "가져올 페이지 URL (http 또는 https)",
+					},
+					"max_tokens": map[string]interface{}{
+						"type":        "integer",
+						"description": "본문 최대 토큰 수 (기본값 4000, 초과 시 앞부분과 끝부분만 유지)",
+					},
+				},
+				"required": []string{"url"},
+			},
+		},
 		{
 			Name:        "monitor_log",
-			Description: "로그 파일을 모니터링하거나 검색합니다.",
+			Description: "로그 파일, systemd journal, 컨테이너 로그를 모니터링하거나 검색합니다.",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
+					"source": map[string]interface{}{
+						"type":        "string",
+						"description": "로그 소스: 'file' (기본값, path 필요), 'journal' (journalctl, unit으로 선택), 'cri' (/var/log/pods의 CRI 형식 컨테이너 로그, path 또는 pod로 선택), 'kubectl' (kubectl logs, pod로 선택)",
+					},
 					"path": map[string]interface{}{
 						"type":        "string",
-						"description": "로그 파일 경로",
+						"description": "로그 파일 경로 (file 또는 cri 소스)",
+					},
+					"unit": map[string]interface{}{
+						"type":        "string",
+						"description": "journal 소스의 systemd 유닛, 쉼표로 여러 개 지정 (예: 'kubelet,iscsid,multipathd')",
+					},
+					"namespace": map[string]interface{}{
+						"type":        "string",
+						"description": "cri 또는 kubectl 소스의 파드 네임스페이스",
+					},
+					"pod": map[string]interface{}{
+						"type":        "string",
+						"description": "cri 또는 kubectl 소스의 파드 이름 (kubectl은 'deployment/이름' 형식도 가능)",
+					},
+					"container": map[string]interface{}{
+						"type":        "string",
+						"description": "컨테이너 이름 (컨테이너가 하나인 파드는 생략 가능)",
+					},
+					"previous": map[string]interface{}{
+						"type":        "boolean",
+						"description": "kubectl 소스에서 재시작 전 이전 컨테이너의 로그를 읽음",
 					},
 					"action": map[string]interface{}{
 						"type":        "string",
-						"description": "동작: 'tail' (실시간 모니터링), 'search' (패턴 검색), 'filter' (레벨 필터링), 'summarize' (요약)",
+						"description": "동작: 'tail' (실시간 모니터링, file 소스만, TUI에서는 백그라운드 감시로 실행), 'search' (패턴 검색), 'filter' (레벨 필터링), 'summarize' (레벨 집계와 메시지 패턴별 횟수, 처음/마지막 시각, 급증 구간 요약), 'watch' (백그라운드 감시 시작, pattern/level에 맞는 새 줄을 이벤트로 보고), 'list_watches' (감시 목록), 'cancel_watch' (watch_id의 감시 중지)",
 					},
 					"pattern": map[string]interface{}{
 						"type":        "string",
-						"description": "검색 패턴 (search 또는 filter 액션 시 필요)",
+						"description": "검색 패턴 (search 또는 filter 액션 시 필요, watch에서는 이벤트로 보고할 줄의 정규식)",
+					},
+					"level": map[string]interface{}{
+						"type":        "string",
+						"description": "watch에서 이벤트로 보고할 로그 레벨 (ERROR, WARN 등, pattern과 함께 쓰면 둘 다 맞는 줄만)",
+					},
+					"inject": map[string]interface{}{
+						"type":        "boolean",
+						"description": "watch 이벤트를 모아 요약해서 대화에 전달 (조치 후 같은 오류가 다시 나는지 지켜볼 때 사용)",
+					},
+					"watch_id": map[string]interface{}{
+						"type":        "string",
+						"description": "cancel_watch로 중지할 감시 ID (예: 'w1', 모두 중지하려면 'all')",
+					},
+					"max_matches": map[string]interface{}{
+						"type":        "integer",
+						"description": "반환할 최대 매치 수 (기본값 200, 나머지는 개수만 표시)",
+					},
+					"context": map[string]interface{}{
+						"type":        "integer",
+						"description": "각 매치 앞뒤로 함께 보여줄 줄 수 (grep -C)",
+					},
+					"since": map[string]interface{}{
+						"type":        "string",
+						"description": "이 시각 이후의 줄만 검색 (예: '30m', '2h', '1d', '2024-01-02 15:04')",
+					},
+					"until": map[string]interface{}{
+						"type":        "string",
+						"description": "이 시각 이전의 줄만 검색 (since와 같은 형식)",
+					},
+					"top": map[string]interface{}{
+						"type":        "integer",
+						"description": "summarize에서 보여줄 메시지 패턴 수 (기본값 20)",
+					},
+					"include_rotated": map[string]interface{}{
+						"type":        "boolean",
+						"description": "회전된 이전 로그(.1, .gz, .zst)와 재시작 전 컨테이너 로그도 오래된 순서로 함께 검색 (since 지정 시 기본값 true)",
 					},
 				},
-				"required": []string{"path", "action"},
+				"required": []string{"action"},
+			},
+		},
+		{
+			Name:        "correlate_logs",
+			Description: "kubelet, CSI 노드 플러그인, CSI 컨트롤러, 스토리지 백엔드처럼 여러 컴포넌트의 로그를 시간 범위 안에서 읽어 타임스탬프와 시간대를 맞춘 하나의 타임라인으로 합칩니다. key로 볼륨 ID, PVC 이름, 노드를 지정하면 각 컴포넌트가 그 대상에 대해 남긴 줄만 모아 보여줍니다.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"sources": map[string]interface{}{
+						"type":        "array",
+						"description": "합칠 로그 소스 목록 (monitor_log와 같은 방식으로 선택)",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"name": map[string]interface{}{
+									"type":        "string",
+									"description": "타임라인에 표시할 컴포넌트 이름 (예: 'kubelet', 'csi-node', 'csi-controller', 'backend')",
+								},
+								"source": map[string]interface{}{
+									"type":        "string",
+									"description": "로그 소스: 'file' (기본값), 'journal', 'cri', 'kubectl'",
+								},
+								"path": map[string]interface{}{
+									"type":        "string",
+									"description": "로그 파일 경로 (file 또는 cri 소스)",
+								},
+								"unit": map[string]interface{}{
+									"type":        "string",
+									"description": "journal 소스의 systemd 유닛, 쉼표로 여러 개 지정",
+								},
+								"namespace": map[string]interface{}{
+									"type":        "string",
+									"description": "cri 또는 kubectl 소스의 파드 네임스페이스",
+								},
+								"pod": map[string]interface{}{
+									"type":        "string",
+									"description": "cri 또는 kubectl 소스의 파드 이름",
+								},
+								"container": map[string]interface{}{
+									"type":        "string",
+									"description": "컨테이너 이름",
+								},
+								"previous": map[string]interface{}{
+									"type":        "boolean",
+									"description": "kubectl 소스에서 재시작 전 이전 컨테이너의 로그를 읽음",
+								},
+								"timezone": map[string]interface{}{
+									"type":        "string",
+									"description": "이 로그가 시간대 없이 기록한 타임스탬프의 시간대 (예: 'UTC', '+09:00', 'Asia/Seoul', 기본값 로컬 시간)",
+								},
+							},
+						},
+					},
+					"since": map[string]interface{}{
+						"type":        "string",
+						"description": "시간 범위 시작 (예: '30m', '2h', '2024-01-02 15:04', 기본값 '1h')",
+					},
+					"until": map[string]interface{}{
+						"type":        "string",
+						"description": "시간 범위 끝 (since와 같은 형식, 기본값 현재)",
+					},
+					"key": map[string]interface{}{
+						"type":        "string",
+						"description": "기준 키: 볼륨 ID, PVC 이름, 노드 이름 등 (대소문자 무시, 쉼표로 여러 개 지정하면 하나라도 포함된 줄)",
+					},
+					"pattern": map[string]interface{}{
+						"type":        "string",
+						"description": "추가로 거를 줄의 정규식",
+					},
+					"max_lines": map[string]interface{}{
+						"type":        "integer",
+						"description": "타임라인에 보여줄 최대 줄 수 (기본값 300, 이른 순서부터)",
+					},
+					"timezone": map[string]interface{}{
+						"type":        "string",
+						"description": "타임라인 시각을 표시할 시간대 (기본값 로컬 시간)",
+					},
+					"include_rotated": map[string]interface{}{
+						"type":        "boolean",
+						"description": "회전된 이전 로그와 재시작 전 컨테이너 로그도 함께 읽음 (기본값 true)",
+					},
+				},
+				"required": []string{"sources"},
 			},
 		},
 		{
@@ -123,7 +391,3 @@ func GetTools() []Tool {
 		},
 	}
 }
-
-
-
-
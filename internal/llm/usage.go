@@ -0,0 +1,33 @@
+package llm
+
+import (
+	"context"
+
+	"github.com/mainbong/storage_doctor/internal/logger"
+)
+
+// UsageReporter receives the token usage a provider reported for one call
+// along with the model that served it
+type UsageReporter func(model string, usage Usage)
+
+type usageReporterKey struct{}
+
+// WithUsageReporter attaches a UsageReporter to ctx
+func WithUsageReporter(ctx context.Context, reporter UsageReporter) context.Context {
+	if reporter == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, usageReporterKey{}, reporter)
+}
+
+func reportUsage(ctx context.Context, model string, usage Usage) {
+	if usage.Total() == 0 {
+		return
+	}
+	logger.Info("LLM 토큰 사용 (%s): 입력 %d, 출력 %d, 캐시 쓰기 %d, 캐시 읽기 %d (캐시 적중률 %.0f%%)",
+		model, usage.InputTokens, usage.OutputTokens, usage.CacheCreationInputTokens, usage.CacheReadInputTokens,
+		usage.CacheHitRate()*100)
+	if reporter, ok := ctx.Value(usageReporterKey{}).(UsageReporter); ok && reporter != nil {
+		reporter(model, usage)
+	}
+}
@@ -62,7 +62,8 @@ func NewLogger(logDir string, level LogLevel) (*Logger, error) {
 	}
 
 	fileLogger := log.New(file, "", log.LstdFlags)
-	consoleLogger := log.New(os.Stdout, "", log.LstdFlags)
+	// Warnings go to stderr so that stdout stays usable for reports
+	consoleLogger := log.New(os.Stderr, "", log.LstdFlags)
 
 	// Also create a symlink to latest.log for easy access
 	latestLog := filepath.Join(logDir, "latest.log")
@@ -0,0 +1,268 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultMaxEntries is how many timeline entries Correlate returns when
+// CorrelateOptions.MaxEntries is not set
+const DefaultMaxEntries = 300
+
+// Component is one log taking part in a correlation, such as kubelet, the CSI
+// node plugin, the CSI controller or the storage backend
+type Component struct {
+	// Name labels the component's lines in the timeline
+	Name   string
+	Source Source
+	// Location is the zone of timestamps the source writes without one; nil
+	// reads them in local time
+	Location *time.Location
+}
+
+// CorrelateOptions selects the lines merged into a timeline
+type CorrelateOptions struct {
+	// Since and Until bound the timeline; zero values leave it open
+	Since time.Time
+	Until time.Time
+	// Keys pivot the timeline: only lines containing one of them (a volume
+	// ID, PVC name or node) are kept. Matching ignores case.
+	Keys []string
+	// Pattern additionally filters lines; nil keeps all
+	Pattern *regexp.Regexp
+	// MaxEntries is the number of entries returned, earliest first; later
+	// ones are only counted
+	MaxEntries int
+	// IncludeRotated also reads rotated files and previous containers
+	IncludeRotated bool
+}
+
+// TimelineEntry is one line of the merged timeline
+type TimelineEntry struct {
+	Component string
+	Level     string
+	Line      Line
+}
+
+// ComponentResult is what one component contributed to a timeline
+type ComponentResult struct {
+	Name string
+	// Matches is the number of lines in the window matching the keys
+	Matches int
+	// Levels counts the matching lines by level
+	Levels map[string]int
+	First  time.Time
+	Last   time.Time
+	// Untimed is the number of matching lines without a timestamp, left out
+	// because they cannot be placed
+	Untimed int
+	// Err is set when the component could not be read; the others still are
+	Err error
+}
+
+// Timeline is the merged, time-ordered view of several components
+type Timeline struct {
+	Entries    []TimelineEntry
+	Components []ComponentResult
+	// Total is the number of matching lines, including those beyond MaxEntries
+	Total int
+}
+
+// Omitted returns how many matching lines were counted but not returned
+func (t *Timeline) Omitted() int {
+	return t.Total - len(t.Entries)
+}
+
+// Correlate reads every component in the window and merges the matching lines
+// into one timeline ordered by their normalized timestamps. Lines logged at
+// the same instant keep the order of the components.
+func Correlate(ctx context.Context, components []Component, opts CorrelateOptions) (*Timeline, error) {
+	if len(components) == 0 {
+		return nil, fmt.Errorf("no log sources to correlate")
+	}
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = DefaultMaxEntries
+	}
+	keys := make([]string, 0, len(opts.Keys))
+	for _, key := range opts.Keys {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, strings.ToLower(key))
+		}
+	}
+
+	timeline := &Timeline{}
+	var entries []TimelineEntry
+	failed := 0
+	for _, component := range components {
+		result := ComponentResult{Name: component.Name, Levels: make(map[string]int)}
+		readOpts := SearchOptions{
+			Since:          opts.Since,
+			Until:          opts.Until,
+			IncludeRotated: opts.IncludeRotated,
+			Location:       component.Location,
+		}
+		kept := 0
+		err := component.Source.Read(ctx, readOpts, func(line Line) bool {
+			if !matchesKeys(line.Text, keys) || (opts.Pattern != nil && !opts.Pattern.MatchString(line.Text)) {
+				return true
+			}
+			if line.Time.IsZero() {
+				result.Untimed++
+				return true
+			}
+			if !inTimeRange(line.Time, readOpts) {
+				return true
+			}
+
+			level := line.Level
+			if level == "" {
+				level = ParseLevel(line.Text)
+			}
+			result.Matches++
+			if level != "" {
+				result.Levels[level]++
+			}
+			if result.First.IsZero() || line.Time.Before(result.First) {
+				result.First = line.Time
+			}
+			if line.Time.After(result.Last) {
+				result.Last = line.Time
+			}
+			// Sources read oldest first, so a component's earliest lines are
+			// all the merge can use
+			if kept < opts.MaxEntries {
+				entries = append(entries, TimelineEntry{Component: component.Name, Level: level, Line: line})
+				kept++
+			}
+			return true
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			result.Err = err
+			failed++
+		}
+		timeline.Total += result.Matches
+		timeline.Components = append(timeline.Components, result)
+	}
+	if failed == len(components) {
+		return nil, fmt.Errorf("failed to read every source: %w", timeline.Components[0].Err)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Line.Time.Before(entries[j].Line.Time)
+	})
+	if len(entries) > opts.MaxEntries {
+		entries = entries[:opts.MaxEntries]
+	}
+	timeline.Entries = entries
+	return timeline, nil
+}
+
+func matchesKeys(text string, keys []string) bool {
+	if len(keys) == 0 {
+		return true
+	}
+	text = strings.ToLower(text)
+	for _, key := range keys {
+		if strings.Contains(text, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatTimeline renders a timeline for the agent with every time shown in
+// loc: a per-component overview, then the interleaved lines
+func FormatTimeline(timeline *Timeline, opts CorrelateOptions, loc *time.Location) string {
+	if loc == nil {
+		loc = time.Local
+	}
+	width := 0
+	for _, component := range timeline.Components {
+		if len(component.Name) > width {
+			width = len(component.Name)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("로그 상관 분석")
+	if !opts.Since.IsZero() || !opts.Until.IsZero() {
+		fmt.Fprintf(&b, " %s ~ %s", formatBound(opts.Since, loc), formatBound(opts.Until, loc))
+	}
+	fmt.Fprintf(&b, " (시각 기준: %s)", zoneName(loc))
+	if len(opts.Keys) > 0 {
+		fmt.Fprintf(&b, "\n키: %s", strings.Join(opts.Keys, ", "))
+	}
+
+	b.WriteString("\n\n컴포넌트별:")
+	for _, component := range timeline.Components {
+		fmt.Fprintf(&b, "\n  %-*s  ", width, component.Name)
+		switch {
+		case component.Err != nil:
+			fmt.Fprintf(&b, "읽기 실패: %v", component.Err)
+			continue
+		case component.Matches == 0:
+			b.WriteString("해당 줄 없음")
+		default:
+			fmt.Fprintf(&b, "%d줄, %s ~ %s", component.Matches,
+				component.First.In(loc).Format("01-02 15:04:05.000"), component.Last.In(loc).Format("01-02 15:04:05.000"))
+			if n := component.Levels["ERROR"]; n > 0 {
+				fmt.Fprintf(&b, ", ERROR %d", n)
+			}
+			if n := component.Levels["WARN"]; n > 0 {
+				fmt.Fprintf(&b, ", WARN %d", n)
+			}
+		}
+		if component.Untimed > 0 {
+			fmt.Fprintf(&b, " (타임스탬프 없는 %d줄 제외)", component.Untimed)
+		}
+	}
+
+	if len(timeline.Entries) == 0 {
+		b.WriteString("\n\n범위 안에 해당하는 로그가 없습니다.")
+		return b.String()
+	}
+	if omitted := timeline.Omitted(); omitted > 0 {
+		fmt.Fprintf(&b, "\n\n타임라인 (%d줄 중 앞의 %d줄):", timeline.Total, len(timeline.Entries))
+	} else {
+		fmt.Fprintf(&b, "\n\n타임라인 (%d줄):", timeline.Total)
+	}
+	for _, entry := range timeline.Entries {
+		fmt.Fprintf(&b, "\n%s [%-*s] %s", entry.Line.Time.In(loc).Format("01-02 15:04:05.000"), width, entry.Component, shortenLine(entry.Line.Text))
+	}
+	if omitted := timeline.Omitted(); omitted > 0 {
+		fmt.Fprintf(&b, "\n... %d줄 더 있음 (since/until이나 key로 범위를 좁히거나 max_lines를 늘리세요)", omitted)
+	}
+	return b.String()
+}
+
+func formatBound(t time.Time, loc *time.Location) string {
+	if t.IsZero() {
+		return "..."
+	}
+	return t.In(loc).Format("2006-01-02 15:04:05")
+}
+
+// zoneName describes loc with its current UTC offset
+func zoneName(loc *time.Location) string {
+	name, offset := time.Now().In(loc).Zone()
+	sign := "+"
+	if offset < 0 {
+		sign = "-"
+		offset = -offset
+	}
+	utc := fmt.Sprintf("UTC%s%02d:%02d", sign, offset/3600, offset%3600/60)
+	if name == "UTC" && offset == 0 {
+		return name
+	}
+	if name == "" || name == utc || strings.HasPrefix(name, "UTC+") || strings.HasPrefix(name, "UTC-") {
+		return utc
+	}
+	return name + ", " + utc
+}
@@ -0,0 +1,119 @@
+package logs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func correlateComponents(t *testing.T) []Component {
+	t.Helper()
+	dir := t.TempDir()
+	write := func(name string, lines ...string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	kubelet := write("kubelet.log",
+		"2025-01-02 10:00:00.000 reconciler: attaching volume pvc-aaa on node-1",
+		"2025-01-02 10:00:03.000 ERROR operation: MountVolume.SetUp failed for volume pvc-aaa: timed out",
+		"2025-01-02 10:00:04.000 reconciler: volume pvc-bbb mounted",
+	)
+	controller := write("controller.log",
+		"2025-01-02T09:59:00Z stdout F ControllerPublishVolume pvc-aaa started early",
+		"2025-01-02T10:00:01.5Z stdout F ControllerPublishVolume pvc-aaa node-1",
+		"2025-01-02T10:00:02.5Z stderr F error: publish of PVC-AAA slow",
+	)
+	// The array logs Seoul time without a zone
+	backend := write("array.log",
+		"header without a timestamp pvc-aaa",
+		"2025-01-02 19:00:02.000 array: LUN for pvc-aaa mapped to node-1",
+	)
+
+	return []Component{
+		{Name: "kubelet", Source: NewFileSource(kubelet), Location: time.UTC},
+		{Name: "csi-controller", Source: NewCRIFileSource(controller)},
+		{Name: "backend", Source: NewFileSource(backend), Location: time.FixedZone("KST", 9*3600)},
+	}
+}
+
+func TestCorrelate_MergesByTime(t *testing.T) {
+	opts := CorrelateOptions{
+		Since: time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC),
+		Until: time.Date(2025, 1, 2, 11, 0, 0, 0, time.UTC),
+		Keys:  []string{"pvc-aaa"},
+	}
+	timeline, err := Correlate(context.Background(), correlateComponents(t), opts)
+	if err != nil {
+		t.Fatalf("Correlate() failed: %v", err)
+	}
+
+	var order []string
+	for _, entry := range timeline.Entries {
+		order = append(order, entry.Component+"@"+entry.Line.Time.UTC().Format("15:04:05.0"))
+	}
+	expected := "kubelet@10:00:00.0 csi-controller@10:00:01.5 backend@10:00:02.0 csi-controller@10:00:02.5 kubelet@10:00:03.0"
+	if got := strings.Join(order, " "); got != expected {
+		t.Errorf("Unexpected order:\n got %s\nwant %s", got, expected)
+	}
+	if timeline.Total != 5 || timeline.Omitted() != 0 {
+		t.Errorf("Expected 5 lines, got %d (%d omitted)", timeline.Total, timeline.Omitted())
+	}
+
+	kubelet, backend := timeline.Components[0], timeline.Components[2]
+	if kubelet.Matches != 2 || kubelet.Levels["ERROR"] != 1 {
+		t.Errorf("Unexpected kubelet result: %+v", kubelet)
+	}
+	if backend.Matches != 1 || backend.Untimed != 1 {
+		t.Errorf("Expected the untimed backend line to be left out, got %+v", backend)
+	}
+
+	digest := FormatTimeline(timeline, opts, time.UTC)
+	for _, want := range []string{
+		"(시각 기준: UTC)",
+		"키: pvc-aaa",
+		"kubelet         2줄, 01-02 10:00:00.000 ~ 01-02 10:00:03.000, ERROR 1",
+		"(타임스탬프 없는 1줄 제외)",
+		"01-02 10:00:02.000 [backend       ] 2025-01-02 19:00:02.000 array: LUN",
+	} {
+		if !strings.Contains(digest, want) {
+			t.Errorf("Expected digest to contain %q:\n%s", want, digest)
+		}
+	}
+}
+
+func TestCorrelate_LimitsAndErrors(t *testing.T) {
+	components := correlateComponents(t)
+	components = append(components, Component{Name: "node-plugin", Source: &fakeSource{err: errors.New("journalctl not found")}})
+
+	opts := CorrelateOptions{Pattern: regexp.MustCompile(`node-1`), MaxEntries: 2}
+	timeline, err := Correlate(context.Background(), components, opts)
+	if err != nil {
+		t.Fatalf("Correlate() failed: %v", err)
+	}
+	if timeline.Total != 3 || len(timeline.Entries) != 2 || timeline.Entries[0].Component != "kubelet" {
+		t.Errorf("Expected the 2 earliest of 3 lines, got %d: %+v", timeline.Total, timeline.Entries)
+	}
+	if timeline.Components[3].Err == nil {
+		t.Error("Expected the failing component to be reported")
+	}
+	digest := FormatTimeline(timeline, opts, time.UTC)
+	if !strings.Contains(digest, "읽기 실패: journalctl not found") || !strings.Contains(digest, "1줄 더 있음") {
+		t.Errorf("Unexpected digest:\n%s", digest)
+	}
+
+	if _, err := Correlate(context.Background(), components[3:], opts); err == nil {
+		t.Error("Expected an error when no source can be read")
+	}
+	if _, err := Correlate(context.Background(), nil, opts); err == nil {
+		t.Error("Expected an error without sources")
+	}
+}
@@ -0,0 +1,284 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPodLogDir is where the kubelet keeps CRI container logs
+const DefaultPodLogDir = "/var/log/pods"
+
+// criRotatedSuffix matches the kubelet's rotation suffix: 0.log.20240102-150405[.gz]
+var criRotatedSuffix = regexp.MustCompile(`^\.\d{8}-\d{6}(\.gz|\.zst)?$`)
+
+// CRISource reads container logs in the CRI format written by the kubelet:
+// "2024-01-02T15:04:05.123456789Z stdout F message". Partial lines (P) are
+// joined with the line completing them.
+type CRISource struct {
+	name  string
+	files func(opts SearchOptions) ([]string, error)
+}
+
+// NewCRIFileSource creates a source reading one CRI log file and, when asked,
+// the files the kubelet rotated out of it
+func NewCRIFileSource(path string) *CRISource {
+	return &CRISource{
+		name: path,
+		files: func(opts SearchOptions) ([]string, error) {
+			if !opts.IncludeRotated {
+				return []string{path}, nil
+			}
+			rotated, err := criRotatedFiles(path)
+			if err != nil {
+				return nil, err
+			}
+			return append(rotated, path), nil
+		},
+	}
+}
+
+// NewPodSource creates a source reading the logs of a pod's container under
+// root (normally DefaultPodLogDir). An empty namespace matches any namespace
+// and container may be empty for single-container pods. Only the current
+// container instance is read unless rotated files are asked for, which adds
+// the logs of earlier restarts.
+func NewPodSource(root, namespace, pod, container string) *CRISource {
+	name := "pod:" + pod
+	if namespace != "" {
+		name = "pod:" + namespace + "/" + pod
+	}
+	if container != "" {
+		name += "/" + container
+	}
+	return &CRISource{
+		name: name,
+		files: func(opts SearchOptions) ([]string, error) {
+			dir, err := podContainerDir(root, namespace, pod, container)
+			if err != nil {
+				return nil, err
+			}
+			return containerLogFiles(dir, opts.IncludeRotated)
+		},
+	}
+}
+
+func (s *CRISource) Name() string {
+	return s.name
+}
+
+func (s *CRISource) Read(ctx context.Context, opts SearchOptions, fn func(Line) bool) error {
+	files, err := s.files(opts)
+	if err != nil {
+		return err
+	}
+	for i, path := range files {
+		if i < len(files)-1 && !opts.Since.IsZero() {
+			if stat, err := os.Stat(path); err == nil && stat.ModTime().Before(opts.Since) {
+				continue
+			}
+		}
+		origin := ""
+		if len(files) > 1 {
+			origin = filepath.Base(path)
+		}
+		more, err := readCRILines(ctx, path, origin, fn)
+		if err != nil || !more {
+			return err
+		}
+	}
+	return nil
+}
+
+// readCRILines streams one CRI log file. It reports whether fn asked for more lines.
+func readCRILines(ctx context.Context, path, origin string, fn func(Line) bool) (bool, error) {
+	reader, err := openLog(path)
+	if err != nil {
+		return false, err
+	}
+	defer reader.Close()
+
+	var partial strings.Builder
+	partialStart := 0
+	var lastTime time.Time
+	scanner := newLineScanner(reader)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		if lineNum%ctxCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return false, err
+			}
+		}
+		raw := scanner.Text()
+		timestamp, stream, flag, message, ok := parseCRILine(raw)
+		if !ok {
+			if !fn(Line{Origin: origin, Number: lineNum, Time: lastTime, Text: raw, Message: raw}) {
+				return false, nil
+			}
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, timestamp)
+		if err == nil {
+			lastTime = t
+		}
+
+		if flag == "P" {
+			if partial.Len() == 0 {
+				partialStart = lineNum
+			}
+			if partial.Len() < maxLineBytes {
+				partial.WriteString(message)
+			}
+			continue
+		}
+		number := lineNum
+		if partial.Len() > 0 {
+			partial.WriteString(message)
+			message = partial.String()
+			number = partialStart
+			partial.Reset()
+		}
+
+		line := Line{
+			Origin:  origin,
+			Number:  number,
+			Time:    lastTime,
+			Stream:  stream,
+			Text:    timestamp + " " + stream + " " + message,
+			Message: message,
+		}
+		if !fn(line) {
+			return false, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("failed to scan file: %w", err)
+	}
+	return true, nil
+}
+
+// parseCRILine splits a CRI log line into its timestamp, stream, P/F flag and message
+func parseCRILine(raw string) (timestamp, stream, flag, message string, ok bool) {
+	fields := strings.SplitN(raw, " ", 4)
+	if len(fields) < 3 || (fields[1] != "stdout" && fields[1] != "stderr") || (fields[2] != "P" && fields[2] != "F") {
+		return "", "", "", "", false
+	}
+	if len(fields) == 4 {
+		message = fields[3]
+	}
+	return fields[0], fields[1], fields[2], message, true
+}
+
+// criRotatedFiles returns the files the kubelet rotated out of a CRI log, oldest first
+func criRotatedFiles(path string) ([]string, error) {
+	dir, base := filepath.Split(path)
+	entries, err := os.ReadDir(filepath.Clean(dir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+	var rotated []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasPrefix(name, base) && criRotatedSuffix.MatchString(name[len(base):]) {
+			rotated = append(rotated, filepath.Join(dir, name))
+		}
+	}
+	// The suffix is a timestamp, so names sort by age
+	sort.Strings(rotated)
+	return rotated, nil
+}
+
+// podContainerDir finds <root>/<namespace>_<pod>_<uid>/<container>
+func podContainerDir(root, namespace, pod, container string) (string, error) {
+	if pod == "" {
+		return "", fmt.Errorf("pod name is required")
+	}
+	if namespace == "" {
+		namespace = "*"
+	}
+	podDirs, err := filepath.Glob(filepath.Join(root, namespace+"_"+pod+"_*"))
+	if err != nil {
+		return "", fmt.Errorf("invalid pod selector: %w", err)
+	}
+	if len(podDirs) == 0 {
+		return "", fmt.Errorf("no logs for pod %s under %s", pod, root)
+	}
+	// A pod recreated under the same name leaves one directory per UID
+	podDir := latestModified(podDirs)
+
+	if container != "" {
+		dir := filepath.Join(podDir, container)
+		if _, err := os.Stat(dir); err != nil {
+			return "", fmt.Errorf("no logs for container %s of pod %s: %w", container, pod, err)
+		}
+		return dir, nil
+	}
+
+	entries, err := os.ReadDir(podDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read directory: %w", err)
+	}
+	var containers []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			containers = append(containers, entry.Name())
+		}
+	}
+	if len(containers) != 1 {
+		return "", fmt.Errorf("pod %s has %d containers (%s); choose one", pod, len(containers), strings.Join(containers, ", "))
+	}
+	return filepath.Join(podDir, containers[0]), nil
+}
+
+// containerLogFiles returns the logs of a container directory, oldest first.
+// The kubelet writes <restart>.log per container instance.
+func containerLogFiles(dir string, includeRotated bool) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+	var restarts []int
+	for _, entry := range entries {
+		if restart, err := strconv.Atoi(strings.TrimSuffix(entry.Name(), ".log")); err == nil && strings.HasSuffix(entry.Name(), ".log") {
+			restarts = append(restarts, restart)
+		}
+	}
+	if len(restarts) == 0 {
+		return nil, fmt.Errorf("no container logs in %s", dir)
+	}
+	sort.Ints(restarts)
+	if !includeRotated {
+		restarts = restarts[len(restarts)-1:]
+	}
+
+	var files []string
+	for _, restart := range restarts {
+		current := filepath.Join(dir, strconv.Itoa(restart)+".log")
+		if includeRotated {
+			rotated, err := criRotatedFiles(current)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, rotated...)
+		}
+		files = append(files, current)
+	}
+	return files, nil
+}
+
+// latestModified returns the most recently modified path
+func latestModified(paths []string) string {
+	latest := paths[0]
+	var latestTime time.Time
+	for _, path := range paths {
+		if stat, err := os.Stat(path); err == nil && stat.ModTime().After(latestTime) {
+			latest, latestTime = path, stat.ModTime()
+		}
+	}
+	return latest
+}
@@ -0,0 +1,94 @@
+package logs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCRISource_Read(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "0.log")
+	content := strings.Join([]string{
+		"2025-01-02T15:04:05.000000001Z stdout F volume attached",
+		"2025-01-02T15:04:06Z stderr P mount failed: exit status 32, ",
+		"2025-01-02T15:04:06Z stderr F wrong fs type",
+		"not a cri line",
+		"2025-01-02T15:04:07Z stdout F ",
+	}, "\n") + "\n"
+	os.WriteFile(path, []byte(content), 0644)
+
+	var lines []Line
+	err := NewCRIFileSource(path).Read(context.Background(), SearchOptions{}, func(line Line) bool {
+		lines = append(lines, line)
+		return true
+	})
+	if err != nil {
+		t.Fatalf("Read() failed: %v", err)
+	}
+	if len(lines) != 4 {
+		t.Fatalf("Expected 4 lines, got %d: %+v", len(lines), lines)
+	}
+	if lines[0].Message != "volume attached" || lines[0].Stream != "stdout" || !lines[0].Time.Equal(time.Date(2025, 1, 2, 15, 4, 5, 1, time.UTC)) {
+		t.Errorf("Unexpected first line: %+v", lines[0])
+	}
+	if lines[1].Message != "mount failed: exit status 32, wrong fs type" || lines[1].Number != 2 || lines[1].Stream != "stderr" {
+		t.Errorf("Expected the partial line to be joined, got %+v", lines[1])
+	}
+	if lines[1].Text != "2025-01-02T15:04:06Z stderr mount failed: exit status 32, wrong fs type" {
+		t.Errorf("Unexpected text: %q", lines[1].Text)
+	}
+	if lines[2].Text != "not a cri line" || !lines[2].Time.Equal(lines[1].Time) {
+		t.Errorf("Expected a malformed line to pass through with the previous time, got %+v", lines[2])
+	}
+}
+
+func TestPodSource_Read(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "longhorn-system_csi-attacher-0_1234", "csi-attacher")
+	os.MkdirAll(dir, 0755)
+	os.WriteFile(filepath.Join(dir, "0.log"), []byte("2025-01-02T10:00:00Z stderr F first instance\n"), 0644)
+	writeGzip(t, filepath.Join(dir, "1.log.20250102-110000.gz"), "2025-01-02T11:00:00Z stderr F rotated\n")
+	os.WriteFile(filepath.Join(dir, "1.log"), []byte("2025-01-02T12:00:00Z stderr F current\n"), 0644)
+
+	read := func(source *CRISource, opts SearchOptions) []string {
+		t.Helper()
+		var texts []string
+		if err := source.Read(context.Background(), opts, func(line Line) bool {
+			texts = append(texts, line.Origin+" "+line.Message)
+			return true
+		}); err != nil {
+			t.Fatalf("Read() failed: %v", err)
+		}
+		return texts
+	}
+
+	source := NewPodSource(root, "", "csi-attacher-0", "")
+	if got := read(source, SearchOptions{}); strings.Join(got, "|") != " current" {
+		t.Errorf("Expected only the current instance, got %v", got)
+	}
+	expected := "0.log first instance|1.log.20250102-110000.gz rotated|1.log current"
+	if got := read(source, SearchOptions{IncludeRotated: true}); strings.Join(got, "|") != expected {
+		t.Errorf("Expected %s, got %v", expected, got)
+	}
+	if source.Name() != "pod:csi-attacher-0" {
+		t.Errorf("Unexpected name %q", source.Name())
+	}
+
+	if err := NewPodSource(root, "default", "csi-attacher-0", "").Read(context.Background(), SearchOptions{}, func(Line) bool { return true }); err == nil {
+		t.Error("Expected an error for a pod in another namespace")
+	}
+}
+
+func TestPodSource_SeveralContainers(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "kube-system_csi-node_1", "driver"), 0755)
+	os.MkdirAll(filepath.Join(root, "kube-system_csi-node_1", "registrar"), 0755)
+
+	err := NewPodSource(root, "kube-system", "csi-node", "").Read(context.Background(), SearchOptions{}, func(Line) bool { return true })
+	if err == nil || !strings.Contains(err.Error(), "driver, registrar") {
+		t.Errorf("Expected the containers to be listed, got %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package logs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mainbong/storage_doctor/internal/shell"
+)
+
+// commandMaxLines bounds every journal and kubectl read; within a time range
+// the newest lines are kept
+const commandMaxLines = 50000
+
+// JournalSource reads systemd-journald entries through journalctl's JSON
+// export, optionally limited to some units (kubelet, iscsid, multipathd)
+type JournalSource struct {
+	streamer shell.CommandStreamer
+	units    []string
+}
+
+// NewJournalSource creates a journal source running journalctl with streamer
+func NewJournalSource(streamer shell.CommandStreamer, units ...string) *JournalSource {
+	return &JournalSource{
+		streamer: streamer,
+		units:    units,
+	}
+}
+
+func (s *JournalSource) Name() string {
+	if len(s.units) == 0 {
+		return "journal"
+	}
+	return "journal:" + strings.Join(s.units, ",")
+}
+
+// command builds the journalctl command line; time bounds are passed on so
+// journalctl skips old entries itself
+func (s *JournalSource) command(opts SearchOptions) string {
+	var b strings.Builder
+	b.WriteString("journalctl --no-pager -o json")
+	for _, unit := range s.units {
+		b.WriteString(" -u " + shellQuote(unit))
+	}
+	fmt.Fprintf(&b, " -n %d", commandMaxLines)
+	if !opts.Since.IsZero() {
+		b.WriteString(" --since " + shellQuote(opts.Since.Local().Format("2006-01-02 15:04:05")))
+	}
+	if !opts.Until.IsZero() {
+		b.WriteString(" --until " + shellQuote(opts.Until.Local().Format("2006-01-02 15:04:05")))
+	}
+	return b.String()
+}
+
+// journalEntry holds the journal fields we show
+type journalEntry struct {
+	Realtime   string          `json:"__REALTIME_TIMESTAMP"`
+	Message    json.RawMessage `json:"MESSAGE"`
+	Priority   string          `json:"PRIORITY"`
+	Identifier string          `json:"SYSLOG_IDENTIFIER"`
+	PID        string          `json:"_PID"`
+	Unit       string          `json:"_SYSTEMD_UNIT"`
+}
+
+func (s *JournalSource) Read(ctx context.Context, opts SearchOptions, fn func(Line) bool) error {
+	number := 0
+	err := s.streamer.Stream(ctx, s.command(opts), func(raw []byte) bool {
+		// journalctl mixes hints like "No entries" into the output
+		if len(raw) == 0 || raw[0] != '{' {
+			return true
+		}
+		var entry journalEntry
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			return true
+		}
+
+		var t time.Time
+		if micros, err := strconv.ParseInt(entry.Realtime, 10, 64); err == nil {
+			t = time.UnixMicro(micros)
+		}
+		identifier := entry.Identifier
+		if identifier == "" {
+			identifier = strings.TrimSuffix(entry.Unit, ".service")
+		}
+		if entry.PID != "" {
+			identifier += "[" + entry.PID + "]"
+		}
+		level := journalLevel(entry.Priority)
+
+		// Multi-line messages become one line each, sharing the entry's time
+		for _, message := range strings.Split(journalMessage(entry.Message), "\n") {
+			number++
+			if number%ctxCheckInterval == 0 && ctx.Err() != nil {
+				return false
+			}
+			line := Line{
+				Number:  number,
+				Time:    t,
+				Level:   level,
+				Text:    t.Format("2006-01-02T15:04:05.000000Z07:00") + " " + identifier + ": " + message,
+				Message: message,
+			}
+			if !fn(line) {
+				return false
+			}
+		}
+		return true
+	})
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		return fmt.Errorf("journalctl failed: %w", err)
+	}
+	return nil
+}
+
+// journalMessage decodes MESSAGE, which journalctl exports as a byte array
+// when it is not valid UTF-8
+func journalMessage(raw json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+	var data []byte
+	var values []int
+	if err := json.Unmarshal(raw, &values); err == nil {
+		for _, value := range values {
+			data = append(data, byte(value))
+		}
+	}
+	return string(data)
+}
+
+// journalLevel maps a syslog priority to a level
+func journalLevel(priority string) string {
+	switch priority {
+	case "0", "1", "2", "3":
+		return "ERROR"
+	case "4":
+		return "WARN"
+	case "5", "6":
+		return "INFO"
+	case "7":
+		return "DEBUG"
+	}
+	return ""
+}
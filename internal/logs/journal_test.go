@@ -0,0 +1,101 @@
+package logs
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mainbong/storage_doctor/internal/shell"
+	"github.com/mainbong/storage_doctor/internal/shell/policy"
+)
+
+func TestJournalSource_Read(t *testing.T) {
+	executor := shell.NewMockCommandExecutor()
+	executor.SetResponse("journalctl --no-pager -o json -u 'iscsid' -n 50000", strings.Join([]string{
+		`{"__REALTIME_TIMESTAMP":"1735830245000000","MESSAGE":"Connection1:0 to [target: iqn.2024-01.io.longhorn] is operational now","PRIORITY":"6","SYSLOG_IDENTIFIER":"iscsid","_PID":"812"}`,
+		`{"__REALTIME_TIMESTAMP":"1735830246000000","MESSAGE":[107,101,114,110,101,108,10,115,101,99,111,110,100],"PRIORITY":"3","_SYSTEMD_UNIT":"iscsid.service"}`,
+		`-- No entries --`,
+	}, "\n"))
+
+	source := NewJournalSource(shell.NewExecutorWithCommandExecutor("", executor), "iscsid")
+	var lines []Line
+	if err := source.Read(context.Background(), SearchOptions{}, func(line Line) bool {
+		lines = append(lines, line)
+		return true
+	}); err != nil {
+		t.Fatalf("Read() failed: %v", err)
+	}
+
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %+v", lines)
+	}
+	if !lines[0].Time.Equal(time.Unix(1735830245, 0)) || lines[0].Level != "INFO" || !strings.HasSuffix(lines[0].Text, " iscsid[812]: Connection1:0 to [target: iqn.2024-01.io.longhorn] is operational now") {
+		t.Errorf("Unexpected first line: %+v", lines[0])
+	}
+	if lines[1].Message != "kernel" || lines[2].Message != "second" || lines[2].Level != "ERROR" || !strings.Contains(lines[2].Text, " iscsid: second") {
+		t.Errorf("Expected a byte-array message split into lines, got %+v", lines[1:])
+	}
+	if source.Name() != "journal:iscsid" {
+		t.Errorf("Unexpected name %q", source.Name())
+	}
+}
+
+func TestJournalSource_Command(t *testing.T) {
+	since := time.Date(2025, 1, 2, 15, 4, 5, 0, time.Local)
+	got := NewJournalSource(nil, "kubelet", "multipathd").command(SearchOptions{Since: since, Until: since.Add(time.Hour)})
+	expected := "journalctl --no-pager -o json -u 'kubelet' -u 'multipathd' -n 50000 --since '2025-01-02 15:04:05' --until '2025-01-02 16:04:05'"
+	if got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
+
+func TestJournalSource_Error(t *testing.T) {
+	executor := shell.NewMockCommandExecutor()
+	executor.SetError("journalctl --no-pager -o json -n 50000", errors.New("exit status 1"))
+
+	err := NewJournalSource(shell.NewExecutorWithCommandExecutor("", executor)).Read(context.Background(), SearchOptions{}, func(Line) bool { return true })
+	if err == nil || !strings.Contains(err.Error(), "journalctl failed") {
+		t.Errorf("Expected a journalctl error, got %v", err)
+	}
+}
+
+func TestJournalSource_RefusedByPolicy(t *testing.T) {
+	executor := shell.NewMockCommandExecutor()
+	streamer := shell.NewExecutorWithCommandExecutor("", executor)
+	engine, err := policy.NewEngineWithRules(policy.Rules{Deny: []string{"journalctl *"}})
+	if err != nil {
+		t.Fatalf("NewEngineWithRules() failed: %v", err)
+	}
+	streamer.SetPolicy(engine)
+
+	err = NewJournalSource(streamer, "kubelet").Read(context.Background(), SearchOptions{}, func(Line) bool { return true })
+	if err == nil || !strings.Contains(err.Error(), "blocked by policy") {
+		t.Errorf("Expected the policy to block journalctl, got %v", err)
+	}
+	if len(executor.GetCommands()) != 0 {
+		t.Errorf("Expected no command to run, got %v", executor.GetCommands())
+	}
+}
+
+func TestMonitor_FilterJournalByPriority(t *testing.T) {
+	executor := shell.NewMockCommandExecutor()
+	executor.SetResponse("journalctl --no-pager -o json -u 'multipathd' -n 50000", strings.Join([]string{
+		`{"__REALTIME_TIMESTAMP":"1735830245000000","MESSAGE":"sdc: path failed","PRIORITY":"3","SYSLOG_IDENTIFIER":"multipathd"}`,
+		`{"__REALTIME_TIMESTAMP":"1735830246000000","MESSAGE":"error count reset","PRIORITY":"6","SYSLOG_IDENTIFIER":"multipathd"}`,
+	}, "\n"))
+
+	monitor := NewSourceMonitor(NewJournalSource(shell.NewExecutorWithCommandExecutor("", executor), "multipathd"))
+	result, err := monitor.FilterWithOptions(context.Background(), "err", SearchOptions{})
+	if err != nil {
+		t.Fatalf("FilterWithOptions() failed: %v", err)
+	}
+	if result.Total != 1 || !strings.HasSuffix(result.Lines[0], "multipathd: sdc: path failed") {
+		t.Errorf("Expected the priority to decide the level, got %v", result.Lines)
+	}
+
+	if err := monitor.Tail(context.Background(), func(string) {}); err == nil {
+		t.Error("Expected tail to be refused for the journal")
+	}
+}
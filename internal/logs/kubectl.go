@@ -0,0 +1,97 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mainbong/storage_doctor/internal/shell"
+)
+
+// KubectlSource reads a pod's container logs through kubectl logs, for
+// clusters where the node's /var/log/pods is not reachable
+type KubectlSource struct {
+	streamer  shell.CommandStreamer
+	namespace string
+	pod       string
+	container string
+	previous  bool
+}
+
+// NewKubectlSource creates a kubectl logs source. pod may be any name kubectl
+// accepts (mypod, deployment/csi-controller); namespace and container may be
+// empty for kubectl's defaults. previous reads the last terminated instance.
+func NewKubectlSource(streamer shell.CommandStreamer, namespace, pod, container string, previous bool) *KubectlSource {
+	return &KubectlSource{
+		streamer:  streamer,
+		namespace: namespace,
+		pod:       pod,
+		container: container,
+		previous:  previous,
+	}
+}
+
+func (s *KubectlSource) Name() string {
+	name := "kubectl:" + s.pod
+	if s.namespace != "" {
+		name = "kubectl:" + s.namespace + "/" + s.pod
+	}
+	if s.container != "" {
+		name += "/" + s.container
+	}
+	return name
+}
+
+// command builds the kubectl logs command line; the start of the time range is
+// passed on so kubectl skips old lines itself
+func (s *KubectlSource) command(opts SearchOptions) string {
+	var b strings.Builder
+	b.WriteString("kubectl logs " + shellQuote(s.pod))
+	if s.namespace != "" {
+		b.WriteString(" -n " + shellQuote(s.namespace))
+	}
+	if s.container != "" {
+		b.WriteString(" -c " + shellQuote(s.container))
+	}
+	if s.previous {
+		b.WriteString(" --previous")
+	}
+	fmt.Fprintf(&b, " --timestamps --tail=%d", commandMaxLines)
+	if !opts.Since.IsZero() {
+		b.WriteString(" --since-time=" + opts.Since.UTC().Format(time.RFC3339))
+	}
+	return b.String()
+}
+
+func (s *KubectlSource) Read(ctx context.Context, opts SearchOptions, fn func(Line) bool) error {
+	if s.pod == "" {
+		return fmt.Errorf("pod name is required")
+	}
+
+	var lastTime time.Time
+	lineNum := 0
+	err := s.streamer.Stream(ctx, s.command(opts), func(raw []byte) bool {
+		lineNum++
+		if lineNum%ctxCheckInterval == 0 && ctx.Err() != nil {
+			return false
+		}
+		text := string(raw)
+		message := text
+		// --timestamps prefixes every line with an RFC 3339 time
+		if timestamp, rest, ok := strings.Cut(text, " "); ok {
+			if t, err := time.Parse(time.RFC3339Nano, timestamp); err == nil {
+				lastTime = t
+				message = rest
+			}
+		}
+		return fn(Line{Number: lineNum, Time: lastTime, Text: text, Message: message})
+	})
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		return fmt.Errorf("kubectl logs failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package logs
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mainbong/storage_doctor/internal/shell"
+)
+
+func TestKubectlSource_Read(t *testing.T) {
+	executor := shell.NewMockCommandExecutor()
+	executor.SetResponse("kubectl logs 'csi-rbdplugin-x7' -n 'rook-ceph' -c 'csi-rbdplugin' --previous --timestamps --tail=50000", strings.Join([]string{
+		"2025-01-02T15:04:05.123456789Z E0102 15:04:05.123456       1 utils.go:210] GRPC error: rbd: map failed",
+		"continuation without timestamp",
+	}, "\n"))
+
+	source := NewKubectlSource(shell.NewExecutorWithCommandExecutor("", executor), "rook-ceph", "csi-rbdplugin-x7", "csi-rbdplugin", true)
+	var lines []Line
+	if err := source.Read(context.Background(), SearchOptions{}, func(line Line) bool {
+		lines = append(lines, line)
+		return true
+	}); err != nil {
+		t.Fatalf("Read() failed: %v", err)
+	}
+
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %+v", lines)
+	}
+	if !lines[0].Time.Equal(time.Date(2025, 1, 2, 15, 4, 5, 123456789, time.UTC)) || !strings.HasPrefix(lines[0].Message, "E0102 ") {
+		t.Errorf("Expected the timestamp prefix to be parsed, got %+v", lines[0])
+	}
+	if !lines[1].Time.Equal(lines[0].Time) || lines[1].Message != "continuation without timestamp" {
+		t.Errorf("Expected the previous time to carry over, got %+v", lines[1])
+	}
+	if source.Name() != "kubectl:rook-ceph/csi-rbdplugin-x7/csi-rbdplugin" {
+		t.Errorf("Unexpected name %q", source.Name())
+	}
+}
+
+func TestKubectlSource_Command(t *testing.T) {
+	since := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+	got := NewKubectlSource(nil, "", "deployment/csi-controller", "", false).command(SearchOptions{Since: since})
+	expected := "kubectl logs 'deployment/csi-controller' --timestamps --tail=50000 --since-time=2025-01-02T15:04:05Z"
+	if got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
@@ -1,7 +1,6 @@
 package logs
 
 import (
-	"bufio"
 	"context"
 	"fmt"
 	"io"
@@ -9,12 +8,14 @@ import (
 	"regexp"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/fsnotify/fsnotify"
 )
 
 // Monitor monitors log files
 type Monitor struct {
+	source      Source
 	filePath    string
 	watcher     *fsnotify.Watcher
 	lastOffset  int64
@@ -29,22 +30,42 @@ func NewMonitor(filePath string) (*Monitor, error) {
 	}
 
 	return &Monitor{
+		source:   NewFileSource(filePath),
 		filePath: filePath,
 		watcher:  watcher,
 	}, nil
 }
 
+// NewSourceMonitor creates a monitor reading from source. Only monitors
+// created with NewMonitor can Tail.
+func NewSourceMonitor(source Source) *Monitor {
+	return &Monitor{source: source}
+}
+
+// Name describes what the monitor reads
+func (m *Monitor) Name() string {
+	return m.source.Name()
+}
+
 // Tail tails a log file and calls onLine for each new line
 func (m *Monitor) Tail(ctx context.Context, onLine func(string)) error {
+	if m.watcher == nil {
+		return fmt.Errorf("tail is only supported for log files, not %s", m.source.Name())
+	}
+
 	// Add file to watcher
 	if err := m.watcher.Add(m.filePath); err != nil {
 		return fmt.Errorf("failed to add file to watcher: %w", err)
 	}
 
 	// Read existing content first (last 100 lines)
-	if err := m.readLastLines(100, onLine); err != nil {
+	lines, err := m.Last(100)
+	if err != nil {
 		return fmt.Errorf("failed to read last lines: %w", err)
 	}
+	for _, line := range lines {
+		onLine(line)
+	}
 	if err := m.setInitialOffset(); err != nil {
 		return fmt.Errorf("failed to read initial offset: %w", err)
 	}
@@ -67,135 +88,226 @@ func (m *Monitor) Tail(ctx context.Context, onLine func(string)) error {
 	}
 }
 
+// DefaultMaxMatches is how many matching lines Search and Filter return when
+// SearchOptions.MaxMatches is not set
+const DefaultMaxMatches = 200
+
+// maxShownLineBytes is where matched lines are cut in results
+const maxShownLineBytes = 2000
+
+// SearchOptions bounds a Search or Filter
+type SearchOptions struct {
+	// MaxMatches is the number of matches returned; later matches are only counted
+	MaxMatches int
+	// Before and After are the context lines shown around each match, like grep -B/-A
+	Before int
+	After  int
+	// Since and Until limit matches to a time range; lines without a timestamp
+	// take the one of the line before them. Zero values leave the range open.
+	Since time.Time
+	Until time.Time
+	// IncludeRotated also reads rotated siblings (.1, .gz, .zst), oldest first
+	IncludeRotated bool
+	// Location is the zone of file timestamps written without one; nil reads
+	// them in local time
+	Location *time.Location
+}
+
+// SearchResult holds the bounded output of a Search or Filter
+type SearchResult struct {
+	// Lines are the shown matches ("12: text") with their context ("11- text"),
+	// prefixed with the file name when the source spans several files
+	Lines []string
+	// Matches is the number of matches in Lines
+	Matches int
+	// Total is the number of matching lines, including those beyond MaxMatches
+	Total int
+}
+
+// Omitted returns how many matches were counted but not returned
+func (r *SearchResult) Omitted() int {
+	return r.Total - r.Matches
+}
+
 // Search searches for a pattern in the log file
 func (m *Monitor) Search(pattern string) ([]string, error) {
-	file, err := os.Open(m.filePath)
+	result, err := m.SearchWithOptions(context.Background(), pattern, SearchOptions{})
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+		return nil, err
 	}
-	defer file.Close()
+	return result.Lines, nil
+}
 
+// SearchWithOptions searches for a pattern, streaming the source with bounded memory
+func (m *Monitor) SearchWithOptions(ctx context.Context, pattern string, opts SearchOptions) (*SearchResult, error) {
 	re, err := regexp.Compile(pattern)
 	if err != nil {
 		return nil, fmt.Errorf("invalid regex pattern: %w", err)
 	}
-
-	var matches []string
-	scanner := bufio.NewScanner(file)
-	lineNum := 1
-
-	for scanner.Scan() {
-		line := scanner.Text()
-		if re.MatchString(line) {
-			matches = append(matches, fmt.Sprintf("%d: %s", lineNum, line))
-		}
-		lineNum++
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("failed to scan file: %w", err)
-	}
-
-	return matches, nil
+	return m.scan(ctx, func(line Line) bool {
+		return re.MatchString(line.Text)
+	}, opts)
 }
 
 // Filter filters log lines by level (ERROR, WARN, INFO, etc.)
 func (m *Monitor) Filter(level string) ([]string, error) {
-	file, err := os.Open(m.filePath)
+	result, err := m.FilterWithOptions(context.Background(), level, SearchOptions{})
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+		return nil, err
 	}
-	defer file.Close()
+	return result.Lines, nil
+}
 
-	var matches []string
-	scanner := bufio.NewScanner(file)
+// FilterWithOptions filters log lines by level, streaming the source with bounded memory
+func (m *Monitor) FilterWithOptions(ctx context.Context, level string, opts SearchOptions) (*SearchResult, error) {
 	levelUpper := strings.ToUpper(level)
+	normalized := normalizeLevel(level)
+	return m.scan(ctx, func(line Line) bool {
+		if line.Level != "" {
+			return line.Level == normalized
+		}
+		return strings.Contains(strings.ToUpper(line.Text), levelUpper)
+	}, opts)
+}
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.Contains(strings.ToUpper(line), levelUpper) {
-			matches = append(matches, line)
+// Last returns the last n lines of the log
+func (m *Monitor) Last(n int) ([]string, error) {
+	if m.watcher != nil {
+		return readLastLines(m.filePath, n)
+	}
+	if n <= 0 {
+		return nil, nil
+	}
+
+	ring := make([]string, 0, n)
+	next := 0
+	err := m.source.Read(context.Background(), SearchOptions{}, func(line Line) bool {
+		if len(ring) < n {
+			ring = append(ring, line.Text)
+		} else {
+			ring[next] = line.Text
+			next = (next + 1) % n
 		}
+		return true
+	})
+	if err != nil {
+		return nil, err
 	}
+	lines := make([]string, 0, len(ring))
+	lines = append(lines, ring[next:]...)
+	return append(lines, ring[:next]...), nil
+}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("failed to scan file: %w", err)
+// scan streams the source, keeping at most opts.MaxMatches matches and their context
+func (m *Monitor) scan(ctx context.Context, match func(Line) bool, opts SearchOptions) (*SearchResult, error) {
+	if opts.MaxMatches <= 0 {
+		opts.MaxMatches = DefaultMaxMatches
 	}
 
-	return matches, nil
-}
+	result := &SearchResult{}
+	var before []Line
+	afterLeft := 0
+	lastShown := 0
+	origin := ""
+	withContext := opts.Before > 0 || opts.After > 0
 
-// Summarize summarizes log file statistics
-func (m *Monitor) Summarize() (map[string]interface{}, error) {
-	file, err := os.Open(m.filePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+	format := func(line Line, sep string) string {
+		prefix := ""
+		if line.Origin != "" {
+			prefix = line.Origin + ":"
+		}
+		return fmt.Sprintf("%s%d%s %s", prefix, line.Number, sep, shortenLine(line.Text))
 	}
-	defer file.Close()
 
-	totalLines := 0
-	errorCount := 0
-	warnCount := 0
-	infoCount := 0
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		totalLines++
-		line := strings.ToUpper(scanner.Text())
-		if strings.Contains(line, "ERROR") {
-			errorCount++
+	err := m.source.Read(ctx, opts, func(line Line) bool {
+		if line.Origin != origin {
+			// Context does not cross into another file
+			origin = line.Origin
+			before = before[:0]
+			afterLeft = 0
+			lastShown = 0
+		}
+
+		if match(line) && inTimeRange(line.Time, opts) {
+			result.Total++
+			if result.Matches < opts.MaxMatches {
+				first := line.Number
+				if len(before) > 0 {
+					first = before[0].Number
+				}
+				if withContext && len(result.Lines) > 0 && (lastShown == 0 || first > lastShown+1) {
+					result.Lines = append(result.Lines, "--")
+				}
+				for _, prev := range before {
+					result.Lines = append(result.Lines, format(prev, "-"))
+				}
+				result.Lines = append(result.Lines, format(line, ":"))
+				result.Matches++
+				before = before[:0]
+				lastShown = line.Number
+				afterLeft = opts.After
+				return true
+			}
 		}
-		if strings.Contains(line, "WARN") {
-			warnCount++
+
+		if afterLeft > 0 {
+			result.Lines = append(result.Lines, format(line, "-"))
+			lastShown = line.Number
+			afterLeft--
+			return true
 		}
-		if strings.Contains(line, "INFO") {
-			infoCount++
+		if opts.Before > 0 && result.Matches < opts.MaxMatches {
+			if len(before) == opts.Before {
+				before = append(before[:0], before[1:]...)
+			}
+			before = append(before, line)
 		}
+		return true
+	})
+	if err != nil {
+		return nil, err
 	}
+	return result, nil
+}
 
-	stats := map[string]interface{}{
-		"total_lines": totalLines,
-		"error_count": errorCount,
-		"warn_count":  warnCount,
-		"info_count":  infoCount,
+// inTimeRange reports whether a line stamped t falls within the options' range
+func inTimeRange(t time.Time, opts SearchOptions) bool {
+	if opts.Since.IsZero() && opts.Until.IsZero() {
+		return true
 	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("failed to scan file: %w", err)
+	if t.IsZero() {
+		return false
 	}
-
-	return stats, nil
+	return !t.Before(opts.Since) && (opts.Until.IsZero() || !t.After(opts.Until))
 }
 
-// readLastLines reads the last N lines from the file
-func (m *Monitor) readLastLines(n int, onLine func(string)) error {
-	file, err := os.Open(m.filePath)
-	if err != nil {
-		return fmt.Errorf("failed to open file: %w", err)
-	}
-	defer file.Close()
-
-	var lines []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+// shortenLine cuts very long lines (JSON payloads, stack dumps) in results
+func shortenLine(line string) string {
+	if len(line) <= maxShownLineBytes {
+		return line
 	}
-
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("failed to scan file: %w", err)
+	cut := maxShownLineBytes
+	for cut > 0 && !utf8.RuneStart(line[cut]) {
+		cut--
 	}
+	return fmt.Sprintf("%s ... (%d바이트 생략)", line[:cut], len(line)-cut)
+}
 
-	// Get last N lines
-	start := len(lines) - n
-	if start < 0 {
-		start = 0
+// Summarize summarizes log file statistics
+func (m *Monitor) Summarize() (map[string]interface{}, error) {
+	summary, err := m.SummarizePatterns(context.Background(), SearchOptions{})
+	if err != nil {
+		return nil, err
 	}
 
-	for i := start; i < len(lines); i++ {
-		onLine(lines[i])
+	stats := map[string]interface{}{
+		"total_lines": summary.TotalLines,
+		"error_count": summary.Levels["ERROR"],
+		"warn_count":  summary.Levels["WARN"],
+		"info_count":  summary.Levels["INFO"],
 	}
 
-	return nil
+	return stats, nil
 }
 
 // readNewLines reads new lines since last read
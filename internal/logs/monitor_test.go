@@ -7,6 +7,7 @@ import (
 	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 )
 
 func TestNewMonitor(t *testing.T) {
@@ -261,3 +262,102 @@ func TestSummarize_FileNotFound(t *testing.T) {
 	}
 }
 
+func writeTestLog(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.log")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	return path
+}
+
+func TestSearchWithOptions_MaxMatches(t *testing.T) {
+	var lines []string
+	for i := 0; i < 50; i++ {
+		lines = append(lines, "ERROR: attach failed", "INFO: retrying")
+	}
+	monitor, _ := NewMonitor(writeTestLog(t, lines...))
+	defer monitor.Close()
+
+	result, err := monitor.SearchWithOptions(context.Background(), "ERROR", SearchOptions{MaxMatches: 3})
+	if err != nil {
+		t.Fatalf("SearchWithOptions() failed: %v", err)
+	}
+	if len(result.Lines) != 3 || result.Matches != 3 || result.Total != 50 || result.Omitted() != 47 {
+		t.Errorf("Expected 3 of 50 matches, got %d lines, %d shown, %d total", len(result.Lines), result.Matches, result.Total)
+	}
+	if result.Lines[1] != "3: ERROR: attach failed" {
+		t.Errorf("Expected numbered matches, got %q", result.Lines[1])
+	}
+}
+
+func TestSearchWithOptions_Context(t *testing.T) {
+	monitor, _ := NewMonitor(writeTestLog(t,
+		"a", "b", "ERROR one", "c", "d", "e", "f", "ERROR two", "ERROR three", "g",
+	))
+	defer monitor.Close()
+
+	result, err := monitor.SearchWithOptions(context.Background(), "ERROR", SearchOptions{Before: 1, After: 1})
+	if err != nil {
+		t.Fatalf("SearchWithOptions() failed: %v", err)
+	}
+	expected := []string{"2- b", "3: ERROR one", "4- c", "--", "7- f", "8: ERROR two", "9: ERROR three", "10- g"}
+	if strings.Join(result.Lines, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected %v, got %v", expected, result.Lines)
+	}
+	if result.Total != 3 {
+		t.Errorf("Expected 3 matches, got %d", result.Total)
+	}
+}
+
+func TestFilterWithOptions_TimeRange(t *testing.T) {
+	monitor, _ := NewMonitor(writeTestLog(t,
+		"2025-01-01 10:00:00 ERROR: before",
+		"2025-01-01 10:05:00 ERROR: inside",
+		"  stack line ERROR continuation",
+		"2025-01-01 10:10:00 ERROR: after",
+	))
+	defer monitor.Close()
+
+	since := time.Date(2025, 1, 1, 10, 1, 0, 0, time.Local)
+	until := time.Date(2025, 1, 1, 10, 9, 0, 0, time.Local)
+	result, err := monitor.FilterWithOptions(context.Background(), "error", SearchOptions{Since: since, Until: until})
+	if err != nil {
+		t.Fatalf("FilterWithOptions() failed: %v", err)
+	}
+	expected := []string{"2: 2025-01-01 10:05:00 ERROR: inside", "3:   stack line ERROR continuation"}
+	if strings.Join(result.Lines, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected %v, got %v", expected, result.Lines)
+	}
+}
+
+func TestSearchWithOptions_IncludeRotated(t *testing.T) {
+	path := writeTestLog(t, "ERROR: current")
+	if err := os.WriteFile(path+".1", []byte("ERROR: previous\n"), 0644); err != nil {
+		t.Fatalf("Failed to create rotated file: %v", err)
+	}
+	writeGzip(t, path+".2.gz", "ERROR: oldest\n")
+
+	monitor, _ := NewMonitor(path)
+	defer monitor.Close()
+
+	result, err := monitor.SearchWithOptions(context.Background(), "ERROR", SearchOptions{IncludeRotated: true})
+	if err != nil {
+		t.Fatalf("SearchWithOptions() failed: %v", err)
+	}
+	expected := []string{"test.log.2.gz:1: ERROR: oldest", "test.log.1:1: ERROR: previous", "test.log:1: ERROR: current"}
+	if strings.Join(result.Lines, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected %v, got %v", expected, result.Lines)
+	}
+}
+
+func TestShortenLine(t *testing.T) {
+	line := strings.Repeat("가", maxShownLineBytes)
+	short := shortenLine(line)
+	if len(short) > maxShownLineBytes+64 || !strings.HasSuffix(short, "바이트 생략)") {
+		t.Errorf("Expected a cut line, got %d bytes", len(short))
+	}
+	if !utf8.ValidString(short) {
+		t.Error("Expected the cut to keep valid UTF-8")
+	}
+}
@@ -0,0 +1,215 @@
+package logs
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// maxLineBytes is the longest line the scanners accept
+	maxLineBytes = 4 << 20
+	// tailBlockSize is how much readLastLines reads per backward seek
+	tailBlockSize = 64 << 10
+	// maxTailBytes bounds the memory readLastLines uses for very long lines
+	maxTailBytes = 8 << 20
+)
+
+// rotatedSuffix matches what logrotate and friends append to a log file name:
+// ".1", ".2.gz", "-20240101.zst" and so on
+var rotatedSuffix = regexp.MustCompile(`^[.-](\d+)(\.gz|\.zst)?$`)
+
+// readCloser closes a decompressor together with its underlying file
+type readCloser struct {
+	io.Reader
+	close func() error
+}
+
+func (r *readCloser) Close() error {
+	return r.close()
+}
+
+// openLog opens a log file, decompressing .gz and .zst files on the fly
+func openLog(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	switch {
+	case strings.HasSuffix(path, ".gz"):
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to read gzip file %s: %w", path, err)
+		}
+		return &readCloser{Reader: gz, close: func() error {
+			gz.Close()
+			return file.Close()
+		}}, nil
+	case strings.HasSuffix(path, ".zst"):
+		dec, err := zstd.NewReader(file, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to read zstd file %s: %w", path, err)
+		}
+		return &readCloser{Reader: dec, close: func() error {
+			dec.Close()
+			return file.Close()
+		}}, nil
+	}
+	return file, nil
+}
+
+// newLineScanner returns a scanner that accepts lines up to maxLineBytes
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineBytes)
+	return scanner
+}
+
+// RotatedFiles returns the rotated siblings of a log file (kubelet.log.1,
+// kubelet.log.2.gz, kubelet.log-20240101.zst, ...), oldest first
+func RotatedFiles(path string) ([]string, error) {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	type rotated struct {
+		path   string
+		number int64
+		dated  bool
+	}
+	var files []rotated
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, base) {
+			continue
+		}
+		match := rotatedSuffix.FindStringSubmatch(name[len(base):])
+		if match == nil {
+			continue
+		}
+		number, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		files = append(files, rotated{
+			path:   filepath.Join(dir, name),
+			number: number,
+			// Date extensions (-20240101) grow with age; rotation counters (.1) shrink
+			dated: len(match[1]) >= 8,
+		})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].dated != files[j].dated {
+			return files[i].dated
+		}
+		if files[i].dated {
+			return files[i].number < files[j].number
+		}
+		return files[i].number > files[j].number
+	})
+
+	paths := make([]string, len(files))
+	for i, file := range files {
+		paths[i] = file.path
+	}
+	return paths, nil
+}
+
+// readLastLines returns the last n lines of a log file. Plain files are read
+// backwards from the end; compressed files are streamed keeping only n lines.
+func readLastLines(path string, n int) ([]string, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	if strings.HasSuffix(path, ".gz") || strings.HasSuffix(path, ".zst") {
+		return streamLastLines(path, n)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	offset := stat.Size()
+	var data []byte
+	newlines := 0
+	for offset > 0 && newlines <= n && len(data) < maxTailBytes {
+		size := int64(tailBlockSize)
+		if offset < size {
+			size = offset
+		}
+		offset -= size
+		block := make([]byte, size)
+		if _, err := file.ReadAt(block, offset); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		newlines += bytes.Count(block, []byte("\n"))
+		data = append(block, data...)
+	}
+
+	data = bytes.TrimSuffix(data, []byte("\n"))
+	if len(data) == 0 {
+		return nil, nil
+	}
+	lines := strings.Split(string(data), "\n")
+	if offset > 0 {
+		// The first line was cut by the block boundary
+		lines = lines[1:]
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, nil
+}
+
+// streamLastLines keeps the last n lines of a file that cannot be read backwards
+func streamLastLines(path string, n int) ([]string, error) {
+	reader, err := openLog(path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	ring := make([]string, 0, n)
+	next := 0
+	scanner := newLineScanner(reader)
+	for scanner.Scan() {
+		if len(ring) < n {
+			ring = append(ring, scanner.Text())
+			continue
+		}
+		ring[next] = scanner.Text()
+		next = (next + 1) % n
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan file: %w", err)
+	}
+	lines := make([]string, 0, len(ring))
+	lines = append(lines, ring[next:]...)
+	return append(lines, ring[:next]...), nil
+}
@@ -0,0 +1,99 @@
+package logs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func writeGzip(t *testing.T, path, content string) {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte(content))
+	gz.Close()
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+func writeZstd(t *testing.T, path, content string) {
+	t.Helper()
+	var buf bytes.Buffer
+	enc, err := zstd.NewWriter(&buf)
+	if err != nil {
+		t.Fatalf("Failed to create zstd writer: %v", err)
+	}
+	enc.Write([]byte(content))
+	enc.Close()
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+func TestRotatedFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "kubelet.log")
+	for _, name := range []string{"kubelet.log", "kubelet.log.1", "kubelet.log.10.gz", "kubelet.log.2.zst", "kubelet.log-20240102.gz", "kubelet.log-20240101", "kubelet.log.bak", "other.log.1"} {
+		os.WriteFile(filepath.Join(dir, name), nil, 0644)
+	}
+
+	files, err := RotatedFiles(path)
+	if err != nil {
+		t.Fatalf("RotatedFiles() failed: %v", err)
+	}
+	var names []string
+	for _, file := range files {
+		names = append(names, filepath.Base(file))
+	}
+	expected := "kubelet.log-20240101 kubelet.log-20240102.gz kubelet.log.10.gz kubelet.log.2.zst kubelet.log.1"
+	if got := strings.Join(names, " "); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
+
+func TestOpenLog_Compressed(t *testing.T) {
+	dir := t.TempDir()
+	writeGzip(t, filepath.Join(dir, "a.log.gz"), "gzip line\n")
+	writeZstd(t, filepath.Join(dir, "a.log.zst"), "zstd line\n")
+
+	for name, expected := range map[string]string{"a.log.gz": "gzip line", "a.log.zst": "zstd line"} {
+		lines, err := readLastLines(filepath.Join(dir, name), 5)
+		if err != nil {
+			t.Fatalf("readLastLines(%s) failed: %v", name, err)
+		}
+		if len(lines) != 1 || lines[0] != expected {
+			t.Errorf("Expected %q from %s, got %v", expected, name, lines)
+		}
+	}
+}
+
+func TestReadLastLines(t *testing.T) {
+	// Lines long enough that the tail spans several backward reads
+	var content strings.Builder
+	for i := 1; i <= 300; i++ {
+		fmt.Fprintf(&content, "%d %s\n", i, strings.Repeat("x", 1000))
+	}
+	path := filepath.Join(t.TempDir(), "big.log")
+	os.WriteFile(path, []byte(content.String()), 0644)
+
+	lines, err := readLastLines(path, 100)
+	if err != nil {
+		t.Fatalf("readLastLines() failed: %v", err)
+	}
+	if len(lines) != 100 || !strings.HasPrefix(lines[0], "201 ") || !strings.HasPrefix(lines[99], "300 ") {
+		t.Errorf("Expected lines 201-300, got %d lines starting %.4q", len(lines), lines[0])
+	}
+
+	short := filepath.Join(t.TempDir(), "short.log")
+	os.WriteFile(short, []byte("one\ntwo"), 0644)
+	if lines, _ := readLastLines(short, 100); strings.Join(lines, ",") != "one,two" {
+		t.Errorf("Expected both lines of a short file, got %v", lines)
+	}
+}
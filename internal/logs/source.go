@@ -0,0 +1,141 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Line is one log line read from a Source
+type Line struct {
+	// Origin is the file the line came from when a source spans several files
+	// (rotated logs, container restarts); empty otherwise
+	Origin string
+	// Number is the line number within Origin
+	Number int
+	// Time is when the line was logged; zero when unknown
+	Time time.Time
+	// Stream is "stdout" or "stderr" for container logs
+	Stream string
+	// Level is the severity reported by the source itself (journald priority),
+	// upper case; empty when the source has none
+	Level string
+	// Text is the line as shown in results
+	Text string
+	// Message is the logged message without the time and stream prefix
+	Message string
+}
+
+// Source reads log lines from a file, the journal or a container runtime
+type Source interface {
+	// Name describes the source, such as a path or "journal:kubelet"
+	Name() string
+	// Read calls fn for each line, oldest first, until fn returns false.
+	// Sources may use opts.Since, opts.Until and opts.IncludeRotated to skip
+	// data early; callers still check the time of every line.
+	Read(ctx context.Context, opts SearchOptions, fn func(Line) bool) error
+}
+
+// ctxCheckInterval is how many lines sources read between context checks
+const ctxCheckInterval = 4096
+
+// FileSource reads a plain, gzip or zstd log file and its rotated siblings
+type FileSource struct {
+	path string
+}
+
+// NewFileSource creates a source reading the log file at path
+func NewFileSource(path string) *FileSource {
+	return &FileSource{path: path}
+}
+
+func (s *FileSource) Name() string {
+	return s.path
+}
+
+func (s *FileSource) Read(ctx context.Context, opts SearchOptions, fn func(Line) bool) error {
+	files := []string{s.path}
+	if opts.IncludeRotated {
+		rotated, err := RotatedFiles(s.path)
+		if err != nil {
+			return err
+		}
+		files = append(rotated, s.path)
+	}
+
+	for _, path := range files {
+		stat, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("failed to open file: %w", err)
+		}
+		if path != s.path && !opts.Since.IsZero() && stat.ModTime().Before(opts.Since) {
+			// Everything in a rotated file predates its last write
+			continue
+		}
+		origin := ""
+		if len(files) > 1 {
+			origin = filepath.Base(path)
+		}
+		more, err := readFileLines(ctx, path, origin, stat.ModTime(), opts.Location, fn)
+		if err != nil || !more {
+			return err
+		}
+	}
+	return nil
+}
+
+// readFileLines streams one file, giving lines without a timestamp the time of
+// the line before them (stack traces, wrapped messages). It reports whether
+// fn asked for more lines.
+func readFileLines(ctx context.Context, path, origin string, reference time.Time, loc *time.Location, fn func(Line) bool) (bool, error) {
+	reader, err := openLog(path)
+	if err != nil {
+		return false, err
+	}
+	defer reader.Close()
+
+	var lastTime time.Time
+	scanner := newLineScanner(reader)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		if lineNum%ctxCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return false, err
+			}
+		}
+		text := scanner.Text()
+		if t, ok := ParseTimestampIn(text, reference, loc); ok {
+			lastTime = t
+		}
+		if !fn(Line{Origin: origin, Number: lineNum, Time: lastTime, Text: text, Message: text}) {
+			return false, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("failed to scan file: %w", err)
+	}
+	return true, nil
+}
+
+// normalizeLevel maps level spellings (E, err, warning, crit, ...) to ERROR,
+// WARN, INFO or DEBUG; other values are only upper-cased
+func normalizeLevel(level string) string {
+	switch level = strings.ToUpper(strings.TrimSpace(level)); level {
+	case "E", "ERR", "ERROR", "F", "FATAL", "CRIT", "CRITICAL", "ALERT", "EMERG", "PANIC":
+		return "ERROR"
+	case "W", "WARN", "WARNING":
+		return "WARN"
+	case "I", "INFO", "NOTICE":
+		return "INFO"
+	case "D", "DEBUG", "TRACE":
+		return "DEBUG"
+	}
+	return level
+}
+
+// shellQuote quotes a value for a sh -c command line
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
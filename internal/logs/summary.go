@@ -0,0 +1,364 @@
+package logs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultTopTemplates is how many templates FormatSummary shows by default
+	DefaultTopTemplates = 20
+	// maxTemplates bounds the templates kept; later new templates are only counted
+	maxTemplates = 2000
+	// maxRateBuckets bounds the per-minute counts kept per template (a week)
+	maxRateBuckets = 7 * 24 * 60
+	// spikeMinCount and spikeFactor decide when a template's busiest minute is
+	// a spike: at least spikeMinCount lines and spikeFactor times its average rate
+	spikeMinCount = 10
+	spikeFactor   = 5
+	// maxExampleBytes is where template examples are cut
+	maxExampleBytes = 300
+)
+
+var (
+	// jsonLevel and logfmtLevel find structured level fields
+	jsonLevel   = regexp.MustCompile(`(?i)"(?:level|lvl|severity)"\s*:\s*"([a-z]+)"`)
+	logfmtLevel = regexp.MustCompile(`(?i)\b(?:level|lvl|severity)=["']?([a-z]+)`)
+	// wordLevel finds a level word in plain text lines: ERROR, [warn], W1231 ...
+	wordLevel = regexp.MustCompile(`\b(ERROR|ERR|WARN|WARNING|INFO|DEBUG|FATAL|CRIT|CRITICAL|TRACE|NOTICE)\b|\[(?i:(error|err|warn|warning|info|debug|fatal|trace))\]`)
+	// klogHeader is the klog prefix up to the source location: "E0102 15:04:05.000000    1234 attacher.go:310] "
+	klogHeader = regexp.MustCompile(`^[IWEF]\d{4} \d{2}:\d{2}:\d{2}(?:\.\d+)?\s+\d+ [^ \]]+\] `)
+	// logfmtMessage finds the msg field of a logfmt line
+	logfmtMessage = regexp.MustCompile(`\bmsg=(?:"((?:[^"\\]|\\.)*)"|(\S+))`)
+	// leadingTimestamp strips the time a plain line starts with
+	leadingTimestamp = regexp.MustCompile(`^\s*(?:\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:?\d{2})?|[A-Z][a-z]{2} +\d{1,2} \d{2}:\d{2}:\d{2})\s*`)
+)
+
+// templateMasks replace the variable parts of a message, most specific first
+var templateMasks = []struct {
+	pattern *regexp.Regexp
+	replace func(string) string
+}{
+	{regexp.MustCompile(`pvc-[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`), placeholder("<pv>")},
+	{regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`), placeholder("<uuid>")},
+	{regexp.MustCompile(`\b\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}(?::\d+)?\b`), placeholder("<ip>")},
+	{regexp.MustCompile(`\b[0-9a-f]{8,}\b`), maskHexID},
+	{regexp.MustCompile(`\b\d+(?:\.\d+)?(?:ns|us|µs|ms|s|m|h|Ki|Mi|Gi|Ti|KiB|MiB|GiB|TiB|B|KB|MB|GB|TB|%)?\b`), placeholder("<n>")},
+}
+
+func placeholder(name string) func(string) string {
+	return func(string) string { return name }
+}
+
+// maskHexID masks container IDs and digests; all-digit runs are left to the
+// number mask and all-letter runs are words
+func maskHexID(id string) string {
+	if strings.Trim(id, "0123456789") == "" || strings.Trim(id, "abcdef") == "" {
+		return id
+	}
+	return "<hex>"
+}
+
+// Template is a cluster of log messages that differ only in masked values
+type Template struct {
+	Pattern string
+	Level   string
+	Count   int
+	First   time.Time
+	Last    time.Time
+	// Example is the first message of the cluster
+	Example string
+	// PeakRate is the most lines logged in one minute, at PeakAt
+	PeakRate int
+	PeakAt   time.Time
+	// Spike reports whether the peak stands out from the template's average rate
+	Spike bool
+
+	minutes map[int64]int
+}
+
+// Summary is a compact digest of a log: level counts and message templates
+type Summary struct {
+	Source     string
+	TotalLines int
+	// Levels counts lines by level; lines without one count under ""
+	Levels map[string]int
+	First  time.Time
+	Last   time.Time
+	// Templates are sorted by severity, then by count
+	Templates []*Template
+	// Unclustered counts lines that arrived after the template limit was reached
+	Unclustered int
+}
+
+// SummarizePatterns parses levels and clusters the log's messages into
+// templates, streaming the source with memory bounded by the template count
+func (m *Monitor) SummarizePatterns(ctx context.Context, opts SearchOptions) (*Summary, error) {
+	summary := &Summary{
+		Source: m.source.Name(),
+		Levels: make(map[string]int),
+	}
+	byPattern := make(map[string]*Template)
+
+	err := m.source.Read(ctx, opts, func(line Line) bool {
+		if !inTimeRange(line.Time, opts) {
+			return true
+		}
+		summary.TotalLines++
+		level := line.Level
+		if level == "" {
+			level = ParseLevel(line.Message)
+		}
+		summary.Levels[level]++
+		if !line.Time.IsZero() {
+			if summary.First.IsZero() || line.Time.Before(summary.First) {
+				summary.First = line.Time
+			}
+			if line.Time.After(summary.Last) {
+				summary.Last = line.Time
+			}
+		}
+
+		message := messageBody(line.Message)
+		if strings.TrimSpace(message) == "" {
+			return true
+		}
+		pattern := TemplateOf(message)
+		template, ok := byPattern[pattern]
+		if !ok {
+			if len(byPattern) >= maxTemplates {
+				summary.Unclustered++
+				return true
+			}
+			template = &Template{
+				Pattern: pattern,
+				Level:   level,
+				Example: cutBytes(message, maxExampleBytes),
+				minutes: make(map[int64]int),
+			}
+			byPattern[pattern] = template
+		}
+		template.add(line.Time, level)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	span := summary.Last.Sub(summary.First)
+	for _, template := range byPattern {
+		template.findPeak(span)
+		summary.Templates = append(summary.Templates, template)
+	}
+	sort.Slice(summary.Templates, func(i, j int) bool {
+		a, b := summary.Templates[i], summary.Templates[j]
+		if levelRank(a.Level) != levelRank(b.Level) {
+			return levelRank(a.Level) < levelRank(b.Level)
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Pattern < b.Pattern
+	})
+	return summary, nil
+}
+
+func (t *Template) add(at time.Time, level string) {
+	t.Count++
+	if levelRank(level) < levelRank(t.Level) {
+		t.Level = level
+	}
+	if at.IsZero() {
+		return
+	}
+	if t.First.IsZero() || at.Before(t.First) {
+		t.First = at
+	}
+	if at.After(t.Last) {
+		t.Last = at
+	}
+	minute := at.Unix() / 60
+	if _, ok := t.minutes[minute]; ok || len(t.minutes) < maxRateBuckets {
+		t.minutes[minute]++
+	}
+}
+
+// findPeak sets the busiest minute and whether it is a spike compared to the
+// template's average rate over the whole log
+func (t *Template) findPeak(span time.Duration) {
+	for minute, count := range t.minutes {
+		at := time.Unix(minute*60, 0)
+		if count > t.PeakRate || (count == t.PeakRate && at.Before(t.PeakAt)) {
+			t.PeakRate, t.PeakAt = count, at
+		}
+	}
+	t.minutes = nil
+
+	spanMinutes := span.Minutes()
+	if spanMinutes < 10 {
+		return
+	}
+	average := float64(t.Count) / spanMinutes
+	t.Spike = t.PeakRate >= spikeMinCount && float64(t.PeakRate) >= spikeFactor*average
+}
+
+// AverageRate returns the template's lines per minute over span
+func (t *Template) AverageRate(span time.Duration) float64 {
+	if span < time.Minute {
+		return float64(t.Count)
+	}
+	return float64(t.Count) / span.Minutes()
+}
+
+// ParseLevel finds the level of a log line: the klog header letter, a JSON or
+// logfmt level field, or a level word. It returns ERROR, WARN, INFO, DEBUG or
+// "" when the line has none.
+func ParseLevel(text string) string {
+	if klogTimestamp.MatchString(text) {
+		return normalizeLevel(text[:1])
+	}
+	if strings.HasPrefix(strings.TrimSpace(text), "{") {
+		if match := jsonLevel.FindStringSubmatch(text); match != nil {
+			return knownLevel(match[1])
+		}
+	}
+	if match := logfmtLevel.FindStringSubmatch(text); match != nil {
+		return knownLevel(match[1])
+	}
+
+	head := text
+	if len(head) > timestampScanBytes {
+		head = head[:timestampScanBytes]
+	}
+	if match := wordLevel.FindStringSubmatch(head); match != nil {
+		return knownLevel(match[1] + match[2])
+	}
+	return ""
+}
+
+// knownLevel normalizes a level, dropping values that are not levels
+func knownLevel(level string) string {
+	switch level = normalizeLevel(level); level {
+	case "ERROR", "WARN", "INFO", "DEBUG":
+		return level
+	}
+	return ""
+}
+
+// levelRank orders levels by severity for sorting
+func levelRank(level string) int {
+	switch level {
+	case "ERROR":
+		return 0
+	case "WARN":
+		return 1
+	case "INFO":
+		return 2
+	case "DEBUG":
+		return 3
+	}
+	return 4
+}
+
+// messageBody returns the message of a line without its timestamp, klog
+// header or structured fields, which would split clusters
+func messageBody(text string) string {
+	trimmed := strings.TrimSpace(text)
+	if strings.HasPrefix(trimmed, "{") {
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(trimmed), &fields); err == nil {
+			for _, key := range []string{"msg", "message", "MESSAGE", "log"} {
+				if message, ok := fields[key].(string); ok {
+					return message
+				}
+			}
+		}
+		return text
+	}
+	if header := klogHeader.FindString(text); header != "" {
+		return text[len(header):]
+	}
+	if match := logfmtMessage.FindStringSubmatch(text); match != nil {
+		if match[1] != "" {
+			return strings.ReplaceAll(match[1], `\"`, `"`)
+		}
+		return match[2]
+	}
+	return leadingTimestamp.ReplaceAllString(text, "")
+}
+
+// TemplateOf masks the variable parts of a message: PV names, UUIDs, IP
+// addresses, hex IDs and numbers
+func TemplateOf(message string) string {
+	for _, mask := range templateMasks {
+		message = mask.pattern.ReplaceAllStringFunc(message, mask.replace)
+	}
+	return cutBytes(strings.TrimSpace(message), maxExampleBytes)
+}
+
+// cutBytes cuts text to at most n bytes without splitting a character
+func cutBytes(text string, n int) string {
+	if len(text) <= n {
+		return text
+	}
+	for n > 0 && !utf8.RuneStart(text[n]) {
+		n--
+	}
+	return text[:n] + "..."
+}
+
+// FormatSummary formats a digest for the LLM with the top templates
+func FormatSummary(summary *Summary, top int) string {
+	if top <= 0 {
+		top = DefaultTopTemplates
+	}
+	const timeLayout = "2006-01-02 15:04:05"
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "로그 요약: %s\n", summary.Source)
+	fmt.Fprintf(&b, "총 라인: %d (ERROR %d, WARN %d, INFO %d, DEBUG %d, 레벨 없음 %d)\n",
+		summary.TotalLines, summary.Levels["ERROR"], summary.Levels["WARN"], summary.Levels["INFO"], summary.Levels["DEBUG"], summary.Levels[""])
+	if !summary.First.IsZero() {
+		fmt.Fprintf(&b, "기간: %s ~ %s\n", summary.First.Local().Format(timeLayout), summary.Last.Local().Format(timeLayout))
+	}
+
+	shown := summary.Templates
+	if len(shown) > top {
+		shown = shown[:top]
+	}
+	fmt.Fprintf(&b, "메시지 패턴 %d개 중 %d개 (심각도, 빈도 순):\n", len(summary.Templates), len(shown))
+
+	span := summary.Last.Sub(summary.First)
+	for i, template := range shown {
+		level := template.Level
+		if level == "" {
+			level = "-"
+		}
+		fmt.Fprintf(&b, "\n%d. [%s] %d회", i+1, level, template.Count)
+		if !template.First.IsZero() {
+			fmt.Fprintf(&b, ", %s ~ %s", template.First.Local().Format(timeLayout), template.Last.Local().Format(timeLayout))
+		}
+		if template.Spike {
+			fmt.Fprintf(&b, ", 급증: %s에 분당 %d회 (평균 분당 %.1f회)",
+				template.PeakAt.Local().Format("2006-01-02 15:04"), template.PeakRate, template.AverageRate(span))
+		}
+		fmt.Fprintf(&b, "\n   패턴: %s\n", template.Pattern)
+		if template.Example != template.Pattern {
+			fmt.Fprintf(&b, "   예시: %s\n", template.Example)
+		}
+	}
+
+	if rest := len(summary.Templates) - len(shown); rest > 0 {
+		fmt.Fprintf(&b, "\n... 패턴 %d개 더 있음 (top을 늘리거나 search로 확인하세요)\n", rest)
+	}
+	if summary.Unclustered > 0 {
+		fmt.Fprintf(&b, "패턴 수 제한(%d개)을 넘어 분류하지 못한 라인: %d\n", maxTemplates, summary.Unclustered)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,113 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		line, expected string
+	}{
+		{"E0102 15:04:05.123456    1234 csi_attacher.go:310] attach failed", "ERROR"},
+		{"W0102 15:04:05.123456    1234 reflector.go:424] watch closed", "WARN"},
+		{`{"level":"warning","ts":1735830245.5,"msg":"slow request"}`, "WARN"},
+		{`time="2025-01-02T15:04:05Z" level=error msg="mount failed"`, "ERROR"},
+		{"2025-01-01 10:00:00 INFO: ERROR budget recalculated", "INFO"},
+		{"2025-01-01 10:00:00 [debug] probe ok", "DEBUG"},
+		{"volume attached without errors", ""},
+		{`level=verbose msg="x"`, ""},
+	}
+	for _, tt := range tests {
+		if got := ParseLevel(tt.line); got != tt.expected {
+			t.Errorf("ParseLevel(%q) = %q, expected %q", tt.line, got, tt.expected)
+		}
+	}
+}
+
+func TestTemplateOf(t *testing.T) {
+	tests := []struct {
+		message, expected string
+	}{
+		{
+			"AttachVolume.Attach failed for volume pvc-0a1b2c3d-1111-2222-3333-444455556666 on node 10.0.0.12:3260 after 3 retries (1500ms)",
+			"AttachVolume.Attach failed for volume <pv> on node <ip> after <n> retries (<n>)",
+		},
+		{
+			"container 4f3c2b1a9e8d7c6b exited with code 137, pod uid 8d2e4c1a-5b6f-4a3e-9c7d-1e2f3a4b5c6d",
+			"container <hex> exited with code <n>, pod uid <uuid>",
+		},
+		{"osd.12 marked down, sdc1 removed, deadbeef cache", "osd.<n> marked down, sdc1 removed, deadbeef cache"},
+	}
+	for _, tt := range tests {
+		if got := TemplateOf(tt.message); got != tt.expected {
+			t.Errorf("TemplateOf(%q) = %q, expected %q", tt.message, got, tt.expected)
+		}
+	}
+}
+
+func TestMessageBody(t *testing.T) {
+	tests := []struct {
+		line, expected string
+	}{
+		{"E0102 15:04:05.123456    1234 csi_attacher.go:310] attach failed", "attach failed"},
+		{`{"level":"error","ts":1735830245.5,"msg":"mount failed","volume":"pvc-1"}`, "mount failed"},
+		{`time="2025-01-02T15:04:05Z" level=error msg="mount \"x\" failed" volume=pvc-1`, `mount "x" failed`},
+		{"2025-01-01 10:00:00 ERROR: Database connection failed", "ERROR: Database connection failed"},
+	}
+	for _, tt := range tests {
+		if got := messageBody(tt.line); got != tt.expected {
+			t.Errorf("messageBody(%q) = %q, expected %q", tt.line, got, tt.expected)
+		}
+	}
+}
+
+func TestSummarizePatterns(t *testing.T) {
+	start := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
+	var lines []string
+	// A steady info message for an hour, and an error burst in one minute
+	for i := 0; i < 60; i++ {
+		at := start.Add(time.Duration(i) * time.Minute)
+		lines = append(lines, fmt.Sprintf("I%s    1 controller.go:88] synced volume pvc-0a1b2c3d-1111-2222-3333-%012d", at.Format("0102 15:04:05.000000"), i))
+	}
+	for i := 0; i < 20; i++ {
+		at := start.Add(30*time.Minute + time.Duration(i)*time.Second)
+		lines = append(lines, fmt.Sprintf("E%s    1 attacher.go:310] attach of 10.0.0.%d timed out after %ds", at.Format("0102 15:04:05.000000"), i, i))
+	}
+
+	monitor, _ := NewMonitor(writeTestLog(t, lines...))
+	defer monitor.Close()
+
+	summary, err := monitor.SummarizePatterns(context.Background(), SearchOptions{})
+	if err != nil {
+		t.Fatalf("SummarizePatterns() failed: %v", err)
+	}
+	if summary.TotalLines != 80 || summary.Levels["ERROR"] != 20 || summary.Levels["INFO"] != 60 {
+		t.Errorf("Unexpected counts: %d lines, %v", summary.TotalLines, summary.Levels)
+	}
+	if len(summary.Templates) != 2 {
+		t.Fatalf("Expected 2 templates, got %d: %+v", len(summary.Templates), summary.Templates)
+	}
+
+	errors := summary.Templates[0]
+	if errors.Level != "ERROR" || errors.Count != 20 || errors.Pattern != "attach of <ip> timed out after <n>" {
+		t.Errorf("Expected the error template first, got %+v", errors)
+	}
+	if !errors.Spike || errors.PeakRate != 20 {
+		t.Errorf("Expected the error burst to be a spike, got peak %d spike %v", errors.PeakRate, errors.Spike)
+	}
+	synced := summary.Templates[1]
+	if synced.Count != 60 || synced.Spike || synced.Pattern != "synced volume <pv>" {
+		t.Errorf("Expected a steady info template, got %+v", synced)
+	}
+
+	digest := FormatSummary(summary, 1)
+	for _, want := range []string{"총 라인: 80 (ERROR 20, WARN 0, INFO 60", "1. [ERROR] 20회", "급증:", "패턴: attach of <ip> timed out after <n>", "패턴 1개 더 있음"} {
+		if !strings.Contains(digest, want) {
+			t.Errorf("Expected digest to contain %q:\n%s", want, digest)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package logs
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timestampScanBytes is how far into a line ParseTimestamp looks
+const timestampScanBytes = 160
+
+var (
+	// isoTimestamp matches RFC 3339 and "2006-01-02 15:04:05" style timestamps,
+	// including CRI, logfmt (time=...), JSON and Ceph lines
+	isoTimestamp = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`)
+	// klogTimestamp matches the klog header: I0102 15:04:05.000000
+	klogTimestamp = regexp.MustCompile(`^[IWEF](\d{2})(\d{2}) (\d{2}:\d{2}:\d{2}(?:\.\d+)?)`)
+	// syslogTimestamp matches the BSD syslog header: Jan  2 15:04:05
+	syslogTimestamp = regexp.MustCompile(`^([A-Z][a-z]{2}) +(\d{1,2}) (\d{2}:\d{2}:\d{2})`)
+	// epochTimestamp matches JSON loggers writing Unix seconds, like zap's "ts":1704164645.123
+	epochTimestamp = regexp.MustCompile(`"(?:ts|time|timestamp)":\s*(\d{10}(?:\.\d+)?)`)
+)
+
+// ParseTimestamp extracts the timestamp of a log line. Formats without a year
+// (klog, syslog) take it from reference, normally the file's modification time.
+// Timestamps without a zone are read in local time.
+func ParseTimestamp(line string, reference time.Time) (time.Time, bool) {
+	return ParseTimestampIn(line, reference, time.Local)
+}
+
+// ParseTimestampIn is ParseTimestamp for logs whose timestamps without a zone
+// were written in loc, such as a storage backend logging in UTC
+func ParseTimestampIn(line string, reference time.Time, loc *time.Location) (time.Time, bool) {
+	if loc == nil {
+		loc = time.Local
+	}
+	head := line
+	if len(head) > timestampScanBytes {
+		head = head[:timestampScanBytes]
+	}
+	if !strings.Contains(head, ":") {
+		return time.Time{}, false
+	}
+
+	if match := isoTimestamp.FindStringSubmatch(head); match != nil {
+		if t, err := parseISO(match[0], match[2], loc); err == nil {
+			return t, true
+		}
+	}
+	if match := klogTimestamp.FindStringSubmatch(head); match != nil {
+		if t, err := time.ParseInLocation("0102 15:04:05.999999999", match[1]+match[2]+" "+match[3], loc); err == nil {
+			return withYear(t, reference, loc), true
+		}
+	}
+	if match := syslogTimestamp.FindStringSubmatch(head); match != nil {
+		if t, err := time.ParseInLocation("Jan 2 15:04:05", match[1]+" "+match[2]+" "+match[3], loc); err == nil {
+			return withYear(t, reference, loc), true
+		}
+	}
+	if match := epochTimestamp.FindStringSubmatch(head); match != nil {
+		if seconds, err := strconv.ParseFloat(match[1], 64); err == nil {
+			return time.Unix(0, int64(seconds*float64(time.Second))), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseISO parses an isoTimestamp match; zone is the matched zone suffix, and
+// loc is used when there is none
+func parseISO(value, zone string, loc *time.Location) (time.Time, error) {
+	value = value[:10] + "T" + value[11:]
+	if zone == "" {
+		return time.ParseInLocation("2006-01-02T15:04:05.999999999", value, loc)
+	}
+	if zone != "Z" && !strings.Contains(zone, ":") {
+		// +0900 -> +09:00
+		value = value[:len(value)-2] + ":" + value[len(value)-2:]
+	}
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+// withYear places a timestamp without a year in the year of reference. A time
+// far after the reference belongs to the previous year (December logs read in
+// January).
+func withYear(t, reference time.Time, loc *time.Location) time.Time {
+	if reference.IsZero() {
+		reference = time.Now()
+	}
+	t = time.Date(reference.In(loc).Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+	if t.After(reference.Add(48 * time.Hour)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+	return t
+}
+
+// ParseTimeBound parses a since/until value: an absolute time ("2024-01-02
+// 15:04", RFC 3339) or a duration before now ("30m", "2h", "1d")
+func ParseTimeBound(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: use a duration like 30m or 2h, or a time like 2024-01-02 15:04", value)
+}
+
+// ParseLocation parses a time zone given as "local", "UTC", a fixed offset
+// ("+09:00", "-0500") or an IANA name ("Asia/Seoul")
+func ParseLocation(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	switch strings.ToLower(name) {
+	case "", "local":
+		return time.Local, nil
+	case "utc", "z":
+		return time.UTC, nil
+	}
+	if name[0] == '+' || name[0] == '-' {
+		offset := strings.Replace(name[1:], ":", "", 1)
+		if len(offset) == 2 {
+			offset += "00"
+		}
+		if len(offset) == 4 {
+			hours, errH := strconv.Atoi(offset[:2])
+			minutes, errM := strconv.Atoi(offset[2:])
+			if errH == nil && errM == nil && hours <= 14 && minutes < 60 {
+				seconds := hours*3600 + minutes*60
+				if name[0] == '-' {
+					seconds = -seconds
+				}
+				return time.FixedZone("UTC"+name, seconds), nil
+			}
+		}
+		return nil, fmt.Errorf("invalid time zone offset %q", name)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q: %w", name, err)
+	}
+	return loc, nil
+}
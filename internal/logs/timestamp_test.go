@@ -0,0 +1,107 @@
+package logs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	reference := time.Date(2025, 1, 3, 0, 0, 0, 0, time.Local)
+	tests := []struct {
+		line     string
+		expected time.Time
+	}{
+		{"2025-01-02T15:04:05.123Z stdout F volume attached", time.Date(2025, 1, 2, 15, 4, 5, 123000000, time.UTC)},
+		{"2025-01-02 15:04:05 ERROR: failed", time.Date(2025, 1, 2, 15, 4, 5, 0, time.Local)},
+		{"2025-01-02T15:04:05.000+0900 7f osd.1 heartbeat_check", time.Date(2025, 1, 2, 6, 4, 5, 0, time.UTC)},
+		{`level=error time=2025-01-02T15:04:05+09:00 msg="mount failed"`, time.Date(2025, 1, 2, 6, 4, 5, 0, time.UTC)},
+		{"E0102 15:04:05.500000    1234 csi_attacher.go:310] attach failed", time.Date(2025, 1, 2, 15, 4, 5, 500000000, time.Local)},
+		{"Dec 31 23:59:59 node1 kernel: I/O error", time.Date(2024, 12, 31, 23, 59, 59, 0, time.Local)},
+		{`{"level":"error","ts":1735830245.5,"msg":"failed"}`, time.Unix(1735830245, 500000000)},
+	}
+	for _, tt := range tests {
+		got, ok := ParseTimestamp(tt.line, reference)
+		if !ok || !got.Equal(tt.expected) {
+			t.Errorf("ParseTimestamp(%q) = %v, %v; expected %v", tt.line, got, ok, tt.expected)
+		}
+	}
+
+	if _, ok := ParseTimestamp("    at io.k8s.Foo(Bar.java:12)", reference); ok {
+		t.Error("Expected no timestamp in a continuation line")
+	}
+}
+
+func TestParseTimeBound(t *testing.T) {
+	now := time.Date(2025, 1, 2, 12, 0, 0, 0, time.Local)
+	tests := []struct {
+		value    string
+		expected time.Time
+	}{
+		{"", time.Time{}},
+		{"30m", now.Add(-30 * time.Minute)},
+		{"2d", now.AddDate(0, 0, -2)},
+		{"2025-01-01 09:30", time.Date(2025, 1, 1, 9, 30, 0, 0, time.Local)},
+		{"2025-01-01T09:30:00Z", time.Date(2025, 1, 1, 9, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := ParseTimeBound(tt.value, now)
+		if err != nil || !got.Equal(tt.expected) {
+			t.Errorf("ParseTimeBound(%q) = %v, %v; expected %v", tt.value, got, err, tt.expected)
+		}
+	}
+
+	if _, err := ParseTimeBound("yesterday", now); err == nil {
+		t.Error("Expected an error for an unknown time")
+	}
+}
+
+func TestParseTimestampIn(t *testing.T) {
+	seoul := time.FixedZone("KST", 9*3600)
+	reference := time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		line     string
+		expected time.Time
+	}{
+		{"2025-01-02 19:04:05 array: LUN mapped", time.Date(2025, 1, 2, 10, 4, 5, 0, time.UTC)},
+		{"I0102 19:04:05.000000 1 x.go:1] attach", time.Date(2025, 1, 2, 10, 4, 5, 0, time.UTC)},
+		// Timestamps with a zone keep it
+		{"2025-01-02T15:04:05Z stdout F ok", time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, ok := ParseTimestampIn(tt.line, reference, seoul)
+		if !ok || !got.Equal(tt.expected) {
+			t.Errorf("ParseTimestampIn(%q) = %v, %v; expected %v", tt.line, got, ok, tt.expected)
+		}
+	}
+}
+
+func TestParseLocation(t *testing.T) {
+	tests := []struct {
+		name   string
+		offset int
+	}{
+		{"UTC", 0},
+		{"+09:00", 9 * 3600},
+		{"-0530", -(5*3600 + 30*60)},
+		{"+02", 2 * 3600},
+	}
+	at := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		loc, err := ParseLocation(tt.name)
+		if err != nil {
+			t.Errorf("ParseLocation(%q) failed: %v", tt.name, err)
+			continue
+		}
+		if _, offset := at.In(loc).Zone(); offset != tt.offset {
+			t.Errorf("ParseLocation(%q) offset = %d, expected %d", tt.name, offset, tt.offset)
+		}
+	}
+	if loc, err := ParseLocation("local"); err != nil || loc != time.Local {
+		t.Errorf("Expected local time, got %v, %v", loc, err)
+	}
+	for _, name := range []string{"+25:00", "+9", "Mars/Olympus"} {
+		if _, err := ParseLocation(name); err == nil {
+			t.Errorf("Expected an error for %q", name)
+		}
+	}
+}
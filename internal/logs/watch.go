@@ -0,0 +1,285 @@
+package logs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultPollInterval is how often Follow re-reads sources that cannot be
+// watched for writes (journal, kubectl, CRI) and re-checks rotated files
+const DefaultPollInterval = 10 * time.Second
+
+// Follow calls fn for each line added to the log from now on until ctx is
+// done. Files are watched for writes and re-opened by name every interval so
+// rotation is picked up; other sources are polled every interval for lines
+// newer than the last one seen.
+func (m *Monitor) Follow(ctx context.Context, interval time.Duration, fn func(Line)) error {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	if m.watcher != nil {
+		return m.followFile(ctx, interval, fn)
+	}
+	return m.followSource(ctx, interval, fn)
+}
+
+func (m *Monitor) followFile(ctx context.Context, interval time.Duration, fn func(Line)) error {
+	if err := m.watcher.Add(m.filePath); err != nil {
+		return fmt.Errorf("failed to add file to watcher: %w", err)
+	}
+	if err := m.setInitialOffset(); err != nil {
+		return fmt.Errorf("failed to read initial offset: %w", err)
+	}
+
+	var lastTime time.Time
+	onLine := func(text string) {
+		if t, ok := ParseTimestamp(text, time.Now()); ok {
+			lastTime = t
+		}
+		fn(Line{Time: lastTime, Level: ParseLevel(text), Text: text, Message: text})
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event := <-m.watcher.Events:
+			if event.Op&fsnotify.Write == fsnotify.Write {
+				if err := m.readNewLines(onLine); err != nil {
+					return fmt.Errorf("failed to read new lines: %w", err)
+				}
+			}
+		case <-ticker.C:
+			// A rotated file is replaced under the same name; watch the new one
+			_ = m.watcher.Add(m.filePath)
+			if err := m.readNewLines(onLine); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("failed to read new lines: %w", err)
+			}
+		case err := <-m.watcher.Errors:
+			return fmt.Errorf("watcher error: %w", err)
+		}
+	}
+}
+
+// followSource re-reads the source from the time of the last line seen. Untimed
+// lines take the time of the line before them, and lines at the last time seen
+// are skipped by count, so lines sharing a timestamp are neither lost nor repeated.
+func (m *Monitor) followSource(ctx context.Context, interval time.Duration, fn func(Line)) error {
+	cursor := time.Now()
+	seen := 0
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		var current, last time.Time
+		atCursor, atLast := 0, 0
+		err := m.source.Read(ctx, SearchOptions{Since: cursor}, func(line Line) bool {
+			if !line.Time.IsZero() {
+				current = line.Time
+			}
+			switch {
+			case current.Before(cursor):
+				return true
+			case current.Equal(cursor):
+				if atCursor++; atCursor > seen {
+					fn(line)
+				}
+			default:
+				fn(line)
+			}
+			if current.After(last) {
+				last, atLast = current, 0
+			}
+			if current.Equal(last) {
+				atLast++
+			}
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		if !last.IsZero() {
+			cursor, seen = last, atLast
+		}
+	}
+}
+
+// Trigger selects the lines of a watch that become events
+type Trigger struct {
+	// Pattern must match the line; nil matches every line
+	Pattern *regexp.Regexp
+	// Level keeps only lines of this level (ERROR, WARN, ...); empty keeps all
+	Level string
+}
+
+// Matches reports whether line fires the trigger
+func (t Trigger) Matches(line Line) bool {
+	if t.Level != "" {
+		level := line.Level
+		if level == "" {
+			level = ParseLevel(line.Text)
+		}
+		if level != normalizeLevel(t.Level) {
+			return false
+		}
+	}
+	return t.Pattern == nil || t.Pattern.MatchString(line.Text)
+}
+
+// WatchEvent is a line that fired a watch, or the error that stopped it
+type WatchEvent struct {
+	WatchID string
+	Source  string
+	Inject  bool
+	// At is when the watch saw the line
+	At   time.Time
+	Line Line
+	// Err is set, without a line, when the watch stopped on its own
+	Err error
+}
+
+// WatchInfo describes a running watch
+type WatchInfo struct {
+	ID        string
+	Source    string
+	Trigger   Trigger
+	Inject    bool
+	Started   time.Time
+	Events    int
+	LastEvent time.Time
+}
+
+type watch struct {
+	info   WatchInfo
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// WatchRegistry runs log watches in the background and reports the lines
+// that fire their triggers. Watches are identified as w1, w2, ...
+type WatchRegistry struct {
+	mu           sync.Mutex
+	watches      map[string]*watch
+	nextID       int
+	onEvent      func(WatchEvent)
+	pollInterval time.Duration
+}
+
+// NewWatchRegistry creates a registry calling onEvent from the watch
+// goroutines for every event
+func NewWatchRegistry(onEvent func(WatchEvent)) *WatchRegistry {
+	return &WatchRegistry{
+		watches:      make(map[string]*watch),
+		onEvent:      onEvent,
+		pollInterval: DefaultPollInterval,
+	}
+}
+
+// Start watches monitor until the watch is cancelled and returns its ID. The
+// registry owns the monitor from then on and closes it when the watch ends.
+func (r *WatchRegistry) Start(monitor *Monitor, trigger Trigger, inject bool) string {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	r.mu.Lock()
+	r.nextID++
+	w := &watch{
+		info: WatchInfo{
+			ID:      "w" + strconv.Itoa(r.nextID),
+			Source:  monitor.Name(),
+			Trigger: trigger,
+			Inject:  inject,
+			Started: time.Now(),
+		},
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	r.watches[w.info.ID] = w
+	interval := r.pollInterval
+	r.mu.Unlock()
+
+	go func() {
+		defer close(w.done)
+		defer monitor.Close()
+
+		err := monitor.Follow(ctx, interval, func(line Line) {
+			if !trigger.Matches(line) {
+				return
+			}
+			now := time.Now()
+			r.mu.Lock()
+			w.info.Events++
+			w.info.LastEvent = now
+			r.mu.Unlock()
+			r.onEvent(WatchEvent{WatchID: w.info.ID, Source: w.info.Source, Inject: inject, At: now, Line: line})
+		})
+
+		r.mu.Lock()
+		delete(r.watches, w.info.ID)
+		r.mu.Unlock()
+		if err != nil && ctx.Err() == nil {
+			r.onEvent(WatchEvent{WatchID: w.info.ID, Source: w.info.Source, Inject: inject, At: time.Now(), Err: err})
+		}
+	}()
+	return w.info.ID
+}
+
+// List returns the running watches in the order they were started
+func (r *WatchRegistry) List() []WatchInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	infos := make([]WatchInfo, 0, len(r.watches))
+	for _, w := range r.watches {
+		infos = append(infos, w.info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return watchNumber(infos[i].ID) < watchNumber(infos[j].ID)
+	})
+	return infos
+}
+
+// Cancel stops a watch and waits for it to close its monitor
+func (r *WatchRegistry) Cancel(id string) error {
+	r.mu.Lock()
+	w, ok := r.watches[id]
+	if ok {
+		delete(r.watches, id)
+	}
+	r.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("no watch %s", id)
+	}
+	w.cancel()
+	<-w.done
+	return nil
+}
+
+// CancelAll stops every watch and returns how many were running
+func (r *WatchRegistry) CancelAll() int {
+	infos := r.List()
+	for _, info := range infos {
+		_ = r.Cancel(info.ID)
+	}
+	return len(infos)
+}
+
+func watchNumber(id string) int {
+	n, _ := strconv.Atoi(id[1:])
+	return n
+}
@@ -0,0 +1,195 @@
+package logs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSource serves a fixed set of lines that tests append to
+type fakeSource struct {
+	mu    sync.Mutex
+	lines []Line
+	err   error
+}
+
+func (s *fakeSource) Name() string { return "fake" }
+
+func (s *fakeSource) add(line Line) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lines = append(s.lines, line)
+}
+
+func (s *fakeSource) Read(ctx context.Context, opts SearchOptions, fn func(Line) bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	for _, line := range s.lines {
+		if !fn(line) {
+			break
+		}
+	}
+	return nil
+}
+
+func waitForEvent(t *testing.T, events <-chan WatchEvent, poke func()) WatchEvent {
+	t.Helper()
+	deadline := time.After(3 * time.Second)
+	for {
+		if poke != nil {
+			poke()
+		}
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(50 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("No watch event received")
+		}
+	}
+}
+
+func TestTriggerMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		trigger  Trigger
+		line     Line
+		expected bool
+	}{
+		{"no trigger", Trigger{}, Line{Text: "anything"}, true},
+		{"pattern", Trigger{Pattern: regexp.MustCompile(`attach.*failed`)}, Line{Text: "attach of pvc-1 failed"}, true},
+		{"pattern miss", Trigger{Pattern: regexp.MustCompile(`attach.*failed`)}, Line{Text: "attach ok"}, false},
+		{"source level", Trigger{Level: "error"}, Line{Level: "ERROR", Text: "iscsid: conn 1 lost"}, true},
+		{"parsed level", Trigger{Level: "warning"}, Line{Text: "W0102 15:04:05.000000 1 x.go:1] slow"}, true},
+		{"level miss", Trigger{Level: "ERROR"}, Line{Text: "INFO: attach failed"}, false},
+		{"both", Trigger{Pattern: regexp.MustCompile(`mount`), Level: "ERROR"}, Line{Text: "ERROR: attach failed"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.trigger.Matches(tt.line); got != tt.expected {
+			t.Errorf("%s: Matches() = %v, expected %v", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestWatchRegistry_File(t *testing.T) {
+	path := writeTestLog(t, "ERROR: old failure")
+	monitor, err := NewMonitor(path)
+	if err != nil {
+		t.Fatalf("NewMonitor() failed: %v", err)
+	}
+
+	events := make(chan WatchEvent, 100)
+	registry := NewWatchRegistry(func(event WatchEvent) { events <- event })
+	registry.pollInterval = 20 * time.Millisecond
+	id := registry.Start(monitor, Trigger{Pattern: regexp.MustCompile(`failed`)}, true)
+	if id != "w1" {
+		t.Errorf("Expected first watch to be w1, got %s", id)
+	}
+
+	event := waitForEvent(t, events, func() {
+		file, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+		file.WriteString("INFO: attach ok\nERROR: mount failed\n")
+		file.Close()
+	})
+	if event.WatchID != "w1" || !event.Inject || event.Line.Text != "ERROR: mount failed" || event.Line.Level != "ERROR" {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+
+	watches := registry.List()
+	if len(watches) != 1 || watches[0].Events == 0 || watches[0].Source != path {
+		t.Errorf("Unexpected watches: %+v", watches)
+	}
+	if err := registry.Cancel("w1"); err != nil {
+		t.Errorf("Cancel() failed: %v", err)
+	}
+	if len(registry.List()) != 0 {
+		t.Error("Expected no watches after Cancel()")
+	}
+	if err := registry.Cancel("w1"); err == nil {
+		t.Error("Expected an error cancelling an unknown watch")
+	}
+}
+
+func TestWatchRegistry_PollsSources(t *testing.T) {
+	source := &fakeSource{}
+	source.add(Line{Time: time.Now().Add(-time.Minute), Level: "ERROR", Text: "old error"})
+
+	events := make(chan WatchEvent, 100)
+	registry := NewWatchRegistry(func(event WatchEvent) { events <- event })
+	registry.pollInterval = 20 * time.Millisecond
+	registry.Start(NewSourceMonitor(source), Trigger{Level: "ERROR"}, false)
+	defer registry.CancelAll()
+
+	source.add(Line{Time: time.Now().Add(time.Second), Level: "INFO", Text: "new info"})
+	source.add(Line{Time: time.Now().Add(2 * time.Second), Level: "ERROR", Text: "new error"})
+
+	event := waitForEvent(t, events, nil)
+	if event.Line.Text != "new error" || event.Source != "fake" {
+		t.Errorf("Expected only the new error, got %+v", event)
+	}
+
+	// Lines already seen are not reported again
+	select {
+	case event := <-events:
+		t.Errorf("Unexpected repeated event: %+v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestWatchRegistry_PollsLinesSharingATimestamp(t *testing.T) {
+	source := &fakeSource{}
+	events := make(chan WatchEvent, 100)
+	registry := NewWatchRegistry(func(event WatchEvent) { events <- event })
+	registry.pollInterval = 20 * time.Millisecond
+	registry.Start(NewSourceMonitor(source), Trigger{}, false)
+	defer registry.CancelAll()
+
+	at := time.Now().Add(time.Second)
+	source.add(Line{Time: at, Text: "first"})
+	if event := waitForEvent(t, events, nil); event.Line.Text != "first" {
+		t.Fatalf("Expected the first line, got %+v", event)
+	}
+
+	// A later line with the same time and an untimed continuation still count as new
+	source.add(Line{Time: at, Text: "second"})
+	source.add(Line{Text: "  continued"})
+	for _, expected := range []string{"second", "  continued"} {
+		if event := waitForEvent(t, events, nil); event.Line.Text != expected {
+			t.Errorf("Expected %q, got %+v", expected, event)
+		}
+	}
+	select {
+	case event := <-events:
+		t.Errorf("Unexpected repeated event: %+v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestWatchRegistry_ReportsErrors(t *testing.T) {
+	source := &fakeSource{err: errors.New("journalctl not found")}
+
+	events := make(chan WatchEvent, 10)
+	registry := NewWatchRegistry(func(event WatchEvent) { events <- event })
+	registry.pollInterval = 20 * time.Millisecond
+	registry.Start(NewSourceMonitor(source), Trigger{}, false)
+	registry.Start(NewSourceMonitor(&fakeSource{}), Trigger{}, false)
+
+	event := waitForEvent(t, events, nil)
+	if event.WatchID != "w1" || event.Err == nil {
+		t.Errorf("Expected w1 to stop with an error, got %+v", event)
+	}
+	watches := registry.List()
+	if len(watches) != 1 || watches[0].ID != "w2" {
+		t.Errorf("Expected only w2 to keep running, got %+v", watches)
+	}
+	if n := registry.CancelAll(); n != 1 {
+		t.Errorf("Expected CancelAll() to stop 1 watch, got %d", n)
+	}
+}
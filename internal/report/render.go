@@ -0,0 +1,200 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/mainbong/storage_doctor/internal/history"
+)
+
+// Render renders a report as "md" (or "markdown"), "html" or "json"
+func Render(r *Report, format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "md", "markdown":
+		return Markdown(r), nil
+	case "html":
+		return HTML(r)
+	case "json":
+		data, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to encode report: %w", err)
+		}
+		return string(data) + "\n", nil
+	default:
+		return "", fmt.Errorf("unsupported report format: %s (md, html, json)", format)
+	}
+}
+
+// Markdown renders a report for pasting into tickets
+func Markdown(r *Report) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# 진단 보고서: %s\n\n", r.Title))
+	sb.WriteString(fmt.Sprintf("- 세션 ID: `%s`\n", r.SessionID))
+	sb.WriteString(fmt.Sprintf("- 기간: %s ~ %s\n", formatTime(r.CreatedAt), formatTime(r.UpdatedAt)))
+	sb.WriteString(fmt.Sprintf("- 명령어 %d개, 파일 변경 %d개, 참고 자료 %d개\n", len(r.Commands), len(r.FileChanges), len(r.Sources)))
+	if r.Usage.Calls > 0 {
+		sb.WriteString("- " + usageText(r.Usage) + "\n")
+	}
+
+	sb.WriteString("\n## 문제\n\n")
+	sb.WriteString(orNone(r.Problem) + "\n")
+
+	sb.WriteString("\n## 실행한 명령어\n")
+	if len(r.Commands) == 0 {
+		sb.WriteString("\n(없음)\n")
+	}
+	for i, cmd := range r.Commands {
+		sb.WriteString(fmt.Sprintf("\n### %d. `%s`\n\n", i+1, cmd.Command))
+		sb.WriteString(fmt.Sprintf("실행 시각: %s\n", formatTime(cmd.Timestamp)))
+		if cmd.InverseCommand != "" {
+			sb.WriteString(fmt.Sprintf("되돌리기: `%s`\n", cmd.InverseCommand))
+		}
+		if cmd.Output != "" {
+			sb.WriteString("\n" + fence(cmd.Output, "text"))
+		}
+	}
+
+	sb.WriteString("\n## 파일 변경\n")
+	if len(r.FileChanges) == 0 {
+		sb.WriteString("\n(없음)\n")
+	}
+	for _, change := range r.FileChanges {
+		sb.WriteString(fmt.Sprintf("\n### `%s` (+%d -%d)\n\n", change.Path, change.Inserted, change.Deleted))
+		if change.Created {
+			sb.WriteString("새 파일\n")
+		}
+		if change.BackupPath != "" {
+			sb.WriteString(fmt.Sprintf("백업: `%s`\n", change.BackupPath))
+		}
+		if change.Diff != "" {
+			sb.WriteString("\n" + fence(change.Diff, "diff"))
+		}
+	}
+
+	sb.WriteString("\n## 참고 자료\n\n")
+	if len(r.Sources) == 0 {
+		sb.WriteString("(없음)\n")
+	}
+	for _, source := range r.Sources {
+		title := source.Title
+		if title == "" {
+			title = source.URL
+		}
+		sb.WriteString(fmt.Sprintf("- [%s](%s) — 검색어: %s\n", title, source.URL, source.Query))
+	}
+
+	sb.WriteString("\n## 결론\n\n")
+	sb.WriteString(orNone(r.Conclusion) + "\n")
+	return sb.String()
+}
+
+// HTML renders a standalone HTML page
+func HTML(r *Report) (string, error) {
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, r); err != nil {
+		return "", fmt.Errorf("failed to render report: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// fence wraps text in a code fence long enough not to clash with backticks inside it
+func fence(text, lang string) string {
+	marker := "```"
+	for strings.Contains(text, marker) {
+		marker += "`"
+	}
+	return marker + lang + "\n" + strings.TrimRight(text, "\n") + "\n" + marker + "\n"
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(기록 없음)"
+	}
+	return s
+}
+
+func formatTime(t time.Time) string {
+	return t.Format("2006-01-02 15:04:05")
+}
+
+// diffLineClass returns the CSS class of a unified diff line
+func diffLineClass(line string) string {
+	switch {
+	case strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---"):
+		return "hdr"
+	case strings.HasPrefix(line, "@@"):
+		return "hunk"
+	case strings.HasPrefix(line, "+"):
+		return "add"
+	case strings.HasPrefix(line, "-"):
+		return "del"
+	default:
+		return ""
+	}
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"time":  formatTime,
+	"none":  orNone,
+	"lines": func(s string) []string { return strings.Split(strings.TrimRight(s, "\n"), "\n") },
+	"class": diffLineClass,
+	"inc":   func(i int) int { return i + 1 },
+	"usage": usageText,
+}).Parse(`<!DOCTYPE html>
+<html lang="ko">
+<head>
+<meta charset="utf-8">
+<title>진단 보고서: {{.Title}}</title>
+<style>
+body { font-family: sans-serif; max-width: 960px; margin: 2em auto; line-height: 1.5; }
+pre { background: #f6f8fa; padding: 0.8em; overflow-x: auto; white-space: pre-wrap; }
+pre span { display: block; }
+.add { background: #e6ffec; } .del { background: #ffebe9; }
+.hunk { color: #0550ae; } .hdr { font-weight: bold; }
+.meta { color: #57606a; }
+</style>
+</head>
+<body>
+<h1>진단 보고서: {{.Title}}</h1>
+<p class="meta">세션 ID: {{.SessionID}}<br>기간: {{time .CreatedAt}} ~ {{time .UpdatedAt}}{{if .Usage.Calls}}<br>{{usage .Usage}}{{end}}</p>
+
+<h2>문제</h2>
+<pre>{{none .Problem}}</pre>
+
+<h2>실행한 명령어</h2>
+{{range $i, $cmd := .Commands}}
+<h3>{{inc $i}}. <code>{{$cmd.Command}}</code></h3>
+<p class="meta">실행 시각: {{time $cmd.Timestamp}}{{if $cmd.InverseCommand}}<br>되돌리기: <code>{{$cmd.InverseCommand}}</code>{{end}}</p>
+{{if $cmd.Output}}<pre>{{$cmd.Output}}</pre>{{end}}
+{{else}}<p>(없음)</p>{{end}}
+
+<h2>파일 변경</h2>
+{{range .FileChanges}}
+<h3><code>{{.Path}}</code> (+{{.Inserted}} -{{.Deleted}})</h3>
+{{if .Created}}<p class="meta">새 파일</p>{{end}}{{if .BackupPath}}<p class="meta">백업: <code>{{.BackupPath}}</code></p>{{end}}
+{{if .Diff}}<pre>{{range lines .Diff}}<span class="{{class .}}">{{.}}</span>{{end}}</pre>{{end}}
+{{else}}<p>(없음)</p>{{end}}
+
+<h2>참고 자료</h2>
+{{if .Sources}}<ul>
+{{range .Sources}}<li><a href="{{.URL}}">{{if .Title}}{{.Title}}{{else}}{{.URL}}{{end}}</a> — 검색어: {{.Query}}</li>
+{{end}}</ul>{{else}}<p>(없음)</p>{{end}}
+
+<h2>결론</h2>
+<pre>{{none .Conclusion}}</pre>
+</body>
+</html>
+`))
+
+// usageText summarizes the LLM calls, tokens and cost of a session
+func usageText(u history.UsageSummary) string {
+	text := fmt.Sprintf("LLM 호출 %d회, 토큰 입력 %d / 출력 %d", u.Calls, u.InputTokens, u.OutputTokens)
+	if cached := u.CacheCreationInputTokens + u.CacheReadInputTokens; cached > 0 {
+		text += fmt.Sprintf(" / 캐시 쓰기 %d / 캐시 읽기 %d", u.CacheCreationInputTokens, u.CacheReadInputTokens)
+	}
+	return text + ", API 비용 " + u.CostText()
+}
@@ -0,0 +1,82 @@
+package report
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRender_Markdown(t *testing.T) {
+	out, err := Render(FromSession(testSession(), DefaultOptions), "md")
+	if err != nil {
+		t.Fatalf("Render() failed: %v", err)
+	}
+	for _, want := range []string{
+		"# 진단 보고서: pvc-pending",
+		"## 문제\n\nPVC data가 Pending 상태입니다",
+		"### 1. `kubectl get pvc`",
+		"```diff\n--- a/etc/app/pvc.yaml",
+		"- [K8s docs](https://kubernetes.io/docs) — 검색어: pvc pending",
+		"## 결론\n\nStorageClass 이름이 잘못되었습니다",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in markdown:\n%s", want, out)
+		}
+	}
+}
+
+func TestRender_MarkdownUsage(t *testing.T) {
+	session := testSession()
+	session.Usage.Calls = 3
+	session.Usage.InputTokens = 12000
+	session.Usage.OutputTokens = 800
+	session.Usage.CostUSD = 0.048
+	out, err := Render(FromSession(session, DefaultOptions), "md")
+	if err != nil {
+		t.Fatalf("Render() failed: %v", err)
+	}
+	if !strings.Contains(out, "- LLM 호출 3회, 토큰 입력 12000 / 출력 800, API 비용 $0.0480\n") {
+		t.Errorf("Expected usage line in markdown:\n%s", out)
+	}
+}
+
+func TestRender_HTMLEscapes(t *testing.T) {
+	session := testSession()
+	session.Problem = "<script>alert(1)</script>"
+	out, err := Render(FromSession(session, DefaultOptions), "html")
+	if err != nil {
+		t.Fatalf("Render() failed: %v", err)
+	}
+	if strings.Contains(out, "<script>") {
+		t.Error("Problem statement was not escaped")
+	}
+	if !strings.Contains(out, `<span class="add">&#43;storageClassName: fast</span>`) {
+		t.Errorf("Expected highlighted diff line in HTML:\n%s", out)
+	}
+}
+
+func TestRender_JSON(t *testing.T) {
+	out, err := Render(FromSession(testSession(), DefaultOptions), "json")
+	if err != nil {
+		t.Fatalf("Render() failed: %v", err)
+	}
+	var decoded Report
+	if err := json.Unmarshal([]byte(out), &decoded); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if decoded.SessionID != "123" || len(decoded.FileChanges) != 2 {
+		t.Errorf("Unexpected decoded report: %+v", decoded)
+	}
+}
+
+func TestRender_UnknownFormat(t *testing.T) {
+	if _, err := Render(FromSession(testSession(), DefaultOptions), "pdf"); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
+
+func TestFence(t *testing.T) {
+	if got := fence("a ``` b", "text"); !strings.HasPrefix(got, "````text\n") {
+		t.Errorf("Expected longer fence, got %q", got)
+	}
+}
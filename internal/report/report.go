@@ -0,0 +1,134 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mainbong/storage_doctor/internal/diff"
+	"github.com/mainbong/storage_doctor/internal/history"
+)
+
+// Options controls how much of a session ends up in a report
+type Options struct {
+	// HeadLines and TailLines are the command output lines kept from each end
+	HeadLines int
+	TailLines int
+}
+
+// DefaultOptions keeps enough output to show what a command printed without flooding the report
+var DefaultOptions = Options{HeadLines: 20, TailLines: 10}
+
+// Report is a postmortem assembled from a saved session
+type Report struct {
+	SessionID   string       `json:"session_id"`
+	Title       string       `json:"title"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	Problem     string       `json:"problem,omitempty"`
+	Commands    []Command    `json:"commands"`
+	FileChanges []FileChange `json:"file_changes"`
+	Sources     []Source     `json:"sources"`
+	Conclusion  string       `json:"conclusion,omitempty"`
+	// Usage is the LLM token usage and API cost of the session
+	Usage history.UsageSummary `json:"usage"`
+}
+
+// Command is a command run during the session
+type Command struct {
+	Timestamp      time.Time `json:"timestamp"`
+	Command        string    `json:"command"`
+	Output         string    `json:"output,omitempty"`
+	OmittedLines   int       `json:"omitted_lines,omitempty"`
+	InverseCommand string    `json:"inverse_command,omitempty"`
+}
+
+// FileChange is a file modified during the session
+type FileChange struct {
+	Timestamp  time.Time `json:"timestamp"`
+	Path       string    `json:"path"`
+	Created    bool      `json:"created,omitempty"`
+	BackupPath string    `json:"backup_path,omitempty"`
+	Diff       string    `json:"diff"`
+	Inserted   int       `json:"inserted"`
+	Deleted    int       `json:"deleted"`
+}
+
+// Source is a web page consulted during the session
+type Source struct {
+	Query string `json:"query"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// FromSession builds a report from a session
+func FromSession(session *history.Session, opts Options) *Report {
+	r := &Report{
+		SessionID:   session.ID,
+		Title:       session.Name,
+		CreatedAt:   session.CreatedAt,
+		UpdatedAt:   session.UpdatedAt,
+		Problem:     strings.TrimSpace(session.Problem),
+		Conclusion:  strings.TrimSpace(session.Conclusion),
+		Commands:    []Command{},
+		FileChanges: []FileChange{},
+		Sources:     []Source{},
+		Usage:       session.Usage,
+	}
+
+	for _, action := range session.Actions {
+		switch action.Type {
+		case history.ActionTypeCommand:
+			output, omitted := trimOutput(action.Output, opts.HeadLines, opts.TailLines)
+			r.Commands = append(r.Commands, Command{
+				Timestamp:      action.Timestamp,
+				Command:        action.Command,
+				Output:         output,
+				OmittedLines:   omitted,
+				InverseCommand: action.InverseCommand,
+			})
+		case history.ActionTypeFile:
+			path := strings.TrimPrefix(action.FilePath, "/")
+			oldName := "a/" + path
+			if action.Created {
+				oldName = "/dev/null"
+			}
+			unified := diff.Unified(oldName, "b/"+path, action.OldValue, action.NewValue, 3)
+			inserted, deleted := diff.CountChanges(unified)
+			r.FileChanges = append(r.FileChanges, FileChange{
+				Timestamp:  action.Timestamp,
+				Path:       action.FilePath,
+				Created:    action.Created,
+				BackupPath: action.BackupPath,
+				Diff:       unified,
+				Inserted:   inserted,
+				Deleted:    deleted,
+			})
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, search := range session.Searches {
+		for _, source := range search.Sources {
+			if source.URL == "" || seen[source.URL] {
+				continue
+			}
+			seen[source.URL] = true
+			r.Sources = append(r.Sources, Source{Query: search.Query, Title: source.Title, URL: source.URL})
+		}
+	}
+	return r
+}
+
+// trimOutput keeps the first head and last tail lines and returns how many were dropped
+func trimOutput(output string, head, tail int) (string, int) {
+	output = strings.TrimRight(output, "\n")
+	lines := strings.Split(output, "\n")
+	if head < 0 || tail < 0 || len(lines) <= head+tail || head+tail == 0 {
+		return output, 0
+	}
+	omitted := len(lines) - head - tail
+	kept := append(append([]string{}, lines[:head]...), fmt.Sprintf("... (%d줄 생략)", omitted))
+	kept = append(kept, lines[len(lines)-tail:]...)
+	return strings.Join(kept, "\n"), omitted
+}
@@ -0,0 +1,77 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mainbong/storage_doctor/internal/history"
+)
+
+func testSession() *history.Session {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	return &history.Session{
+		ID:        "123",
+		Name:      "pvc-pending",
+		CreatedAt: now,
+		UpdatedAt: now.Add(time.Hour),
+		Problem:   "PVC data가 Pending 상태입니다",
+		Actions: []history.Action{
+			{Type: history.ActionTypeCommand, Timestamp: now, Command: "kubectl get pvc", Output: "NAME STATUS\ndata Pending\n"},
+			{Type: history.ActionTypeFile, Timestamp: now, FilePath: "/etc/app/pvc.yaml", OldValue: "storageClassName: slow\n", NewValue: "storageClassName: fast\n", BackupPath: "/backups/pvc.yaml.bak"},
+			{Type: history.ActionTypeFile, Timestamp: now, FilePath: "/etc/app/new.yaml", NewValue: "a: 1\n", Created: true},
+		},
+		Searches: []history.Search{
+			{Query: "pvc pending", Sources: []history.Source{{Title: "K8s docs", URL: "https://kubernetes.io/docs"}, {Title: "dup", URL: "https://kubernetes.io/docs"}}},
+			{Query: "no results"},
+		},
+		Conclusion: "StorageClass 이름이 잘못되었습니다",
+	}
+}
+
+func TestFromSession(t *testing.T) {
+	r := FromSession(testSession(), DefaultOptions)
+
+	if r.Problem != "PVC data가 Pending 상태입니다" || r.Conclusion != "StorageClass 이름이 잘못되었습니다" {
+		t.Errorf("Unexpected problem/conclusion: %q / %q", r.Problem, r.Conclusion)
+	}
+	if len(r.Commands) != 1 || r.Commands[0].Output != "NAME STATUS\ndata Pending" {
+		t.Errorf("Unexpected commands: %+v", r.Commands)
+	}
+	if len(r.FileChanges) != 2 {
+		t.Fatalf("Expected 2 file changes, got %d", len(r.FileChanges))
+	}
+	change := r.FileChanges[0]
+	if !strings.Contains(change.Diff, "-storageClassName: slow") || change.Inserted != 1 || change.Deleted != 1 {
+		t.Errorf("Unexpected change: %+v", change)
+	}
+	if !strings.Contains(r.FileChanges[1].Diff, "--- /dev/null") {
+		t.Errorf("Expected new file diff, got %s", r.FileChanges[1].Diff)
+	}
+	if len(r.Sources) != 1 || r.Sources[0].Query != "pvc pending" {
+		t.Errorf("Expected one deduplicated source, got %+v", r.Sources)
+	}
+}
+
+func TestTrimOutput(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 50; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	output, omitted := trimOutput(strings.Join(lines, "\n"), 3, 2)
+	if omitted != 45 {
+		t.Errorf("Expected 45 omitted lines, got %d", omitted)
+	}
+	expected := "line 1\nline 2\nline 3\n... (45줄 생략)\nline 49\nline 50"
+	if output != expected {
+		t.Errorf("Unexpected output:\n%s", output)
+	}
+
+	if output, omitted := trimOutput("a\nb\n", 3, 2); output != "a\nb" || omitted != 0 {
+		t.Errorf("Short output should be kept, got %q (%d)", output, omitted)
+	}
+	if _, omitted := trimOutput(strings.Join(lines, "\n"), 0, 0); omitted != 0 {
+		t.Error("Zero limits should keep everything")
+	}
+}
@@ -0,0 +1,84 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/mainbong/storage_doctor/internal/httpclient"
+)
+
+// BingProvider implements search using the Bing Web Search API
+type BingProvider struct {
+	apiKey string
+	client httpclient.HTTPClient
+}
+
+// NewBingProvider creates a new Bing provider
+func NewBingProvider(apiKey string) *BingProvider {
+	return NewBingProviderWithClient(apiKey, httpclient.NewDefaultHTTPClient())
+}
+
+// NewBingProviderWithClient creates a new Bing provider with a custom HTTPClient (for testing)
+func NewBingProviderWithClient(apiKey string, client httpclient.HTTPClient) *BingProvider {
+	return &BingProvider{
+		apiKey: apiKey,
+		client: client,
+	}
+}
+
+func (p *BingProvider) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	if p.apiKey == "" {
+		return nil, fmt.Errorf("bing API key not set")
+	}
+
+	searchURL := fmt.Sprintf(
+		"https://api.bing.microsoft.com/v7.0/search?q=%s&count=%d&responseFilter=Webpages&textFormat=Raw",
+		url.QueryEscape(query),
+		limit,
+	)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Ocp-Apim-Subscription-Key", p.apiKey)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError("bing", resp)
+	}
+
+	var response struct {
+		WebPages struct {
+			Value []struct {
+				Name    string `json:"name"`
+				URL     string `json:"url"`
+				Snippet string `json:"snippet"`
+			} `json:"value"`
+		} `json:"webPages"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	results := make([]SearchResult, 0, len(response.WebPages.Value))
+	for _, item := range response.WebPages.Value {
+		results = append(results, SearchResult{
+			Title:   item.Name,
+			URL:     item.URL,
+			Snippet: item.Snippet,
+		})
+	}
+
+	return results, nil
+}
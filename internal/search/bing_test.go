@@ -0,0 +1,52 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mainbong/storage_doctor/internal/httpclient"
+)
+
+const bingTestURL = "https://api.bing.microsoft.com/v7.0/search?q=longhorn+faulted&count=5&responseFilter=Webpages&textFormat=Raw"
+
+func TestBingProvider_Search(t *testing.T) {
+	mockClient := httpclient.NewMockHTTPClient()
+	mockClient.SetResponse(bingTestURL, 200, `{"webPages":{"value":[
+		{"name":"Longhorn volume faulted","url":"https://longhorn.io/kb/faulted","snippet":"All replicas failed"},
+		{"name":"Issue #1234","url":"https://github.com/longhorn/longhorn/issues/1234","snippet":"Faulted after node reboot"}]}}`, nil)
+
+	provider := NewBingProviderWithClient("test-key", mockClient)
+	results, err := provider.Search(context.Background(), "longhorn faulted", 5)
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+
+	if len(results) != 2 || results[0].Title != "Longhorn volume faulted" || results[1].URL != "https://github.com/longhorn/longhorn/issues/1234" {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+	if key := mockClient.GetRequests()[0].Header.Get("Ocp-Apim-Subscription-Key"); key != "test-key" {
+		t.Errorf("Expected subscription key header, got %q", key)
+	}
+}
+
+func TestBingProvider_Search_RateLimited(t *testing.T) {
+	mockClient := httpclient.NewMockHTTPClient()
+	mockClient.SetResponse(bingTestURL, 429, `{"error":{"code":"429"}}`, map[string]string{"Retry-After": "30"})
+
+	provider := NewBingProviderWithClient("test-key", mockClient)
+	_, err := provider.Search(context.Background(), "longhorn faulted", 5)
+
+	var rateLimit *RateLimitError
+	if !errors.As(err, &rateLimit) || rateLimit.Provider != "bing" || rateLimit.RetryAfter != 30*time.Second {
+		t.Errorf("Expected a bing rate limit error with retry after 30s, got %v", err)
+	}
+}
+
+func TestBingProvider_Search_NoAPIKey(t *testing.T) {
+	provider := NewBingProviderWithClient("", httpclient.NewMockHTTPClient())
+	if _, err := provider.Search(context.Background(), "test", 5); err == nil {
+		t.Error("Expected error for missing API key, got nil")
+	}
+}
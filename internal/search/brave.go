@@ -0,0 +1,110 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mainbong/storage_doctor/internal/httpclient"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// braveMaxCount is the largest result count the Brave Search API accepts
+const braveMaxCount = 20
+
+// BraveProvider implements search using the Brave Search API
+type BraveProvider struct {
+	apiKey string
+	client httpclient.HTTPClient
+}
+
+// NewBraveProvider creates a new Brave provider
+func NewBraveProvider(apiKey string) *BraveProvider {
+	return NewBraveProviderWithClient(apiKey, httpclient.NewDefaultHTTPClient())
+}
+
+// NewBraveProviderWithClient creates a new Brave provider with a custom HTTPClient (for testing)
+func NewBraveProviderWithClient(apiKey string, client httpclient.HTTPClient) *BraveProvider {
+	return &BraveProvider{
+		apiKey: apiKey,
+		client: client,
+	}
+}
+
+func (p *BraveProvider) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	if p.apiKey == "" {
+		return nil, fmt.Errorf("brave API key not set")
+	}
+	if limit <= 0 || limit > braveMaxCount {
+		limit = braveMaxCount
+	}
+
+	searchURL := fmt.Sprintf(
+		"https://api.search.brave.com/res/v1/web/search?q=%s&count=%d",
+		url.QueryEscape(query),
+		limit,
+	)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Subscription-Token", p.apiKey)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError("brave", resp)
+	}
+
+	var response struct {
+		Web struct {
+			Results []struct {
+				Title       string `json:"title"`
+				URL         string `json:"url"`
+				Description string `json:"description"`
+			} `json:"results"`
+		} `json:"web"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	results := make([]SearchResult, 0, len(response.Web.Results))
+	for _, item := range response.Web.Results {
+		results = append(results, SearchResult{
+			Title:   stripTags(item.Title),
+			URL:     item.URL,
+			Snippet: stripTags(item.Description),
+		})
+	}
+
+	return results, nil
+}
+
+// stripTags removes the <strong> highlighting Brave puts around query terms
+func stripTags(text string) string {
+	if !strings.Contains(text, "<") && !strings.Contains(text, "&") {
+		return text
+	}
+	nodes, err := html.ParseFragment(strings.NewReader(text), &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div})
+	if err != nil {
+		return text
+	}
+	var b strings.Builder
+	for _, n := range nodes {
+		b.WriteString(nodeText(n))
+	}
+	return strings.TrimSpace(b.String())
+}
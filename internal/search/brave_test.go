@@ -0,0 +1,41 @@
+package search
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mainbong/storage_doctor/internal/httpclient"
+)
+
+func TestBraveProvider_Search(t *testing.T) {
+	mockClient := httpclient.NewMockHTTPClient()
+	mockClient.SetResponse("https://api.search.brave.com/res/v1/web/search?q=rook+osd+crashloop&count=20", 200, `{"web":{"results":[
+		{"title":"OSD pods in <strong>CrashLoopBackOff</strong>","url":"https://rook.io/docs/troubleshooting","description":"Check the <strong>OSD</strong> logs &amp; the device"}]}}`, nil)
+
+	provider := NewBraveProviderWithClient("test-key", mockClient)
+	// Brave caps count at 20
+	results, err := provider.Search(context.Background(), "rook osd crashloop", 50)
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(results))
+	}
+	if results[0].Title != "OSD pods in CrashLoopBackOff" || results[0].Snippet != "Check the OSD logs & the device" {
+		t.Errorf("Expected highlighting to be stripped, got %+v", results[0])
+	}
+	if token := mockClient.GetRequests()[0].Header.Get("X-Subscription-Token"); token != "test-key" {
+		t.Errorf("Expected subscription token header, got %q", token)
+	}
+}
+
+func TestBraveProvider_Search_Error(t *testing.T) {
+	mockClient := httpclient.NewMockHTTPClient()
+	mockClient.SetResponse("https://api.search.brave.com/res/v1/web/search?q=test&count=5", 500, "Internal Server Error", nil)
+
+	provider := NewBraveProviderWithClient("test-key", mockClient)
+	if _, err := provider.Search(context.Background(), "test", 5); err == nil {
+		t.Error("Expected error for 500 status, got nil")
+	}
+}
@@ -79,7 +79,7 @@ func (p *DuckDuckGoProvider) parseHTMLResults(raw string, limit int) []SearchRes
 		}
 		if n.Type == html.ElementNode && n.Data == "a" && hasClass(n, "result__a") {
 			title := strings.TrimSpace(nodeText(n))
-			url := strings.TrimSpace(getAttr(n, "href"))
+			url := resolveDuckDuckGoURL(strings.TrimSpace(getAttr(n, "href")))
 			snippet := ""
 			if parent := findAncestorWithClass(n, "result"); parent != nil {
 				if snippetNode := findDescendantWithClass(parent, "result__snippet"); snippetNode != nil {
@@ -108,6 +108,18 @@ func (p *DuckDuckGoProvider) parseHTMLResults(raw string, limit int) []SearchRes
 	return results
 }
 
+// resolveDuckDuckGoURL returns the target of a DuckDuckGo redirect link (//duckduckgo.com/l/?uddg=...)
+func resolveDuckDuckGoURL(href string) string {
+	parsed, err := url.Parse(href)
+	if err != nil || !strings.HasSuffix(parsed.Host, "duckduckgo.com") || parsed.Path != "/l/" {
+		return href
+	}
+	if target := parsed.Query().Get("uddg"); target != "" {
+		return target
+	}
+	return href
+}
+
 func getAttr(n *html.Node, key string) string {
 	for _, attr := range n.Attr {
 		if attr.Key == key {
@@ -37,7 +37,12 @@ func TestDuckDuckGoProvider_Search_Error(t *testing.T) {
 	}
 }
 
-
-
-
-
+func TestResolveDuckDuckGoURL(t *testing.T) {
+	redirect := "//duckduckgo.com/l/?uddg=https%3A%2F%2Fgithub.com%2Frook%2Frook%2Fissues%2F42&rut=abc"
+	if got := resolveDuckDuckGoURL(redirect); got != "https://github.com/rook/rook/issues/42" {
+		t.Errorf("Expected the redirect target, got %q", got)
+	}
+	if got := resolveDuckDuckGoURL("https://longhorn.io/docs"); got != "https://longhorn.io/docs" {
+		t.Errorf("Expected direct links unchanged, got %q", got)
+	}
+}
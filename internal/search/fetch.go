@@ -0,0 +1,548 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mainbong/storage_doctor/internal/filesystem"
+	"github.com/mainbong/storage_doctor/internal/httpclient"
+	"github.com/mainbong/storage_doctor/internal/logger"
+	"golang.org/x/net/html"
+)
+
+const (
+	// DefaultPageTokens is the token budget of a fetched page when the caller gives none
+	DefaultPageTokens = 4000
+	// pageCacheTTL is how long a fetched page is served from the disk cache
+	pageCacheTTL = 24 * time.Hour
+	// maxPageBytes caps how much of a response body is read
+	maxPageBytes = 5 << 20
+	// githubAPIURL is the GitHub REST API used for issue and pull request threads
+	githubAPIURL = "https://api.github.com"
+)
+
+// Page is the readable text of a fetched URL
+type Page struct {
+	URL       string    `json:"url"`
+	Title     string    `json:"title,omitempty"`
+	Content   string    `json:"content"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// Fetcher downloads pages found by search and extracts their readable text
+type Fetcher struct {
+	client   httpclient.HTTPClient
+	fs       filesystem.FileSystem
+	cacheDir string
+	now      func() time.Time
+}
+
+// NewFetcher creates a new page fetcher caching pages in cacheDir ("" disables
+// the cache). It only connects to public addresses since the URLs come from
+// search results and the model.
+func NewFetcher(cacheDir string) *Fetcher {
+	return NewFetcherWithClient(cacheDir, httpclient.NewPublicHTTPClient(), filesystem.NewOSFileSystem())
+}
+
+// NewFetcherWithClient creates a new page fetcher with a custom HTTPClient and FileSystem (for testing)
+func NewFetcherWithClient(cacheDir string, client httpclient.HTTPClient, fs filesystem.FileSystem) *Fetcher {
+	return &Fetcher{
+		client:   client,
+		fs:       fs,
+		cacheDir: cacheDir,
+		now:      time.Now,
+	}
+}
+
+// Fetch downloads rawURL and returns its readable text. GitHub issues and pull
+// requests are read with their comments, and GitHub file views are read raw.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (*Page, error) {
+	target, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, fmt.Errorf("invalid URL: %s", rawURL)
+	}
+	if err := checkPublicHost(target.Hostname()); err != nil {
+		return nil, err
+	}
+	target.Fragment = ""
+
+	if page, ok := f.cached(target.String()); ok {
+		return page, nil
+	}
+
+	var page *Page
+	if owner, repo, number, ok := githubIssue(target); ok {
+		page, err = f.fetchGitHubIssue(ctx, owner, repo, number)
+		if err != nil {
+			// The API is rate limited without a token; the HTML page still has the thread
+			logger.Warn("GitHub API 조회 실패, 페이지에서 추출합니다: %v", err)
+			page, err = f.fetchPage(ctx, target.String())
+		}
+	} else {
+		page, err = f.fetchPage(ctx, rawGitHubURL(target))
+	}
+	if err != nil {
+		return nil, err
+	}
+	page.URL = target.String()
+	page.FetchedAt = f.now()
+	f.store(page)
+	return page, nil
+}
+
+// checkPublicHost rejects URLs naming the local host or a non-public IP.
+// Names resolving to such addresses are refused by the client when dialing.
+func checkPublicHost(host string) error {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: %s", httpclient.ErrNonPublicAddress, host)
+	}
+	if ip := net.ParseIP(host); ip != nil && !httpclient.IsPublicIP(ip) {
+		return fmt.Errorf("%w: %s", httpclient.ErrNonPublicAddress, host)
+	}
+	return nil
+}
+
+// fetchPage downloads a page and extracts its text according to its content type
+func (f *Fetcher) fetchPage(ctx context.Context, pageURL string) (*Page, error) {
+	body, contentType, err := f.get(ctx, pageURL, "text/html,text/plain,text/markdown;q=0.9,*/*;q=0.5")
+	if err != nil {
+		return nil, err
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "" {
+		mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(body))
+	}
+
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		title, text := extractReadableText(body)
+		return &Page{Title: title, Content: text}, nil
+	case strings.HasPrefix(mediaType, "text/") || mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return &Page{Content: strings.TrimSpace(string(body))}, nil
+	default:
+		return nil, fmt.Errorf("unsupported content type %s: %s", mediaType, pageURL)
+	}
+}
+
+func (f *Fetcher) get(ctx context.Context, pageURL, accept string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+	req.Header.Set("Accept", accept)
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("failed to fetch %s: HTTP %d", pageURL, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPageBytes))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read response: %w", err)
+	}
+	return body, resp.Header.Get("Content-Type"), nil
+}
+
+type githubComment struct {
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	User      struct {
+		Login string `json:"login"`
+	} `json:"user"`
+}
+
+type githubIssueResponse struct {
+	githubComment
+	Title       string `json:"title"`
+	State       string `json:"state"`
+	PullRequest *struct {
+		MergedAt *time.Time `json:"merged_at"`
+	} `json:"pull_request"`
+}
+
+// fetchGitHubIssue reads an issue or pull request and its comments as markdown
+func (f *Fetcher) fetchGitHubIssue(ctx context.Context, owner, repo string, number int) (*Page, error) {
+	base := fmt.Sprintf("%s/repos/%s/%s/issues/%d", githubAPIURL, owner, repo, number)
+	body, _, err := f.get(ctx, base, "application/vnd.github+json")
+	if err != nil {
+		return nil, err
+	}
+	var issue githubIssueResponse
+	if err := json.Unmarshal(body, &issue); err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub issue: %w", err)
+	}
+
+	body, _, err = f.get(ctx, base+"/comments?per_page=100", "application/vnd.github+json")
+	if err != nil {
+		return nil, err
+	}
+	var comments []githubComment
+	if err := json.Unmarshal(body, &comments); err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub comments: %w", err)
+	}
+
+	state := issue.State
+	if issue.PullRequest != nil && issue.PullRequest.MergedAt != nil {
+		state = "merged"
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("# %s (%s/%s#%d, %s)\n", issue.Title, owner, repo, number, state))
+	writeGitHubComment(&b, issue.githubComment)
+	for _, comment := range comments {
+		b.WriteString("\n---")
+		writeGitHubComment(&b, comment)
+	}
+	return &Page{Title: issue.Title, Content: strings.TrimSpace(b.String())}, nil
+}
+
+func writeGitHubComment(b *strings.Builder, comment githubComment) {
+	b.WriteString(fmt.Sprintf("\n@%s (%s):\n", comment.User.Login, comment.CreatedAt.Format("2006-01-02")))
+	b.WriteString(strings.TrimSpace(comment.Body))
+	b.WriteString("\n")
+}
+
+// githubIssue reports whether u is a GitHub issue or pull request page
+func githubIssue(u *url.URL) (string, string, int, bool) {
+	if u.Host != "github.com" && u.Host != "www.github.com" {
+		return "", "", 0, false
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 4 || (parts[2] != "issues" && parts[2] != "pull") {
+		return "", "", 0, false
+	}
+	number, err := strconv.Atoi(parts[3])
+	if err != nil || number <= 0 {
+		return "", "", 0, false
+	}
+	return parts[0], parts[1], number, true
+}
+
+// rawGitHubURL rewrites a GitHub file view to its raw content, leaving other URLs unchanged
+func rawGitHubURL(u *url.URL) string {
+	if u.Host != "github.com" && u.Host != "www.github.com" {
+		return u.String()
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 5 || parts[2] != "blob" {
+		return u.String()
+	}
+	return "https://raw.githubusercontent.com/" + strings.Join(append(parts[:2:2], parts[3:]...), "/")
+}
+
+// cachePath returns the cache file of a URL
+func (f *Fetcher) cachePath(pageURL string) string {
+	sum := sha256.Sum256([]byte(pageURL))
+	return filepath.Join(f.cacheDir, hex.EncodeToString(sum[:16])+".json")
+}
+
+func (f *Fetcher) cached(pageURL string) (*Page, bool) {
+	if f.cacheDir == "" {
+		return nil, false
+	}
+	data, err := f.fs.ReadFile(f.cachePath(pageURL))
+	if err != nil {
+		return nil, false
+	}
+	var page Page
+	if err := json.Unmarshal(data, &page); err != nil || page.URL != pageURL {
+		return nil, false
+	}
+	if f.now().Sub(page.FetchedAt) > pageCacheTTL {
+		return nil, false
+	}
+	return &page, true
+}
+
+func (f *Fetcher) store(page *Page) {
+	if f.cacheDir == "" {
+		return
+	}
+	data, err := json.Marshal(page)
+	if err != nil {
+		return
+	}
+	if err := f.fs.MkdirAll(f.cacheDir, 0755); err != nil {
+		logger.Warn("페이지 캐시 디렉토리 생성 실패: %v", err)
+		return
+	}
+	if err := f.fs.WriteFile(f.cachePath(page.URL), data, 0644); err != nil {
+		logger.Warn("페이지 캐시 저장 실패: %v", err)
+	}
+}
+
+// FormatPage formats a fetched page for LLM context, truncated to about maxTokens tokens
+func FormatPage(page *Page, maxTokens int) string {
+	if maxTokens <= 0 {
+		maxTokens = DefaultPageTokens
+	}
+	var builder strings.Builder
+	if page.Title != "" {
+		builder.WriteString(fmt.Sprintf("페이지: %s\n", page.Title))
+	}
+	builder.WriteString(fmt.Sprintf("URL: %s\n\n", page.URL))
+	if strings.TrimSpace(page.Content) == "" {
+		builder.WriteString("(본문을 추출하지 못했습니다)")
+		return builder.String()
+	}
+	builder.WriteString(truncateToTokens(page.Content, maxTokens))
+	return builder.String()
+}
+
+// truncateToTokens cuts text to about maxTokens tokens, counting three bytes per
+// token like the LLM estimator. The end is kept as well as the start, since the
+// fix is often in the last comments of an issue thread.
+func truncateToTokens(text string, maxTokens int) string {
+	limit := maxTokens * 3
+	if len(text) <= limit {
+		return text
+	}
+	headLen := limit * 2 / 3
+	tailLen := limit - headLen
+	head := text[:runeStart(text, headLen)]
+	tail := text[runeStart(text, len(text)-tailLen):]
+	if i := strings.LastIndex(head, "\n"); i > len(head)/2 {
+		head = head[:i]
+	}
+	if i := strings.Index(tail, "\n"); i >= 0 && i < len(tail)/2 {
+		tail = tail[i+1:]
+	}
+	omitted := (len(text) - len(head) - len(tail) + 2) / 3
+	return fmt.Sprintf("%s\n\n... (중략: 약 %d 토큰) ...\n\n%s", strings.TrimRight(head, "\n"), omitted, tail)
+}
+
+// runeStart moves i back to the start of the UTF-8 character containing it
+func runeStart(text string, i int) int {
+	for i > 0 && i < len(text) && !utf8.RuneStart(text[i]) {
+		i--
+	}
+	return i
+}
+
+// Elements whose content is never part of the readable text
+var skippedElements = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true, "svg": true, "iframe": true,
+	"nav": true, "header": true, "footer": true, "aside": true, "form": true, "button": true, "select": true,
+}
+
+// Elements separated by a blank line, and elements that start a new line
+var (
+	paragraphElements = map[string]bool{
+		"p": true, "pre": true, "blockquote": true, "table": true, "ul": true, "ol": true, "dl": true,
+		"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "figure": true, "details": true,
+	}
+	lineElements = map[string]bool{
+		"div": true, "section": true, "article": true, "main": true, "li": true, "tr": true,
+		"dt": true, "dd": true, "summary": true, "figcaption": true, "hr": true,
+	}
+)
+
+var blankLinesPattern = regexp.MustCompile(`\n{3,}`)
+
+// extractReadableText returns the title and main text of an HTML page as plain
+// text with markdown headings, list items and code blocks
+func extractReadableText(raw []byte) (string, string) {
+	doc, err := html.Parse(bytes.NewReader(raw))
+	if err != nil {
+		return "", strings.TrimSpace(string(raw))
+	}
+
+	title := ""
+	if n := findElement(doc, func(n *html.Node) bool { return n.Data == "title" }); n != nil {
+		var w textWriter
+		w.walk(n)
+		title = strings.TrimSpace(w.b.String())
+	}
+
+	var w textWriter
+	w.walk(mainContent(doc))
+	text := strings.TrimSpace(blankLinesPattern.ReplaceAllString(w.b.String(), "\n\n"))
+	return title, text
+}
+
+// mainContent returns the element holding the page's main content, or the whole document
+func mainContent(doc *html.Node) *html.Node {
+	if n := findElement(doc, func(n *html.Node) bool { return n.Data == "main" || attrValue(n, "role") == "main" }); n != nil {
+		return n
+	}
+	if n := findElement(doc, func(n *html.Node) bool { return n.Data == "article" }); n != nil {
+		return n
+	}
+	if n := findElement(doc, func(n *html.Node) bool { return n.Data == "body" }); n != nil {
+		return n
+	}
+	return doc
+}
+
+func findElement(n *html.Node, match func(*html.Node) bool) *html.Node {
+	if n.Type == html.ElementNode && match(n) {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findElement(c, match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func attrValue(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+func isHidden(n *html.Node) bool {
+	for _, attr := range n.Attr {
+		if attr.Key == "hidden" || (attr.Key == "aria-hidden" && attr.Val == "true") {
+			return true
+		}
+	}
+	return false
+}
+
+// textWriter collects the text of an HTML tree, collapsing whitespace outside <pre>
+type textWriter struct {
+	b         strings.Builder
+	newlines  int  // line breaks to write before the next text
+	space     bool // a space to write before the next text
+	lineStart bool // nothing has been written on the current line yet
+	pre       int
+}
+
+func (w *textWriter) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.text(n.Data)
+		return
+	case html.ElementNode:
+		if skippedElements[n.Data] || isHidden(n) {
+			return
+		}
+	}
+
+	switch {
+	case n.Type != html.ElementNode:
+		w.children(n)
+	case n.Data == "br":
+		w.lineBreak(1)
+	case len(n.Data) == 2 && n.Data[0] == 'h' && n.Data[1] >= '1' && n.Data[1] <= '6':
+		w.lineBreak(2)
+		w.prefix(strings.Repeat("#", int(n.Data[1]-'0')) + " ")
+		w.children(n)
+		w.lineBreak(2)
+	case n.Data == "li":
+		w.lineBreak(1)
+		w.prefix("- ")
+		w.children(n)
+		w.lineBreak(1)
+	case n.Data == "pre":
+		w.lineBreak(2)
+		w.prefix("```\n")
+		w.pre++
+		w.children(n)
+		w.pre--
+		if !w.lineStart {
+			w.lineBreak(1)
+		}
+		w.prefix("```")
+		w.lineBreak(2)
+	case n.Data == "td" || n.Data == "th":
+		w.space = true
+		w.children(n)
+		w.space = true
+	case paragraphElements[n.Data]:
+		w.lineBreak(2)
+		w.children(n)
+		w.lineBreak(2)
+	case lineElements[n.Data]:
+		w.lineBreak(1)
+		w.children(n)
+		w.lineBreak(1)
+	default:
+		w.children(n)
+	}
+}
+
+func (w *textWriter) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.walk(c)
+	}
+}
+
+func (w *textWriter) text(s string) {
+	if w.pre > 0 {
+		w.flush()
+		w.b.WriteString(s)
+		w.lineStart = strings.HasSuffix(s, "\n")
+		return
+	}
+	leading := strings.TrimLeft(s, " \t\r\n\f") != s
+	trailing := strings.TrimRight(s, " \t\r\n\f") != s
+	collapsed := strings.Join(strings.Fields(s), " ")
+	if collapsed == "" {
+		if s != "" {
+			w.space = true
+		}
+		return
+	}
+	if leading {
+		w.space = true
+	}
+	w.flush()
+	w.b.WriteString(collapsed)
+	w.space = trailing
+}
+
+// prefix writes a marker such as "- " at the start of the current line
+func (w *textWriter) prefix(s string) {
+	w.space = false
+	w.flush()
+	w.b.WriteString(s)
+	w.lineStart = true
+}
+
+// lineBreak asks for n line breaks before the next text
+func (w *textWriter) lineBreak(n int) {
+	if w.b.Len() > 0 && n > w.newlines {
+		w.newlines = n
+	}
+	w.space = false
+}
+
+func (w *textWriter) flush() {
+	if w.newlines > 0 {
+		w.b.WriteString(strings.Repeat("\n", w.newlines))
+		w.newlines = 0
+		w.lineStart = true
+	}
+	if w.space && !w.lineStart && w.b.Len() > 0 {
+		w.b.WriteByte(' ')
+	}
+	w.space = false
+	w.lineStart = false
+}
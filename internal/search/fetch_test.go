@@ -0,0 +1,188 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mainbong/storage_doctor/internal/filesystem"
+	"github.com/mainbong/storage_doctor/internal/httpclient"
+)
+
+func TestFetcher_ExtractsMainText(t *testing.T) {
+	mockClient := httpclient.NewMockHTTPClient()
+	page := `<html><head><title>Longhorn KB</title><script>var x = 1;</script></head><body>
+<nav><a href="/">Home</a> <a href="/docs">Docs</a></nav>
+<main>
+  <h1>Volume stuck   attaching</h1>
+  <p>The volume is <b>stuck</b> because of a
+     stale VolumeAttachment.</p>
+  <ul><li>Check the node</li><li>Delete the attachment</li></ul>
+  <pre>kubectl get volumeattachment
+kubectl delete volumeattachment csi-123</pre>
+  <div aria-hidden="true">tracking pixel</div>
+</main>
+<footer>Copyright</footer>
+</body></html>`
+	mockClient.SetResponse("https://longhorn.io/kb/stuck", 200, page, map[string]string{"Content-Type": "text/html; charset=utf-8"})
+
+	fetcher := NewFetcherWithClient("", mockClient, filesystem.NewMockFileSystem())
+	result, err := fetcher.Fetch(context.Background(), "https://longhorn.io/kb/stuck#cause")
+	if err != nil {
+		t.Fatalf("Fetch() failed: %v", err)
+	}
+
+	if result.Title != "Longhorn KB" || result.URL != "https://longhorn.io/kb/stuck" {
+		t.Errorf("Unexpected title or URL: %q %q", result.Title, result.URL)
+	}
+	expected := "# Volume stuck attaching\n\n" +
+		"The volume is stuck because of a stale VolumeAttachment.\n\n" +
+		"- Check the node\n- Delete the attachment\n\n" +
+		"```\nkubectl get volumeattachment\nkubectl delete volumeattachment csi-123\n```"
+	if result.Content != expected {
+		t.Errorf("Unexpected content:\n%s\n--- expected ---\n%s", result.Content, expected)
+	}
+}
+
+func TestFetcher_GitHubIssueThread(t *testing.T) {
+	mockClient := httpclient.NewMockHTTPClient()
+	mockClient.SetResponse("https://api.github.com/repos/rook/rook/issues/1234", 200,
+		`{"title":"OSD pods crashloop after upgrade","state":"closed","body":"OSDs crash with bluefs error","created_at":"2024-03-01T10:00:00Z","user":{"login":"alice"}}`, nil)
+	mockClient.SetResponse("https://api.github.com/repos/rook/rook/issues/1234/comments?per_page=100", 200,
+		`[{"body":"Set bluefs_buffered_io to false","created_at":"2024-03-02T10:00:00Z","user":{"login":"bob"}}]`, nil)
+
+	fetcher := NewFetcherWithClient("", mockClient, filesystem.NewMockFileSystem())
+	result, err := fetcher.Fetch(context.Background(), "https://github.com/rook/rook/issues/1234")
+	if err != nil {
+		t.Fatalf("Fetch() failed: %v", err)
+	}
+
+	for _, want := range []string{"# OSD pods crashloop after upgrade (rook/rook#1234, closed)", "@alice (2024-03-01):\nOSDs crash", "@bob (2024-03-02):\nSet bluefs_buffered_io"} {
+		if !strings.Contains(result.Content, want) {
+			t.Errorf("Expected %q in content:\n%s", want, result.Content)
+		}
+	}
+}
+
+func TestFetcher_GitHubIssueFallsBackToPage(t *testing.T) {
+	mockClient := httpclient.NewMockHTTPClient()
+	mockClient.SetResponse("https://api.github.com/repos/longhorn/longhorn/issues/42", 403, `{"message":"API rate limit exceeded"}`, nil)
+	mockClient.SetResponse("https://github.com/longhorn/longhorn/issues/42", 200,
+		`<html><body><main><p>Replica rebuild hangs</p></main></body></html>`, map[string]string{"Content-Type": "text/html"})
+
+	fetcher := NewFetcherWithClient("", mockClient, filesystem.NewMockFileSystem())
+	result, err := fetcher.Fetch(context.Background(), "https://github.com/longhorn/longhorn/issues/42")
+	if err != nil {
+		t.Fatalf("Fetch() failed: %v", err)
+	}
+	if result.Content != "Replica rebuild hangs" {
+		t.Errorf("Expected text from the issue page, got %q", result.Content)
+	}
+}
+
+func TestFetcher_GitHubBlobReadsRaw(t *testing.T) {
+	mockClient := httpclient.NewMockHTTPClient()
+	mockClient.SetResponse("https://raw.githubusercontent.com/rook/rook/master/Documentation/Troubleshooting/ceph-common-issues.md", 200,
+		"# Ceph common issues\n\n## Too few PGs\n", map[string]string{"Content-Type": "text/plain; charset=utf-8"})
+
+	fetcher := NewFetcherWithClient("", mockClient, filesystem.NewMockFileSystem())
+	result, err := fetcher.Fetch(context.Background(), "https://github.com/rook/rook/blob/master/Documentation/Troubleshooting/ceph-common-issues.md")
+	if err != nil {
+		t.Fatalf("Fetch() failed: %v", err)
+	}
+	if result.Content != "# Ceph common issues\n\n## Too few PGs" {
+		t.Errorf("Expected the raw markdown, got %q", result.Content)
+	}
+}
+
+func TestFetcher_CachesPages(t *testing.T) {
+	mockClient := httpclient.NewMockHTTPClient()
+	mockClient.SetResponse("https://example.com/doc", 200, "plain doc", map[string]string{"Content-Type": "text/plain"})
+	mockFS := filesystem.NewMockFileSystem()
+
+	fetcher := NewFetcherWithClient("/cache/pages", mockClient, mockFS)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	fetcher.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if _, err := fetcher.Fetch(context.Background(), "https://example.com/doc"); err != nil {
+			t.Fatalf("Fetch() failed: %v", err)
+		}
+	}
+	if got := len(mockClient.GetRequests()); got != 1 {
+		t.Errorf("Expected the second fetch to be served from cache, got %d requests", got)
+	}
+
+	now = now.Add(pageCacheTTL + time.Minute)
+	if _, err := fetcher.Fetch(context.Background(), "https://example.com/doc"); err != nil {
+		t.Fatalf("Fetch() failed: %v", err)
+	}
+	if got := len(mockClient.GetRequests()); got != 2 {
+		t.Errorf("Expected an expired page to be fetched again, got %d requests", got)
+	}
+}
+
+func TestFetcher_Errors(t *testing.T) {
+	mockClient := httpclient.NewMockHTTPClient()
+	mockClient.SetResponse("https://example.com/image.png", 200, "\x89PNG\r\n\x1a\n", map[string]string{"Content-Type": "image/png"})
+	fetcher := NewFetcherWithClient("", mockClient, filesystem.NewMockFileSystem())
+
+	for _, rawURL := range []string{"file:///etc/passwd", "not a url", "https://example.com/missing", "https://example.com/image.png"} {
+		if _, err := fetcher.Fetch(context.Background(), rawURL); err == nil {
+			t.Errorf("Expected error for %s", rawURL)
+		}
+	}
+}
+
+func TestFetcher_RefusesNonPublicAddresses(t *testing.T) {
+	mockClient := httpclient.NewMockHTTPClient()
+	fetcher := NewFetcherWithClient("", mockClient, filesystem.NewMockFileSystem())
+	for _, rawURL := range []string{
+		"http://127.0.0.1:8080/", "http://localhost/admin", "http://169.254.169.254/latest/meta-data/",
+		"http://10.0.0.5/", "http://[::1]/", "http://[fd00::1]/", "http://0.0.0.0/", "http://100.100.100.200/",
+	} {
+		if _, err := fetcher.Fetch(context.Background(), rawURL); !errors.Is(err, httpclient.ErrNonPublicAddress) {
+			t.Errorf("Expected %s to be refused, got %v", rawURL, err)
+		}
+	}
+	if got := len(mockClient.GetRequests()); got != 0 {
+		t.Errorf("Expected no requests, got %d", got)
+	}
+
+	// Names resolving to a private address, and redirects to one, are refused when dialing
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("internal"))
+	}))
+	defer server.Close()
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	if _, err := NewFetcher("").client.Do(req); !errors.Is(err, httpclient.ErrNonPublicAddress) {
+		t.Errorf("Expected the public client to refuse %s, got %v", server.URL, err)
+	}
+}
+
+func TestFormatPage_TruncatesToTokenBudget(t *testing.T) {
+	var lines []string
+	for i := 0; i < 500; i++ {
+		lines = append(lines, "line of the issue thread")
+	}
+	lines = append(lines, "FINAL FIX COMMENT")
+	page := &Page{URL: "https://example.com", Title: "Thread", Content: strings.Join(lines, "\n")}
+
+	formatted := FormatPage(page, 100)
+	if len(formatted) > 400 {
+		t.Errorf("Expected about 300 bytes of content, got %d", len(formatted))
+	}
+	if !strings.Contains(formatted, "중략") || !strings.HasPrefix(formatted, "페이지: Thread\nURL: https://example.com\n\nline of") {
+		t.Errorf("Expected a truncation marker after the header, got:\n%s", formatted)
+	}
+	if !strings.HasSuffix(formatted, "FINAL FIX COMMENT") {
+		t.Errorf("Expected the end of the thread to be kept, got:\n%s", formatted)
+	}
+	if short := FormatPage(&Page{URL: "https://example.com", Content: "짧은 본문"}, 0); !strings.HasSuffix(short, "짧은 본문") {
+		t.Errorf("Expected short content unchanged, got %q", short)
+	}
+}
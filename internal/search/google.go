@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 
@@ -57,8 +56,7 @@ func (p *GoogleProvider) Search(ctx context.Context, query string, limit int) ([
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("google API error: %d - %s", resp.StatusCode, string(body))
+		return nil, apiError("google", resp)
 	}
 
 	var response struct {
@@ -0,0 +1,515 @@
+package search
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/mainbong/storage_doctor/internal/filesystem"
+	"github.com/mainbong/storage_doctor/internal/logger"
+)
+
+const (
+	// localIndexVersion changes whenever tokenizing or the index format changes
+	localIndexVersion = 1
+	localIndexFile    = "local.json"
+	// maxIndexedBytes caps how much of a single file is read
+	maxIndexedBytes = 4 << 20
+	// titleWeight is how many times a title term counts as a body term
+	titleWeight = 3
+	// BM25 parameters
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// LocalProvider searches runbooks, vendor docs, session reports and man pages
+// on local disk. Files are indexed into an on-disk BM25 inverted index, which
+// is rebuilt when a file is added, changed or removed.
+type LocalProvider struct {
+	paths     []string
+	indexPath string
+	fs        filesystem.FileSystem
+	mu        sync.Mutex
+	index     *localIndex
+}
+
+// localIndex is the inverted index stored in indexDir/local.json
+type localIndex struct {
+	Version   int                  `json:"version"`
+	Paths     []string             `json:"paths"`
+	Docs      []localDoc           `json:"docs"`
+	Postings  map[string][]posting `json:"postings"`
+	AvgLength float64              `json:"avg_length"`
+}
+
+// localDoc is an indexed file; Size and ModTime detect changes
+type localDoc struct {
+	Path    string    `json:"path"`
+	Title   string    `json:"title"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	Length  int       `json:"length"`
+}
+
+// posting is a document index and the term's frequency in that document
+type posting [2]int
+
+// NewLocalProvider creates a new local provider indexing paths into indexDir
+func NewLocalProvider(paths []string, indexDir string) *LocalProvider {
+	return NewLocalProviderWithFS(paths, indexDir, filesystem.NewOSFileSystem())
+}
+
+// NewLocalProviderWithFS creates a new local provider with a custom FileSystem (for testing)
+func NewLocalProviderWithFS(paths []string, indexDir string, fs filesystem.FileSystem) *LocalProvider {
+	cleaned := make([]string, 0, len(paths))
+	for _, path := range paths {
+		cleaned = append(cleaned, filepath.Clean(path))
+	}
+	sort.Strings(cleaned)
+	return &LocalProvider{
+		paths:     cleaned,
+		indexPath: filepath.Join(indexDir, localIndexFile),
+		fs:        fs,
+	}
+}
+
+func (p *LocalProvider) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	p.mu.Lock()
+	index, err := p.currentIndex(ctx)
+	p.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	terms := uniqueTerms(tokenize(query))
+	scores := index.score(terms)
+	ranked := make([]int, 0, len(scores))
+	for doc := range scores {
+		ranked = append(ranked, doc)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if scores[ranked[i]] != scores[ranked[j]] {
+			return scores[ranked[i]] > scores[ranked[j]]
+		}
+		return index.Docs[ranked[i]].Path < index.Docs[ranked[j]].Path
+	})
+	if limit > 0 && len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+
+	results := make([]SearchResult, 0, len(ranked))
+	for _, i := range ranked {
+		doc := index.Docs[i]
+		results = append(results, SearchResult{
+			Title:   doc.Title,
+			URL:     doc.Path,
+			Snippet: p.snippet(doc.Path, terms),
+		})
+	}
+	return results, nil
+}
+
+// Reindex rebuilds the index from scratch and returns the number of indexed documents
+func (p *LocalProvider) Reindex(ctx context.Context) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	files, err := p.scan(ctx)
+	if err != nil {
+		return 0, err
+	}
+	index, err := p.build(ctx, files)
+	if err != nil {
+		return 0, err
+	}
+	p.index = index
+	if err := p.save(index); err != nil {
+		return 0, err
+	}
+	return len(index.Docs), nil
+}
+
+// currentIndex returns the index, rebuilding it when the indexed files changed
+func (p *LocalProvider) currentIndex(ctx context.Context) (*localIndex, error) {
+	files, err := p.scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if p.index == nil {
+		p.index = p.load()
+	}
+	if p.index.upToDate(p.paths, files) {
+		return p.index, nil
+	}
+
+	logger.Info("로컬 검색 색인 생성: 문서 %d개", len(files))
+	index, err := p.build(ctx, files)
+	if err != nil {
+		return nil, err
+	}
+	p.index = index
+	if err := p.save(index); err != nil {
+		logger.Warn("로컬 검색 색인 저장 실패: %v", err)
+	}
+	return index, nil
+}
+
+// scan lists the indexable files under the configured paths, sorted by path
+func (p *LocalProvider) scan(ctx context.Context) ([]localDoc, error) {
+	seen := make(map[string]bool)
+	var files []localDoc
+	for _, root := range p.paths {
+		err := p.fs.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			if err != nil {
+				if path == root {
+					logger.Warn("로컬 검색 경로를 읽을 수 없습니다: %v", err)
+				}
+				return nil
+			}
+			if info.IsDir() {
+				if path != root && strings.HasPrefix(info.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if documentKind(path) == "" || seen[path] {
+				return nil
+			}
+			seen[path] = true
+			files = append(files, localDoc{Path: path, Size: info.Size(), ModTime: info.ModTime()})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+func (p *LocalProvider) build(ctx context.Context, files []localDoc) (*localIndex, error) {
+	index := &localIndex{
+		Version:  localIndexVersion,
+		Paths:    p.paths,
+		Docs:     make([]localDoc, 0, len(files)),
+		Postings: make(map[string][]posting),
+	}
+	totalLength := 0
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		// Unreadable files stay in the index without terms, so they are not retried on every search
+		title, text, err := p.readDocument(file.Path)
+		if err != nil {
+			logger.Warn("로컬 문서 색인 실패: %s: %v", file.Path, err)
+		}
+
+		freqs := make(map[string]int)
+		for _, term := range tokenize(text) {
+			freqs[term]++
+		}
+		for _, term := range tokenize(title) {
+			freqs[term] += titleWeight
+		}
+
+		doc := len(index.Docs)
+		length := 0
+		for term, freq := range freqs {
+			index.Postings[term] = append(index.Postings[term], posting{doc, freq})
+			length += freq
+		}
+		file.Title = title
+		file.Length = length
+		index.Docs = append(index.Docs, file)
+		totalLength += length
+	}
+	if len(index.Docs) > 0 {
+		index.AvgLength = float64(totalLength) / float64(len(index.Docs))
+	}
+	return index, nil
+}
+
+func (p *LocalProvider) load() *localIndex {
+	data, err := p.fs.ReadFile(p.indexPath)
+	if err != nil {
+		return nil
+	}
+	var index localIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		logger.Warn("로컬 검색 색인을 읽을 수 없어 다시 만듭니다: %v", err)
+		return nil
+	}
+	return &index
+}
+
+func (p *LocalProvider) save(index *localIndex) error {
+	data, err := json.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("failed to marshal index: %w", err)
+	}
+	if err := p.fs.MkdirAll(filepath.Dir(p.indexPath), 0755); err != nil {
+		return fmt.Errorf("failed to create index directory: %w", err)
+	}
+	if err := p.fs.WriteFile(p.indexPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return nil
+}
+
+// upToDate reports whether the index was built from exactly these paths and files
+func (idx *localIndex) upToDate(paths []string, files []localDoc) bool {
+	if idx == nil || idx.Version != localIndexVersion || strings.Join(idx.Paths, "\n") != strings.Join(paths, "\n") {
+		return false
+	}
+	if len(idx.Docs) != len(files) {
+		return false
+	}
+	for i, file := range files {
+		doc := idx.Docs[i]
+		if doc.Path != file.Path || doc.Size != file.Size || !doc.ModTime.Equal(file.ModTime) {
+			return false
+		}
+	}
+	return true
+}
+
+// score returns the BM25 score of every document containing one of terms
+func (idx *localIndex) score(terms []string) map[int]float64 {
+	scores := make(map[int]float64)
+	n := float64(len(idx.Docs))
+	for _, term := range terms {
+		postings := idx.Postings[term]
+		if len(postings) == 0 {
+			continue
+		}
+		df := float64(len(postings))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for _, entry := range postings {
+			tf := float64(entry[1])
+			norm := 1 - bm25B + bm25B*float64(idx.Docs[entry[0]].Length)/idx.AvgLength
+			scores[entry[0]] += idf * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+		}
+	}
+	return scores
+}
+
+// snippet returns the line of a document matching the most query terms
+func (p *LocalProvider) snippet(path string, terms []string) string {
+	_, text, err := p.readDocument(path)
+	if err != nil {
+		return ""
+	}
+	wanted := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		wanted[term] = true
+	}
+
+	best, bestHits := "", 0
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(line, "#-* "))
+		if line == "" {
+			continue
+		}
+		hits := 0
+		for _, term := range uniqueTerms(tokenize(line)) {
+			if wanted[term] {
+				hits++
+			}
+		}
+		if hits > bestHits || best == "" {
+			best, bestHits = line, hits
+		}
+	}
+	if len(best) > 240 {
+		best = best[:runeStart(best, 240)] + "..."
+	}
+	return best
+}
+
+// documentKind returns how a file is read: "text", "html", "man" or "" if it is not indexed
+func documentKind(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown", ".txt", ".text", ".rst":
+		return "text"
+	case ".html", ".htm":
+		return "html"
+	}
+	// Man pages are only recognized inside man directories, since rotated logs end in .1 too
+	if strings.HasPrefix(filepath.Base(filepath.Dir(path)), "man") && manPagePattern.MatchString(filepath.Base(path)) {
+		return "man"
+	}
+	return ""
+}
+
+var manPagePattern = regexp.MustCompile(`\.[1-9][a-z]*(\.gz)?$`)
+
+// readDocument returns the title and plain text of an indexed file
+func (p *LocalProvider) readDocument(path string) (string, string, error) {
+	data, err := p.fs.ReadFile(path)
+	if err != nil {
+		return "", "", err
+	}
+	if len(data) > maxIndexedBytes {
+		data = data[:maxIndexedBytes]
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	switch documentKind(path) {
+	case "html":
+		title, text := extractReadableText(data)
+		if title == "" {
+			title = name
+		}
+		return title, text, nil
+	case "man":
+		if strings.HasSuffix(path, ".gz") {
+			reader, err := gzip.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return "", "", fmt.Errorf("failed to decompress man page: %w", err)
+			}
+			defer reader.Close()
+			data, err = io.ReadAll(io.LimitReader(reader, maxIndexedBytes))
+			if err != nil {
+				return "", "", fmt.Errorf("failed to decompress man page: %w", err)
+			}
+		}
+		title, text := roffToText(string(data))
+		if title == "" {
+			title = strings.TrimSuffix(filepath.Base(path), ".gz")
+		}
+		return title, text, nil
+	default:
+		text := string(data)
+		return markdownTitle(text, name), text, nil
+	}
+}
+
+// markdownTitle returns the first heading of a Markdown or reST document
+func markdownTitle(text, fallback string) string {
+	lines := strings.SplitN(text, "\n", 40)
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "# ") {
+			return strings.TrimSpace(line[2:])
+		}
+		// Setext and reST headings are underlined with = or -
+		if i > 0 && len(line) >= 3 && strings.Trim(line, "=-") == "" && strings.TrimSpace(lines[i-1]) != "" {
+			return strings.TrimSpace(lines[i-1])
+		}
+	}
+	return fallback
+}
+
+var (
+	roffFontPattern   = regexp.MustCompile(`\\f(\(..|\[[^\]]*\]|.)`)
+	roffEscapePattern = regexp.MustCompile(`\\(\(..|\*\(..|\*.|\[[^\]]*\]|.)`)
+)
+
+// roffToText converts a man page to plain text and returns its name(section) title
+func roffToText(source string) (string, string) {
+	title := ""
+	var b strings.Builder
+	for _, line := range strings.Split(source, "\n") {
+		if strings.HasPrefix(line, `.\"`) || strings.HasPrefix(line, `'\"`) {
+			continue
+		}
+		if !strings.HasPrefix(line, ".") && !strings.HasPrefix(line, "'") {
+			b.WriteString(roffUnescape(line) + "\n")
+			continue
+		}
+
+		fields := strings.Fields(line[1:])
+		if len(fields) == 0 {
+			continue
+		}
+		args := strings.ReplaceAll(strings.Join(fields[1:], " "), `"`, "")
+		switch fields[0] {
+		case "TH":
+			if len(fields) >= 3 {
+				title = fmt.Sprintf("%s(%s)", strings.ToLower(strings.Trim(fields[1], `"`)), strings.Trim(fields[2], `"`))
+			}
+		case "SH", "SS":
+			b.WriteString("\n## " + roffUnescape(args) + "\n")
+		case "B", "I", "SM", "BI", "BR", "IB", "IR", "RB", "RI":
+			b.WriteString(roffUnescape(args) + "\n")
+		case "IP", "TP", "PP", "P", "LP", "br", "sp":
+			b.WriteString("\n")
+			if args != "" && fields[0] == "IP" {
+				b.WriteString(roffUnescape(args) + "\n")
+			}
+		}
+	}
+	return title, b.String()
+}
+
+func roffUnescape(text string) string {
+	text = roffFontPattern.ReplaceAllString(text, "")
+	text = strings.NewReplacer(`\-`, "-", `\e`, `\`, `\(aq`, "'", `\(em`, "-", `\(en`, "-", `\ `, " ").Replace(text)
+	return roffEscapePattern.ReplaceAllString(text, "")
+}
+
+// stopWords are common English words left out of the index
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"for": true, "from": true, "if": true, "in": true, "is": true, "it": true, "of": true, "on": true,
+	"or": true, "that": true, "the": true, "this": true, "to": true, "was": true, "with": true,
+}
+
+// tokenize splits text into lowercase terms. Hangul words are also indexed as
+// character bigrams, so a query matches words with different particles.
+func tokenize(text string) []string {
+	var terms []string
+	var word []rune
+	flush := func() {
+		if len(word) >= 2 && !stopWords[string(word)] {
+			terms = append(terms, string(word))
+			if unicode.Is(unicode.Hangul, word[0]) && len(word) > 2 {
+				for i := 0; i+1 < len(word); i++ {
+					terms = append(terms, string(word[i:i+2]))
+				}
+			}
+		}
+		word = word[:0]
+	}
+	for _, r := range strings.ToLower(text) {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		// "PVC가" is split into "pvc" and "가"
+		if len(word) > 0 && unicode.Is(unicode.Hangul, word[len(word)-1]) != unicode.Is(unicode.Hangul, r) {
+			flush()
+		}
+		word = append(word, r)
+	}
+	flush()
+	return terms
+}
+
+func uniqueTerms(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	unique := terms[:0:0]
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			unique = append(unique, term)
+		}
+	}
+	return unique
+}
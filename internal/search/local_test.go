@@ -0,0 +1,135 @@
+package search
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mainbong/storage_doctor/internal/filesystem"
+)
+
+func newTestDocs(t *testing.T) *filesystem.MockFileSystem {
+	t.Helper()
+	mockFS := filesystem.NewMockFileSystem()
+	mockFS.AddDir("/docs", 0755)
+	mockFS.AddDir("/docs/longhorn", 0755)
+	mockFS.AddDir("/docs/.git", 0755)
+	mockFS.AddDir("/man", 0755)
+	mockFS.AddDir("/man/man8", 0755)
+
+	mockFS.AddFile("/docs/longhorn/volume-attach.md", []byte("# Longhorn volume stuck attaching\n\nDelete the stale VolumeAttachment and restart the CSI attacher.\n"), 0644)
+	mockFS.AddFile("/docs/pvc-pending.md", []byte("# PVC가 Pending 상태일 때\n\nStorageClass 이름과 프로비저너 로그를 확인합니다.\n"), 0644)
+	mockFS.AddFile("/docs/ceph.html", []byte("<html><head><title>Ceph OSD full</title></head><body><nav>menu</nav><main><p>Raise the nearfull ratio before the OSD fills.</p></main></body></html>"), 0644)
+	mockFS.AddFile("/docs/kubelet.log.1", []byte("volume attaching volume attaching"), 0644)
+	mockFS.AddFile("/docs/.git/notes.md", []byte("volume attaching"), 0644)
+
+	var manPage bytes.Buffer
+	gz := gzip.NewWriter(&manPage)
+	gz.Write([]byte(".TH MULTIPATH 8 2024\n.SH NAME\nmultipath \\- Device mapper target autoconfig\n.SH OPTIONS\n.B \\-ll\nShow the current \\fBmultipath\\fR topology.\n"))
+	gz.Close()
+	mockFS.AddFile("/man/man8/multipath.8.gz", manPage.Bytes(), 0644)
+	return mockFS
+}
+
+func TestLocalProvider_Search(t *testing.T) {
+	mockFS := newTestDocs(t)
+	provider := NewLocalProviderWithFS([]string{"/docs", "/man"}, "/index", mockFS)
+
+	tests := []struct {
+		query, path, title string
+	}{
+		{"volume stuck attaching", "/docs/longhorn/volume-attach.md", "Longhorn volume stuck attaching"},
+		{"pvc pending 상태", "/docs/pvc-pending.md", "PVC가 Pending 상태일 때"},
+		{"OSD nearfull", "/docs/ceph.html", "Ceph OSD full"},
+		{"multipath topology", "/man/man8/multipath.8.gz", "multipath(8)"},
+	}
+	for _, tt := range tests {
+		results, err := provider.Search(context.Background(), tt.query, 3)
+		if err != nil {
+			t.Fatalf("Search(%q) failed: %v", tt.query, err)
+		}
+		if len(results) == 0 || results[0].URL != tt.path || results[0].Title != tt.title {
+			t.Errorf("Search(%q): expected %s (%s) first, got %+v", tt.query, tt.path, tt.title, results)
+		}
+	}
+
+	results, _ := provider.Search(context.Background(), "volume attaching", 10)
+	for _, result := range results {
+		if strings.Contains(result.URL, "kubelet.log") || strings.Contains(result.URL, ".git") {
+			t.Errorf("Expected rotated logs and hidden directories to be skipped, got %s", result.URL)
+		}
+	}
+	if results[0].Snippet != "Delete the stale VolumeAttachment and restart the CSI attacher." && results[0].Snippet != "Longhorn volume stuck attaching" {
+		t.Errorf("Expected a matching line as snippet, got %q", results[0].Snippet)
+	}
+}
+
+func TestLocalProvider_PersistsAndRefreshesIndex(t *testing.T) {
+	mockFS := newTestDocs(t)
+	if _, err := NewLocalProviderWithFS([]string{"/docs"}, "/index", mockFS).Search(context.Background(), "ceph", 5); err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	if len(mockFS.GetFile("/index/local.json")) == 0 {
+		t.Fatal("Expected the index to be saved")
+	}
+
+	// A new provider answers from the saved index without reading the documents
+	mockFS.SetReadError("/docs/ceph.html", errors.New("permission denied"))
+	results, err := NewLocalProviderWithFS([]string{"/docs"}, "/index", mockFS).Search(context.Background(), "nearfull", 5)
+	if err != nil || len(results) != 1 || results[0].Title != "Ceph OSD full" {
+		t.Fatalf("Expected the saved index to be used, got %+v (%v)", results, err)
+	}
+
+	// A changed file rebuilds the index
+	provider := NewLocalProviderWithFS([]string{"/docs"}, "/index", mockFS)
+	mockFS.AddFile("/docs/pvc-pending.md", []byte("# PVC가 Pending 상태일 때\n\nWaitForFirstConsumer 바인딩 모드를 확인합니다.\n"), 0644)
+	results, _ = provider.Search(context.Background(), "WaitForFirstConsumer", 5)
+	if len(results) != 1 || results[0].URL != "/docs/pvc-pending.md" {
+		t.Errorf("Expected the changed document to be reindexed, got %+v", results)
+	}
+}
+
+func TestLocalProvider_Reindex(t *testing.T) {
+	mockFS := newTestDocs(t)
+	count, err := NewLocalProviderWithFS([]string{"/docs", "/man"}, "/index", mockFS).Reindex(context.Background())
+	if err != nil {
+		t.Fatalf("Reindex() failed: %v", err)
+	}
+	if count != 4 {
+		t.Errorf("Expected 4 indexed documents, got %d", count)
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	got := tokenize("PVC가 Pending 상태의 csi-rbdplugin, a volume")
+	expected := []string{"pvc", "pending", "상태의", "상태", "태의", "csi", "rbdplugin", "volume"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestMergeResults(t *testing.T) {
+	local := []SearchResult{{URL: "/l1"}, {URL: "/l2"}, {URL: "/l3"}, {URL: "/l4"}}
+	web := []SearchResult{{URL: "https://w1"}, {URL: "https://w2"}, {URL: "https://w3"}}
+
+	urls := func(results []SearchResult) string {
+		var parts []string
+		for _, result := range results {
+			parts = append(parts, result.URL)
+		}
+		return strings.Join(parts, " ")
+	}
+	if got := urls(mergeResults(local, web, 5)); got != "/l1 /l2 /l3 https://w1 https://w2" {
+		t.Errorf("Expected local results first with room for web, got %s", got)
+	}
+	if got := urls(mergeResults(local, web[:1], 5)); got != "/l1 /l2 /l3 /l4 https://w1" {
+		t.Errorf("Expected local results to fill unused web slots, got %s", got)
+	}
+	if got := urls(mergeResults(nil, web, 2)); got != "https://w1 https://w2" {
+		t.Errorf("Expected web results only, got %s", got)
+	}
+}
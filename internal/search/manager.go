@@ -3,45 +3,161 @@ package search
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"strings"
 
 	"github.com/mainbong/storage_doctor/internal/config"
+	"github.com/mainbong/storage_doctor/internal/logger"
 )
 
 // Manager manages web search operations
 type Manager struct {
 	provider Provider
+	// local is searched alongside a web provider when local paths are configured
+	local *LocalProvider
 }
 
 // NewManager creates a new search manager
 func NewManager(cfg *config.Config) (*Manager, error) {
 	var provider Provider
+	var err error
 
 	switch cfg.Search.Provider {
+	case "multi":
+		provider, err = newMultiProvider(cfg)
+	case "local":
+		if len(cfg.Search.Local.Paths) == 0 {
+			return nil, fmt.Errorf("local search requires search.local.paths")
+		}
+		provider = NewLocalProvider(cfg.Search.Local.Paths, localIndexDir())
+	default:
+		provider, err = newWebProvider(cfg.Search.Provider, cfg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	manager := &Manager{
+		provider: provider,
+	}
+	if cfg.Search.Provider != "local" && len(cfg.Search.Local.Paths) > 0 {
+		manager.local = NewLocalProvider(cfg.Search.Local.Paths, localIndexDir())
+	}
+	return manager, nil
+}
+
+// newWebProvider creates the web search provider called name
+func newWebProvider(name string, cfg *config.Config) (Provider, error) {
+	switch name {
 	case "google":
 		if cfg.Search.Google.APIKey == "" || cfg.Search.Google.CX == "" {
 			return nil, fmt.Errorf("google search requires API key and CX")
 		}
-		provider = NewGoogleProvider(cfg.Search.Google.APIKey, cfg.Search.Google.CX)
+		return NewGoogleProvider(cfg.Search.Google.APIKey, cfg.Search.Google.CX), nil
+	case "bing":
+		if cfg.Search.Bing.APIKey == "" {
+			return nil, fmt.Errorf("bing search requires API key")
+		}
+		return NewBingProvider(cfg.Search.Bing.APIKey), nil
+	case "brave":
+		if cfg.Search.Brave.APIKey == "" {
+			return nil, fmt.Errorf("brave search requires API key")
+		}
+		return NewBraveProvider(cfg.Search.Brave.APIKey), nil
 	case "serper":
 		if cfg.Search.Serper.APIKey == "" {
 			return nil, fmt.Errorf("serper search requires API key")
 		}
-		provider = NewSerperProvider(cfg.Search.Serper.APIKey)
+		return NewSerperProvider(cfg.Search.Serper.APIKey), nil
 	case "duckduckgo":
-		provider = NewDuckDuckGoProvider()
+		return NewDuckDuckGoProvider(), nil
 	default:
-		return nil, fmt.Errorf("unknown search provider: %s", cfg.Search.Provider)
+		return nil, fmt.Errorf("unknown search provider: %s", name)
 	}
+}
 
-	return &Manager{
-		provider: provider,
-	}, nil
+// multiProviders is the priority order "multi" uses when search.providers is empty
+var multiProviders = []string{"brave", "bing", "google", "serper", "duckduckgo"}
+
+// newMultiProvider creates the fan-out provider of search.provider "multi". Without
+// search.providers, providers missing an API key are left out.
+func newMultiProvider(cfg *config.Config) (Provider, error) {
+	names := cfg.Search.Providers
+	explicit := len(names) > 0
+	if !explicit {
+		names = multiProviders
+	}
+
+	var backends []NamedProvider
+	for _, name := range names {
+		provider, err := newWebProvider(name, cfg)
+		if err != nil {
+			if explicit {
+				return nil, err
+			}
+			continue
+		}
+		backends = append(backends, NamedProvider{Name: name, Provider: provider})
+	}
+	if len(backends) == 0 {
+		return nil, fmt.Errorf("multi search has no usable providers")
+	}
+	return NewMultiProvider(backends...), nil
 }
 
-// Search performs a web search
+// localIndexDir is where the local search index is stored
+func localIndexDir() string {
+	return filepath.Join(config.GetConfigDir(), "index")
+}
+
+// Search performs a web search, with local documents ahead of web results when configured
 func (m *Manager) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
-	return m.provider.Search(ctx, query, limit)
+	if m.local == nil {
+		return m.provider.Search(ctx, query, limit)
+	}
+
+	localResults, localErr := m.local.Search(ctx, query, limit)
+	webResults, webErr := m.provider.Search(ctx, query, limit)
+	switch {
+	case localErr != nil && webErr != nil:
+		return nil, webErr
+	case localErr != nil:
+		logger.Warn("로컬 검색 실패: %v (웹 결과만 사용)", localErr)
+	case webErr != nil:
+		logger.Warn("웹 검색 실패: %v (로컬 결과만 사용)", webErr)
+	}
+	return mergeResults(localResults, webResults, limit), nil
+}
+
+// mergeResults puts local results first but keeps at least half of limit for web results
+func mergeResults(local, web []SearchResult, limit int) []SearchResult {
+	if limit <= 0 {
+		return append(local, web...)
+	}
+	localLimit := limit - len(web)
+	if localLimit < (limit+1)/2 {
+		localLimit = (limit + 1) / 2
+	}
+	if len(local) > localLimit {
+		local = local[:localLimit]
+	}
+	merged := append(local[:len(local):len(local)], web...)
+	if len(merged) > limit {
+		merged = merged[:limit]
+	}
+	return merged
+}
+
+// Reindex rebuilds the local search index and returns the number of indexed documents
+func (m *Manager) Reindex(ctx context.Context) (int, error) {
+	local := m.local
+	if provider, ok := m.provider.(*LocalProvider); ok {
+		local = provider
+	}
+	if local == nil {
+		return 0, fmt.Errorf("local search is not configured (set search.local.paths)")
+	}
+	return local.Reindex(ctx)
 }
 
 // FormatResults formats search results as a string for LLM context
@@ -55,7 +171,11 @@ func (m *Manager) FormatResults(results []SearchResult) string {
 
 	for i, result := range results {
 		builder.WriteString(fmt.Sprintf("%d. %s\n", i+1, result.Title))
-		builder.WriteString(fmt.Sprintf("   URL: %s\n", result.URL))
+		if strings.HasPrefix(result.URL, "http://") || strings.HasPrefix(result.URL, "https://") {
+			builder.WriteString(fmt.Sprintf("   URL: %s\n", result.URL))
+		} else {
+			builder.WriteString(fmt.Sprintf("   로컬 문서: %s (read_file로 전체 내용 확인)\n", result.URL))
+		}
 		builder.WriteString(fmt.Sprintf("   요약: %s\n\n", result.Snippet))
 	}
 
@@ -40,17 +40,24 @@ func TestFormatResults_Empty(t *testing.T) {
 func TestNewManager_DuckDuckGo(t *testing.T) {
 	cfg := &config.Config{
 		Search: struct {
-			Provider string `json:"provider"`
-			Google   struct {
+			Provider  string   `json:"provider"`
+			Providers []string `json:"providers,omitempty"`
+			Google    struct {
 				APIKey string `json:"api_key"`
 				CX     string `json:"cx"`
 			} `json:"google"`
 			Bing struct {
 				APIKey string `json:"api_key"`
 			} `json:"bing"`
+			Brave struct {
+				APIKey string `json:"api_key"`
+			} `json:"brave"`
 			Serper struct {
 				APIKey string `json:"api_key"`
 			} `json:"serper"`
+			Local struct {
+				Paths []string `json:"paths,omitempty"`
+			} `json:"local"`
 		}{
 			Provider: "duckduckgo",
 		},
@@ -69,17 +76,24 @@ func TestNewManager_DuckDuckGo(t *testing.T) {
 func TestNewManager_Google(t *testing.T) {
 	cfg := &config.Config{
 		Search: struct {
-			Provider string `json:"provider"`
-			Google   struct {
+			Provider  string   `json:"provider"`
+			Providers []string `json:"providers,omitempty"`
+			Google    struct {
 				APIKey string `json:"api_key"`
 				CX     string `json:"cx"`
 			} `json:"google"`
 			Bing struct {
 				APIKey string `json:"api_key"`
 			} `json:"bing"`
+			Brave struct {
+				APIKey string `json:"api_key"`
+			} `json:"brave"`
 			Serper struct {
 				APIKey string `json:"api_key"`
 			} `json:"serper"`
+			Local struct {
+				Paths []string `json:"paths,omitempty"`
+			} `json:"local"`
 		}{
 			Provider: "google",
 			Google: struct {
@@ -105,17 +119,24 @@ func TestNewManager_Google(t *testing.T) {
 func TestNewManager_Google_MissingKeys(t *testing.T) {
 	cfg := &config.Config{
 		Search: struct {
-			Provider string `json:"provider"`
-			Google   struct {
+			Provider  string   `json:"provider"`
+			Providers []string `json:"providers,omitempty"`
+			Google    struct {
 				APIKey string `json:"api_key"`
 				CX     string `json:"cx"`
 			} `json:"google"`
 			Bing struct {
 				APIKey string `json:"api_key"`
 			} `json:"bing"`
+			Brave struct {
+				APIKey string `json:"api_key"`
+			} `json:"brave"`
 			Serper struct {
 				APIKey string `json:"api_key"`
 			} `json:"serper"`
+			Local struct {
+				Paths []string `json:"paths,omitempty"`
+			} `json:"local"`
 		}{
 			Provider: "google",
 		},
@@ -130,17 +151,24 @@ func TestNewManager_Google_MissingKeys(t *testing.T) {
 func TestNewManager_Serper(t *testing.T) {
 	cfg := &config.Config{
 		Search: struct {
-			Provider string `json:"provider"`
-			Google   struct {
+			Provider  string   `json:"provider"`
+			Providers []string `json:"providers,omitempty"`
+			Google    struct {
 				APIKey string `json:"api_key"`
 				CX     string `json:"cx"`
 			} `json:"google"`
 			Bing struct {
 				APIKey string `json:"api_key"`
 			} `json:"bing"`
+			Brave struct {
+				APIKey string `json:"api_key"`
+			} `json:"brave"`
 			Serper struct {
 				APIKey string `json:"api_key"`
 			} `json:"serper"`
+			Local struct {
+				Paths []string `json:"paths,omitempty"`
+			} `json:"local"`
 		}{
 			Provider: "serper",
 			Serper: struct {
@@ -164,17 +192,24 @@ func TestNewManager_Serper(t *testing.T) {
 func TestNewManager_Serper_MissingKey(t *testing.T) {
 	cfg := &config.Config{
 		Search: struct {
-			Provider string `json:"provider"`
-			Google   struct {
+			Provider  string   `json:"provider"`
+			Providers []string `json:"providers,omitempty"`
+			Google    struct {
 				APIKey string `json:"api_key"`
 				CX     string `json:"cx"`
 			} `json:"google"`
 			Bing struct {
 				APIKey string `json:"api_key"`
 			} `json:"bing"`
+			Brave struct {
+				APIKey string `json:"api_key"`
+			} `json:"brave"`
 			Serper struct {
 				APIKey string `json:"api_key"`
 			} `json:"serper"`
+			Local struct {
+				Paths []string `json:"paths,omitempty"`
+			} `json:"local"`
 		}{
 			Provider: "serper",
 		},
@@ -189,17 +224,24 @@ func TestNewManager_Serper_MissingKey(t *testing.T) {
 func TestNewManager_UnknownProvider(t *testing.T) {
 	cfg := &config.Config{
 		Search: struct {
-			Provider string `json:"provider"`
-			Google   struct {
+			Provider  string   `json:"provider"`
+			Providers []string `json:"providers,omitempty"`
+			Google    struct {
 				APIKey string `json:"api_key"`
 				CX     string `json:"cx"`
 			} `json:"google"`
 			Bing struct {
 				APIKey string `json:"api_key"`
 			} `json:"bing"`
+			Brave struct {
+				APIKey string `json:"api_key"`
+			} `json:"brave"`
 			Serper struct {
 				APIKey string `json:"api_key"`
 			} `json:"serper"`
+			Local struct {
+				Paths []string `json:"paths,omitempty"`
+			} `json:"local"`
 		}{
 			Provider: "unknown",
 		},
@@ -211,3 +253,75 @@ func TestNewManager_UnknownProvider(t *testing.T) {
 	}
 }
 
+func TestNewManager_Local(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Search.Provider = "local"
+	if _, err := NewManager(cfg); err == nil {
+		t.Error("Expected error for local search without paths")
+	}
+
+	cfg.Search.Local.Paths = []string{"/srv/runbooks"}
+	manager, err := NewManager(cfg)
+	if err != nil {
+		t.Fatalf("NewManager() failed: %v", err)
+	}
+	if _, ok := manager.provider.(*LocalProvider); !ok || manager.local != nil {
+		t.Errorf("Expected a local-only manager, got %+v", manager)
+	}
+
+	cfg.Search.Provider = "duckduckgo"
+	manager, err = NewManager(cfg)
+	if err != nil {
+		t.Fatalf("NewManager() failed: %v", err)
+	}
+	if manager.local == nil {
+		t.Error("Expected local results to be merged with web search")
+	}
+}
+
+func TestFormatResults_LocalDocument(t *testing.T) {
+	manager := &Manager{}
+	formatted := manager.FormatResults([]SearchResult{{Title: "Runbook", URL: "/srv/runbooks/pvc.md", Snippet: "PVC"}})
+	if !strings.Contains(formatted, "로컬 문서: /srv/runbooks/pvc.md") {
+		t.Errorf("Expected the local path to be shown, got %q", formatted)
+	}
+}
+
+func TestNewManager_Multi(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Search.Provider = "multi"
+	cfg.Search.Brave.APIKey = "brave-key"
+
+	manager, err := NewManager(cfg)
+	if err != nil {
+		t.Fatalf("NewManager() failed: %v", err)
+	}
+	multi, ok := manager.provider.(*MultiProvider)
+	if !ok {
+		t.Fatalf("Expected a multi provider, got %T", manager.provider)
+	}
+	var names []string
+	for _, backend := range multi.providers {
+		names = append(names, backend.Name)
+	}
+	if strings.Join(names, ",") != "brave,duckduckgo" {
+		t.Errorf("Expected providers with API keys plus duckduckgo, got %v", names)
+	}
+
+	cfg.Search.Providers = []string{"bing", "duckduckgo"}
+	if _, err := NewManager(cfg); err == nil {
+		t.Error("Expected error for an explicitly listed provider without API key")
+	}
+}
+
+func TestNewManager_Bing(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Search.Provider = "bing"
+	if _, err := NewManager(cfg); err == nil {
+		t.Error("Expected error for bing without API key")
+	}
+	cfg.Search.Bing.APIKey = "bing-key"
+	if _, err := NewManager(cfg); err != nil {
+		t.Errorf("NewManager() failed: %v", err)
+	}
+}
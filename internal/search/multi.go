@@ -0,0 +1,205 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mainbong/storage_doctor/internal/logger"
+)
+
+const (
+	// multiProviderTimeout bounds each backend so a slow one does not hold up the others
+	multiProviderTimeout = 10 * time.Second
+	// defaultRateLimitCooldown is how long a rate-limited backend is skipped without a Retry-After
+	defaultRateLimitCooldown = time.Minute
+)
+
+// NamedProvider is a backend of a MultiProvider
+type NamedProvider struct {
+	Name     string
+	Provider Provider
+}
+
+// MultiProvider queries several providers concurrently and merges their
+// results. Pages returned by more providers rank higher. A failing backend is
+// left out of the merge, and a rate-limited one is skipped until it recovers.
+type MultiProvider struct {
+	providers []NamedProvider
+	timeout   time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	cooldown map[string]time.Time
+}
+
+// NewMultiProvider creates a provider fanning out to providers, listed in priority order
+func NewMultiProvider(providers ...NamedProvider) *MultiProvider {
+	return &MultiProvider{
+		providers: providers,
+		timeout:   multiProviderTimeout,
+		now:       time.Now,
+		cooldown:  make(map[string]time.Time),
+	}
+}
+
+func (p *MultiProvider) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	active := p.activeProviders()
+	if len(active) == 0 {
+		return nil, fmt.Errorf("all search providers are rate limited")
+	}
+
+	lists := make([][]SearchResult, len(active))
+	errs := make([]error, len(active))
+	var wg sync.WaitGroup
+	for i, backend := range active {
+		wg.Add(1)
+		go func(i int, backend NamedProvider) {
+			defer wg.Done()
+			backendCtx, cancel := context.WithTimeout(ctx, p.timeout)
+			defer cancel()
+			lists[i], errs[i] = backend.Provider.Search(backendCtx, query, limit)
+		}(i, backend)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var failures []string
+	for i, err := range errs {
+		if err != nil {
+			p.recordFailure(active[i].Name, err)
+			failures = append(failures, fmt.Sprintf("%s: %v", active[i].Name, err))
+		}
+	}
+	if len(failures) == len(active) {
+		return nil, fmt.Errorf("all search providers failed: %s", strings.Join(failures, "; "))
+	}
+	return mergeRanked(lists, limit), nil
+}
+
+// activeProviders returns the providers that are not resting after a rate limit
+func (p *MultiProvider) activeProviders() []NamedProvider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	active := make([]NamedProvider, 0, len(p.providers))
+	for _, backend := range p.providers {
+		if until, ok := p.cooldown[backend.Name]; ok && now.Before(until) {
+			continue
+		}
+		active = append(active, backend)
+	}
+	return active
+}
+
+func (p *MultiProvider) recordFailure(name string, err error) {
+	var rateLimit *RateLimitError
+	if !errors.As(err, &rateLimit) {
+		logger.Warn("검색 프로바이더 %s 실패 (나머지 결과 사용): %v", name, err)
+		return
+	}
+
+	wait := rateLimit.RetryAfter
+	if wait <= 0 {
+		wait = defaultRateLimitCooldown
+	}
+	p.mu.Lock()
+	p.cooldown[name] = p.now().Add(wait)
+	p.mu.Unlock()
+	logger.Warn("검색 프로바이더 %s 요청 제한: %s 동안 제외합니다", name, wait)
+}
+
+// mergedResult is a page with the providers' votes for it
+type mergedResult struct {
+	result SearchResult
+	votes  int
+	score  float64
+}
+
+// mergeRanked merges result lists in priority order, deduplicating by
+// normalized URL. Pages found by more providers come first; ties are broken
+// by reciprocal rank, then by the order the pages were first seen.
+func mergeRanked(lists [][]SearchResult, limit int) []SearchResult {
+	byKey := make(map[string]*mergedResult)
+	var merged []*mergedResult
+	for _, list := range lists {
+		seen := make(map[string]bool)
+		for rank, result := range list {
+			key := normalizeURL(result.URL)
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			entry, ok := byKey[key]
+			if !ok {
+				entry = &mergedResult{result: result}
+				byKey[key] = entry
+				merged = append(merged, entry)
+			}
+			if entry.result.Title == "" {
+				entry.result.Title = result.Title
+			}
+			if entry.result.Snippet == "" {
+				entry.result.Snippet = result.Snippet
+			}
+			entry.votes++
+			entry.score += 1 / float64(rank+1)
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		if merged[i].votes != merged[j].votes {
+			return merged[i].votes > merged[j].votes
+		}
+		return merged[i].score > merged[j].score
+	})
+	if limit > 0 && len(merged) > limit {
+		merged = merged[:limit]
+	}
+
+	results := make([]SearchResult, len(merged))
+	for i, entry := range merged {
+		results[i] = entry.result
+	}
+	return results
+}
+
+// trackingParams are query parameters that do not change the page
+var trackingParams = map[string]bool{"ref": true, "fbclid": true, "gclid": true, "msclkid": true}
+
+// normalizeURL returns a key identifying the same page across providers:
+// scheme, "www.", default ports, fragments, trailing slashes and tracking
+// parameters are ignored
+func normalizeURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+	query := u.Query()
+	for key := range query {
+		if strings.HasPrefix(key, "utm_") || trackingParams[key] {
+			query.Del(key)
+		}
+	}
+
+	key := host + strings.TrimSuffix(u.EscapedPath(), "/")
+	if encoded := query.Encode(); encoded != "" {
+		key += "?" + encoded
+	}
+	return key
+}
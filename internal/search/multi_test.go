@@ -0,0 +1,104 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubProvider returns fixed results or an error and counts its calls
+type stubProvider struct {
+	results []SearchResult
+	err     error
+	calls   int
+}
+
+func (p *stubProvider) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	p.calls++
+	return p.results, p.err
+}
+
+func resultURLs(results []SearchResult) string {
+	var urls []string
+	for _, result := range results {
+		urls = append(urls, result.URL)
+	}
+	return strings.Join(urls, " ")
+}
+
+func TestMultiProvider_MergesByAgreement(t *testing.T) {
+	brave := &stubProvider{results: []SearchResult{
+		{Title: "Rook docs", URL: "https://rook.io/docs/osd"},
+		{Title: "Issue 42", URL: "https://github.com/rook/rook/issues/42?utm_source=brave"},
+	}}
+	bing := &stubProvider{results: []SearchResult{
+		{Title: "Blog", URL: "https://blog.example.com/ceph"},
+		{Title: "Issue 42", URL: "http://www.github.com/rook/rook/issues/42/", Snippet: "OSD crash after upgrade"},
+	}}
+	provider := NewMultiProvider(NamedProvider{"brave", brave}, NamedProvider{"bing", bing})
+
+	results, err := provider.Search(context.Background(), "rook osd", 5)
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+
+	expected := "https://github.com/rook/rook/issues/42?utm_source=brave https://rook.io/docs/osd https://blog.example.com/ceph"
+	if got := resultURLs(results); got != expected {
+		t.Errorf("Expected the page both providers found first, got %s", got)
+	}
+	if results[0].Snippet != "OSD crash after upgrade" {
+		t.Errorf("Expected a missing snippet to be filled from another provider, got %q", results[0].Snippet)
+	}
+}
+
+func TestMultiProvider_DegradesOnFailure(t *testing.T) {
+	ddg := &stubProvider{err: errors.New("duckduckgo API error: 403")}
+	brave := &stubProvider{err: &RateLimitError{Provider: "brave", RetryAfter: 30 * time.Second}}
+	bing := &stubProvider{results: []SearchResult{{Title: "KB", URL: "https://longhorn.io/kb"}}}
+	provider := NewMultiProvider(NamedProvider{"brave", brave}, NamedProvider{"bing", bing}, NamedProvider{"duckduckgo", ddg})
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	provider.now = func() time.Time { return now }
+
+	results, err := provider.Search(context.Background(), "longhorn", 5)
+	if err != nil || resultURLs(results) != "https://longhorn.io/kb" {
+		t.Fatalf("Expected the working provider's results, got %+v (%v)", results, err)
+	}
+
+	// The rate-limited provider rests until Retry-After passes; other failures are retried
+	provider.Search(context.Background(), "longhorn", 5)
+	if brave.calls != 1 || ddg.calls != 2 {
+		t.Errorf("Expected brave to be skipped while rate limited, got brave %d / duckduckgo %d calls", brave.calls, ddg.calls)
+	}
+	now = now.Add(31 * time.Second)
+	provider.Search(context.Background(), "longhorn", 5)
+	if brave.calls != 2 {
+		t.Errorf("Expected brave to be queried again after Retry-After, got %d calls", brave.calls)
+	}
+
+	bing.err = errors.New("bing API error: 500")
+	if _, err := provider.Search(context.Background(), "longhorn", 5); err == nil || !strings.Contains(err.Error(), "all search providers failed") {
+		t.Errorf("Expected an error when every provider fails, got %v", err)
+	}
+}
+
+func TestNormalizeURL(t *testing.T) {
+	same := []string{
+		"https://www.GitHub.com/rook/rook/issues/42",
+		"http://github.com/rook/rook/issues/42/",
+		"https://github.com:443/rook/rook/issues/42#issuecomment-1",
+		"https://github.com/rook/rook/issues/42?utm_source=x&ref=search",
+	}
+	for _, raw := range same {
+		if got := normalizeURL(raw); got != "github.com/rook/rook/issues/42" {
+			t.Errorf("normalizeURL(%q) = %q", raw, got)
+		}
+	}
+	if normalizeURL("https://example.com/search?q=a&page=2") != normalizeURL("https://example.com/search?page=2&q=a") {
+		t.Error("Expected query parameter order to be ignored")
+	}
+	if normalizeURL("https://example.com/a?id=1") == normalizeURL("https://example.com/a?id=2") {
+		t.Error("Expected different pages to stay distinct")
+	}
+}
@@ -2,6 +2,12 @@ package search
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // SearchResult represents a search result
@@ -16,3 +22,28 @@ type Provider interface {
 	Search(ctx context.Context, query string, limit int) ([]SearchResult, error)
 }
 
+// RateLimitError is returned when a search backend rejects a query with HTTP 429
+type RateLimitError struct {
+	Provider   string
+	RetryAfter time.Duration // 0 when the backend did not say
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%s API rate limited (retry after %s)", e.Provider, e.RetryAfter)
+	}
+	return fmt.Sprintf("%s API rate limited", e.Provider)
+}
+
+// apiError converts a non-200 response to an error, reporting 429 as *RateLimitError
+func apiError(provider string, resp *http.Response) error {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		retryAfter := time.Duration(0)
+		if seconds, err := strconv.Atoi(strings.TrimSpace(resp.Header.Get("Retry-After"))); err == nil && seconds > 0 {
+			retryAfter = time.Duration(seconds) * time.Second
+		}
+		return &RateLimitError{Provider: provider, RetryAfter: retryAfter}
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("%s API error: %d - %s", provider, resp.StatusCode, string(body))
+}
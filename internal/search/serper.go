@@ -5,7 +5,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 
 	"github.com/mainbong/storage_doctor/internal/httpclient"
@@ -60,8 +59,7 @@ func (p *SerperProvider) Search(ctx context.Context, query string, limit int) ([
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("serper API error: %d - %s", resp.StatusCode, string(body))
+		return nil, apiError("serper", resp)
 	}
 
 	var response struct {
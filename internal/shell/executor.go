@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"strings"
+
+	"github.com/mainbong/storage_doctor/internal/shell/policy"
 )
 
 // ApprovalMode represents the command approval mode
@@ -21,6 +23,7 @@ type Executor struct {
 	approvalMode    ApprovalMode
 	workingDir      string
 	commandExecutor CommandExecutor
+	policy          *policy.Engine
 }
 
 // NewExecutor creates a new shell executor
@@ -47,8 +50,22 @@ func (e *Executor) GetApprovalMode() ApprovalMode {
 	return e.approvalMode
 }
 
+// SetPolicy sets the command policy used to classify commands before execution
+func (e *Executor) SetPolicy(engine *policy.Engine) {
+	e.policy = engine
+}
+
+// GetPolicy returns the command policy (nil if none is configured)
+func (e *Executor) GetPolicy() *policy.Engine {
+	return e.policy
+}
+
 // Execute executes a shell command with approval
 func (e *Executor) Execute(command string) (string, error) {
+	if e.policy != nil {
+		return e.executeWithPolicy(command)
+	}
+
 	// Check approval
 	if e.approvalMode == ApprovalModeManual {
 		approved, err := e.requestApproval(command)
@@ -64,6 +81,35 @@ func (e *Executor) Execute(command string) (string, error) {
 	return e.runCommand(command)
 }
 
+// executeWithPolicy applies the command policy: auto-approval only covers
+// read-only commands and destructive commands need a typed confirmation.
+func (e *Executor) executeWithPolicy(command string) (string, error) {
+	evaluation := e.policy.Evaluate(command)
+
+	switch e.policy.Decide(evaluation, e.approvalMode != ApprovalModeManual) {
+	case policy.DecisionDeny:
+		return "", fmt.Errorf("command blocked by policy: %s", evaluation.Reason)
+	case policy.DecisionConfirm:
+		confirmed, err := e.requestConfirmation(evaluation)
+		if err != nil {
+			return "", err
+		}
+		if !confirmed {
+			return "", fmt.Errorf("command execution cancelled by user")
+		}
+	case policy.DecisionAsk:
+		approved, err := e.requestApproval(command)
+		if err != nil {
+			return "", err
+		}
+		if !approved {
+			return "", fmt.Errorf("command execution cancelled by user")
+		}
+	}
+
+	return e.runCommand(command)
+}
+
 // runCommand runs a shell command and returns the output
 func (e *Executor) runCommand(command string) (string, error) {
 	output, err := e.commandExecutor.Execute(command, e.workingDir)
@@ -107,6 +153,22 @@ func (e *Executor) requestApproval(command string) (bool, error) {
 	}
 }
 
+// requestConfirmation asks the user to type a confirmation phrase for a destructive command
+func (e *Executor) requestConfirmation(evaluation policy.Evaluation) (bool, error) {
+	phrase := evaluation.ConfirmationText()
+	fmt.Printf("\n[위험 명령어 실행 요청]\n")
+	fmt.Printf("명령어: %s\n", evaluation.Command)
+	fmt.Printf("위험 사유: %s\n", evaluation.Reason)
+	fmt.Printf("실행하려면 '%s'를 정확히 입력하세요: ", phrase)
+
+	reader := bufio.NewReader(os.Stdin)
+	response, err := reader.ReadString('\n')
+	if err != nil {
+		return false, fmt.Errorf("failed to read input: %w", err)
+	}
+	return strings.TrimSpace(response) == phrase, nil
+}
+
 // ExecuteSilent executes a command without approval (for internal use)
 func (e *Executor) ExecuteSilent(command string) (string, error) {
 	return e.runCommand(command)
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"strings"
 	"testing"
+
+	"github.com/mainbong/storage_doctor/internal/shell/policy"
 )
 
 func TestNewExecutor(t *testing.T) {
@@ -141,3 +143,40 @@ func TestExecute_WorkingDir(t *testing.T) {
 	}
 }
 
+
+func TestExecute_PolicyDeny(t *testing.T) {
+	mockExecutor := NewMockCommandExecutor()
+	executor := NewExecutorWithCommandExecutor("", mockExecutor)
+	executor.SetApprovalMode(ApprovalModeAuto)
+
+	engine, err := policy.NewEngineWithRules(policy.Rules{Deny: []string{"rm -rf *"}})
+	if err != nil {
+		t.Fatalf("NewEngineWithRules() failed: %v", err)
+	}
+	executor.SetPolicy(engine)
+
+	if _, err := executor.Execute("sudo rm -rf /data"); err == nil {
+		t.Fatal("Expected denied command to fail")
+	}
+	if len(mockExecutor.GetCommands()) != 0 {
+		t.Errorf("Expected denied command not to run, got %v", mockExecutor.GetCommands())
+	}
+}
+
+func TestExecute_PolicyAutoApprovesReadOnly(t *testing.T) {
+	mockExecutor := NewMockCommandExecutor()
+	executor := NewExecutorWithCommandExecutor("", mockExecutor)
+	executor.SetApprovalMode(ApprovalModeAuto)
+
+	engine, _ := policy.NewEngineWithRules(policy.Rules{})
+	executor.SetPolicy(engine)
+	mockExecutor.SetResponse("lsblk", "sda")
+
+	output, err := executor.Execute("lsblk")
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if output != "sda" {
+		t.Errorf("Expected output 'sda', got '%s'", output)
+	}
+}
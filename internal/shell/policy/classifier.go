@@ -0,0 +1,733 @@
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Class is the risk class of a command
+type Class int
+
+const (
+	ClassReadOnly    Class = iota // Only inspects state
+	ClassMutating                 // Changes state but is recoverable
+	ClassDestructive              // May destroy data or take storage offline
+)
+
+// String returns the class name
+func (c Class) String() string {
+	switch c {
+	case ClassReadOnly:
+		return "read-only"
+	case ClassMutating:
+		return "mutating"
+	case ClassDestructive:
+		return "destructive"
+	default:
+		return "unknown"
+	}
+}
+
+// Label returns a short Korean label for display
+func (c Class) Label() string {
+	switch c {
+	case ClassReadOnly:
+		return "읽기 전용"
+	case ClassMutating:
+		return "변경"
+	case ClassDestructive:
+		return "파괴적"
+	default:
+		return "알 수 없음"
+	}
+}
+
+// readOnlyCommands are programs that never change system state regardless of arguments
+var readOnlyCommands = map[string]bool{
+	"ls": true, "cat": true, "head": true, "tail": true, "less": true, "more": true,
+	"grep": true, "egrep": true, "fgrep": true, "zgrep": true, "zcat": true, "rg": true,
+	"wc": true, "cut": true, "tr": true, "column": true,
+	"jq": true, "diff": true, "cmp": true, "comm": true, "base64": true,
+	"md5sum": true, "sha1sum": true, "sha256sum": true, "strings": true, "xxd": true, "od": true, "hexdump": true,
+	"echo": true, "printf": true, "true": true, "false": true, "test": true, "[": true, "[[": true, "read": true, "sleep": true,
+	"id": true, "whoami": true, "which": true, "whereis": true, "type": true, "pwd": true,
+	"uname": true, "uptime": true, "free": true, "nproc": true, "lscpu": true,
+	"df": true, "du": true, "lsblk": true, "blkid": true, "findmnt": true, "stat": true, "file": true,
+	"realpath": true, "readlink": true, "basename": true, "dirname": true,
+	"ps": true, "pgrep": true, "pidof": true, "top": true, "htop": true, "lsof": true,
+	"iostat": true, "vmstat": true, "mpstat": true, "pidstat": true, "sar": true, "iotop": true,
+	"ss": true, "netstat": true, "ping": true, "traceroute": true, "nslookup": true, "dig": true, "host": true,
+	"lsscsi": true, "lspci": true, "lsmod": true, "lsattr": true, "getfacl": true, "namei": true,
+	"pvs": true, "vgs": true, "lvs": true, "pvdisplay": true, "vgdisplay": true, "lvdisplay": true, "pvscan": true, "vgscan": true, "lvscan": true,
+	"xfs_info": true, "dumpe2fs": true, "showmount": true, "getenforce": true, "sestatus": true, "printenv": true,
+}
+
+// storageResources are Kubernetes resources whose deletion can lose data or cluster-wide state
+var storageResources = map[string]bool{
+	"pv": true, "pvs": true, "persistentvolume": true, "persistentvolumes": true,
+	"pvc": true, "pvcs": true, "persistentvolumeclaim": true, "persistentvolumeclaims": true,
+	"sc": true, "storageclass": true, "storageclasses": true,
+	"volumeattachment": true, "volumeattachments": true,
+	"volumesnapshot": true, "volumesnapshots": true, "volumesnapshotcontent": true, "volumesnapshotcontents": true,
+	"csidriver": true, "csidrivers": true, "csinode": true, "csinodes": true,
+	"ns": true, "namespace": true, "namespaces": true, "node": true, "nodes": true, "no": true,
+	"crd": true, "crds": true, "customresourcedefinition": true, "customresourcedefinitions": true,
+	"statefulset": true, "statefulsets": true, "sts": true, "all": true,
+}
+
+// kubectlValueFlags are kubectl flags that consume the following argument
+var kubectlValueFlags = map[string]bool{
+	"-n": true, "--namespace": true, "--context": true, "--kubeconfig": true, "--cluster": true,
+	"--user": true, "-s": true, "--server": true, "-o": true, "--output": true, "-l": true,
+	"--selector": true, "-c": true, "--container": true, "-f": true, "--filename": true,
+	"--field-selector": true, "--sort-by": true, "--token": true, "--as": true, "--tail": true,
+	"--since": true, "-p": true,
+}
+
+// cephValueFlags are the value flags shared by the Ceph CLIs
+var cephValueFlags = []string{"-n", "--name", "--id", "--user", "-c", "--conf", "-k", "--keyring", "-m", "--mon-host", "--cluster", "--format"}
+
+// awkValueFlags are the awk flags that consume the following argument
+var awkValueFlags = flagSet(nil, "-F", "--field-separator", "-v", "--assign", "-f", "--file", "-e", "--source", "-i", "--include", "-l", "--load")
+
+// commandValueFlags lists, by program, the flags that consume the following
+// argument so that their values are not taken for subcommands
+var commandValueFlags = map[string]map[string]bool{
+	"ceph":        flagSet(cephValueFlags, "-f", "-i", "--in-file", "-o", "--out-file", "--connect-timeout"),
+	"rbd":         flagSet(cephValueFlags, "-p", "--pool", "--namespace", "--image", "--snap", "-s", "--size", "-o", "--options"),
+	"rados":       flagSet(cephValueFlags, "-p", "--pool", "-N", "--namespace", "-s", "--snap"),
+	"ceph-volume": flagSet(nil, "--cluster", "--log-level", "--log-path"),
+	"cryptsetup":  flagSet(nil, "-d", "--key-file", "--type", "-M", "-c", "--cipher", "-s", "--key-size", "-S", "--key-slot", "--header", "-h", "--hash"),
+	"zpool":       flagSet(nil, "-o", "-O", "-R", "-d"),
+	"zfs":         flagSet(nil, "-o", "-t", "-s", "-S", "-d"),
+	"helm":        flagSet(nil, "-n", "--namespace", "--kube-context"),
+	"systemctl":   flagSet(nil, "-t", "--type", "--state", "-p", "--property", "-H", "--host", "-M", "--machine", "-n", "--lines", "-o", "--output"),
+	"iscsiadm":    flagSet(nil, "-m", "--mode", "-T", "--targetname", "-p", "--portal", "-o", "--op", "-n", "--name", "-v", "--value", "-t", "--type", "-I", "--interface"),
+	"wipefs":      flagSet(nil, "-o", "--offset", "-t", "--types", "-O", "--output"),
+	"parted":      flagSet(nil, "-a", "--align"),
+	"sed":         flagSet(nil, "-e", "--expression", "-f", "--file", "-l", "--line-length"),
+	"awk":         awkValueFlags,
+	"gawk":        awkValueFlags,
+	"mawk":        awkValueFlags,
+	"nawk":        awkValueFlags,
+	"date":        flagSet(nil, "-d", "--date", "-f", "--file", "-r", "--reference", "-s", "--set"),
+	"hostname":    flagSet(nil, "-F", "--file"),
+	"mount":       flagSet(nil, "-t", "--types", "-o", "--options", "-O", "--test-opts", "-L", "--label", "-U", "--uuid"),
+	"sort":        flagSet(nil, "-o", "--output", "-k", "--key", "-t", "--field-separator", "-S", "--buffer-size", "-T", "--temporary-directory", "--parallel", "--files0-from", "--compress-program"),
+	"uniq":        flagSet(nil, "-f", "--skip-fields", "-s", "--skip-chars", "-w", "--check-chars"),
+	"yq":          flagSet(nil, "-o", "--output-format", "-p", "--input-format", "--front-matter", "--expression"),
+}
+
+// partedMutatingCommands are the parted commands that change the disk
+var partedMutatingCommands = []string{"mklabel", "mktable", "mkpart", "mkpartfs", "mkfs", "rm", "resize", "resizepart", "move", "cp", "name", "set", "disk_set", "toggle", "disk_toggle", "rescue"}
+
+// sgdiskReadFlags are the only sgdisk options that leave the disk untouched
+var sgdiskReadFlags = map[string]bool{
+	"-p": true, "--print": true, "-i": true, "--info": true, "-v": true, "--verify": true,
+	"-O": true, "--print-mbr": true, "-D": true, "--display-alignment": true, "-P": true, "--pretend": true,
+}
+
+// awkSideEffect matches awk programs that run commands or write files:
+// system(), pipes to or from commands and print/printf output redirection
+var awkSideEffect = regexp.MustCompile(`\bsystem\s*\(|\|\s*getline|\bprintf?\b[^;}\n]*[>|]`)
+
+// ClassifySegment classifies a single simple command and explains the reason
+func ClassifySegment(seg Segment) (Class, string) {
+	class, reason := classifyArgs(seg.Args)
+
+	for _, r := range seg.Redirects {
+		redirectClass, redirectReason := classifyRedirect(r)
+		if redirectClass > class {
+			class, reason = redirectClass, redirectReason
+		}
+	}
+	return class, reason
+}
+
+func classifyRedirect(r Redirect) (Class, string) {
+	if !strings.Contains(r.Op, ">") {
+		return ClassReadOnly, ""
+	}
+	target := r.Target
+	switch {
+	case target == "" || target == "/dev/null" || target == "/dev/stdout" || target == "/dev/stderr" || target == "-":
+		return ClassReadOnly, ""
+	case strings.HasPrefix(r.Op, ">&") && isNumber(target):
+		return ClassReadOnly, ""
+	case isBlockDevice(target):
+		return ClassDestructive, fmt.Sprintf("블록 장치에 직접 쓰기 (%s)", target)
+	default:
+		return ClassMutating, fmt.Sprintf("파일 쓰기 리다이렉션 (%s)", target)
+	}
+}
+
+func classifyArgs(args []string) (Class, string) {
+	if len(args) == 0 {
+		return ClassReadOnly, ""
+	}
+	program := Segment{Args: args}.Program()
+	rest := args[1:]
+	scanned := scanArgs(rest, commandValueFlags[program])
+	positional := scanned.positional
+
+	switch {
+	case strings.HasPrefix(program, "mkfs") || program == "mke2fs" || program == "mkswap":
+		return destructive(program, "파일시스템 포맷")
+	case program == "wipefs":
+		// Without -a/-o wipefs only lists signatures
+		if scanned.has("-n", "--no-act") || !scanned.has("-a", "--all", "-o", "--offset") {
+			return ClassReadOnly, ""
+		}
+		return destructive(program, "파일시스템 시그니처 삭제")
+	case program == "shred" || program == "blkdiscard":
+		return destructive(program, "디스크 데이터 삭제")
+	case program == "dd":
+		for _, arg := range rest {
+			if strings.HasPrefix(arg, "of=") {
+				return destructive(program, "dd 출력 대상 쓰기 ("+strings.TrimPrefix(arg, "of=")+")")
+			}
+		}
+		return ClassReadOnly, ""
+	case program == "rm":
+		for _, arg := range rest {
+			if arg == "--recursive" || strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && strings.ContainsAny(arg, "rR") {
+				return destructive(program, "재귀 삭제")
+			}
+		}
+		return mutating(program)
+	case program == "fdisk" || program == "sfdisk":
+		if scanned.has("-l", "--list", "-d", "--dump", "-s", "--show-size") {
+			return ClassReadOnly, ""
+		}
+		return destructive(program, "파티션 테이블 변경")
+	case program == "gdisk":
+		if scanned.has("-l") {
+			return ClassReadOnly, ""
+		}
+		return destructive(program, "파티션 테이블 변경")
+	case program == "sgdisk":
+		// Most sgdisk options write, so only a command made of print options is read-only
+		for _, flag := range scanned.flags {
+			if !sgdiskReadFlags[flag] {
+				return destructive(program, "파티션 테이블 변경")
+			}
+		}
+		if len(scanned.flags) == 0 {
+			return destructive(program, "파티션 테이블 변경")
+		}
+		return ClassReadOnly, ""
+	case program == "parted":
+		// -s is --script here: it only suppresses prompts
+		for _, arg := range positional {
+			if containsWord(partedMutatingCommands, arg) {
+				return destructive(program+" "+arg, "파티션 테이블 변경")
+			}
+		}
+		if scanned.has("-l", "--list") || containsWord(positional, "print") {
+			return ClassReadOnly, ""
+		}
+		return destructive(program, "파티션 테이블 변경")
+	case program == "mdadm":
+		if scanned.has("--zero-superblock", "--create", "-C") {
+			return destructive(program, "RAID 메타데이터 변경")
+		}
+		if scanned.has("--detail", "-D", "--examine", "-E", "--query", "-Q") {
+			return ClassReadOnly, ""
+		}
+		return mutating(program)
+	case program == "cryptsetup":
+		return classifyBySubcommand(program, positional, []string{"status", "luksDump", "isLuks", "luksUUID"}, []string{"luksFormat", "erase", "luksErase", "luksKillSlot", "reencrypt"})
+	case program == "lvremove" || program == "vgremove" || program == "pvremove" || program == "lvreduce":
+		return destructive(program, "LVM 볼륨 제거")
+	case program == "zpool":
+		return classifyBySubcommand(program, positional, []string{"status", "list", "iostat", "get", "history", "events"}, []string{"destroy", "labelclear"})
+	case program == "zfs":
+		return classifyBySubcommand(program, positional, []string{"list", "get", "diff", "holds"}, []string{"destroy", "rollback"})
+	case program == "ceph":
+		return classifyBySubcommand(program, positional, []string{"status", "health", "df", "versions", "version", "quorum_status", "mon_status", "report", "tree", "stat", "dump", "ls", "list", "get", "detail", "query"}, []string{"purge", "destroy", "delete", "rm", "zap"})
+	case program == "ceph-volume":
+		return classifyBySubcommand(program, positional, []string{"list", "inventory"}, []string{"zap"})
+	case program == "rbd" || program == "rados":
+		return classifyBySubcommand(program, positional, []string{"ls", "list", "info", "status", "du", "showmapped", "df", "stat", "lspools", "get"}, []string{"rm", "remove", "purge", "trash", "rmpool"})
+	case program == "kubectl" || program == "oc":
+		return classifyKubectl(program, rest)
+	case program == "helm":
+		return classifyBySubcommand(program, positional, []string{"list", "ls", "status", "get", "history", "show", "search", "template", "version", "env", "lint"}, []string{"uninstall", "delete", "del"})
+	case program == "systemctl":
+		return classifyBySubcommand(program, positional, []string{"status", "show", "is-active", "is-enabled", "is-failed", "list-units", "list-unit-files", "list-timers", "cat", "list-dependencies"}, nil)
+	case program == "crictl" || program == "docker" || program == "podman" || program == "nerdctl" || program == "ctr":
+		return classifyBySubcommand(program, positional, []string{"ps", "logs", "inspect", "inspecti", "inspectp", "images", "pods", "info", "version", "stats", "top", "ls", "list"}, nil)
+	case program == "nvme":
+		return classifyBySubcommand(program, positional, []string{"list", "smart-log", "id-ctrl", "id-ns", "error-log", "list-subsys", "show-regs", "fw-log", "list-ns"}, []string{"format", "sanitize", "delete-ns"})
+	case program == "journalctl":
+		for _, arg := range rest {
+			if strings.HasPrefix(arg, "--vacuum") || arg == "--rotate" || arg == "--flush" {
+				return mutating(program)
+			}
+		}
+		return ClassReadOnly, ""
+	case program == "dmesg":
+		if scanned.has("-c", "-C", "--clear", "--read-clear") {
+			return mutating(program)
+		}
+		return ClassReadOnly, ""
+	case program == "mount":
+		// Only listing the mounted filesystems (optionally of some types) is read-only
+		if len(positional) > 0 {
+			return mutating(program)
+		}
+		for _, flag := range scanned.flags {
+			if !containsWord([]string{"-l", "--show-labels", "-t", "--types", "-h", "--help", "-V", "--version"}, flag) {
+				return mutating(program)
+			}
+		}
+		return ClassReadOnly, ""
+	case program == "fuser":
+		if scanned.has("-k", "--kill", "-K") {
+			return mutating(program)
+		}
+		return ClassReadOnly, ""
+	case program == "yq":
+		if scanned.has("-i", "--inplace") {
+			return mutating(program)
+		}
+		return ClassReadOnly, ""
+	case program == "sort":
+		if scanned.has("-o", "--output", "--compress-program") {
+			return mutating(program)
+		}
+		return ClassReadOnly, ""
+	case program == "uniq":
+		// A second operand is the output file
+		if len(positional) > 1 && positional[1] != "-" {
+			return mutating(program)
+		}
+		return ClassReadOnly, ""
+	case program == "ip":
+		for _, arg := range positional {
+			switch arg {
+			case "add", "del", "delete", "set", "flush", "change", "replace":
+				return mutating(program)
+			}
+		}
+		return ClassReadOnly, ""
+	case program == "sed":
+		if scanned.has("-i", "--in-place", "-f", "--file") {
+			return mutating(program)
+		}
+		// The script is the first operand unless given with -e
+		scripts := scanned.valuesOf("-e", "--expression")
+		if len(scripts) == 0 && len(positional) > 0 {
+			scripts = positional[:1]
+		}
+		for _, script := range scripts {
+			if sedScriptHasSideEffects(script) {
+				return mutating(program)
+			}
+		}
+		return ClassReadOnly, ""
+	case program == "awk" || program == "gawk" || program == "mawk" || program == "nawk":
+		if scanned.has("-f", "--file", "-i", "--include", "-l", "--load") {
+			return mutating(program)
+		}
+		programs := scanned.valuesOf("-e", "--source")
+		if len(programs) == 0 && len(positional) > 0 {
+			programs = positional[:1]
+		}
+		for _, text := range programs {
+			if awkSideEffect.MatchString(text) {
+				return mutating(program)
+			}
+		}
+		return ClassReadOnly, ""
+	case program == "date":
+		// An operand other than +FORMAT sets the clock, like -s
+		if scanned.has("-s", "--set") {
+			return mutating(program)
+		}
+		for _, arg := range positional {
+			if !strings.HasPrefix(arg, "+") {
+				return mutating(program)
+			}
+		}
+		return ClassReadOnly, ""
+	case program == "hostname":
+		if len(positional) > 0 || scanned.has("-F", "--file") {
+			return mutating(program)
+		}
+		return ClassReadOnly, ""
+	case program == "find":
+		return classifyFind(rest)
+	case program == "tee":
+		for _, arg := range positional {
+			if isBlockDevice(arg) {
+				return destructive(program, "블록 장치에 직접 쓰기 ("+arg+")")
+			}
+			if arg != "/dev/null" {
+				return mutating(program)
+			}
+		}
+		return ClassReadOnly, ""
+	case program == "curl":
+		for _, arg := range rest {
+			if arg == "-d" || strings.HasPrefix(arg, "--data") || arg == "-F" || arg == "--form" || arg == "-T" || arg == "--upload-file" ||
+				arg == "-o" || arg == "-O" || arg == "--output" || arg == "--remote-name" {
+				return mutating(program)
+			}
+		}
+		for i, arg := range rest {
+			if (arg == "-X" || arg == "--request") && i+1 < len(rest) {
+				method := strings.ToUpper(rest[i+1])
+				if method != "GET" && method != "HEAD" {
+					return mutating(program)
+				}
+			}
+		}
+		return ClassReadOnly, ""
+	case program == "iscsiadm":
+		if scanned.has("--login", "-l", "--logout", "-u", "-o", "--op") {
+			return mutating(program)
+		}
+		return ClassReadOnly, ""
+	case program == "multipath":
+		if scanned.has("-f", "-F", "-r", "-w", "-W", "-a") {
+			return mutating(program)
+		}
+		return ClassReadOnly, ""
+	case program == "smartctl":
+		for _, arg := range rest {
+			if arg == "-t" || arg == "--test" || arg == "-s" || arg == "-o" || arg == "-S" || strings.HasPrefix(arg, "--smart=") || strings.HasPrefix(arg, "--set") {
+				return mutating(program)
+			}
+		}
+		return ClassReadOnly, ""
+	case program == "hdparm":
+		for _, arg := range rest {
+			if strings.HasPrefix(arg, "--security") || strings.HasPrefix(arg, "--trim") {
+				return destructive(program, "디스크 보안 삭제/트림")
+			}
+		}
+		if scanned.has("-I", "-i", "-t", "-T", "-g", "-C") {
+			return ClassReadOnly, ""
+		}
+		return mutating(program)
+	case program == "tune2fs":
+		if len(rest) == 2 && rest[0] == "-l" {
+			return ClassReadOnly, ""
+		}
+		return mutating(program)
+	case program == "fsck" || strings.HasPrefix(program, "fsck.") || program == "e2fsck" || program == "xfs_repair":
+		if scanned.has("-n") {
+			return ClassReadOnly, ""
+		}
+		return mutating(program)
+	case program == "blockdev":
+		for _, arg := range rest {
+			if strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--get") && arg != "--report" && arg != "-v" && arg != "-q" {
+				return mutating(program)
+			}
+		}
+		return ClassReadOnly, ""
+	case program == "reboot" || program == "shutdown" || program == "poweroff" || program == "halt":
+		return destructive(program, "노드 재시작/종료")
+	case readOnlyCommands[program]:
+		return ClassReadOnly, ""
+	default:
+		return mutating(program)
+	}
+}
+
+func classifyKubectl(program string, rest []string) (Class, string) {
+	scanned := scanArgs(rest, kubectlValueFlags)
+	positional := scanned.positional
+	if len(positional) == 0 {
+		return ClassReadOnly, ""
+	}
+
+	switch positional[0] {
+	case "get", "describe", "logs", "log", "top", "explain", "version", "api-resources", "api-versions", "cluster-info", "diff", "events", "wait":
+		return ClassReadOnly, ""
+	case "auth":
+		if len(positional) > 1 && (positional[1] == "can-i" || positional[1] == "whoami") {
+			return ClassReadOnly, ""
+		}
+		return mutating(program + " auth")
+	case "config":
+		if len(positional) > 1 {
+			switch positional[1] {
+			case "view", "get-contexts", "current-context", "get-clusters", "get-users":
+				return ClassReadOnly, ""
+			}
+		}
+		return mutating(program + " config")
+	case "exec":
+		// Classify by the command executed inside the container
+		for i, arg := range rest {
+			if arg == "--" && i+1 < len(rest) {
+				class, reason := classifyArgs(rest[i+1:])
+				if reason != "" {
+					reason = program + " exec: " + reason
+				}
+				return class, reason
+			}
+		}
+		return mutating(program + " exec")
+	case "delete":
+		if scanned.has("--all", "-A", "--all-namespaces", "-f", "--filename") {
+			return destructive(program+" delete", "대량 또는 매니페스트 기반 삭제")
+		}
+		if len(positional) > 1 {
+			for _, resource := range strings.Split(positional[1], ",") {
+				resource = strings.ToLower(strings.SplitN(resource, "/", 2)[0])
+				resource = strings.SplitN(resource, ".", 2)[0]
+				if storageResources[resource] {
+					return destructive(program+" delete "+resource, "스토리지/클러스터 리소스 삭제")
+				}
+			}
+		}
+		return mutating(program + " delete")
+	default:
+		return mutating(program + " " + positional[0])
+	}
+}
+
+func classifyFind(rest []string) (Class, string) {
+	class := ClassReadOnly
+	reason := ""
+	for i := 0; i < len(rest); i++ {
+		switch rest[i] {
+		case "-delete":
+			return destructive("find", "일치하는 파일 일괄 삭제")
+		case "-fprint", "-fprint0", "-fprintf", "-fls":
+			if class < ClassMutating {
+				class, reason = mutating("find " + rest[i])
+			}
+		case "-exec", "-execdir", "-ok", "-okdir":
+			end := i + 1
+			for end < len(rest) && rest[end] != ";" && rest[end] != "+" {
+				end++
+			}
+			innerClass, innerReason := classifyArgs(rest[i+1 : end])
+			if innerClass > class {
+				class, reason = innerClass, "find -exec: "+innerReason
+			}
+			i = end
+		}
+	}
+	return class, reason
+}
+
+// classifyBySubcommand classifies CLIs whose behavior depends on a verb among the first positional arguments
+func classifyBySubcommand(program string, positional []string, readVerbs, destroyVerbs []string) (Class, string) {
+	if len(positional) > 3 {
+		positional = positional[:3]
+	}
+	for _, arg := range positional {
+		if containsWord(destroyVerbs, arg) {
+			return destructive(program+" "+arg, "데이터 삭제 작업")
+		}
+	}
+	if len(positional) == 0 && len(readVerbs) > 0 {
+		return ClassReadOnly, ""
+	}
+	for _, arg := range positional {
+		if containsWord(readVerbs, arg) {
+			return ClassReadOnly, ""
+		}
+	}
+	verb := program
+	if len(positional) > 0 {
+		verb += " " + positional[0]
+	}
+	return mutating(verb)
+}
+
+func mutating(what string) (Class, string) {
+	return ClassMutating, fmt.Sprintf("상태를 변경할 수 있는 명령어 (%s)", what)
+}
+
+func destructive(what, why string) (Class, string) {
+	return ClassDestructive, fmt.Sprintf("%s: %s", what, why)
+}
+
+// scannedArgs is a command's arguments split into flags and operands
+type scannedArgs struct {
+	// flags are the flag names; combined short flags such as -af appear
+	// both whole and as each of their letters
+	flags []string
+	// values holds the values of flags, by flag name
+	values     map[string][]string
+	positional []string
+}
+
+// scanArgs splits args into flags and positional arguments up to "--". The
+// value of a flag in valueFlags, attached (-nfoo, --name=foo) or in the next
+// argument, is recorded for the flag instead of as an operand.
+func scanArgs(args []string, valueFlags map[string]bool) scannedArgs {
+	scanned := scannedArgs{values: make(map[string][]string)}
+	addValue := func(flag, value string) {
+		scanned.values[flag] = append(scanned.values[flag], value)
+	}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			scanned.positional = append(scanned.positional, arg)
+			continue
+		}
+		if strings.HasPrefix(arg, "--") {
+			name, value, attached := strings.Cut(arg, "=")
+			scanned.flags = append(scanned.flags, name)
+			if attached {
+				addValue(name, value)
+			} else if valueFlags[name] && i+1 < len(args) {
+				i++
+				addValue(name, args[i])
+			}
+			continue
+		}
+
+		// The whole word is kept for single-dash long options such as -delete
+		scanned.flags = append(scanned.flags, arg)
+		if valueFlags[arg] {
+			if i+1 < len(args) {
+				i++
+				addValue(arg, args[i])
+			}
+			continue
+		}
+		for j := 1; j < len(arg); j++ {
+			flag := "-" + arg[j:j+1]
+			if len(arg) > 2 {
+				scanned.flags = append(scanned.flags, flag)
+			}
+			if !valueFlags[flag] {
+				continue
+			}
+			if j+1 < len(arg) {
+				addValue(flag, arg[j+1:])
+			} else if i+1 < len(args) {
+				i++
+				addValue(flag, args[i])
+			}
+			break
+		}
+	}
+	return scanned
+}
+
+// has reports whether any of the flags was given
+func (s scannedArgs) has(flags ...string) bool {
+	for _, name := range s.flags {
+		if containsWord(flags, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// valuesOf returns the values given to any of the flags, in order
+func (s scannedArgs) valuesOf(flags ...string) []string {
+	var values []string
+	for _, flag := range flags {
+		values = append(values, s.values[flag]...)
+	}
+	return values
+}
+
+func flagSet(base []string, flags ...string) map[string]bool {
+	set := make(map[string]bool, len(base)+len(flags))
+	for _, flag := range append(base, flags...) {
+		set[flag] = true
+	}
+	return set
+}
+
+// sedScriptHasSideEffects reports whether a sed script writes files (w, W and
+// the w flag of s) or runs commands (e and the e flag of s)
+func sedScriptHasSideEffects(script string) bool {
+	s := []rune(script)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '/', '\\':
+			// Regex address: /re/ or \cREc
+			if c == '\\' {
+				if i++; i >= len(s) {
+					return false
+				}
+			}
+			i = skipDelimited(s, i+1, s[i])
+		case 's', 'y':
+			if i+1 >= len(s) {
+				return false
+			}
+			delim := s[i+1]
+			i = skipDelimited(s, i+2, delim)
+			i = skipDelimited(s, i+1, delim)
+			if c == 's' {
+				for i+1 < len(s) && !strings.ContainsRune(";}\n", s[i+1]) {
+					i++
+					if s[i] == 'w' || s[i] == 'e' {
+						return true
+					}
+				}
+			}
+		case 'w', 'W', 'e':
+			return true
+		case 'a', 'i', 'c', 'r', 'R', '#':
+			// Text, a file name or a comment runs to the end of the line
+			for i+1 < len(s) && s[i+1] != '\n' {
+				i++
+			}
+		case 'b', 't', 'T', ':':
+			for i+1 < len(s) && !strings.ContainsRune(";\n", s[i+1]) {
+				i++
+			}
+		}
+	}
+	return false
+}
+
+// skipDelimited returns the index of the next unescaped delim from i
+func skipDelimited(s []rune, i int, delim rune) int {
+	for ; i < len(s) && s[i] != delim; i++ {
+		if s[i] == '\\' {
+			i++
+		}
+	}
+	return i
+}
+
+func containsWord(words []string, word string) bool {
+	for _, w := range words {
+		if w == word {
+			return true
+		}
+	}
+	return false
+}
+
+func isNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isBlockDevice reports whether a path looks like a raw disk or volume device
+func isBlockDevice(path string) bool {
+	for _, prefix := range []string{"/dev/sd", "/dev/nvme", "/dev/vd", "/dev/xvd", "/dev/hd", "/dev/mapper/", "/dev/dm-", "/dev/md", "/dev/disk/", "/dev/rbd", "/dev/nbd", "/dev/loop", "/dev/zd"} {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+)
+
+func classify(command string) Class {
+	class := ClassReadOnly
+	for _, seg := range Parse(command) {
+		if c, _ := ClassifySegment(seg); c > class {
+			class = c
+		}
+	}
+	return class
+}
+
+func TestClassifySegment(t *testing.T) {
+	tests := []struct {
+		command  string
+		expected Class
+	}{
+		// Read-only diagnostics
+		{"lsblk -f", ClassReadOnly},
+		{"df -h | grep /data", ClassReadOnly},
+		{"cat /proc/mdstat", ClassReadOnly},
+		{"kubectl get pvc -A -o wide", ClassReadOnly},
+		{"kubectl -n rook-ceph logs deploy/rook-ceph-operator --tail 100", ClassReadOnly},
+		{"kubectl exec -n rook-ceph pod -- ceph status", ClassReadOnly},
+		{"ceph osd tree", ClassReadOnly},
+		{"zpool status", ClassReadOnly},
+		{"journalctl -u kubelet --since today", ClassReadOnly},
+		{"smartctl -a /dev/sda", ClassReadOnly},
+		{"fdisk -l /dev/sda", ClassReadOnly},
+		{"wipefs /dev/sdb", ClassReadOnly},
+		{"dd if=/dev/sda bs=512 count=1 2>/dev/null | xxd", ClassReadOnly},
+		{"sed -n 1,10p /etc/fstab", ClassReadOnly},
+		{"find /var/log -name '*.log'", ClassReadOnly},
+		{"systemctl status kubelet", ClassReadOnly},
+		{"parted -s /dev/sdb print", ClassReadOnly},
+		{"parted -l", ClassReadOnly},
+		{"sgdisk -p /dev/sdb", ClassReadOnly},
+		{"awk -F: '$3 > 1000 {print $1}' /etc/passwd", ClassReadOnly},
+		{"sed -n '/error/p' /var/log/messages", ClassReadOnly},
+		{"sed -e 's/a/b/g' -e 's/w/x/' file", ClassReadOnly},
+		{"date +%s", ClassReadOnly},
+		{"date -u -d yesterday", ClassReadOnly},
+		{"hostname -f", ClassReadOnly},
+		{"fuser -vm /var/lib/kubelet/pods/x", ClassReadOnly},
+		{"mount", ClassReadOnly},
+		{"mount -t nfs4", ClassReadOnly},
+		{"yq '.spec' values.yaml", ClassReadOnly},
+		{"sort -k2 -n /tmp/x", ClassReadOnly},
+		{"uniq -c /tmp/a", ClassReadOnly},
+		{"ip netns exec ns1 ip addr", ClassReadOnly},
+		{"kubectl auth can-i delete pvc", ClassReadOnly},
+		{"kubectl auth whoami", ClassReadOnly},
+		{"for f in /var/log/*.log; do tail -n 5 $f; done", ClassReadOnly},
+		{"while read dev; do lsblk $dev; done < devices", ClassReadOnly},
+		{"if [ -b /dev/sdb ]; then blkid /dev/sdb; fi", ClassReadOnly},
+
+		// Mutating
+		{"systemctl restart kubelet", ClassMutating},
+		{"echo test > /tmp/out", ClassMutating},
+		{"sed -i s/a/b/ /etc/fstab", ClassMutating},
+		{"rm /tmp/file", ClassMutating},
+		{"kubectl apply -f pvc.yaml", ClassMutating},
+		{"kubectl delete pod foo", ClassMutating},
+		{"mount /dev/sdb1 /mnt", ClassMutating},
+		{"journalctl --vacuum-time=1d", ClassMutating},
+		{"some-unknown-tool --flag", ClassMutating},
+		{"find /tmp -name x -exec touch {} ;", ClassMutating},
+		{"awk '{ system(\"rm \" $1) }' list", ClassMutating},
+		{"awk '{ print $0 > \"/etc/fstab\" }' fstab.new", ClassMutating},
+		{"awk -f script.awk data", ClassMutating},
+		{"sed -n 'w /etc/fstab' fstab.new", ClassMutating},
+		{"sed 's/.*/rm &/e' list", ClassMutating},
+		{"sed -ni 1d /etc/fstab", ClassMutating},
+		{"date -s '2026-01-01 00:00'", ClassMutating},
+		{"date 010100002026", ClassMutating},
+		{"hostname node-2", ClassMutating},
+		{"fuser -km /var/lib/kubelet/pods/x", ClassMutating},
+		{"mount -a", ClassMutating},
+		{"yq -i '.a = 1' values.yaml", ClassMutating},
+		{"sort -o /etc/fstab /tmp/x", ClassMutating},
+		{"uniq /tmp/a /etc/fstab", ClassMutating},
+		{"ip vrf exec blue systemctl restart kubelet", ClassMutating},
+		{"kubectl auth reconcile -f rbac.yaml", ClassMutating},
+		{"for i in 1 2 3; do systemctl restart kubelet; done", ClassMutating},
+
+		// Destructive
+		{"mkfs.ext4 /dev/sdb", ClassDestructive},
+		{"sudo wipefs -a /dev/sdb", ClassDestructive},
+		{"dd if=/dev/zero of=/dev/sdb bs=1M", ClassDestructive},
+		{"rm -rf /var/lib/rook", ClassDestructive},
+		{"echo $(rm -rf /data)", ClassDestructive},
+		{"bash -c 'shred /dev/sda'", ClassDestructive},
+		{"echo x > /dev/sdb", ClassDestructive},
+		{"echo x | tee /dev/nvme0n1", ClassDestructive},
+		{"kubectl delete pvc data-0", ClassDestructive},
+		{"kubectl delete pod --all", ClassDestructive},
+		{"ceph osd purge 3 --yes-i-really-mean-it", ClassDestructive},
+		{"zpool destroy tank", ClassDestructive},
+		{"sgdisk --zap-all /dev/sdb", ClassDestructive},
+		{"find /data -delete", ClassDestructive},
+		{"lsblk && mkfs.xfs /dev/sdc", ClassDestructive},
+		{"wipefs -af /dev/sdb", ClassDestructive},
+		{"ceph -n client.admin osd pool delete rbd rbd --yes-i-really-really-mean-it", ClassDestructive},
+		{"rbd -p replicapool rm pvc-1234", ClassDestructive},
+		{"parted -s /dev/sdb mklabel gpt", ClassDestructive},
+		{"parted /dev/sdb print rm 1", ClassDestructive},
+		{"sgdisk -p -d 1 /dev/sdb", ClassDestructive},
+		{"for d in sdb sdc; do wipefs -a /dev/$d; done", ClassDestructive},
+		{"while true; do rm -rf /var/lib/rook; done", ClassDestructive},
+		{"ip netns exec ns1 rm -rf /var/lib/etcd", ClassDestructive},
+		{"ip -all netns exec wipefs -a /dev/sdb", ClassDestructive},
+	}
+
+	for _, tt := range tests {
+		if got := classify(tt.command); got != tt.expected {
+			t.Errorf("classify(%q) = %s, want %s", tt.command, got, tt.expected)
+		}
+	}
+}
+
+func TestClassifySegment_Reason(t *testing.T) {
+	class, reason := ClassifySegment(Segment{Args: []string{"mkfs.ext4", "/dev/sdb"}})
+	if class != ClassDestructive {
+		t.Fatalf("Expected destructive, got %s", class)
+	}
+	if reason == "" {
+		t.Error("Expected a reason for destructive command")
+	}
+}
+
+func TestClassLabel(t *testing.T) {
+	for _, c := range []Class{ClassReadOnly, ClassMutating, ClassDestructive} {
+		if c.Label() == "" || c.String() == "" {
+			t.Errorf("Expected non-empty label and name for %d", c)
+		}
+	}
+}
+
+func TestScanArgs(t *testing.T) {
+	scanned := scanArgs([]string{"-af", "-n", "client.admin", "-Fvalue", "--format=json", "osd", "--", "rm"}, map[string]bool{"-n": true, "-F": true})
+	for _, flag := range []string{"-af", "-a", "-f", "-n", "-F", "--format"} {
+		if !scanned.has(flag) {
+			t.Errorf("Expected flag %s in %v", flag, scanned.flags)
+		}
+	}
+	if got := scanned.valuesOf("-n", "-F", "--format"); strings.Join(got, ",") != "client.admin,value,json" {
+		t.Errorf("Unexpected values %v", got)
+	}
+	if strings.Join(scanned.positional, ",") != "osd" {
+		t.Errorf("Expected only osd as positional, got %v", scanned.positional)
+	}
+}
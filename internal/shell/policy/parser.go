@@ -0,0 +1,420 @@
+package policy
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// Redirect represents an output or input redirection attached to a command
+type Redirect struct {
+	Op     string // ">", ">>", "<", ">&", "&>" ...
+	Target string
+}
+
+// Segment is a single simple command extracted from a shell command line
+type Segment struct {
+	Args      []string
+	Redirects []Redirect
+}
+
+// Program returns the base name of the executed program
+func (s Segment) Program() string {
+	if len(s.Args) == 0 {
+		return ""
+	}
+	return filepath.Base(s.Args[0])
+}
+
+// String returns the segment as a space separated command
+func (s Segment) String() string {
+	return strings.Join(s.Args, " ")
+}
+
+// Parse splits a shell command line into simple commands.
+// Pipelines, lists (&&, ||, ;, &), command substitutions ($(...), `...`),
+// process substitutions and "sh -c" strings are all flattened, and wrapper
+// programs such as sudo, env, xargs and timeout are stripped so that each
+// segment starts with the program that actually runs.
+func Parse(command string) []Segment {
+	return parseDepth(command, 0)
+}
+
+// maxParseDepth bounds recursion through nested substitutions and sh -c strings
+const maxParseDepth = 8
+
+func parseDepth(command string, depth int) []Segment {
+	if depth > maxParseDepth {
+		return nil
+	}
+
+	lex := &lexer{src: []rune(command)}
+	raw := lex.run()
+
+	var segments []Segment
+	for _, seg := range raw {
+		args, nested := unwrap(seg.Args, depth)
+		segments = append(segments, nested...)
+		if len(args) > 0 || len(seg.Redirects) > 0 {
+			segments = append(segments, Segment{Args: args, Redirects: seg.Redirects})
+		}
+	}
+	for _, sub := range lex.subs {
+		segments = append(segments, parseDepth(sub, depth+1)...)
+	}
+	return segments
+}
+
+type lexer struct {
+	src  []rune
+	pos  int
+	subs []string
+}
+
+func (l *lexer) peek(offset int) rune {
+	if l.pos+offset >= len(l.src) {
+		return 0
+	}
+	return l.src[l.pos+offset]
+}
+
+// run tokenizes the input and groups words into segments
+func (l *lexer) run() []Segment {
+	var segments []Segment
+	current := Segment{}
+	flush := func() {
+		if len(current.Args) > 0 || len(current.Redirects) > 0 {
+			segments = append(segments, current)
+		}
+		current = Segment{}
+	}
+
+	for l.pos < len(l.src) {
+		r := l.src[l.pos]
+		switch {
+		case r == ' ' || r == '\t' || r == '\r':
+			l.pos++
+		case r == '\n' || r == ';' || r == '|' || r == '&' && l.peek(1) != '>':
+			// Command separators: ;, newline, |, ||, |&, &&, &
+			l.pos++
+			if (r == '|' || r == '&') && (l.peek(0) == '|' || l.peek(0) == '&') {
+				l.pos++
+			}
+			flush()
+		case r == '#' && l.atWordStart():
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		case r == '(' || r == ')' || r == '{' && l.isGroupBrace() || r == '}' && l.isGroupBrace():
+			// Subshells and groups only change scoping, not what runs
+			l.pos++
+			flush()
+		case l.isRedirectStart():
+			current.Redirects = append(current.Redirects, l.readRedirect())
+		default:
+			word := l.readWord()
+			if word != "" {
+				current.Args = append(current.Args, word)
+			}
+		}
+	}
+	flush()
+	return segments
+}
+
+func (l *lexer) atWordStart() bool {
+	return l.pos == 0 || unicode.IsSpace(l.src[l.pos-1]) || strings.ContainsRune(";|&(", l.src[l.pos-1])
+}
+
+func (l *lexer) isGroupBrace() bool {
+	next := l.peek(1)
+	return l.atWordStart() && (next == 0 || unicode.IsSpace(next) || next == ';')
+}
+
+// isRedirectStart reports whether a redirection operator starts at the current position.
+// Process substitutions (<(...) and >(...)) are handled as words.
+func (l *lexer) isRedirectStart() bool {
+	offset := 0
+	for unicode.IsDigit(l.peek(offset)) {
+		offset++
+	}
+	r := l.peek(offset)
+	if offset > 0 && !l.atWordStart() {
+		return false
+	}
+	switch r {
+	case '>', '<':
+		return l.peek(offset+1) != '('
+	case '&':
+		return offset == 0 && l.peek(1) == '>'
+	}
+	return false
+}
+
+func (l *lexer) readRedirect() Redirect {
+	for unicode.IsDigit(l.peek(0)) {
+		l.pos++
+	}
+	var op strings.Builder
+	for l.pos < len(l.src) && strings.ContainsRune("<>&|", l.src[l.pos]) && op.Len() < 3 {
+		op.WriteRune(l.src[l.pos])
+		l.pos++
+	}
+	for l.pos < len(l.src) && (l.src[l.pos] == ' ' || l.src[l.pos] == '\t') {
+		l.pos++
+	}
+	return Redirect{Op: op.String(), Target: l.readWord()}
+}
+
+// readWord reads a single shell word, resolving quotes and recording substitutions
+func (l *lexer) readWord() string {
+	var word strings.Builder
+	for l.pos < len(l.src) {
+		r := l.src[l.pos]
+		switch {
+		case unicode.IsSpace(r) || strings.ContainsRune(";|&()", r) && !l.isSubstitutionStart():
+			return word.String()
+		case (r == '<' || r == '>') && l.peek(1) == '(':
+			l.pos += 2
+			l.subs = append(l.subs, l.readUntilClose())
+			word.WriteString("<(...)")
+		case r == '<' || r == '>':
+			return word.String()
+		case r == '\\':
+			if l.pos+1 < len(l.src) {
+				if l.src[l.pos+1] != '\n' {
+					word.WriteRune(l.src[l.pos+1])
+				}
+				l.pos += 2
+			} else {
+				l.pos++
+			}
+		case r == '\'':
+			l.pos++
+			for l.pos < len(l.src) && l.src[l.pos] != '\'' {
+				word.WriteRune(l.src[l.pos])
+				l.pos++
+			}
+			l.pos++
+		case r == '"':
+			l.pos++
+			l.readDoubleQuoted(&word)
+		case l.isSubstitutionStart():
+			l.readSubstitution(&word)
+		case r == '`':
+			l.pos++
+			start := l.pos
+			for l.pos < len(l.src) && l.src[l.pos] != '`' {
+				l.pos++
+			}
+			l.subs = append(l.subs, string(l.src[start:min(l.pos, len(l.src))]))
+			l.pos++
+			word.WriteString("`...`")
+		default:
+			word.WriteRune(r)
+			l.pos++
+		}
+	}
+	return word.String()
+}
+
+func (l *lexer) isSubstitutionStart() bool {
+	return l.pos < len(l.src) && l.src[l.pos] == '$' && l.peek(1) == '('
+}
+
+func (l *lexer) readSubstitution(word *strings.Builder) {
+	if l.peek(2) == '(' {
+		// Arithmetic expansion $((...)) runs no commands
+		l.pos += 3
+		l.readUntilClose()
+		if l.peek(0) == ')' {
+			l.pos++
+		}
+		word.WriteString("$((...))")
+		return
+	}
+	l.pos += 2
+	l.subs = append(l.subs, l.readUntilClose())
+	word.WriteString("$(...)")
+}
+
+func (l *lexer) readDoubleQuoted(word *strings.Builder) {
+	for l.pos < len(l.src) {
+		r := l.src[l.pos]
+		switch {
+		case r == '"':
+			l.pos++
+			return
+		case r == '\\' && l.pos+1 < len(l.src) && strings.ContainsRune("\"\\$`", l.src[l.pos+1]):
+			word.WriteRune(l.src[l.pos+1])
+			l.pos += 2
+		case l.isSubstitutionStart():
+			l.readSubstitution(word)
+		case r == '`':
+			l.pos++
+			start := l.pos
+			for l.pos < len(l.src) && l.src[l.pos] != '`' {
+				l.pos++
+			}
+			l.subs = append(l.subs, string(l.src[start:min(l.pos, len(l.src))]))
+			l.pos++
+			word.WriteString("`...`")
+		default:
+			word.WriteRune(r)
+			l.pos++
+		}
+	}
+}
+
+// readUntilClose returns the text up to the matching closing parenthesis and consumes it
+func (l *lexer) readUntilClose() string {
+	start := l.pos
+	depth := 1
+	var quote rune
+	for l.pos < len(l.src) {
+		r := l.src[l.pos]
+		switch {
+		case quote != 0:
+			if r == '\\' && quote == '"' {
+				l.pos++
+			} else if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '\\':
+			l.pos++
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+			if depth == 0 {
+				inner := string(l.src[start:l.pos])
+				l.pos++
+				return inner
+			}
+		}
+		l.pos++
+	}
+	return string(l.src[start:min(l.pos, len(l.src))])
+}
+
+// unwrap strips wrapper programs and leading variable assignments.
+// It returns the remaining arguments and any segments parsed from
+// embedded command strings (sh -c "...").
+func unwrap(args []string, depth int) ([]string, []Segment) {
+	for len(args) > 0 {
+		if isAssignment(args[0]) {
+			args = args[1:]
+			continue
+		}
+		// Compound command keywords prefix the command that actually runs;
+		// for/select/case headers only bind words, whose substitutions are
+		// parsed on their own
+		switch args[0] {
+		case "if", "then", "elif", "else", "fi", "while", "until", "do", "done", "esac", "!":
+			args = args[1:]
+			continue
+		case "for", "select", "case":
+			return nil, nil
+		}
+
+		switch filepath.Base(args[0]) {
+		case "sudo", "doas":
+			args = skipOptions(args[1:], map[string]bool{"-u": true, "-g": true, "-C": true, "-h": true, "-p": true, "-U": true, "-r": true, "-t": true, "-D": true, "--user": true, "--group": true})
+		case "env":
+			args = skipOptions(args[1:], map[string]bool{"-u": true, "-C": true, "-S": true, "--unset": true, "--chdir": true})
+		case "nohup", "time", "exec", "command", "builtin", "setsid", "unbuffer":
+			args = skipOptions(args[1:], nil)
+		case "nice", "ionice", "stdbuf", "chrt", "taskset":
+			args = skipOptions(args[1:], map[string]bool{"-n": true, "-c": true, "-p": true, "--adjustment": true, "--class": true, "--classdata": true})
+		case "watch":
+			args = skipOptions(args[1:], map[string]bool{"-n": true, "--interval": true, "-d": false})
+		case "timeout":
+			args = skipOptions(args[1:], map[string]bool{"-s": true, "-k": true, "--signal": true, "--kill-after": true})
+			if len(args) > 0 {
+				args = args[1:] // duration
+			}
+		case "xargs":
+			args = skipOptions(args[1:], map[string]bool{"-I": true, "-i": false, "-n": true, "-P": true, "-d": true, "-L": true, "-s": true, "-E": true, "-a": true, "--max-args": true, "--max-procs": true, "--delimiter": true, "--arg-file": true, "--replace": true})
+		case "nsenter":
+			args = skipOptions(args[1:], map[string]bool{"-t": true, "--target": true, "-S": true, "-G": true, "--setuid": true, "--setgid": true, "-w": false})
+		case "chroot":
+			args = skipOptions(args[1:], nil)
+			if len(args) > 0 {
+				args = args[1:] // new root
+			}
+		case "ip":
+			// ip netns exec NAME cmd and ip vrf exec NAME cmd run cmd; with
+			// -all, netns exec runs it in every namespace and takes no name
+			rest := skipOptions(args[1:], map[string]bool{"-n": true, "-netns": true, "-f": true, "-family": true, "-b": true, "-batch": true, "-rc": true, "-rcvbuf": true})
+			if len(rest) < 3 || (rest[0] != "netns" && rest[0] != "vrf") || rest[1] != "exec" {
+				return args, nil
+			}
+			all := false
+			for _, opt := range args[1 : len(args)-len(rest)] {
+				if opt == "-all" || opt == "-a" {
+					all = true
+				}
+			}
+			if all && rest[0] == "netns" {
+				args = rest[2:]
+			} else {
+				args = rest[3:]
+			}
+		case "sh", "bash", "zsh", "dash", "ksh", "ash":
+			for i := 1; i < len(args); i++ {
+				if strings.HasPrefix(args[i], "-") && !strings.HasPrefix(args[i], "--") && strings.Contains(args[i], "c") && i+1 < len(args) {
+					return nil, parseDepth(args[i+1], depth+1)
+				}
+			}
+			return args, nil
+		default:
+			return args, nil
+		}
+	}
+	return args, nil
+}
+
+// skipOptions drops leading options; valueFlags lists options that consume the next argument
+func skipOptions(args []string, valueFlags map[string]bool) []string {
+	for len(args) > 0 {
+		arg := args[0]
+		if arg == "--" {
+			return args[1:]
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			// Bare assignments (env FOO=bar cmd) are also skipped
+			if isAssignment(arg) {
+				args = args[1:]
+				continue
+			}
+			return args
+		}
+		args = args[1:]
+		if valueFlags[arg] && !strings.Contains(arg, "=") && len(args) > 0 {
+			args = args[1:]
+		}
+	}
+	return args
+}
+
+func isAssignment(arg string) bool {
+	idx := strings.Index(arg, "=")
+	if idx <= 0 {
+		return false
+	}
+	for i, r := range arg[:idx] {
+		if !(r == '_' || unicode.IsLetter(r) || i > 0 && unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,125 @@
+package policy
+
+import (
+	"reflect"
+	"testing"
+)
+
+func programs(segments []Segment) []string {
+	var names []string
+	for _, seg := range segments {
+		names = append(names, seg.Program())
+	}
+	return names
+}
+
+func TestParse_Separators(t *testing.T) {
+	tests := []struct {
+		command  string
+		expected []string
+	}{
+		{"ls -l", []string{"ls"}},
+		{"ls | grep foo", []string{"ls", "grep"}},
+		{"lsblk && mkfs.ext4 /dev/sdb", []string{"lsblk", "mkfs.ext4"}},
+		{"df -h; rm -rf /data || echo fail", []string{"df", "rm", "echo"}},
+		{"sleep 1 & ps aux", []string{"sleep", "ps"}},
+		{"(cd /tmp && ls)", []string{"cd", "ls"}},
+		{"{ df; lsblk; }", []string{"df", "lsblk"}},
+		{"ls # ; rm -rf /", []string{"ls"}},
+		{"echo a\nrm b", []string{"echo", "rm"}},
+		{"for f in $(ls); do rm $f; done", []string{"rm", "ls"}},
+		{"while true; do df; done", []string{"true", "df"}},
+		{"if lsblk; then echo ok; else echo no; fi", []string{"lsblk", "echo", "echo"}},
+		{"ip netns exec ns1 rm -rf /data", []string{"rm"}},
+		{"ip -n ns1 addr", []string{"ip"}},
+	}
+
+	for _, tt := range tests {
+		got := programs(Parse(tt.command))
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Parse(%q) programs = %v, want %v", tt.command, got, tt.expected)
+		}
+	}
+}
+
+func TestParse_Quotes(t *testing.T) {
+	segments := Parse(`grep "a | b; rm -rf /" 'c && d' e\ f`)
+	if len(segments) != 1 {
+		t.Fatalf("Expected 1 segment, got %d: %v", len(segments), segments)
+	}
+	expected := []string{"grep", "a | b; rm -rf /", "c && d", "e f"}
+	if !reflect.DeepEqual(segments[0].Args, expected) {
+		t.Errorf("Expected args %v, got %v", expected, segments[0].Args)
+	}
+}
+
+func TestParse_Substitutions(t *testing.T) {
+	tests := []struct {
+		command  string
+		expected []string
+	}{
+		{"echo $(rm -rf /data)", []string{"echo", "rm"}},
+		{"echo \"$(wipefs -a /dev/sdb)\"", []string{"echo", "wipefs"}},
+		{"echo `shred /dev/sda`", []string{"echo", "shred"}},
+		{"diff <(lsblk) <(cat /tmp/a)", []string{"diff", "lsblk", "cat"}},
+		{"echo $((1 + 2))", []string{"echo"}},
+	}
+
+	for _, tt := range tests {
+		got := programs(Parse(tt.command))
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Parse(%q) programs = %v, want %v", tt.command, got, tt.expected)
+		}
+	}
+}
+
+func TestParse_Wrappers(t *testing.T) {
+	tests := []struct {
+		command  string
+		expected string
+	}{
+		{"sudo -u root mkfs.xfs /dev/sdb", "mkfs.xfs /dev/sdb"},
+		{"FOO=bar env -u X BAZ=1 lsblk -f", "lsblk -f"},
+		{"timeout -s KILL 10 dd if=/dev/zero of=/dev/sda", "dd if=/dev/zero of=/dev/sda"},
+		{"nohup nice -n 10 /usr/sbin/fdisk /dev/sdb", "/usr/sbin/fdisk /dev/sdb"},
+		{"xargs -I {} rm -rf {}", "rm -rf {}"},
+		{"bash -c 'rm -rf /var/lib/data'", "rm -rf /var/lib/data"},
+		{"sudo sh -ec \"wipefs -a /dev/sdc\"", "wipefs -a /dev/sdc"},
+	}
+
+	for _, tt := range tests {
+		segments := Parse(tt.command)
+		if len(segments) != 1 {
+			t.Errorf("Parse(%q) expected 1 segment, got %v", tt.command, segments)
+			continue
+		}
+		if got := segments[0].String(); got != tt.expected {
+			t.Errorf("Parse(%q) = %q, want %q", tt.command, got, tt.expected)
+		}
+	}
+}
+
+func TestParse_Redirects(t *testing.T) {
+	segments := Parse("lsblk 2>/dev/null >out.txt 2>&1 < in.txt")
+	if len(segments) != 1 {
+		t.Fatalf("Expected 1 segment, got %v", segments)
+	}
+	expected := []Redirect{
+		{Op: ">", Target: "/dev/null"},
+		{Op: ">", Target: "out.txt"},
+		{Op: ">&", Target: "1"},
+		{Op: "<", Target: "in.txt"},
+	}
+	if !reflect.DeepEqual(segments[0].Redirects, expected) {
+		t.Errorf("Expected redirects %v, got %v", expected, segments[0].Redirects)
+	}
+	if !reflect.DeepEqual(segments[0].Args, []string{"lsblk"}) {
+		t.Errorf("Expected args [lsblk], got %v", segments[0].Args)
+	}
+}
+
+func TestParse_Empty(t *testing.T) {
+	if segments := Parse("   "); len(segments) != 0 {
+		t.Errorf("Expected no segments, got %v", segments)
+	}
+}
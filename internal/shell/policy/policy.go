@@ -0,0 +1,220 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mainbong/storage_doctor/internal/filesystem"
+)
+
+// Decision is what the caller should do with a command
+type Decision int
+
+const (
+	DecisionAllow   Decision = iota // Run without asking
+	DecisionAsk                     // Ask the user for approval
+	DecisionConfirm                 // Require the user to type a confirmation phrase
+	DecisionDeny                    // Never run
+)
+
+// Rules is the user-editable rule file format.
+// Patterns are matched against each simple command (after sudo/env/xargs
+// wrappers are stripped); "*" matches any sequence of characters.
+type Rules struct {
+	// Allow marks matching commands as read-only so they may be auto-approved
+	Allow []string `json:"allow"`
+	// Deny blocks matching commands entirely
+	Deny []string `json:"deny"`
+	// Confirm treats matching commands as destructive
+	Confirm []string `json:"confirm"`
+	// BlockDestructive blocks destructive commands instead of asking for typed confirmation
+	BlockDestructive bool `json:"block_destructive"`
+}
+
+// Evaluation is the result of classifying a command
+type Evaluation struct {
+	Command  string
+	Class    Class
+	Reason   string
+	Denied   bool
+	Segments []Segment
+	// Critical is the segment that determined the class
+	Critical Segment
+}
+
+// ConfirmationText returns the phrase the user must type to confirm a destructive command
+func (e Evaluation) ConfirmationText() string {
+	args := e.Critical.Args
+	if len(args) == 0 {
+		return "yes"
+	}
+	parts := []string{e.Critical.Program()}
+	for _, arg := range args[1:] {
+		if len(parts) >= 3 {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			parts = append(parts, arg)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Engine classifies commands and applies user rules
+type Engine struct {
+	rules   Rules
+	allow   []*regexp.Regexp
+	deny    []*regexp.Regexp
+	confirm []*regexp.Regexp
+}
+
+// NewEngine creates a policy engine from the rule file at path, creating it with defaults if missing
+func NewEngine(path string) (*Engine, error) {
+	return NewEngineWithFS(path, filesystem.NewOSFileSystem())
+}
+
+// NewEngineWithFS creates a policy engine using a custom FileSystem (for testing)
+func NewEngineWithFS(path string, fs filesystem.FileSystem) (*Engine, error) {
+	rules := Rules{Allow: []string{}, Deny: []string{}, Confirm: []string{}}
+
+	if _, err := fs.Stat(path); err == nil {
+		data, err := fs.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read policy file: %w", err)
+		}
+		if err := json.Unmarshal(data, &rules); err != nil {
+			return nil, fmt.Errorf("failed to parse policy file: %w", err)
+		}
+	} else {
+		data, err := json.MarshalIndent(rules, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal policy file: %w", err)
+		}
+		if err := fs.WriteFile(path, data, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write default policy file: %w", err)
+		}
+	}
+
+	return NewEngineWithRules(rules)
+}
+
+// NewEngineWithRules creates a policy engine from in-memory rules
+func NewEngineWithRules(rules Rules) (*Engine, error) {
+	engine := &Engine{rules: rules}
+	var err error
+	if engine.allow, err = compilePatterns(rules.Allow); err != nil {
+		return nil, fmt.Errorf("invalid allow rule: %w", err)
+	}
+	if engine.deny, err = compilePatterns(rules.Deny); err != nil {
+		return nil, fmt.Errorf("invalid deny rule: %w", err)
+	}
+	if engine.confirm, err = compilePatterns(rules.Confirm); err != nil {
+		return nil, fmt.Errorf("invalid confirm rule: %w", err)
+	}
+	return engine, nil
+}
+
+// Evaluate parses and classifies a command. The command's class is the most
+// dangerous class among all of its simple commands.
+func (e *Engine) Evaluate(command string) Evaluation {
+	segments := Parse(command)
+	evaluation := Evaluation{
+		Command:  command,
+		Class:    ClassReadOnly,
+		Segments: segments,
+	}
+
+	if len(segments) == 0 {
+		evaluation.Class = ClassMutating
+		evaluation.Reason = "명령어를 해석할 수 없습니다"
+		return evaluation
+	}
+
+	for i, seg := range segments {
+		text := seg.String()
+		if matchAny(e.deny, text) {
+			evaluation.Denied = true
+			evaluation.Class = ClassDestructive
+			evaluation.Reason = fmt.Sprintf("거부 규칙에 일치 (%s)", text)
+			evaluation.Critical = seg
+			return evaluation
+		}
+
+		var class Class
+		var reason string
+		switch {
+		case matchAny(e.confirm, text):
+			class, reason = ClassDestructive, fmt.Sprintf("확인 규칙에 일치 (%s)", text)
+		case matchAny(e.allow, text) && !hasWriteRedirect(seg):
+			class = ClassReadOnly
+		default:
+			class, reason = ClassifySegment(seg)
+		}
+
+		if i == 0 || class > evaluation.Class {
+			evaluation.Class = class
+			evaluation.Reason = reason
+			evaluation.Critical = seg
+		}
+	}
+
+	if evaluation.Class == ClassDestructive && e.rules.BlockDestructive {
+		evaluation.Denied = true
+	}
+	return evaluation
+}
+
+// Decide returns what to do with an evaluated command. Auto-approval only
+// ever applies to read-only commands.
+func (e *Engine) Decide(evaluation Evaluation, autoApprove bool) Decision {
+	switch {
+	case evaluation.Denied:
+		return DecisionDeny
+	case evaluation.Class == ClassDestructive:
+		return DecisionConfirm
+	case evaluation.Class == ClassReadOnly && autoApprove:
+		return DecisionAllow
+	default:
+		return DecisionAsk
+	}
+}
+
+func hasWriteRedirect(seg Segment) bool {
+	for _, r := range seg.Redirects {
+		if class, _ := classifyRedirect(r); class > ClassReadOnly {
+			return true
+		}
+	}
+	return false
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		pattern = strings.Join(strings.Fields(pattern), " ")
+		if pattern == "" {
+			continue
+		}
+		parts := strings.Split(pattern, "*")
+		for i, part := range parts {
+			parts[i] = regexp.QuoteMeta(part)
+		}
+		re, err := regexp.Compile("^" + strings.Join(parts, ".*") + "$")
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+func matchAny(patterns []*regexp.Regexp, text string) bool {
+	for _, re := range patterns {
+		if re.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package policy
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mainbong/storage_doctor/internal/filesystem"
+)
+
+func TestNewEngineWithFS_CreatesDefaultFile(t *testing.T) {
+	mockFS := filesystem.NewMockFileSystem()
+
+	engine, err := NewEngineWithFS("/config/command_policy.json", mockFS)
+	if err != nil {
+		t.Fatalf("NewEngineWithFS() failed: %v", err)
+	}
+	if engine == nil {
+		t.Fatal("NewEngineWithFS() returned nil")
+	}
+
+	data := mockFS.GetFile("/config/command_policy.json")
+	if data == nil {
+		t.Fatal("Expected default policy file to be written")
+	}
+	var rules Rules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		t.Fatalf("Default policy file is not valid JSON: %v", err)
+	}
+}
+
+func TestNewEngineWithFS_LoadsRules(t *testing.T) {
+	mockFS := filesystem.NewMockFileSystem()
+	mockFS.AddFile("/config/command_policy.json", []byte(`{
+		"allow": ["my-inspect *"],
+		"deny": ["rm -rf /"],
+		"confirm": ["kubectl drain *"]
+	}`), 0644)
+
+	engine, err := NewEngineWithFS("/config/command_policy.json", mockFS)
+	if err != nil {
+		t.Fatalf("NewEngineWithFS() failed: %v", err)
+	}
+
+	if got := engine.Evaluate("my-inspect --all").Class; got != ClassReadOnly {
+		t.Errorf("Expected allow rule to make command read-only, got %s", got)
+	}
+	if !engine.Evaluate("sudo rm -rf /").Denied {
+		t.Error("Expected deny rule to match after stripping sudo")
+	}
+	if got := engine.Evaluate("kubectl drain node-1").Class; got != ClassDestructive {
+		t.Errorf("Expected confirm rule to make command destructive, got %s", got)
+	}
+}
+
+func TestNewEngineWithFS_InvalidFile(t *testing.T) {
+	mockFS := filesystem.NewMockFileSystem()
+	mockFS.AddFile("/config/command_policy.json", []byte("{invalid"), 0644)
+
+	if _, err := NewEngineWithFS("/config/command_policy.json", mockFS); err == nil {
+		t.Error("Expected error for invalid policy file")
+	}
+}
+
+func TestEvaluate_AllowRuleDoesNotCoverRedirect(t *testing.T) {
+	engine, err := NewEngineWithRules(Rules{Allow: []string{"my-inspect*"}})
+	if err != nil {
+		t.Fatalf("NewEngineWithRules() failed: %v", err)
+	}
+
+	if got := engine.Evaluate("my-inspect > /etc/fstab").Class; got == ClassReadOnly {
+		t.Error("Expected write redirect to override allow rule")
+	}
+}
+
+func TestEvaluate_MostDangerousSegmentWins(t *testing.T) {
+	engine, _ := NewEngineWithRules(Rules{})
+
+	evaluation := engine.Evaluate("lsblk; systemctl restart kubelet && wipefs -a /dev/sdb")
+	if evaluation.Class != ClassDestructive {
+		t.Fatalf("Expected destructive, got %s", evaluation.Class)
+	}
+	if evaluation.Critical.Program() != "wipefs" {
+		t.Errorf("Expected critical segment wipefs, got %s", evaluation.Critical.Program())
+	}
+	if len(evaluation.Segments) != 3 {
+		t.Errorf("Expected 3 segments, got %d", len(evaluation.Segments))
+	}
+}
+
+func TestEvaluate_BlockDestructive(t *testing.T) {
+	engine, _ := NewEngineWithRules(Rules{BlockDestructive: true})
+
+	if !engine.Evaluate("mkfs.ext4 /dev/sdb").Denied {
+		t.Error("Expected destructive command to be denied")
+	}
+	if engine.Evaluate("systemctl restart kubelet").Denied {
+		t.Error("Expected mutating command not to be denied")
+	}
+}
+
+func TestDecide(t *testing.T) {
+	engine, _ := NewEngineWithRules(Rules{Deny: []string{"reboot*"}})
+
+	tests := []struct {
+		command     string
+		autoApprove bool
+		expected    Decision
+	}{
+		{"lsblk", true, DecisionAllow},
+		{"lsblk", false, DecisionAsk},
+		{"systemctl restart kubelet", true, DecisionAsk},
+		{"mkfs.ext4 /dev/sdb", true, DecisionConfirm},
+		{"reboot now", true, DecisionDeny},
+	}
+
+	for _, tt := range tests {
+		evaluation := engine.Evaluate(tt.command)
+		if got := engine.Decide(evaluation, tt.autoApprove); got != tt.expected {
+			t.Errorf("Decide(%q, %v) = %d, want %d", tt.command, tt.autoApprove, got, tt.expected)
+		}
+	}
+}
+
+func TestConfirmationText(t *testing.T) {
+	engine, _ := NewEngineWithRules(Rules{})
+
+	tests := []struct {
+		command  string
+		expected string
+	}{
+		{"sudo mkfs.ext4 -F /dev/sdb", "mkfs.ext4 /dev/sdb"},
+		{"wipefs -a /dev/sdb", "wipefs /dev/sdb"},
+		{"kubectl delete pvc data-0 -n app", "kubectl delete pvc"},
+		{"zpool destroy tank", "zpool destroy tank"},
+	}
+
+	for _, tt := range tests {
+		if got := engine.Evaluate(tt.command).ConfirmationText(); got != tt.expected {
+			t.Errorf("ConfirmationText(%q) = %q, want %q", tt.command, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package shell
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sync"
+
+	"github.com/mainbong/storage_doctor/internal/filesystem"
+)
+
+// CommandFixture is a recorded sequence of shell commands and their results
+type CommandFixture struct {
+	Commands []RecordedCommand `json:"commands"`
+}
+
+// RecordedCommand is one command run through a CommandExecutor
+type RecordedCommand struct {
+	Command  string `json:"command"`
+	Dir      string `json:"dir,omitempty"`
+	Output   string `json:"output"`
+	Error    string `json:"error,omitempty"`
+	ExitCode int    `json:"exit_code,omitempty"`
+}
+
+// CommandRecorder runs commands on another CommandExecutor and records them to a fixture file
+type CommandRecorder struct {
+	mu      sync.Mutex
+	next    CommandExecutor
+	fs      filesystem.FileSystem
+	path    string
+	fixture CommandFixture
+}
+
+// NewCommandRecorder creates a CommandRecorder writing to path
+func NewCommandRecorder(next CommandExecutor, path string) *CommandRecorder {
+	return NewCommandRecorderWithFS(next, path, filesystem.NewOSFileSystem())
+}
+
+// NewCommandRecorderWithFS creates a CommandRecorder with a custom FileSystem (for testing)
+func NewCommandRecorderWithFS(next CommandExecutor, path string, fs filesystem.FileSystem) *CommandRecorder {
+	return &CommandRecorder{next: next, fs: fs, path: path}
+}
+
+func (r *CommandRecorder) Execute(command string, dir string) ([]byte, error) {
+	output, err := r.next.Execute(command, dir)
+
+	recorded := RecordedCommand{Command: command, Dir: dir, Output: string(output)}
+	if err != nil {
+		recorded.Error = err.Error()
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			recorded.ExitCode = exitErr.ExitCode()
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fixture.Commands = append(r.fixture.Commands, recorded)
+	if saveErr := r.save(); saveErr != nil {
+		return output, saveErr
+	}
+	return output, err
+}
+
+// Fixture returns the commands recorded so far
+func (r *CommandRecorder) Fixture() CommandFixture {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return CommandFixture{Commands: append([]RecordedCommand(nil), r.fixture.Commands...)}
+}
+
+func (r *CommandRecorder) save() error {
+	data, err := marshalFixture(r.fixture)
+	if err != nil {
+		return fmt.Errorf("failed to marshal command fixture: %w", err)
+	}
+	if err := r.fs.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("failed to create fixture directory: %w", err)
+	}
+	if err := r.fs.WriteFile(r.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write command fixture: %w", err)
+	}
+	return nil
+}
+
+// CommandReplayer answers commands from a fixture file without running anything
+type CommandReplayer struct {
+	mu       sync.Mutex
+	path     string
+	fixture  CommandFixture
+	served   map[string]int // Recordings already replayed per command
+	commands []string
+}
+
+// NewCommandReplayer creates a CommandReplayer reading the fixture at path
+func NewCommandReplayer(path string) (*CommandReplayer, error) {
+	return NewCommandReplayerWithFS(path, filesystem.NewOSFileSystem())
+}
+
+// NewCommandReplayerWithFS creates a CommandReplayer with a custom FileSystem (for testing)
+func NewCommandReplayerWithFS(path string, fs filesystem.FileSystem) (*CommandReplayer, error) {
+	data, err := fs.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read command fixture: %w", err)
+	}
+	var fixture CommandFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("failed to parse command fixture %s: %w", path, err)
+	}
+	return &CommandReplayer{path: path, fixture: fixture, served: make(map[string]int)}, nil
+}
+
+// Execute returns the recorded result of command. A command run several times
+// gets its recordings in order, and the last one once they are used up.
+func (r *CommandReplayer) Execute(command string, dir string) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.commands = append(r.commands, command)
+
+	var matches []RecordedCommand
+	for _, recorded := range r.fixture.Commands {
+		if recorded.Command == command {
+			matches = append(matches, recorded)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no recorded result in %s for command: %s", r.path, command)
+	}
+	index := r.served[command]
+	if index >= len(matches) {
+		index = len(matches) - 1
+	}
+	r.served[command]++
+
+	recorded := matches[index]
+	if recorded.Error != "" {
+		return []byte(recorded.Output), &ReplayedError{Message: recorded.Error, ExitCode: recorded.ExitCode}
+	}
+	return []byte(recorded.Output), nil
+}
+
+// GetCommands returns all commands replayed
+func (r *CommandReplayer) GetCommands() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.commands...)
+}
+
+// ReplayedError is the recorded failure of a replayed command
+type ReplayedError struct {
+	Message  string
+	ExitCode int
+}
+
+func (e *ReplayedError) Error() string {
+	return e.Message
+}
+
+// marshalFixture indents a fixture and keeps shell characters such as > and & readable
+func marshalFixture(fixture interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(fixture); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
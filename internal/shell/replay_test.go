@@ -0,0 +1,80 @@
+package shell
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mainbong/storage_doctor/internal/filesystem"
+)
+
+func TestCommandRecorder_RecordAndReplay(t *testing.T) {
+	fs := filesystem.NewMockFileSystem()
+	mockExecutor := NewMockCommandExecutor()
+	mockExecutor.SetResponse("df -h /var", "Filesystem Size Used Avail Use% Mounted on\n/dev/sda1 50G 50G 0 100% /var\n")
+	mockExecutor.SetError("kubectl get pvc", errors.New("exit status 1"))
+
+	recorder := NewCommandRecorderWithFS(mockExecutor, "/fixtures/commands.json", fs)
+	if _, err := recorder.Execute("df -h /var", "/tmp"); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if _, err := recorder.Execute("kubectl get pvc", ""); err == nil {
+		t.Fatal("Expected the recorder to pass the command error through")
+	}
+	if len(recorder.Fixture().Commands) != 2 {
+		t.Fatalf("Expected 2 recorded commands, got %d", len(recorder.Fixture().Commands))
+	}
+	if fs.GetFile("/fixtures/commands.json") == nil {
+		t.Fatal("Expected the fixture to be written after each command")
+	}
+
+	replayer, err := NewCommandReplayerWithFS("/fixtures/commands.json", fs)
+	if err != nil {
+		t.Fatalf("NewCommandReplayerWithFS() failed: %v", err)
+	}
+	executor := NewExecutorWithCommandExecutor("", replayer)
+	executor.SetApprovalMode(ApprovalModeAuto)
+
+	output, err := executor.Execute("df -h /var")
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if !strings.Contains(output, "100%") {
+		t.Errorf("Expected recorded output, got %q", output)
+	}
+
+	_, err = executor.Execute("kubectl get pvc")
+	var replayed *ReplayedError
+	if !errors.As(err, &replayed) || replayed.Message != "exit status 1" {
+		t.Errorf("Expected the recorded error, got %v", err)
+	}
+
+	if _, err := executor.Execute("rm -rf /"); err == nil || !strings.Contains(err.Error(), "no recorded result") {
+		t.Errorf("Expected a missing recording error, got %v", err)
+	}
+	if commands := replayer.GetCommands(); len(commands) != 3 {
+		t.Errorf("Expected 3 replayed commands, got %v", commands)
+	}
+}
+
+func TestCommandReplayer_RepeatedCommandInOrder(t *testing.T) {
+	fs := filesystem.NewMockFileSystem()
+	fs.AddFile("/commands.json", []byte(`{"commands":[
+		{"command":"kubectl get pvc data","output":"Pending"},
+		{"command":"kubectl get pvc data","output":"Bound"}
+	]}`), 0644)
+
+	replayer, err := NewCommandReplayerWithFS("/commands.json", fs)
+	if err != nil {
+		t.Fatalf("NewCommandReplayerWithFS() failed: %v", err)
+	}
+	for _, expected := range []string{"Pending", "Bound", "Bound"} {
+		output, err := replayer.Execute("kubectl get pvc data", "")
+		if err != nil {
+			t.Fatalf("Execute() failed: %v", err)
+		}
+		if string(output) != expected {
+			t.Errorf("Expected %q, got %q", expected, string(output))
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package shell
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/mainbong/storage_doctor/internal/shell/policy"
+)
+
+const (
+	// maxStreamLineBytes bounds one line of streamed output
+	maxStreamLineBytes = 4 << 20
+	// streamWaitDelay is how long a stopped command's children may keep its
+	// output open before it is closed on them
+	streamWaitDelay = 2 * time.Second
+)
+
+// CommandStreamer runs a command and passes its standard output to fn line by
+// line until fn returns false or ctx is done
+type CommandStreamer interface {
+	Stream(ctx context.Context, command string, fn func(line []byte) bool) error
+}
+
+// StreamingCommandExecutor is a CommandExecutor that can also hand over a
+// command's standard output while it runs
+type StreamingCommandExecutor interface {
+	CommandExecutor
+	ExecuteStream(ctx context.Context, command string, dir string, fn func(line []byte) bool) error
+}
+
+// ExecuteStream runs command bound to ctx and passes its standard output to fn
+// line by line. The command is killed when fn returns false.
+func (e *OSCommandExecutor) ExecuteStream(ctx context.Context, command string, dir string, fn func(line []byte) bool) error {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	cmd := exec.CommandContext(runCtx, "sh", "-c", command)
+	if dir != "" {
+		cmd.Dir = dir
+	}
+	killProcessGroup(cmd)
+	cmd.WaitDelay = streamWaitDelay
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open command output: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start command: %w", err)
+	}
+
+	stopped := false
+	scanErr := scanLines(stdout, func(line []byte) bool {
+		if !fn(line) {
+			stopped = true
+			return false
+		}
+		return true
+	})
+	if stopped || scanErr != nil {
+		cancel()
+	}
+	waitErr := cmd.Wait()
+
+	switch {
+	case stopped:
+		return nil
+	case ctx.Err() != nil:
+		return ctx.Err()
+	case scanErr != nil:
+		return fmt.Errorf("failed to read command output: %w", scanErr)
+	case waitErr != nil:
+		return fmt.Errorf("%w: %s", waitErr, firstLine(stderr.Bytes()))
+	}
+	return nil
+}
+
+// Stream runs a command the program builds itself, such as journalctl or
+// kubectl logs, and passes its standard output to fn line by line. The output
+// goes to the program instead of the user, so only commands the policy rates
+// read-only run; they need no approval.
+func (e *Executor) Stream(ctx context.Context, command string, fn func(line []byte) bool) error {
+	engine := e.policy
+	if engine == nil {
+		var err error
+		if engine, err = policy.NewEngineWithRules(policy.Rules{}); err != nil {
+			return err
+		}
+	}
+	evaluation := engine.Evaluate(command)
+	if evaluation.Denied {
+		return fmt.Errorf("command blocked by policy: %s", evaluation.Reason)
+	}
+	if evaluation.Class != policy.ClassReadOnly {
+		return fmt.Errorf("command is not read-only: %s", evaluation.Reason)
+	}
+
+	if streaming, ok := e.commandExecutor.(StreamingCommandExecutor); ok {
+		return streaming.ExecuteStream(ctx, command, e.workingDir, fn)
+	}
+	// Mock and replay executors only return the whole output
+	output, err := e.commandExecutor.Execute(command, e.workingDir)
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, firstLine(output))
+	}
+	return scanLines(bytes.NewReader(output), fn)
+}
+
+func scanLines(r io.Reader, fn func(line []byte) bool) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxStreamLineBytes)
+	for scanner.Scan() {
+		if !fn(scanner.Bytes()) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// firstLine returns the first line of a command's output, for error messages
+func firstLine(output []byte) string {
+	text := strings.TrimSpace(string(output))
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		text = text[:i]
+	}
+	return text
+}
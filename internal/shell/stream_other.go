@@ -0,0 +1,9 @@
+//go:build !unix
+
+package shell
+
+import "os/exec"
+
+// killProcessGroup is a no-op where process groups are not available; the
+// command's children are cut off by its WaitDelay instead
+func killProcessGroup(cmd *exec.Cmd) {}
@@ -0,0 +1,74 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOSCommandExecutor_ExecuteStream(t *testing.T) {
+	executor := NewOSCommandExecutor()
+
+	var lines []string
+	err := executor.ExecuteStream(context.Background(), "printf 'a\\nb\\nc\\n'", "", func(line []byte) bool {
+		lines = append(lines, string(line))
+		return true
+	})
+	if err != nil || strings.Join(lines, ",") != "a,b,c" {
+		t.Errorf("Expected lines a,b,c, got %v (err %v)", lines, err)
+	}
+
+	// Stopping early kills a command that would run forever
+	count := 0
+	start := time.Now()
+	err = executor.ExecuteStream(context.Background(), "yes", "", func(line []byte) bool {
+		count++
+		return count < 3
+	})
+	if err != nil || count != 3 {
+		t.Errorf("Expected to stop after 3 lines without error, got %d lines (err %v)", count, err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Stopping took %s", elapsed)
+	}
+
+	err = executor.ExecuteStream(context.Background(), "echo oops >&2; exit 3", "", func([]byte) bool { return true })
+	if err == nil || !strings.Contains(err.Error(), "oops") {
+		t.Errorf("Expected the exit error with stderr, got %v", err)
+	}
+}
+
+func TestOSCommandExecutor_ExecuteStreamCancelled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	err := NewOSCommandExecutor().ExecuteStream(ctx, "sleep 10", "", func([]byte) bool { return true })
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the context error, got %v", err)
+	}
+}
+
+func TestExecutor_StreamOnlyRunsReadOnlyCommands(t *testing.T) {
+	mock := NewMockCommandExecutor()
+	mock.SetResponse("journalctl -u kubelet", "one\ntwo\n")
+	executor := NewExecutorWithCommandExecutor("", mock)
+
+	var lines []string
+	err := executor.Stream(context.Background(), "journalctl -u kubelet", func(line []byte) bool {
+		lines = append(lines, string(line))
+		return true
+	})
+	if err != nil || strings.Join(lines, ",") != "one,two" {
+		t.Errorf("Expected lines one,two, got %v (err %v)", lines, err)
+	}
+
+	err = executor.Stream(context.Background(), "journalctl --vacuum-time=1d", func([]byte) bool { return true })
+	if err == nil || !strings.Contains(err.Error(), "not read-only") {
+		t.Errorf("Expected a mutating command to be refused, got %v", err)
+	}
+	if got := mock.GetCommands(); len(got) != 1 {
+		t.Errorf("Expected only the read-only command to run, got %v", got)
+	}
+}